@@ -2,4 +2,10 @@ module tawesoft.co.uk/gopkg/gocomply
 
 go 1.16
 
-require github.com/jdxcode/netrc v0.0.0-20210204082910-926c7f70242a
+require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/google/licensecheck v0.3.1
+	github.com/jdxcode/netrc v0.0.0-20210204082910-926c7f70242a
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,159 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// ModuleList is a list of modules to scan.
+type ModuleList []Module
+
+// ListModules returns every module required by the main module in the
+// current directory, as the gocomply command scans by default.
+func ListModules(ctx context.Context) (ModuleList, error) {
+	modules, err := listModules(ctx)
+	return ModuleList(modules), err
+}
+
+// ParseModule parses a module given as a bare module path or as
+// "path@version".
+func ParseModule(s string) Module {
+	return parseModuleArg(s)
+}
+
+// Options configure Scan. The zero value scans every module as the gocomply
+// command does by default.
+type Options struct {
+	// Proxy is a module proxy (as in GOPROXY) to fetch licenses from, from
+	// each module's zip, before each module's repository.
+	Proxy string
+
+	// OverridesFile is an overrides file (YAML) assigning the licenses of
+	// specific modules manually.
+	OverridesFile string
+
+	// NoKnownModules looks every module up rather than using the built-in
+	// table of well-known modules' repositories.
+	NoKnownModules bool
+
+	// SourceCopyrights also collects copyright notices from the headers of
+	// each module's Go source files in the module cache.
+	SourceCopyrights bool
+
+	// Obligations summarises the standard obligations of each identified
+	// license.
+	Obligations bool
+
+	// DisableSSH never falls back to a git clone over SSH for a private
+	// module.
+	DisableSSH bool
+
+	// Resolvers resolve the modules of providers that gocomply doesn't know,
+	// in order, before gocomply itself.
+	Resolvers []Resolver
+
+	// Fetcher, if not nil, makes every HTTP request instead of gocomply's own
+	// client.
+	Fetcher Fetcher
+
+	// RateLimiter, if not nil, paces every HTTP request instead of the
+	// default PoliteRateLimiter, e.g. NoRateLimit against an internal forge.
+	RateLimiter RateLimiter
+
+	// Events, if not nil, is called with each progress event as Scan runs,
+	// instead of writing warnings to stderr. Informational messages are
+	// discarded. Events is called from the goroutine that called Scan.
+	Events func(Event)
+}
+
+// options returns the command-line options equivalent to Options.
+func (opts Options) options(ctx context.Context) (*options, error) {
+	o := &options{
+		Proxy:       opts.Proxy,
+		NoKnown:     opts.NoKnownModules,
+		Copyrights:  opts.SourceCopyrights,
+		Obligations: opts.Obligations,
+		SSH:         !opts.DisableSSH,
+		overrides:   make(overrides),
+		private:     loadPrivatePatterns(ctx),
+	}
+	if opts.OverridesFile != "" {
+		ovs, err := loadOverrides(opts.OverridesFile)
+		if err != nil {
+			return nil, err
+		}
+		o.overrides = ovs
+	}
+	return o, nil
+}
+
+// Result is the result of scanning one module: its report entry or, if its
+// license couldn't be found, an error.
+type Result struct {
+	Module Module
+	Entry  Entry
+	Err    error
+}
+
+// credentialsOnce loads credentials for Scan once.
+var credentialsOnce sync.Once
+
+// loadCredentials reads credentials from netrc files, the environment and
+// the GitHub CLI, returning any error from parsing a netrc file.
+func loadCredentials(ctx context.Context) error {
+	err := parseNetrc()
+	configureGitHubAuth(ctx)
+	configureGitLab(gitlabConfig{}, os.Getenv)
+	return err
+}
+
+// Scan finds the license of each module. A module whose license couldn't
+// be found has a Result with an error, and doesn't stop the scan. Scan
+// returns an error, with the results so far, only if the options are invalid
+// or ctx is done before every module is scanned.
+//
+// Scan uses package-level state, such as the rate limiter and credentials,
+// so it shouldn't be called concurrently.
+func Scan(ctx context.Context, modules ModuleList, opts Options) ([]Result, error) {
+	o, err := opts.options(ctx)
+	if err != nil {
+		return nil, err
+	}
+	credentialsOnce.Do(func() {
+		if err := loadCredentials(ctx); err != nil {
+			logf(levelWarning, phaseSetup, "", err, "warning: %v", err)
+		}
+	})
+
+	oldResolvers, oldFetcher, oldHandler := resolvers, httpFetcher, eventHandler
+	resolvers, httpFetcher, eventHandler = opts.Resolvers, opts.Fetcher, opts.Events
+	defer func() { resolvers, httpFetcher, eventHandler = oldResolvers, oldFetcher, oldHandler }()
+	if opts.RateLimiter != nil {
+		oldLimiter := rateLimiter
+		rateLimiter = opts.RateLimiter
+		defer func() { rateLimiter = oldLimiter }()
+	}
+
+	send := func(ev Event) {
+		if opts.Events != nil {
+			opts.Events(ev)
+		}
+	}
+
+	results := make([]Result, 0, len(modules))
+	for _, m := range modules {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		send(ModuleStarted{m})
+		entry, err := scanModule(ctx, m, o)
+		if err != nil {
+			send(Failed{m, err})
+		} else {
+			send(LicenseFound{m, entry})
+		}
+		results = append(results, Result{Module: m, Entry: entry, Err: err})
+	}
+	return results, nil
+}
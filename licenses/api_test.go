@@ -0,0 +1,206 @@
+package licenses
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	data := "overrides:\n  - module: example.org/a\n    spdx: MIT\n    text: License A\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modules := ModuleList{ParseModule("example.org/a@v1.0.0")}
+	results, err := Scan(context.Background(), modules, Options{OverridesFile: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (len(results) != 1) || (results[0].Err != nil) {
+		t.Fatalf("expected one result but got %+v", results)
+	}
+	if e := results[0].Entry; (e.Module != "example.org/a") || (e.Version != "v1.0.0") || (e.License != "License A") {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = Scan(ctx, modules, Options{OverridesFile: path})
+	if (err != context.Canceled) || (len(results) != 0) {
+		t.Errorf("expected no results and %v but got %+v, %v", context.Canceled, results, err)
+	}
+}
+
+// forgeResolver resolves example.org/ modules to a fake forge
+type forgeResolver struct{}
+
+func (forgeResolver) Lookup(ctx context.Context, module string) (GoImport, bool, error) {
+	if !strings.HasPrefix(module, "example.org/") {
+		return GoImport{}, false, nil
+	}
+	return GoImport{ImportPrefix: module, Vcs: "git", RepoRoot: "https://forge.test/" + module}, true, nil
+}
+
+func (forgeResolver) FileURLs(ctx context.Context, gi GoImport, file string) ([]FileURL, bool) {
+	if !strings.HasPrefix(gi.RepoRoot, "https://forge.test/") {
+		return nil, false
+	}
+	return []FileURL{{gi.RepoRoot + "/raw/" + file, "trunk"}}, true
+}
+
+// replayFetcher serves recorded response bodies by URL, and 404 otherwise
+type replayFetcher map[string]string
+
+func (f replayFetcher) Do(req *http.Request) (*http.Response, error) {
+	body, ok := f[req.URL.String()]
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}
+	if !ok {
+		resp.StatusCode = http.StatusNotFound
+	}
+	resp.Body = io.NopCloser(strings.NewReader(body))
+	return resp, nil
+}
+
+func TestScanResolverFetcher(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	opts := Options{
+		Resolvers: []Resolver{forgeResolver{}},
+		Fetcher:   replayFetcher{"https://forge.test/example.org/a/raw/LICENSE": testMITLicense},
+	}
+	results, err := Scan(context.Background(), ModuleList{{Path: "example.org/a"}}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (len(results) != 1) || (results[0].Err != nil) {
+		t.Fatalf("expected one result but got %+v", results)
+	}
+	e := results[0].Entry
+	if (e.SPDX != "MIT") || (e.License != testMITLicense) {
+		t.Errorf("expected the MIT license but got %s (%s)", e.SPDX, e.License)
+	}
+	if (resolvers != nil) || (httpFetcher != nil) {
+		t.Errorf("expected the resolvers and fetcher to be restored")
+	}
+}
+
+// countingLimiter counts the requests to each host, without waiting
+type countingLimiter map[string]int
+
+func (l countingLimiter) Wait(ctx context.Context, host string) error {
+	l[host]++
+	return nil
+}
+
+func TestScanRateLimiter(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldLimiter := rateLimiter
+	limiter := countingLimiter{}
+	opts := Options{
+		Resolvers:   []Resolver{forgeResolver{}},
+		Fetcher:     replayFetcher{"https://forge.test/example.org/limited/raw/LICENSE": testMITLicense},
+		RateLimiter: limiter,
+	}
+	if _, err := Scan(context.Background(), ModuleList{{Path: "example.org/limited"}}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limiter["forge.test"] == 0 {
+		t.Errorf("expected the rate limiter to be used but got %v", limiter)
+	}
+	if rateLimiter != oldLimiter {
+		t.Errorf("expected the rate limiter to be restored")
+	}
+}
+
+func TestScanEvents(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	var events []Event
+	opts := Options{
+		Resolvers: []Resolver{forgeResolver{}},
+		Fetcher:   replayFetcher{"https://forge.test/example.org/a/raw/LICENSE": testMITLicense},
+		Events:    func(ev Event) { events = append(events, ev) },
+	}
+	modules := ModuleList{{Path: "example.org/a"}, {Path: "example.org/b"}}
+	if _, err := Scan(context.Background(), modules, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var kinds []string
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case ModuleStarted:
+			kinds = append(kinds, "started "+ev.Module.Path)
+		case LicenseFound:
+			kinds = append(kinds, "found "+ev.Entry.SPDX)
+		case Failed:
+			kinds = append(kinds, "failed "+ev.Module.Path)
+		}
+	}
+	expected := []string{"started example.org/a", "found MIT", "started example.org/b", "failed example.org/b"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected events %v but got %v", expected, kinds)
+	}
+	if eventHandler != nil {
+		t.Errorf("expected the event handler to be restored")
+	}
+}
+
+func TestScanNoWarnings(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	var warnings []Warning
+	opts := Options{
+		Resolvers: []Resolver{forgeResolver{}},
+		Fetcher:   replayFetcher{"https://forge.test/example.org/a/raw/LICENSE": testMITLicense},
+		Events: func(ev Event) {
+			if w, ok := ev.(Warning); ok {
+				warnings = append(warnings, w)
+			}
+		},
+	}
+	if _, err := Scan(context.Background(), ModuleList{{Path: "example.org/a"}}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings for a successful scan but got %+v", warnings)
+	}
+}
+
+func TestLogEmitEvents(t *testing.T) {
+	var events []Event
+	eventHandler = func(ev Event) { events = append(events, ev) }
+	defer func() { eventHandler = nil }()
+
+	logf(levelInfo, phaseModule, "example.org/a", nil, "> example.org/a")
+	logf(levelDebug, phaseLookup, "example.org/a", nil, "example.org/a: repository https://example.org/a (git)")
+	logf(levelTrace, "", "", nil, "GET https://example.org/LICENSE: 200 OK (1ms)")
+	logf(levelWarning, phaseLicense, "example.org/a", &httpStatusError{URL: "https://example.org/LICENSE", StatusCode: 500}, "warning: failed")
+
+	expected := []Event{Warning{Module: "example.org/a", Message: "warning: failed", URL: "https://example.org/LICENSE", Status: 500}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %+v but got %+v", expected, events)
+	}
+}
@@ -0,0 +1,126 @@
+package licenses
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditRecord is one line of an audit log: a request that gocomply made.
+type auditRecord struct {
+	Time       string `json:"time"` // RFC 3339, when the request was made
+	Method     string `json:"method"`
+	URL        string `json:"url"` // without any user info
+	Host       string `json:"host"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Bytes      int64  `json:"bytes"`       // of the response body read
+	DurationMS int64  `json:"duration_ms"` // until the response body is closed
+	CacheHit   bool   `json:"cache_hit"`   // revalidated with 304 Not Modified
+}
+
+// auditLog records every request made during a run as a line of JSON, for a
+// review of exactly which hosts were contacted, such as by a security team.
+type auditLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+	err error // the first error writing the log
+}
+
+// requestAudit, if not nil, records every request (see auditFetcher)
+var requestAudit *auditLog
+
+// openAuditLog creates the audit log at path, replacing any existing one.
+func openAuditLog(path string) (*auditLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating audit log: %v", err)
+	}
+	return &auditLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// record appends a request to the log.
+func (a *auditLog) record(r auditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(r); (err != nil) && (a.err == nil) {
+		a.err = err
+	}
+}
+
+// Close closes the log, returning any error writing it.
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err := a.f.Close()
+	if a.err != nil {
+		err = a.err
+	}
+	if err != nil {
+		return fmt.Errorf("error writing audit log: %v", err)
+	}
+	return nil
+}
+
+// auditFetcher is a Fetcher that records each request it makes in an audit
+// log, once its response body is closed.
+type auditFetcher struct {
+	Fetcher
+	log *auditLog
+}
+
+func (f auditFetcher) Do(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.User = nil
+	r := auditRecord{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Method: req.Method,
+		URL:    u.String(),
+		Host:   u.Host,
+	}
+	conditional := (req.Header.Get("If-None-Match") != "") || (req.Header.Get("If-Modified-Since") != "")
+
+	start := time.Now()
+	resp, err := f.Fetcher.Do(req)
+	if err != nil {
+		r.Error = err.Error()
+		r.DurationMS = time.Since(start).Milliseconds()
+		f.log.record(r)
+		return nil, err
+	}
+
+	r.Status = resp.StatusCode
+	r.CacheHit = conditional && (resp.StatusCode == http.StatusNotModified)
+	resp.Body = &auditBody{ReadCloser: resp.Body, done: func(n int64) {
+		r.Bytes = n
+		r.DurationMS = time.Since(start).Milliseconds()
+		f.log.record(r)
+	}}
+	return resp, nil
+}
+
+// auditBody counts the bytes read from a response body, calling done with
+// the count when it is first closed.
+type auditBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *auditBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
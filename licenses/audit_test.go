@@ -0,0 +1,71 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "License A")
+	}))
+	defer server.Close()
+
+	oldLimiter, oldRetries := rateLimiter, httpRetries
+	rateLimiter, httpRetries = NoRateLimit, 0
+	defer func() { rateLimiter, httpRetries = oldLimiter, oldRetries }()
+
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	audit, err := openAuditLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	requestAudit = audit
+	httpGet(context.Background(), server.URL+"/LICENSE", nil)
+	httpGet(context.Background(), server.URL+"/missing", nil)
+	httpGet(context.Background(), "http://127.0.0.1:1/LICENSE", nil)
+	requestAudit = nil
+	if err := audit.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []auditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 requests but got %+v", records)
+	}
+	if (records[0].URL != server.URL+"/LICENSE") || (records[0].Method != "GET") ||
+		(records[0].Status != http.StatusOK) || (records[0].Bytes != int64(len("License A"))) {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+	if records[1].Status != http.StatusNotFound {
+		t.Errorf("expected %d but got %+v", http.StatusNotFound, records[1])
+	}
+	if (records[2].Error == "") || (records[2].Host != "127.0.0.1:1") {
+		t.Errorf("expected an error for 127.0.0.1:1 but got %+v", records[2])
+	}
+}
@@ -0,0 +1,87 @@
+package licenses
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// baselineEntry is an approved module and, optionally, its approved license.
+type baselineEntry struct {
+	Module string `json:"module"`
+	SPDX   string `json:"spdx"`
+}
+
+// baseline is an approved inventory of modules and licenses, typically
+// exported from a compliance system, used to find unapproved additions.
+type baseline struct {
+	// approved SPDX expressions for each module. An empty expression approves
+	// the module under any license.
+	approvedSPDX map[string][]string
+}
+
+// loadBaseline reads an approved inventory from a JSON file. The file may be
+// a gocomply json report, an object with a "modules" array, or a bare array,
+// where each element has a "module" and, optionally, an "spdx" field.
+func loadBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %v", err)
+	}
+
+	entries, err := parseBaseline(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing baseline %q: %v", path, err)
+	}
+
+	b := &baseline{approvedSPDX: make(map[string][]string)}
+	for _, e := range entries {
+		if e.Module == "" {
+			return nil, fmt.Errorf("error parsing baseline %q: entry without a module", path)
+		}
+		b.approvedSPDX[e.Module] = append(b.approvedSPDX[e.Module], e.SPDX)
+	}
+	return b, nil
+}
+
+func parseBaseline(data []byte) ([]baselineEntry, error) {
+	var list []baselineEntry
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+
+	var doc struct {
+		Entries []baselineEntry `json:"entries"` // gocomply json report
+		Modules []baselineEntry `json:"modules"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return append(doc.Entries, doc.Modules...), nil
+}
+
+// approved returns true if the baseline approves an entry's module and
+// license. If the baseline names a license for the module, the entry's
+// license must have been identified as exactly that SPDX expression.
+func (b *baseline) approved(e Entry) bool {
+	for _, spdx := range b.approvedSPDX[e.Module] {
+		if (spdx == "") || (spdx == e.SPDX) {
+			return true
+		}
+	}
+	return false
+}
+
+// reason explains why an entry is not approved.
+func (b *baseline) reason(e Entry) string {
+	approved, ok := b.approvedSPDX[e.Module]
+	if !ok {
+		return "module not in baseline"
+	}
+
+	spdx := e.SPDX
+	if spdx == "" {
+		spdx = spdxNoAssertion
+	}
+	return fmt.Sprintf("license %s not in baseline (approved: %v)", spdx, approved)
+}
@@ -0,0 +1,44 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	inputs := []string{
+		`[{"module": "example.org/a", "spdx": "MIT"}, {"module": "example.org/b"}]`,
+		`{"modules": [{"module": "example.org/a", "spdx": "MIT"}, {"module": "example.org/b"}]}`,
+		`{"generated": "2021-06-01T12:00:00Z", "entries": [{"module": "example.org/a", "license": "...", "spdx": "MIT"}, {"module": "example.org/b"}]}`,
+	}
+
+	for i, input := range inputs {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := loadBaseline(path)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		tests := []struct {
+			entry    Entry
+			approved bool
+		}{
+			{Entry{Module: "example.org/a", SPDX: "MIT"}, true},
+			{Entry{Module: "example.org/a", SPDX: "GPL-3.0"}, false},
+			{Entry{Module: "example.org/a"}, false},
+			{Entry{Module: "example.org/b", SPDX: "GPL-3.0"}, true},
+			{Entry{Module: "example.org/c", SPDX: "MIT"}, false},
+		}
+		for _, test := range tests {
+			if b.approved(test.entry) != test.approved {
+				t.Errorf("test %d: expected approved=%t for %+v", i, test.approved, test.entry)
+			}
+		}
+	}
+}
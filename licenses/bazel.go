@@ -0,0 +1,168 @@
+package licenses
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// bazelCall matches the start of a call, in a Starlark file, that declares a
+// Go module: a go_repository rule (in deps.bzl, WORKSPACE, or the output of
+// "bazel query --output=build"), or a go_deps extension tag in MODULE.bazel
+var bazelCall = regexp.MustCompile(`\b(go_repository|go_deps\.module|go_deps\.from_file)\s*\(`)
+
+// bazelAttr matches a string attribute of a call, e.g. importpath = "x"
+var bazelAttr = regexp.MustCompile(`\b([a-z_]+)\s*=\s*"([^"]*)"`)
+
+// bazelModules returns the Go modules of a Bazel workspace from a Starlark
+// file, for a workspace built with rules_go rather than the go command: the
+// go_repository rules of deps.bzl or WORKSPACE (or of saved "bazel query
+// 'kind(go_repository, //external:*)' --output=build" output), or the go_deps
+// extension of MODULE.bazel, including any go.mod it reads with from_file.
+func bazelModules(path string) ([]Module, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Bazel file: %v", err)
+	}
+
+	var modules []Module
+	seen := make(map[string]bool)
+	add := func(m Module) {
+		if (m.Path != "") && !seen[m.Path] {
+			seen[m.Path] = true
+			modules = append(modules, m)
+		}
+	}
+
+	for _, call := range bazelCalls(string(data)) {
+		switch call.name {
+		case "go_repository":
+			m := Module{Path: call.attrs["importpath"], Version: call.attrs["version"]}
+			if replace := call.attrs["replace"]; replace != "" {
+				m.Replace, m.ReplaceVersion = replace, m.Version
+			}
+			add(m)
+		case "go_deps.module":
+			add(Module{Path: call.attrs["path"], Version: call.attrs["version"]})
+		case "go_deps.from_file":
+			goMod := bazelLabelPath(filepath.Dir(path), call.attrs["go_mod"])
+			data, err := os.ReadFile(goMod)
+			if err != nil {
+				return nil, fmt.Errorf("error reading go.mod for go_deps: %v", err)
+			}
+			required, err := parseGoModRequires(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s: %v", goMod, err)
+			}
+			for _, m := range required {
+				add(m)
+			}
+		}
+	}
+
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no Go modules found in %s (expected go_repository rules or a go_deps extension)", path)
+	}
+	return modules, nil
+}
+
+// bazelCallAttrs is a call declaring a Go module and its string attributes.
+type bazelCallAttrs struct {
+	name  string
+	attrs map[string]string
+}
+
+// bazelCalls returns each call in a Starlark file that declares a Go module
+// (see bazelCall), with its string attributes. Comments are ignored.
+func bazelCalls(src string) []bazelCallAttrs {
+	src = stripStarlarkComments(src)
+
+	var calls []bazelCallAttrs
+	for _, loc := range bazelCall.FindAllStringSubmatchIndex(src, -1) {
+		args := src[loc[1]:]
+		if end := closingParen(args); end >= 0 {
+			args = args[:end]
+		}
+		call := bazelCallAttrs{name: src[loc[2]:loc[3]], attrs: make(map[string]string)}
+		for _, m := range bazelAttr.FindAllStringSubmatch(args, -1) {
+			call.attrs[m[1]] = m[2]
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// stripStarlarkComments removes the "#" comments of Starlark source, except
+// within a string.
+func stripStarlarkComments(src string) string {
+	var b strings.Builder
+	var quote byte
+	comment := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case comment:
+			if c != '\n' {
+				continue
+			}
+			comment = false
+		case quote != 0:
+			if c == '\\' && (i+1 < len(src)) {
+				b.WriteByte(c)
+				i++
+				c = src[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"') || (c == '\''):
+			quote = c
+		case c == '#':
+			comment = true
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// closingParen returns the index of the parenthesis closing a call whose
+// arguments begin s, or -1.
+func closingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"') || (c == '\''):
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// bazelLabelPath returns the file named by a Bazel label in the main
+// repository, such as "//:go.mod" or "//services/api:go.mod", relative to the
+// workspace directory.
+func bazelLabelPath(workspace string, label string) string {
+	label = strings.TrimLeft(strings.TrimPrefix(label, "@"), "/")
+	pkg, name := "", label
+	if idx := strings.IndexByte(label, ':'); idx >= 0 {
+		pkg, name = label[:idx], label[idx+1:]
+	}
+	return filepath.Join(workspace, filepath.FromSlash(pkg), name)
+}
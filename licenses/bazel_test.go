@@ -0,0 +1,79 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBazelModules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data string) string {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	depsBzl := write("deps.bzl", `load("@bazel_gazelle//:deps.bzl", "go_repository")
+
+def go_dependencies():
+    go_repository(
+        name = "com_github_example_a",
+        importpath = "github.com/example/a",
+        sum = "h1:abc=",
+        version = "v1.0.0",
+    )
+    # go_repository(name = "com_github_example_old", importpath = "github.com/example/old", version = "v0.1.0")
+    go_repository(
+        name = "org_example_b",
+        build_file_proto_mode = "disable_global",  # a comment with "quotes" (and parentheses
+        importpath = "example.org/b",
+        replace = "example.org/b-fork",
+        version = "v0.2.0",
+    )
+`)
+	modules, err := bazelModules(depsBzl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Module{
+		{Path: "github.com/example/a", Version: "v1.0.0"},
+		{Path: "example.org/b", Version: "v0.2.0", Replace: "example.org/b-fork", ReplaceVersion: "v0.2.0"},
+	}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("expected %+v but got %+v", expected, modules)
+	}
+
+	write("services/api/go.mod", "module example.org/api\n\nrequire (\n\tgithub.com/example/a v1.0.0\n\tgithub.com/example/c v1.2.0 // indirect\n)\n")
+	moduleBazel := write("MODULE.bazel", `bazel_dep(name = "gazelle", version = "0.35.0")
+
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//services/api:go.mod")
+go_deps.module(
+    path = "example.org/tool",
+    sum = "h1:def=",
+    version = "v0.3.0",
+)
+use_repo(go_deps, "com_github_example_a")
+`)
+	modules, err = bazelModules(moduleBazel)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []Module{
+		{Path: "github.com/example/a", Version: "v1.0.0"},
+		{Path: "github.com/example/c", Version: "v1.2.0"},
+		{Path: "example.org/tool", Version: "v0.3.0"},
+	}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("expected %+v but got %+v", expected, modules)
+	}
+
+	if _, err := bazelModules(write("BUILD.bazel", `go_library(name = "lib")`)); err == nil {
+		t.Errorf("expected an error for a file without any Go modules")
+	}
+}
@@ -0,0 +1,184 @@
+package licenses
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheEnv names a cache directory if --cache isn't given
+const cacheEnv = "GOCOMPLY_CACHE"
+
+// defaultCacheDir returns the cache directory used by a bare --cache, in the
+// user's cache directory, or an empty string if there's nowhere to put it.
+func defaultCacheDir() string {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cache, "gocomply", "downloads")
+}
+
+// cacheEntry is a cached HTTP response body with its validators, so that it
+// can be revalidated with a conditional request rather than downloaded again.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         string `json:"body"`
+	Stored       string `json:"stored"` // RFC 3339
+}
+
+// cacheStore stores cacheEntries by key.
+type cacheStore interface {
+	// Get returns the entry for a key. If there is none, ok is false and
+	// err is nil.
+	Get(ctx context.Context, key string) (entry cacheEntry, ok bool, err error)
+	Put(ctx context.Context, key string, entry cacheEntry) error
+}
+
+// httpCache is the cache used by httpDo, or nil if there is no cache.
+var httpCache cacheStore
+
+// cacheStats counts how requests used the cache.
+type cacheStats struct {
+	Hits      int `json:"hits"`      // revalidated and unchanged
+	Refreshed int `json:"refreshed"` // revalidated and downloaded again
+	Misses    int `json:"misses"`    // not in the cache
+}
+
+// cacheCounts counts how requests used the cache in this run.
+var cacheCounts struct {
+	sync.Mutex
+	cacheStats
+}
+
+func countCache(count *int) {
+	cacheCounts.Lock()
+	*count++
+	cacheCounts.Unlock()
+}
+
+// cacheKey returns the cache key for a URL.
+func cacheKey(rsc string) string {
+	sum := sha256.Sum256([]byte(rsc))
+	return hex.EncodeToString(sum[:])
+}
+
+// dirCache is a cacheStore keeping one JSON file per entry in a directory.
+type dirCache struct {
+	dir string
+}
+
+func (c dirCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func (c dirCache) Get(ctx context.Context, key string) (cacheEntry, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return cacheEntry{}, false, nil
+	} else if err != nil {
+		return cacheEntry{}, false, fmt.Errorf("error reading cache: %v", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// a corrupt entry is just a miss
+		return cacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (c dirCache) Put(ctx context.Context, key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+
+	// write then rename, so a concurrent reader never sees a partial entry,
+	// to a temporary file of its own, so concurrent writers don't collide
+	f, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	return nil
+}
+
+// revalidate adds the validators of a cached entry for the request's URL, if
+// any, to make it a conditional request. The entry is returned for use if
+// the response is 304 Not Modified.
+func revalidate(req *http.Request) (cacheEntry, bool) {
+	if (httpCache == nil) || (req.Method != "GET") {
+		return cacheEntry{}, false
+	}
+
+	entry, ok, err := httpCache.Get(req.Context(), cacheKey(req.URL.String()))
+	if err != nil {
+		logf(levelWarning, "", "", err, "warning: %v", err)
+		return cacheEntry{}, false
+	}
+	if !ok {
+		countCache(&cacheCounts.Misses)
+		return cacheEntry{}, false
+	}
+
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return entry, true
+}
+
+// storeResponse caches a successful response body, if the response has an
+// ETag or Last-Modified header to revalidate it with later.
+func storeResponse(req *http.Request, resp *http.Response, body string) {
+	if (httpCache == nil) || (req.Method != "GET") {
+		return
+	}
+
+	entry := cacheEntry{
+		URL:          req.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+		Stored:       time.Now().UTC().Format(time.RFC3339),
+	}
+	if (entry.ETag == "") && (entry.LastModified == "") {
+		return
+	}
+
+	if err := httpCache.Put(req.Context(), cacheKey(entry.URL), entry); err != nil {
+		logf(levelWarning, "", "", err, "warning: %v", err)
+	}
+}
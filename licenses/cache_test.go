@@ -0,0 +1,79 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHTTPCacheRevalidation(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		fmt.Fprint(w, "License A")
+	}))
+	defer server.Close()
+
+	oldCache, oldLimiter := httpCache, rateLimiter
+	httpCache, rateLimiter = dirCache{dir: t.TempDir()}, NoRateLimit
+	defer func() { httpCache, rateLimiter = oldCache, oldLimiter }()
+
+	for i := 0; i < 2; i++ {
+		text, err := httpGet(context.Background(), server.URL+"/LICENSE", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if text != "License A" {
+			t.Errorf("expected %q but got %q", "License A", text)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("expected 1 download but got %d", downloads)
+	}
+}
+
+func TestDirCacheMiss(t *testing.T) {
+	c := dirCache{dir: t.TempDir()}
+	if _, ok, err := c.Get(context.Background(), cacheKey("https://example.org/LICENSE")); ok || (err != nil) {
+		t.Errorf("expected a miss but got (%t, %v)", ok, err)
+	}
+}
+
+func TestDirCacheConcurrentPut(t *testing.T) {
+	c := dirCache{dir: t.TempDir()}
+	key := cacheKey("https://example.org/LICENSE")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- c.Put(context.Background(), key, cacheEntry{Body: fmt.Sprintf("body %d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if _, ok, err := c.Get(context.Background(), key); !ok || (err != nil) {
+		t.Errorf("expected a hit but got (%t, %v)", ok, err)
+	}
+	tmps, _ := filepath.Glob(filepath.Join(c.dir, "*", "*.tmp"))
+	if len(tmps) != 0 {
+		t.Errorf("expected no temporary files left but got %v", tmps)
+	}
+}
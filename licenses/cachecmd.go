@@ -0,0 +1,197 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheStatsFile records the cumulative cacheStats of a cache directory
+const cacheStatsFile = "stats.json"
+
+// defaultCacheMaxAge is how old a cache entry is before cache clean removes it
+const defaultCacheMaxAge = 30 * 24 * time.Hour
+
+// addStats adds the counts of this run to the cache's statistics.
+func (c dirCache) addStats() error {
+	cacheCounts.Lock()
+	counts := cacheCounts.cacheStats
+	cacheCounts.cacheStats = cacheStats{}
+	cacheCounts.Unlock()
+	if counts == (cacheStats{}) {
+		return nil
+	}
+
+	stats, err := c.stats()
+	if err != nil {
+		return err
+	}
+	stats.Hits += counts.Hits
+	stats.Refreshed += counts.Refreshed
+	stats.Misses += counts.Misses
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("error writing cache statistics: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, cacheStatsFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing cache statistics: %v", err)
+	}
+	return nil
+}
+
+// stats returns the cache's cumulative statistics.
+func (c dirCache) stats() (cacheStats, error) {
+	var stats cacheStats
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheStatsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	} else if err != nil {
+		return stats, fmt.Errorf("error reading cache statistics: %v", err)
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return stats, fmt.Errorf("error reading cache statistics: %v", err)
+	}
+	return stats, nil
+}
+
+// walk calls fn for each entry file in the cache, with its stored time (zero
+// if it can't be read). Partially written entries are included, as stale.
+func (c dirCache) walk(fn func(path string, info fs.FileInfo, stored time.Time) error) error {
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (filepath.Dir(path) == filepath.Clean(c.dir)) {
+			return nil // e.g. the stats file
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var stored time.Time
+		if strings.HasSuffix(path, ".json") {
+			if data, err := os.ReadFile(path); err == nil {
+				var entry cacheEntry
+				if json.Unmarshal(data, &entry) == nil {
+					stored, _ = time.Parse(time.RFC3339, entry.Stored)
+				}
+			}
+		}
+		return fn(path, info, stored)
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// runCache implements the cache command, managing the --cache directory:
+//
+//   - status: the number and size of entries, and the hit rate
+//   - clean: remove entries stored more than --older-than ago
+//   - warm: download every module's license into the cache, without a report
+func runCache(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	if o.Cache == "" {
+		return fmt.Errorf("the cache command requires --cache (or $%s)", cacheEnv)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing cache command: status, clean or warm")
+	}
+	c := dirCache{dir: o.Cache}
+
+	switch args[0] {
+	case "status":
+		return cacheStatus(c, stdout)
+	case "clean":
+		return cacheClean(c, o.CacheMaxAge, stdout)
+	case "warm":
+		summary := newRunSummary()
+		modules, err := modulesToScan(ctx, o, args[1:])
+		if err != nil {
+			return err
+		}
+		err = scanModules(ctx, o, modules, summary, func(e Entry) error { return nil })
+		if err != nil {
+			return err
+		}
+		summary.finish()
+		summary.log()
+		return nil
+	default:
+		return fmt.Errorf("unknown cache command %q (expected status, clean or warm)", args[0])
+	}
+}
+
+func cacheStatus(c dirCache, stdout io.Writer) error {
+	entries := 0
+	var size int64
+	var oldest, newest time.Time
+	err := c.walk(func(path string, info fs.FileInfo, stored time.Time) error {
+		entries++
+		size += info.Size()
+		if !stored.IsZero() {
+			if oldest.IsZero() || stored.Before(oldest) {
+				oldest = stored
+			}
+			if stored.After(newest) {
+				newest = stored
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading cache: %v", err)
+	}
+
+	stats, err := c.stats()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "cache: %s\n", c.dir)
+	fmt.Fprintf(&b, "entries: %d (%.1f MiB)\n", entries, float64(size)/(1<<20))
+	if !oldest.IsZero() {
+		fmt.Fprintf(&b, "stored: %s to %s\n", oldest.Format(time.RFC3339), newest.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "hits: %d, refreshed: %d, misses: %d", stats.Hits, stats.Refreshed, stats.Misses)
+	if total := stats.Hits + stats.Refreshed + stats.Misses; total > 0 {
+		fmt.Fprintf(&b, " (%.1f%% hit rate)", 100*float64(stats.Hits)/float64(total))
+	}
+	b.WriteString("\n")
+
+	_, err = io.WriteString(stdout, b.String())
+	return err
+}
+
+func cacheClean(c dirCache, maxAge time.Duration, stdout io.Writer) error {
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	err := c.walk(func(path string, info fs.FileInfo, stored time.Time) error {
+		if stored.IsZero() || stored.Before(cutoff) || (maxAge == 0) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error cleaning cache: %v", err)
+	}
+
+	_, err = fmt.Fprintf(stdout, "removed %d entries\n", removed)
+	return err
+}
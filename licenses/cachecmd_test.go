@@ -0,0 +1,46 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheStatusAndClean(t *testing.T) {
+	c := dirCache{dir: t.TempDir()}
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+
+	c.Put(context.Background(), cacheKey("https://example.org/a"), cacheEntry{URL: "https://example.org/a", Body: "A", Stored: old})
+	c.Put(context.Background(), cacheKey("https://example.org/b"), cacheEntry{URL: "https://example.org/b", Body: "B", Stored: recent})
+
+	cacheCounts.Lock()
+	cacheCounts.cacheStats = cacheStats{Hits: 3, Misses: 1}
+	cacheCounts.Unlock()
+	if err := c.addStats(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runCache(context.Background(), &options{Cache: c.dir}, []string{"status"}, &stdout); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"entries: 2 ", "hits: 3, refreshed: 0, misses: 1 (75.0% hit rate)"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected status to contain %q but got %q", expected, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if err := runCache(context.Background(), &options{Cache: c.dir, CacheMaxAge: 24 * time.Hour}, []string{"clean"}, &stdout); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "removed 1 entries\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	if _, ok, _ := c.Get(context.Background(), cacheKey("https://example.org/b")); !ok {
+		t.Errorf("expected the recent entry to remain")
+	}
+}
@@ -0,0 +1,134 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// nativeLibrary is a C library linked by cgo, whose license is outside of
+// the Go modules scanned and needs reviewing separately.
+type nativeLibrary struct {
+	Name string `json:"name"` // e.g. "sqlite3", or "libz.so.1" from a binary
+
+	// FoundIn lists where the library is linked: each package with a #cgo
+	// directive for it, or the binary that is dynamically linked against it.
+	FoundIn []string `json:"found_in"`
+}
+
+// nativeLibraries returns the native libraries linked by the main module's
+// packages, from their cgo directives, and by the built binary, if given,
+// sorted by name.
+func nativeLibraries(ctx context.Context, binary string) ([]nativeLibrary, error) {
+	stdout, err := exec.CommandContext(ctx, "go", "list", "-deps", "-f",
+		`{{if .CgoFiles}}{{.ImportPath}}{{"\t"}}{{join .CgoLDFLAGS " "}}{{"\t"}}{{join .CgoPkgConfig " "}}{{end}}`,
+		"./...").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+	libs := parseCgoLibraries(stdout)
+
+	if binary != "" {
+		names, err := binaryLibraries(binary)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			libs = append(libs, nativeLibrary{name, []string{filepath.Base(binary)}})
+		}
+	}
+	return mergeNativeLibraries(libs), nil
+}
+
+// parseCgoLibraries parses "go list" output of a package's import path, its
+// cgo LDFLAGS and its pkg-config packages, separated by tabs, one package per
+// line, into the libraries each package links: each "-l" flag and
+// pkg-config package.
+func parseCgoLibraries(stdout []byte) []nativeLibrary {
+	var libs []nativeLibrary
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		pkg := fields[0]
+
+		ldflags := strings.Fields(fields[1])
+		for i, flag := range ldflags {
+			name := ""
+			switch {
+			case (flag == "-l") && (i+1 < len(ldflags)):
+				name = ldflags[i+1]
+			case strings.HasPrefix(flag, "-l"):
+				name = flag[2:]
+			}
+			if name != "" {
+				libs = append(libs, nativeLibrary{name, []string{pkg}})
+			}
+		}
+		for _, name := range strings.Fields(fields[2]) {
+			if !strings.HasPrefix(name, "-") {
+				libs = append(libs, nativeLibrary{name, []string{pkg + " (pkg-config)"}})
+			}
+		}
+	}
+	return libs
+}
+
+// binaryLibraries returns the shared libraries that a built ELF, Mach-O or
+// PE binary is dynamically linked against.
+func binaryLibraries(path string) ([]string, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return f.ImportedLibraries()
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return f.ImportedLibraries()
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return f.ImportedLibraries()
+	}
+	return nil, fmt.Errorf("%s is not an ELF, Mach-O or PE binary", path)
+}
+
+// mergeNativeLibraries combines the libraries with the same name, sorted by
+// name.
+func mergeNativeLibraries(libs []nativeLibrary) []nativeLibrary {
+	byName := make(map[string]*nativeLibrary)
+	var names []string
+	for _, lib := range libs {
+		merged, ok := byName[lib.Name]
+		if !ok {
+			merged = &nativeLibrary{Name: lib.Name}
+			byName[lib.Name] = merged
+			names = append(names, lib.Name)
+		}
+	next:
+		for _, f := range lib.FoundIn {
+			for _, existing := range merged.FoundIn {
+				if existing == f {
+					continue next
+				}
+			}
+			merged.FoundIn = append(merged.FoundIn, f)
+		}
+	}
+	sort.Strings(names)
+
+	result := make([]nativeLibrary, len(names))
+	for i, name := range names {
+		result[i] = *byName[name]
+	}
+	return result
+}
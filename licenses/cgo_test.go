@@ -0,0 +1,51 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCgoLibraries(t *testing.T) {
+	stdout := "example.org/a/db\t-L/opt/lib -lsqlite3 -l m -pthread\t\n" +
+		"example.org/a/zip\t-lz\tzlib --static\n" +
+		"malformed line\n"
+
+	libs := mergeNativeLibraries(parseCgoLibraries([]byte(stdout)))
+	expected := []nativeLibrary{
+		{"m", []string{"example.org/a/db"}},
+		{"sqlite3", []string{"example.org/a/db"}},
+		{"z", []string{"example.org/a/zip"}},
+		{"zlib", []string{"example.org/a/zip (pkg-config)"}},
+	}
+	if !reflect.DeepEqual(libs, expected) {
+		t.Errorf("expected %+v but got %+v", expected, libs)
+	}
+}
+
+func TestMergeNativeLibraries(t *testing.T) {
+	libs := mergeNativeLibraries([]nativeLibrary{
+		{"z", []string{"example.org/a"}},
+		{"m", []string{"server"}},
+		{"z", []string{"example.org/b"}},
+		{"z", []string{"example.org/a"}},
+	})
+	expected := []nativeLibrary{
+		{"m", []string{"server"}},
+		{"z", []string{"example.org/a", "example.org/b"}},
+	}
+	if !reflect.DeepEqual(libs, expected) {
+		t.Errorf("expected %+v but got %+v", expected, libs)
+	}
+}
+
+func TestBinaryLibrariesNotBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := binaryLibraries(path); err == nil {
+		t.Errorf("expected an error for a file that isn't a binary")
+	}
+}
@@ -0,0 +1,158 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// checkFailedError is returned by a check that finds problems. Missing
+// counts the problems that are a missing or unidentified license, rather
+// than a violation. See exitStatus.
+type checkFailedError struct {
+	Problems int
+	Missing  int
+}
+
+func (e *checkFailedError) Error() string {
+	return fmt.Sprintf("check failed: %d problem(s) found", e.Problems)
+}
+
+// staleReportError is returned by a check against a committed report that
+// is out of date.
+type staleReportError struct {
+	Path  string
+	Hunks int
+}
+
+func (e *staleReportError) Error() string {
+	return fmt.Sprintf("check failed: %s is out of date (%d change(s))", e.Path, e.Hunks)
+}
+
+// runCheck implements the check command: every module is scanned and
+// compared against the baseline and the license policy, whichever are
+// given. Each unapproved module or license, and each policy violation, is
+// written to stdout, and the check fails if there are any. With --against,
+// the report is instead compared with a committed one (see
+// runCheckAgainst).
+func runCheck(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	if o.Against != "" {
+		return runCheckAgainst(ctx, o, args, stdout)
+	}
+	if (o.baseline == nil) && (o.policy == nil) {
+		return fmt.Errorf("check requires a --baseline, a --policy (or a %s or %s file) or --against", defaultPolicyFile, defaultTOMLPolicyFile)
+	}
+
+	summary := newRunSummary()
+
+	modules, err := modulesToScan(ctx, o, args)
+	if err != nil {
+		return err
+	}
+
+	problems, missing := 0, 0
+	problem := func(module string, reason string) error {
+		problems++
+		ciViolation(module, reason)
+		_, err := fmt.Fprintf(stdout, "%s: %s\n", module, reason)
+		return err
+	}
+
+	err = scanModules(ctx, o, modules, summary, func(e Entry) error {
+		if (o.baseline != nil) && !o.baseline.approved(e) {
+			if err := problem(e.Module, o.baseline.reason(e)); err != nil {
+				return err
+			}
+		}
+		if o.policy != nil {
+			if reason := o.policy.violation(e); reason != "" {
+				return problem(e.Module, reason)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// a module without a license is unknown to the policy
+	if o.policy != nil {
+		for _, module := range summary.Failed {
+			if reason := o.policy.missing(module); reason != "" {
+				missing++
+				if err := problem(module, reason); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	summary.finish()
+	summary.log()
+	if err := writeCISummary(summary, "", problems, os.Getenv); err != nil {
+		return err
+	}
+	if o.SummaryPath != "" {
+		if err := summary.writeFile(o.SummaryPath); err != nil {
+			return err
+		}
+	}
+
+	if problems > 0 {
+		return &checkFailedError{Problems: problems, Missing: missing}
+	}
+	return nil
+}
+
+// runCheckAgainst regenerates the report, as runScan would, and compares it
+// with a committed report. If they differ, a unified diff from the committed
+// report to the regenerated one is written to stdout, and the check fails.
+//
+// Unless a format is given, on the command-line or in the policy file, the
+// report is regenerated in the format of the committed report.
+func runCheckAgainst(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	committed, err := os.ReadFile(o.Against)
+	if err != nil {
+		return fmt.Errorf("error reading committed report: %v", err)
+	}
+
+	formatGiven := o.set["format"] || o.set["list"] ||
+		((o.policy != nil) && (o.policy.Report.Format != ""))
+	if !formatGiven {
+		switch format := detectReportFormat(committed); format {
+		case "json", "gosource", "ort":
+			o.Format = format
+		case "list":
+			o.List = true
+		}
+	}
+
+	// the report goes to the buffer, not the output file, which may well be
+	// the committed report, and has the committed report's timestamps, so
+	// that only a change to its content is a difference
+	var regenerated bytes.Buffer
+	output := o.Output
+	times := parseReportTimes(committed)
+	o.Output, o.committed = "", &times
+	err = runScan(ctx, o, args, &regenerated)
+	o.Output, o.committed = output, nil
+	if err != nil {
+		return err
+	}
+
+	hunks, err := writeUnifiedDiff(stdout, o.Against, o.Against+" (regenerated)",
+		splitLines(strings.ReplaceAll(string(committed), "\r\n", "\n")),
+		splitLines(regenerated.String()))
+	if err != nil {
+		return err
+	}
+	if hunks > 0 {
+		ciViolation(o.Against, "out of date: regenerate it and commit the result")
+		return &staleReportError{Path: o.Against, Hunks: hunks}
+	}
+	logf(levelInfo, phaseSummary, "", nil, "%s is up to date", o.Against)
+	return nil
+}
@@ -0,0 +1,126 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCheckAgainst(t *testing.T) {
+	committed := filepath.Join(t.TempDir(), "3rd-party-licenses.txt")
+	newOptions := func() *options {
+		return &options{
+			Format:  "text",
+			Output:  committed,
+			Against: committed,
+			overrides: overrides{
+				"example.org/a": override{When: overrideAlways, Text: "License A"},
+				stdlibModule:    override{When: overrideAlways, Text: "License Go"},
+			},
+		}
+	}
+	args := []string{"example.org/a@v1.0.0"}
+
+	// write the report to commit, as the scan command would
+	var report bytes.Buffer
+	o := newOptions()
+	o.Output = ""
+	if err := runScan(context.Background(), o, args, &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(committed, report.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runCheck(context.Background(), newOptions(), args, &stdout); err != nil {
+		t.Fatalf("expected an up to date report but got %v:\n%s", err, stdout.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no diff but got %q", stdout.String())
+	}
+
+	// the committed report is left alone, even though it's the output file
+	data, _ := os.ReadFile(committed)
+	if !bytes.Equal(data, report.Bytes()) {
+		t.Errorf("expected the committed report to be unchanged")
+	}
+
+	// a license changes upstream
+	o = newOptions()
+	o.overrides["example.org/a"] = override{When: overrideAlways, Text: "License A, revised"}
+	err := runCheck(context.Background(), o, args, &stdout)
+	var stale *staleReportError
+	if !errors.As(err, &stale) || (exitStatus(err) != exitViolation) {
+		t.Fatalf("expected a stale report but got %v", err)
+	}
+	if !strings.Contains(stdout.String(), "\n-License A\n+License A, revised\n") {
+		t.Errorf("expected a diff of the license but got:\n%s", stdout.String())
+	}
+}
+
+func TestRunCheckAgainstFormat(t *testing.T) {
+	committed := filepath.Join(t.TempDir(), "licenses.json")
+	if err := os.WriteFile(committed, []byte(`{"generated": "", "entries": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &options{
+		Format:  "text",
+		Against: committed,
+		overrides: overrides{
+			"example.org/a": override{When: overrideAlways, Text: "License A"},
+			stdlibModule:    override{When: overrideAlways, Text: "License Go"},
+		},
+	}
+	var stdout bytes.Buffer
+	err := runCheck(context.Background(), o, []string{"example.org/a@v1.0.0"}, &stdout)
+	if (o.Format != "json") || (err == nil) || !strings.Contains(stdout.String(), `+  "entries": [`) {
+		t.Errorf("expected a diff of JSON reports but got %s, %v:\n%s", o.Format, err, stdout.String())
+	}
+}
+
+func TestRunCheckAgainstTimestamps(t *testing.T) {
+	if epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); ok {
+		os.Unsetenv("SOURCE_DATE_EPOCH")
+		defer os.Setenv("SOURCE_DATE_EPOCH", epoch)
+	}
+
+	for _, format := range []string{"json", "text"} {
+		committed := filepath.Join(t.TempDir(), "licenses."+format)
+		newOptions := func() *options {
+			return &options{
+				Format:     format,
+				Checksums:  true,
+				Provenance: true,
+				Trailer:    format == "text",
+				Against:    committed,
+				overrides: overrides{
+					"example.org/a": override{When: overrideAlways, Text: "License A"},
+					stdlibModule:    override{When: overrideAlways, Text: "License Go"},
+				},
+			}
+		}
+		args := []string{"example.org/a@v1.0.0"}
+
+		var report bytes.Buffer
+		if err := runScan(context.Background(), newOptions(), args, &report); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if err := os.WriteFile(committed, report.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// regenerated at a later time, which isn't a difference
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+		var stdout bytes.Buffer
+		if err := runCheck(context.Background(), newOptions(), args, &stdout); err != nil {
+			t.Errorf("%s: expected an up to date report but got %v:\n%s", format, err, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package licenses
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ciMode is "github" with --ci=github, to integrate with GitHub Actions, or
+// empty.
+var ciMode string
+
+func validateCI(mode string) error {
+	switch mode {
+	case "", "github":
+		return nil
+	default:
+		return fmt.Errorf("unknown CI integration %q (expected github)", mode)
+	}
+}
+
+// githubEscape escapes the data of a GitHub Actions workflow command, or
+// with property true, the value of one of its properties.
+func githubEscape(s string, property bool) string {
+	s = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+	if property {
+		s = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(s)
+	}
+	return s
+}
+
+// githubAnnotation returns the workflow command that annotates a warning or
+// error event in GitHub Actions, or an empty string for other events.
+func githubAnnotation(ev logEvent) string {
+	if (ev.Level != levelWarning) && (ev.Level != levelError) {
+		return ""
+	}
+	title := "gocomply"
+	if ev.Module != "" {
+		title += ": " + ev.Module
+	}
+	return fmt.Sprintf("::%s title=%s::%s", ev.Level, githubEscape(title, true), githubEscape(ev.Message, false))
+}
+
+// ciViolation logs a policy violation or other problem found by a check
+// as an error, so that it is annotated in CI. Outside CI, the problem is
+// only written to stdout by the check.
+func ciViolation(module string, reason string) {
+	if ciMode != "" {
+		logf(levelError, phaseLicense, module, nil, "%s: %s", module, reason)
+	}
+}
+
+// writeCISummary writes a run's summary as a GitHub Actions job summary, and
+// its counts and the report path as step outputs, with --ci=github.
+// Otherwise, it does nothing. getenv is os.Getenv, except in tests.
+func writeCISummary(s *runSummary, report string, violations int, getenv func(string) string) error {
+	if ciMode != "github" {
+		return nil
+	}
+
+	failures := s.LookupFailures + s.LicenseFailures
+	var b strings.Builder
+	b.WriteString("### gocomply\n\n")
+	b.WriteString("| Modules | Licenses found | Failed | Unidentified | Violations | License changed |\n")
+	b.WriteString("|--------:|---------------:|-------:|-------------:|-----------:|----------------:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d |\n\n",
+		s.Modules, s.Found, failures, len(s.Unknown), violations, len(s.LicenseChanged))
+
+	if len(s.SPDX) > 0 {
+		ids := make([]string, 0, len(s.SPDX))
+		for id := range s.SPDX {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		b.WriteString("| License | Modules |\n|---------|--------:|\n")
+		for _, id := range ids {
+			fmt.Fprintf(&b, "| %s | %d |\n", id, s.SPDX[id])
+		}
+		b.WriteString("\n")
+	}
+
+	list := func(title string, modules []string) {
+		if len(modules) > 0 {
+			fmt.Fprintf(&b, "**%s:** `%s`\n\n", title, strings.Join(modules, "`, `"))
+		}
+	}
+	list("Missing from report", s.Failed)
+	list("Unidentified licenses", s.Unknown)
+	list("License changed", s.LicenseChanged)
+	list("Not scanned", s.NotScanned)
+
+	if err := appendFile(getenv("GITHUB_STEP_SUMMARY"), b.String()); err != nil {
+		return fmt.Errorf("error writing job summary: %v", err)
+	}
+
+	outputs := fmt.Sprintf("modules=%d\nlicenses-found=%d\nfailed=%d\nunidentified=%d\nviolations=%d\nlicense-changed=%d\nreport=%s\n",
+		s.Modules, s.Found, failures, len(s.Unknown), violations, len(s.LicenseChanged), report)
+	if err := appendFile(getenv("GITHUB_OUTPUT"), outputs); err != nil {
+		return fmt.Errorf("error writing step outputs: %v", err)
+	}
+	return nil
+}
+
+// appendFile appends text to a file, if path isn't empty.
+func appendFile(path string, text string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
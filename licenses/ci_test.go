@@ -0,0 +1,71 @@
+package licenses
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogGitHubAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	oldOutput, oldMode := logOutput, ciMode
+	logOutput, ciMode = &buf, "github"
+	defer func() { logOutput, ciMode = oldOutput, oldMode }()
+
+	logf(levelInfo, phaseModule, "example.org/a", nil, "[1/2] example.org/a")
+	logf(levelWarning, phaseLicense, "example.org/a", nil, "no license found:\n100%% sure")
+	logf(levelError, phaseLicense, "example.org/b,c", nil, "example.org/b,c: GPL-3.0-only is denied")
+
+	expected := "[1/2] example.org/a\n" +
+		"::warning title=gocomply%3A example.org/a::no license found:%0A100%25 sure\n" +
+		"::error title=gocomply%3A example.org/b%2Cc::example.org/b,c: GPL-3.0-only is denied\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestWriteCISummary(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{
+		"GITHUB_STEP_SUMMARY": filepath.Join(dir, "summary.md"),
+		"GITHUB_OUTPUT":       filepath.Join(dir, "output"),
+	}
+	getenv := func(key string) string { return env[key] }
+
+	s := newRunSummary()
+	s.Modules = 3
+	s.found(Entry{Module: "example.org/a", SPDX: "MIT"})
+	s.found(Entry{Module: "example.org/b"})
+	s.licenseFailed("example.org/c", os.ErrNotExist)
+
+	// without --ci=github, nothing is written
+	if err := writeCISummary(s, "licenses.txt", 1, getenv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(env["GITHUB_OUTPUT"]); !os.IsNotExist(err) {
+		t.Errorf("expected no step outputs but got %v", err)
+	}
+
+	oldMode := ciMode
+	ciMode = "github"
+	defer func() { ciMode = oldMode }()
+	if err := writeCISummary(s, "licenses.txt", 1, getenv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary, _ := os.ReadFile(env["GITHUB_STEP_SUMMARY"])
+	for _, expected := range []string{"| 3 | 2 | 1 | 1 | 1 | 0 |\n", "| MIT | 1 |\n", "**Missing from report:** `example.org/c`\n"} {
+		if !strings.Contains(string(summary), expected) {
+			t.Errorf("expected the job summary to contain %q but got:\n%s", expected, summary)
+		}
+	}
+
+	output, _ := os.ReadFile(env["GITHUB_OUTPUT"])
+	for _, expected := range []string{"modules=3\n", "licenses-found=2\n", "failed=1\n", "violations=1\n", "report=licenses.txt\n"} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("expected the step outputs to contain %q but got:\n%s", expected, output)
+		}
+	}
+}
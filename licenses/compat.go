@@ -0,0 +1,299 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Compatibility finding severities, from least to most severe
+const (
+	severityInfo    = "info"    // an obligation to be aware of
+	severityWarning = "warning" // needs a closer look by a human
+	severityError   = "error"   // commonly considered incompatible
+)
+
+// Distribution models, which decide which copyleft obligations apply
+const (
+	distributeBinary = "binary" // binaries are distributed to others (the default)
+	distributeSource = "source" // only source code is distributed
+	distributeSaaS   = "saas"   // only run as a network service, never distributed
+)
+
+// licenseFamily is a broad category of license for compatibility checks
+type licenseFamily int
+
+const (
+	familyUnknown         licenseFamily = iota
+	familyPermissive                    // e.g. MIT, BSD-3-Clause, Apache-2.0
+	familyWeakCopyleft                  // e.g. LGPL, MPL: copyleft limited to the library or file
+	familyStrongCopyleft                // e.g. GPL: copyleft extends to the whole program
+	familyNetworkCopyleft               // e.g. AGPL: copyleft extends to network use
+)
+
+var licenseFamilies = map[string]licenseFamily{
+	"0BSD":         familyPermissive,
+	"Apache-2.0":   familyPermissive,
+	"BSD-1-Clause": familyPermissive,
+	"BSD-2-Clause": familyPermissive,
+	"BSD-3-Clause": familyPermissive,
+	"BSL-1.0":      familyPermissive,
+	"CC0-1.0":      familyPermissive,
+	"ISC":          familyPermissive,
+	"MIT":          familyPermissive,
+	"MIT-0":        familyPermissive,
+	"Unlicense":    familyPermissive,
+	"Zlib":         familyPermissive,
+
+	"CDDL-1.0":          familyWeakCopyleft,
+	"EPL-1.0":           familyWeakCopyleft,
+	"EPL-2.0":           familyWeakCopyleft,
+	"LGPL-2.1-only":     familyWeakCopyleft,
+	"LGPL-2.1-or-later": familyWeakCopyleft,
+	"LGPL-3.0-only":     familyWeakCopyleft,
+	"LGPL-3.0-or-later": familyWeakCopyleft,
+	"MPL-2.0":           familyWeakCopyleft,
+
+	"GPL-2.0-only":     familyStrongCopyleft,
+	"GPL-2.0-or-later": familyStrongCopyleft,
+	"GPL-3.0-only":     familyStrongCopyleft,
+	"GPL-3.0-or-later": familyStrongCopyleft,
+
+	"AGPL-3.0-only":     familyNetworkCopyleft,
+	"AGPL-3.0-or-later": familyNetworkCopyleft,
+	"SSPL-1.0":          familyNetworkCopyleft,
+}
+
+// gpl2OnlyIncompatible are licenses commonly considered incompatible with
+// GPL-2.0-only, which can't be combined with their additional terms.
+var gpl2OnlyIncompatible = map[string]bool{
+	"Apache-2.0":        true,
+	"GPL-3.0-only":      true,
+	"GPL-3.0-or-later":  true,
+	"LGPL-3.0-only":     true,
+	"LGPL-3.0-or-later": true,
+	"AGPL-3.0-only":     true,
+	"AGPL-3.0-or-later": true,
+	"EPL-1.0":           true,
+	"EPL-2.0":           true,
+	"CDDL-1.0":          true,
+	"MPL-1.1":           true,
+}
+
+// projectLicense describes the license and distribution model of the
+// project being checked.
+type projectLicense struct {
+	License      string `yaml:"license" json:"license"` // SPDX expression
+	Distribution string `yaml:"distribution" json:"distribution"`
+}
+
+// compatFinding is one compatibility concern about a dependency
+type compatFinding struct {
+	Module   string `json:"module"`
+	SPDX     string `json:"spdx"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// detectProjectLicense identifies the license of the project in the current
+// directory from its license file.
+func detectProjectLicense() (string, error) {
+	for _, name := range repoLicenseFiles {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		if c := classifyLicense(string(data)); c.SPDX != "" {
+			return c.SPDX, nil
+		}
+	}
+	return "", fmt.Errorf("unable to detect the project's license from a license file in the current directory (give it with --project-license)")
+}
+
+// compatibility returns any compatibility findings for a dependency's
+// license, for a project with the given license and distribution model.
+// For a dependency under several alternative (OR) licenses, the least
+// severe alternative applies.
+func (p projectLicense) compatibility(e Entry) []compatFinding {
+	if e.SPDX == "" {
+		return []compatFinding{{e.Module, spdxNoAssertion, severityWarning, "license unknown"}}
+	}
+
+	var best []compatFinding
+	bestSeverity := -1
+	for i, alternative := range splitSPDXOr(e.SPDX) {
+		var findings []compatFinding
+		worst := 0
+		for _, id := range spdxExpressionIDs(alternative) {
+			if severity, message := p.compatibilityOf(id); message != "" {
+				findings = append(findings, compatFinding{e.Module, id, severity, message})
+				if rank := severityRank(severity); rank > worst {
+					worst = rank
+				}
+			}
+		}
+		if (i == 0) || (worst < bestSeverity) || ((worst == bestSeverity) && (len(findings) < len(best))) {
+			best, bestSeverity = findings, worst
+		}
+	}
+	return best
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case severityError:
+		return 3
+	case severityWarning:
+		return 2
+	case severityInfo:
+		return 1
+	}
+	return 0
+}
+
+// compatibilityOf returns the severity and a description of any concern with
+// a single dependency license, or an empty message if there is none.
+func (p projectLicense) compatibilityOf(id string) (string, string) {
+	projectIDs := spdxExpressionIDs(p.License)
+	projectFamily := familyPermissive
+	for _, pid := range projectIDs {
+		if f := licenseFamilies[pid]; f > projectFamily {
+			projectFamily = f
+		}
+		if (pid == "GPL-2.0-only") && gpl2OnlyIncompatible[id] {
+			return severityError, fmt.Sprintf("%s is commonly considered incompatible with the project's %s", id, pid)
+		}
+	}
+
+	// the project is already under the same terms
+	for _, pid := range projectIDs {
+		if strings.EqualFold(id, pid) {
+			return "", ""
+		}
+	}
+
+	distributed := p.Distribution != distributeSaaS
+	binary := (p.Distribution == "") || (p.Distribution == distributeBinary)
+
+	switch licenseFamilies[id] {
+	case familyPermissive:
+		return "", ""
+
+	case familyWeakCopyleft:
+		if !distributed {
+			return severityInfo, fmt.Sprintf("%s is weak copyleft, but imposes no obligations on network use", id)
+		}
+		if binary && strings.HasPrefix(id, "LGPL") {
+			return severityWarning, fmt.Sprintf("%s requires that users can relink a binary with a modified library, which is awkward for statically linked Go binaries", id)
+		}
+		return severityInfo, fmt.Sprintf("%s is weak copyleft: modifications to its files must be released under %s", id, id)
+
+	case familyStrongCopyleft:
+		if !distributed {
+			return severityInfo, fmt.Sprintf("%s is copyleft, but imposes no obligations on network use", id)
+		}
+		if projectFamily >= familyStrongCopyleft {
+			return severityInfo, fmt.Sprintf("%s is copyleft: the whole program must be released under compatible terms", id)
+		}
+		return severityError, fmt.Sprintf("%s is copyleft: distributing the program requires releasing it under %s, not %s", id, id, p.License)
+
+	case familyNetworkCopyleft:
+		if projectFamily >= familyNetworkCopyleft {
+			return severityInfo, fmt.Sprintf("%s is network copyleft: the source must be offered to network users", id)
+		}
+		return severityError, fmt.Sprintf("%s is network copyleft: even running the program as a service requires releasing it under %s, not %s", id, id, p.License)
+	}
+
+	return severityWarning, fmt.Sprintf("%s is not a license known to the compatibility check", id)
+}
+
+// validate returns an error if the distribution model is unknown.
+func (p projectLicense) validate() error {
+	switch p.Distribution {
+	case "", distributeBinary, distributeSource, distributeSaaS:
+		return nil
+	default:
+		return fmt.Errorf("unknown distribution %q (expected %s, %s or %s)", p.Distribution, distributeBinary, distributeSource, distributeSaaS)
+	}
+}
+
+// runCompat implements the compat command: every module is scanned and its
+// license checked for compatibility with the project's own license. Each
+// finding is written to stdout, as a "severity: module: message" line or, with
+// --format=json, as a JSON array. The command fails if any finding is an error.
+func runCompat(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	project := o.projectLicense()
+	if project.License == "" {
+		detected, err := detectProjectLicense()
+		if err != nil {
+			return err
+		}
+		project.License = detected
+	}
+	if err := project.validate(); err != nil {
+		return err
+	}
+	if (o.Format != "text") && (o.Format != "json") {
+		return fmt.Errorf("unknown compat format %q", o.Format)
+	}
+
+	logf(levelInfo, phaseSetup, "", nil, "checking compatibility with %s (distribution: %s)", project.License, project.distribution())
+
+	modules, err := modulesToScan(ctx, o, args)
+	if err != nil {
+		return err
+	}
+
+	summary := newRunSummary()
+	findings := []compatFinding{}
+	failures := 0
+
+	err = scanModules(ctx, o, modules, summary, func(e Entry) error {
+		for _, f := range project.compatibility(e) {
+			if f.Severity == severityError {
+				failures++
+			}
+			findings = append(findings, f)
+			if o.Format == "text" {
+				if _, err := fmt.Fprintf(stdout, "%s: %s: %s\n", f.Severity, f.Module, f.Message); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return fmt.Errorf("error writing findings: %v", err)
+		}
+	}
+
+	summary.finish()
+	summary.log()
+	if o.SummaryPath != "" {
+		if err := summary.writeFile(o.SummaryPath); err != nil {
+			return err
+		}
+	}
+
+	if failures > 0 {
+		return &checkFailedError{Problems: failures}
+	}
+	return nil
+}
+
+func (p projectLicense) distribution() string {
+	if p.Distribution == "" {
+		return distributeBinary
+	}
+	return p.Distribution
+}
@@ -0,0 +1,44 @@
+package licenses
+
+import "testing"
+
+func TestCompatibility(t *testing.T) {
+	tests := []struct {
+		project  projectLicense
+		spdx     string
+		severity string // of the only finding, or empty for no findings
+	}{
+		{projectLicense{License: "MIT"}, "BSD-3-Clause", ""},
+		{projectLicense{License: "MIT"}, "", severityWarning},
+		{projectLicense{License: "MIT"}, "GPL-3.0-only", severityError},
+		{projectLicense{License: "MIT", Distribution: distributeSaaS}, "GPL-3.0-only", severityInfo},
+		{projectLicense{License: "MIT", Distribution: distributeSaaS}, "AGPL-3.0-only", severityError},
+		{projectLicense{License: "MIT"}, "LGPL-2.1-only", severityWarning},
+		{projectLicense{License: "MIT", Distribution: distributeSource}, "LGPL-2.1-only", severityInfo},
+		{projectLicense{License: "MIT"}, "MPL-2.0", severityInfo},
+		{projectLicense{License: "GPL-3.0-or-later"}, "GPL-3.0-or-later", ""},
+		{projectLicense{License: "GPL-3.0-or-later"}, "GPL-2.0-or-later", severityInfo},
+		{projectLicense{License: "GPL-2.0-only"}, "Apache-2.0", severityError},
+		{projectLicense{License: "MIT"}, "GPL-3.0-only OR MIT", ""},
+		{projectLicense{License: "MIT"}, "Example-1.0", severityWarning},
+	}
+
+	for _, tt := range tests {
+		findings := tt.project.compatibility(Entry{Module: "example.org/a", SPDX: tt.spdx})
+		if tt.severity == "" {
+			if len(findings) != 0 {
+				t.Errorf("%+v %q: expected no findings but got %+v", tt.project, tt.spdx, findings)
+			}
+			continue
+		}
+		if (len(findings) != 1) || (findings[0].Severity != tt.severity) {
+			t.Errorf("%+v %q: expected one %s finding but got %+v", tt.project, tt.spdx, tt.severity, findings)
+		}
+	}
+}
+
+func TestProjectLicenseValidate(t *testing.T) {
+	if err := (projectLicense{Distribution: "cloud"}).validate(); err == nil {
+		t.Errorf("expected an error for an unknown distribution")
+	}
+}
@@ -0,0 +1,122 @@
+package licenses
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// reportConfig sets the defaults of the report options in the policy file,
+// so that a project can commit them. Unset fields keep their defaults, and
+// command-line options take precedence.
+type reportConfig struct {
+	Format      string `yaml:"format"`
+	Output      string `yaml:"output"`
+	Trailer     *bool  `yaml:"trailer"`
+	Checksums   *bool  `yaml:"checksums"`
+	Provenance  *bool  `yaml:"provenance"`
+	Obligations *bool  `yaml:"obligations"`
+	Copyrights  *bool  `yaml:"source_copyrights"`
+	PlainText   *bool  `yaml:"plain_text"`
+	Tools       string `yaml:"tools"`
+}
+
+// authConfig sets which credentials and fallbacks may be used, in the policy
+// file. Unset fields keep their defaults, and command-line options take
+// precedence.
+type authConfig struct {
+	GitCredentials *bool `yaml:"git_credentials"`
+	SSH            *bool `yaml:"ssh"`
+}
+
+// forgeLayoutNames are the layouts that may be given for a host in the
+// forges section of the policy file.
+var forgeLayoutNames = map[string]forgeLayout{
+	layoutGitea.Name:  layoutGitea,
+	layoutGitLab.Name: layoutGitLab,
+	layoutCgit.Name:   layoutCgit,
+	layoutGogs.Name:   layoutGogs,
+}
+
+// validateForges checks that each host in the forges section of the policy
+// file has a known layout.
+func validateForges(forges map[string]string) error {
+	for host, name := range forges {
+		if _, ok := forgeLayoutNames[name]; !ok {
+			return fmt.Errorf("unknown layout %q for forge %s (expected gitea, gitlab, cgit or gogs)", name, host)
+		}
+	}
+	return nil
+}
+
+// forgeHosts are the layouts of hosts given in the policy file, by lower
+// case host, which detectForgeLayouts uses instead of fetching a
+// repository's page to identify its forge.
+var forgeHosts = map[string]forgeLayout{}
+
+// configureForges sets forgeHosts from the forges section of the policy
+// file (already validated).
+func configureForges(forges map[string]string) {
+	forgeHosts = make(map[string]forgeLayout)
+	for host, name := range forges {
+		forgeHosts[strings.ToLower(host)] = forgeLayoutNames[name]
+	}
+}
+
+// forgeHostLayout returns the layout configured for the host of a
+// repository URL, if any.
+func forgeHostLayout(root string) (forgeLayout, bool) {
+	u, err := url.Parse(root)
+	if err != nil {
+		return forgeLayout{}, false
+	}
+	layout, ok := forgeHosts[strings.ToLower(u.Host)]
+	return layout, ok
+}
+
+// applyConfig sets the options that weren't given on the command-line from
+// the policy file, if any.
+func (o *options) applyConfig() {
+	if o.policy == nil {
+		return
+	}
+
+	setString := func(dst *string, value string, flags ...string) {
+		for _, f := range flags {
+			if o.set[f] {
+				return
+			}
+		}
+		if value != "" {
+			*dst = value
+		}
+	}
+	setBool := func(dst *bool, value *bool, flag string) {
+		if (value != nil) && !o.set[flag] {
+			*dst = *value
+		}
+	}
+
+	r := o.policy.Report
+	setString(&o.Format, r.Format, "format")
+	setString(&o.Output, r.Output, "output", "o")
+	setBool(&o.Trailer, r.Trailer, "trailer")
+	setBool(&o.Checksums, r.Checksums, "checksums")
+	setBool(&o.Provenance, r.Provenance, "provenance")
+	setBool(&o.Obligations, r.Obligations, "obligations")
+	setBool(&o.Copyrights, r.Copyrights, "source-copyrights")
+	setBool(&o.PlainText, r.PlainText, "plain-text")
+	setString(&o.Tools, r.Tools, "tools")
+
+	// already validated
+	if !o.set["exclude"] {
+		o.Exclude, _ = parsePatterns(o.policy.Exclude)
+	}
+	if !o.set["only"] {
+		o.Only, _ = parsePatterns(o.policy.Only)
+	}
+
+	a := o.policy.Auth
+	setBool(&o.GitCredentials, a.GitCredentials, "git-credentials")
+	setBool(&o.SSH, a.SSH, "ssh")
+}
@@ -0,0 +1,104 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := `report:
+  format: json
+  output: licenses.json
+  checksums: true
+auth:
+  ssh: false
+forges:
+  git.example.org: cgit
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the command-line takes precedence
+	o := &options{Format: "text", SSH: true, policy: p, set: map[string]bool{"o": true}}
+	o.applyConfig()
+	if (o.Format != "json") || (o.Output != "") || !o.Checksums || o.SSH {
+		t.Errorf("unexpected options %+v", o)
+	}
+
+	configureForges(p.Forges)
+	defer configureForges(nil)
+	expected := []FileURL{
+		{"https://git.example.org/user/repo/plain/LICENSE?h=main", "main"},
+		{"https://git.example.org/user/repo/plain/LICENSE?h=master", "master"},
+	}
+	if got := resolveForgeFileURL(context.Background(), "https://git.example.org/user/repo", "LICENSE"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+
+	if err := os.WriteFile(path, []byte("forges:\n  git.example.org: svn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(path); err == nil {
+		t.Errorf("expected an error for an unknown forge layout")
+	}
+}
+
+func TestLoadPolicyTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gocomply.toml")
+	data := `allow = ["MIT", "BSD-3-Clause"]
+
+[report]
+format = "json"
+checksums = true
+
+[auth]
+git_credentials = false
+
+[forges]
+"git.example.org" = "cgit"
+
+[[exceptions]]
+module = "example.org/foo"
+reason = "internal tool"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"MIT", "BSD-3-Clause"}
+	if !reflect.DeepEqual(p.Allow, expected) {
+		t.Errorf("expected %v but got %v", expected, p.Allow)
+	}
+	if (p.Unknown != "deny") || (len(p.Exceptions) != 1) || (p.Exceptions[0].Module != "example.org/foo") {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	if (p.Report.Format != "json") || (p.Report.Checksums == nil) || !*p.Report.Checksums {
+		t.Errorf("unexpected report config %+v", p.Report)
+	}
+	if (p.Auth.GitCredentials == nil) || *p.Auth.GitCredentials || (p.Auth.SSH != nil) {
+		t.Errorf("unexpected auth config %+v", p.Auth)
+	}
+	if p.Forges["git.example.org"] != "cgit" {
+		t.Errorf("expected cgit but got %q", p.Forges["git.example.org"])
+	}
+
+	if err := os.WriteFile(path, []byte("[forges]\n\"git.example.org\" = \"svn\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(path); err == nil {
+		t.Errorf("expected an error for an unknown forge layout")
+	}
+}
@@ -0,0 +1,142 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// copyrightLine matches a line starting with a copyright notice, after any
+// comment markers, e.g. "Copyright (c) 2009 The Go Authors."
+var copyrightLine = regexp.MustCompile(`(?i)^[\s#*/;-]*(copyright\b|\(c\)|©)`)
+
+// copyrightMarker distinguishes an actual notice from prose that merely
+// mentions copyright, such as "the above copyright notice" or the template
+// "Copyright [yyyy] [name of copyright owner]": a notice has a year or a
+// copyright symbol.
+var copyrightMarker = regexp.MustCompile(`(?i)\(c\)|©|\b(19|20)\d\d\b`)
+
+// extractCopyrights returns each distinct copyright notice in a text, in
+// order, without any leading comment markers.
+func extractCopyrights(text string) []string {
+	var notices []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !copyrightLine.MatchString(line) || !copyrightMarker.MatchString(line) {
+			continue
+		}
+
+		notice := strings.Join(strings.Fields(strings.TrimLeft(line, " \t#*/;-")), " ")
+		if !seen[notice] {
+			seen[notice] = true
+			notices = append(notices, notice)
+		}
+	}
+	return notices
+}
+
+// mergeCopyrights appends each notice in b that is not already in a.
+func mergeCopyrights(a []string, b []string) []string {
+	seen := make(map[string]bool)
+	for _, notice := range a {
+		seen[notice] = true
+	}
+	for _, notice := range b {
+		if !seen[notice] {
+			seen[notice] = true
+			a = append(a, notice)
+		}
+	}
+	return a
+}
+
+// moduleDir returns the directory of a module in the local module cache, or
+// an empty string if it hasn't been downloaded. For a module that go.mod
+// replaces, it is the replacement's directory.
+func moduleDir(ctx context.Context, m Module) (string, error) {
+	if m.ReplaceDir != "" {
+		return m.ReplaceDir, nil
+	}
+	m = m.source()
+	arg := m.Path
+	if m.Version != "" {
+		arg += "@" + m.Version
+	}
+
+	stdout, err := exec.CommandContext(ctx, "go", "list", "-m", "-f", "{{.Dir}}", arg).Output()
+	if err != nil {
+		return "", fmt.Errorf("go list error for module %q: %+v: %s", arg, err, exitErrorStderr(err))
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// sourceCopyrights returns each distinct copyright notice in the header
+// comments (the lines before the package clause) of the Go source files in a
+// directory tree. Vendored code and test data are skipped.
+func sourceCopyrights(dir string) ([]string, error) {
+	var notices []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case "vendor", "testdata":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".go") {
+			return nil
+		}
+
+		header, err := sourceHeader(path)
+		if err != nil {
+			return err
+		}
+		notices = mergeCopyrights(notices, extractCopyrights(header))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading source headers: %v", err)
+	}
+	return notices, nil
+}
+
+// sourceHeader returns the lines of a Go source file before its package
+// clause.
+func sourceHeader(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return readSourceHeader(f)
+}
+
+// readSourceHeader returns the lines of Go source before its package clause.
+func readSourceHeader(r io.Reader) (string, error) {
+	var b strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String(), scanner.Err()
+}
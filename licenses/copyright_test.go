@@ -0,0 +1,58 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractCopyrights(t *testing.T) {
+	text := `Copyright (c) 2009 The Go Authors. All rights reserved.
+Copyright 2015  Example   Ltd
+ * © Someone Else
+
+Redistributions of source code must retain the above copyright notice.
+Copyright [yyyy] [name of copyright owner]
+THE SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS 1999
+Copyright (c) 2009 The Go Authors. All rights reserved.
+`
+	expected := []string{
+		"Copyright (c) 2009 The Go Authors. All rights reserved.",
+		"Copyright 2015 Example Ltd",
+		"© Someone Else",
+	}
+
+	if got := extractCopyrights(text); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+}
+
+func TestSourceCopyrights(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":          "// Copyright 2020 A\n\npackage a\n\n// Copyright 2021 not a header\n",
+		"b/b.go":        "/*\n * Copyright (c) 2021 B\n */\npackage b\n",
+		"c.txt":         "Copyright 2022 not Go source\n",
+		"vendor/v/v.go": "// Copyright 2023 vendored\npackage v\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := sourceCopyrights(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"Copyright 2020 A", "Copyright (c) 2021 B"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+}
@@ -0,0 +1,111 @@
+package licenses
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Modules from the same repository, such as many golang.org/x/* modules or
+// the modules of a monorepo, share the results of lookups and license
+// fetches, rather than each repeating the same requests.
+
+// lookups maps each go-import prefix to the result of looking up a module
+// under that prefix.
+var lookups = struct {
+	sync.Mutex
+	byPrefix map[string]lookupResult
+}{byPrefix: make(map[string]lookupResult)}
+
+type lookupResult struct {
+	gi GoImport
+	gs GoSource
+}
+
+// sharedLookup returns the repository of a module from an earlier lookup of
+// a module under the same go-import prefix, if any.
+func sharedLookup(module string) (GoImport, GoSource, bool) {
+	lookups.Lock()
+	defer lookups.Unlock()
+
+	for prefix := module; prefix != ""; {
+		if r, ok := lookups.byPrefix[prefix]; ok {
+			return r.gi, r.gs, true
+		}
+		i := strings.LastIndex(prefix, "/")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return GoImport{}, GoSource{}, false
+}
+
+// rememberLookup records the result of looking up a module so that other
+// modules under the same go-import prefix can share it.
+func rememberLookup(module string, gi GoImport, gs GoSource) {
+	prefix := gi.ImportPrefix
+	if (prefix == "") || ((prefix != module) && !strings.HasPrefix(module, prefix+"/")) {
+		return
+	}
+
+	lookups.Lock()
+	lookups.byPrefix[prefix] = lookupResult{gi, gs}
+	lookups.Unlock()
+}
+
+// licenses maps each repository (and module subdirectory) to the result of
+// fetching its license. See licenseKey.
+var licenses = struct {
+	sync.Mutex
+	byKey map[string]licenseFile
+}{byKey: make(map[string]licenseFile)}
+
+// majorVersionSuffix matches the major version suffix of a module path,
+// which isn't a directory in the repository.
+var majorVersionSuffix = regexp.MustCompile(`(^|/)v[0-9]+$`)
+
+// licenseKey identifies the work of fetching a module's license: its
+// repository, how files in it are found, and the module's subdirectory
+// within it.
+func licenseKey(module string, gi GoImport, gs GoSource) string {
+	subdir := strings.TrimPrefix(strings.TrimPrefix(module, gi.ImportPrefix), "/")
+	subdir = majorVersionSuffix.ReplaceAllString(subdir, "")
+	return strings.Join([]string{gi.Vcs, gi.RepoRoot, gs.Directory, gs.File, subdir}, " ")
+}
+
+// moduleSubdir returns the directory of a module within its repository, or
+// an empty string for a module at the repository root or if its directory
+// isn't known (such as from a resolver that doesn't give an import prefix).
+func moduleSubdir(module string, gi GoImport) string {
+	if (gi.ImportPrefix == "") || !strings.HasPrefix(module, gi.ImportPrefix+"/") {
+		return ""
+	}
+	subdir := strings.TrimPrefix(module, gi.ImportPrefix+"/")
+	return majorVersionSuffix.ReplaceAllString(subdir, "")
+}
+
+// sharedLicense returns the license of a module from its repository, reusing
+// the license found for an earlier module with the same licenseKey, if any.
+// Failures aren't reused, so that a retry tries again.
+func sharedLicense(ctx context.Context, module string, gi GoImport, gs GoSource) (licenseFile, error) {
+	key := licenseKey(module, gi, gs)
+
+	licenses.Lock()
+	license, ok := licenses.byKey[key]
+	licenses.Unlock()
+	if ok {
+		return license, nil
+	}
+
+	license, err := getLicense(ctx, module, gi, gs)
+	if err != nil {
+		return licenseFile{}, err
+	}
+
+	licenses.Lock()
+	licenses.byKey[key] = license
+	licenses.Unlock()
+	return license, nil
+}
@@ -0,0 +1,37 @@
+package licenses
+
+import "testing"
+
+func TestSharedLookup(t *testing.T) {
+	gi := GoImport{ImportPrefix: "example.org/mono", Vcs: "git", RepoRoot: "https://example.org/mono.git"}
+	rememberLookup("example.org/mono/a", gi, GoSource{})
+	defer func() {
+		lookups.Lock()
+		delete(lookups.byPrefix, gi.ImportPrefix)
+		lookups.Unlock()
+	}()
+
+	for _, module := range []string{"example.org/mono", "example.org/mono/b/c"} {
+		if got, _, ok := sharedLookup(module); !ok || (got != gi) {
+			t.Errorf("%s: expected a shared lookup but got (%+v, %t)", module, got, ok)
+		}
+	}
+	if _, _, ok := sharedLookup("example.org/monorail"); ok {
+		t.Errorf("expected no shared lookup for a different module with a common prefix")
+	}
+}
+
+func TestLicenseKey(t *testing.T) {
+	gi := GoImport{ImportPrefix: "github.com/example/mono", Vcs: "git", RepoRoot: "https://github.com/example/mono"}
+
+	root := licenseKey("github.com/example/mono", gi, GoSource{})
+	if v2 := licenseKey("github.com/example/mono/v2", gi, GoSource{}); v2 != root {
+		t.Errorf("expected a major version to share the key %q but got %q", root, v2)
+	}
+	if sub := licenseKey("github.com/example/mono/sub", gi, GoSource{}); sub == root {
+		t.Errorf("expected a subdirectory to have its own key")
+	}
+	if sub, subV2 := licenseKey("github.com/example/mono/sub", gi, GoSource{}), licenseKey("github.com/example/mono/sub/v3", gi, GoSource{}); sub != subV2 {
+		t.Errorf("expected %q and %q to be equal", sub, subV2)
+	}
+}
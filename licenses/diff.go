@@ -0,0 +1,186 @@
+package licenses
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffMaxCells bounds the table used to find the longest common subsequence
+// of the lines that differ. Past it, they are all shown as replaced.
+const diffMaxCells = 16 * 1024 * 1024
+
+// diffOp is a line of a diff: unchanged (' '), removed ('-') or added ('+').
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// splitLines splits text into lines, without their line endings.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineDiff returns the edits that turn lines a into lines b.
+func lineDiff(a []string, b []string) []diffOp {
+	var ops []diffOp
+
+	// unchanged lines at the start and end are common
+	prefix := 0
+	for (prefix < len(a)) && (prefix < len(b)) && (a[prefix] == b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for (suffix < len(a)-prefix) && (suffix < len(b)-prefix) &&
+		(a[len(a)-1-suffix] == b[len(b)-1-suffix]) {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(ma)+1)*(len(mb)+1) > diffMaxCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		ops = append(ops, lcsDiff(ma, mb)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiff returns the edits that turn a into b, keeping their longest
+// common subsequence of lines.
+func lcsDiff(a []string, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if l, r := lcs[(i+1)*width+j], lcs[i*width+j+1]; l >= r {
+				lcs[i*width+j] = l
+			} else {
+				lcs[i*width+j] = r
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for (i < len(a)) && (j < len(b)) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// writeUnifiedDiff writes the differences between lines a, named from, and
+// lines b, named to, in the unified diff format, and returns the number of
+// hunks written, which is zero if they are the same.
+func writeUnifiedDiff(w io.Writer, from string, to string, a []string, b []string) (int, error) {
+	ops := lineDiff(a, b)
+
+	// the line of a and of b at each op, from 0
+	posA := make([]int, len(ops)+1)
+	posB := make([]int, len(ops)+1)
+	for k, op := range ops {
+		posA[k+1], posB[k+1] = posA[k], posB[k]
+		if op.Kind != '+' {
+			posA[k+1]++
+		}
+		if op.Kind != '-' {
+			posB[k+1]++
+		}
+	}
+
+	hunks := 0
+	for k := 0; k < len(ops); {
+		if ops[k].Kind == ' ' {
+			k++
+			continue
+		}
+
+		// a hunk runs from the context before this change to the context
+		// after the last change that is close enough to join it
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end, unchanged := k, 0
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				unchanged = 0
+			} else if unchanged == 2*diffContext {
+				break
+			} else {
+				unchanged++
+			}
+			end++
+		}
+		if unchanged > diffContext {
+			end -= unchanged - diffContext
+		}
+
+		if hunks == 0 {
+			if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to); err != nil {
+				return hunks, err
+			}
+		}
+		hunks++
+
+		header := fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(posA[start], posA[end]), hunkRange(posB[start], posB[end]))
+		var body strings.Builder
+		body.WriteString(header)
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&body, "%c%s\n", op.Kind, op.Line)
+		}
+		if _, err := io.WriteString(w, body.String()); err != nil {
+			return hunks, err
+		}
+		k = end
+	}
+	return hunks, nil
+}
+
+// hunkRange formats the lines from start up to end (from 0) of a hunk as
+// "line,count" (from 1). An empty range is given by the line before it.
+func hunkRange(start int, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
@@ -0,0 +1,70 @@
+package licenses
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+	}
+	b := append([]string{}, a...)
+	b[1] = "line two"                   // changed
+	b = append(b[:10], b[11:]...)       // line 11 removed
+	b = append(b, "line 21", "line 22") // added at the end
+
+	var out bytes.Buffer
+	hunks, err := writeUnifiedDiff(&out, "a", "b", a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+ line 5
+@@ -8,7 +8,6 @@
+ line 8
+ line 9
+ line 10
+-line 11
+ line 12
+ line 13
+ line 14
+@@ -18,3 +17,5 @@
+ line 18
+ line 19
+ line 20
++line 21
++line 22
+`
+	if (hunks != 3) || (out.String() != expected) {
+		t.Errorf("expected 3 hunks:\n%s\nbut got %d:\n%s", expected, hunks, out.String())
+	}
+}
+
+func TestWriteUnifiedDiffSame(t *testing.T) {
+	var out bytes.Buffer
+	lines := splitLines("a\nb\n")
+	hunks, err := writeUnifiedDiff(&out, "a", "b", lines, lines)
+	if (err != nil) || (hunks != 0) || (out.Len() != 0) {
+		t.Errorf("expected no diff but got %d, %v: %q", hunks, err, out.String())
+	}
+}
+
+func TestWriteUnifiedDiffEmpty(t *testing.T) {
+	var out bytes.Buffer
+	hunks, err := writeUnifiedDiff(&out, "a", "b", nil, []string{"x"})
+	expected := "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n"
+	if (err != nil) || (hunks != 1) || (out.String() != expected) {
+		t.Errorf("expected %q but got %d, %v: %q", expected, hunks, err, out.String())
+	}
+}
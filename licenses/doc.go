@@ -0,0 +1,29 @@
+// Package licenses finds the license of each module that a Go module
+// depends on, as the gocomply command does, for tools such as release
+// pipelines and internal dashboards that embed it.
+//
+// List the modules of the main module in the current directory, or give
+// modules directly, and Scan them:
+//
+//	modules, err := licenses.ListModules(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	results, err := licenses.Scan(ctx, modules, licenses.Options{})
+//	if err != nil {
+//	    return err
+//	}
+//	for _, r := range results {
+//	    if r.Err != nil {
+//	        log.Printf("%s: %v", r.Module.Path, r.Err)
+//	        continue
+//	    }
+//	    fmt.Println(r.Module.Path, r.Entry.SPDX)
+//	}
+//
+// Credentials are read as the gocomply command reads them: from netrc
+// files, the environment and git (see the README). Requests are paced by
+// the package-level rate limiter (see RateLimiter).
+//
+// Main runs the gocomply command itself.
+package licenses
@@ -0,0 +1,200 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// planOnly is true for --dry-run, so that nothing is downloaded only to
+// decide where to download from (see detectForgeLayouts).
+var planOnly bool
+
+// modulePlan is how a module's license would be found, as printed by
+// --dry-run.
+type modulePlan struct {
+	Module   string `json:"module"`
+	Version  string `json:"version,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Repo     string `json:"repository,omitempty"`
+
+	// Requests are the requests that would be made, in order, until one
+	// succeeds. For license files, these are the URLs of "LICENSE", and
+	// Files lists every file name that would be tried at each.
+	Requests []FileURL `json:"requests,omitempty"`
+	Files    []string  `json:"files,omitempty"`
+
+	// Notes describe credentials and fallbacks, and Error why the module
+	// can't be resolved.
+	Notes []string `json:"notes,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// providerName names the provider of a repository, as resolveFileURL
+// chooses it.
+func providerName(gi GoImport) string {
+	root := gi.RepoRoot
+	switch {
+	case gi.Vcs != "git":
+		return gi.Vcs
+	case strings.HasPrefix(root, "https://go.googlesource.com/"):
+		return "googlesource"
+	case strings.HasPrefix(root, "https://git.sr.ht/"):
+		return "sourcehut"
+	case strings.HasPrefix(root, "https://gopkg.in/"):
+		return "gopkg.in"
+	case strings.HasPrefix(root, "https://github.com/"):
+		return "github"
+	}
+	if _, _, ok := gitlabProject(root); ok {
+		return "gitlab"
+	}
+	return "forge"
+}
+
+// planModule resolves a module to its repository, as scanModule does, and
+// returns the requests that would be made for its license without making
+// them. Only the lookup of a module's go-import meta tags, if it isn't
+// already known, is made.
+func planModule(ctx context.Context, m Module, o *options) modulePlan {
+	plan := modulePlan{Module: m.Path, Version: m.Version}
+
+	if ov, ok := o.overrides[m.Path]; ok {
+		if ov.When == overrideAlways {
+			plan.Provider = "override"
+			return plan
+		}
+		plan.Notes = append(plan.Notes, "falls back to its override")
+	}
+
+	if proxy := o.proxy(); (proxy.URL != "") && (m.Version != "") && !o.private.noProxy(m.Path) {
+		plan.Requests = append(plan.Requests, FileURL{URL: proxy.zipURL(m)})
+		plan.Notes = append(plan.Notes, "module proxy first, then the repository")
+	}
+
+	gi, gs, err := lookupModule(ctx, m.Path, o)
+	if err != nil {
+		plan.Error = fmt.Sprintf("unable to lookup module: %v", err)
+		return plan
+	}
+	plan.Repo = gi.RepoRoot
+	plan.Provider = providerName(gi)
+	if o.private.isPrivate(m.Path) {
+		plan.Notes = append(plan.Notes, "private (GOPRIVATE)")
+	}
+
+	if _, ok := resolverFileURLs(ctx, gi, "LICENSE"); ok {
+		plan.Provider = "resolver"
+	} else if (plan.Provider == "github") && githubAuth.IsSet() {
+		dir := strings.TrimSuffix(strings.TrimPrefix(gi.RepoRoot, "https://github.com/"), ".git")
+		plan.Provider = "github api"
+		plan.Requests = append(plan.Requests,
+			FileURL{URL: fmt.Sprintf("%s/repos/%s/license", githubAPI, dir)},
+			FileURL{URL: fmt.Sprintf("%s/repos/%s/git/trees/HEAD", githubAPI, dir), Ref: "HEAD"})
+		plan.Notes = append(plan.Notes, "then the raw files, if the API fails")
+	} else if plan.Provider == "github" {
+		plan.Notes = append(plan.Notes, "no GitHub credentials: raw files only")
+	} else if plan.Provider == "gopkg.in" {
+		plan.Notes = append(plan.Notes, "the tag or branch is found from its GitHub repository's refs")
+	} else if plan.Provider == "gitlab" {
+		host, project, _ := gitlabProject(gi.RepoRoot)
+		plan.Provider = "gitlab api"
+		plan.Requests = append(plan.Requests,
+			FileURL{URL: gitlabAPIURL(host, project, "repository/tree?per_page=100"), Ref: "HEAD"})
+		plan.Notes = append(plan.Notes, "then the raw files, if the API fails")
+	} else if plan.Provider == "forge" {
+		if api, ok := giteaRepo(ctx, gi.RepoRoot); ok {
+			plan.Provider = "gitea api"
+			plan.Requests = append(plan.Requests, FileURL{URL: api + "/contents", Ref: "HEAD"})
+			plan.Notes = append(plan.Notes, "then the raw files, if the API fails")
+		}
+	}
+
+	urls, _, err := resolveFileURL(ctx, gi, gs, "LICENSE")
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	plan.Requests = append(plan.Requests, urls...)
+	plan.Files = httpLicenseFiles
+
+	if o.trySSH(m.Path, gi) {
+		if remote, ok := sshRemote(gi.RepoRoot); ok {
+			plan.Notes = append(plan.Notes, "falls back to a shallow clone of "+remote)
+		}
+	}
+	return plan
+}
+
+// String formats a plan as an indented block of text.
+func (p modulePlan) String() string {
+	var b strings.Builder
+	b.WriteString(p.Module)
+	if p.Version != "" {
+		b.WriteString("@" + p.Version)
+	}
+	b.WriteString("\n")
+
+	line := func(key string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %s: %s\n", key, value)
+		}
+	}
+	line("provider", p.Provider)
+	line("repository", p.Repo)
+	for _, r := range p.Requests {
+		if r.Ref != "" {
+			fmt.Fprintf(&b, "  GET %s (ref %s)\n", r.URL, r.Ref)
+		} else {
+			fmt.Fprintf(&b, "  GET %s\n", r.URL)
+		}
+	}
+	line("files", strings.Join(p.Files, ", "))
+	for _, n := range p.Notes {
+		line("note", n)
+	}
+	line("error", p.Error)
+	return b.String()
+}
+
+// runDryRun implements --dry-run: each module is resolved to its provider,
+// and the requests that would be made for its license are written to stdout
+// (as text, or with --format=json, as a JSON array) without downloading
+// anything. The command fails if any module can't be resolved.
+func runDryRun(ctx context.Context, o *options, modules []Module, stdout io.Writer) error {
+	planOnly = true
+	defer func() { planOnly = false }()
+
+	plans := []modulePlan{}
+	failures := 0
+	for _, m := range modules {
+		if stopping(ctx) {
+			return stopped(ctx)
+		}
+		p := planModule(ctx, m, o)
+		if p.Error != "" {
+			failures++
+		}
+		if o.Format == "json" {
+			plans = append(plans, p)
+		} else if _, err := fmt.Fprintln(stdout, p); err != nil {
+			return err
+		}
+	}
+
+	if o.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plans); err != nil {
+			return err
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d module(s) can't be resolved", failures)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPlanModule(t *testing.T) {
+	oldAuth := githubAuth
+	githubAuth = &BasicAuth{}
+	defer func() { githubAuth = oldAuth }()
+
+	o := &options{overrides: overrides{"example.org/a": override{When: overrideAlways, Text: "License A"}}}
+
+	plan := planModule(context.Background(), Module{Path: "golang.org/x/text", Version: "v0.3.3"}, o)
+	expected := `golang.org/x/text@v0.3.3
+  provider: github
+  repository: https://github.com/golang/text
+  GET https://raw.githubusercontent.com/golang/text/main/LICENSE (ref main)
+  GET https://raw.githubusercontent.com/golang/text/master/LICENSE (ref master)
+  files: ` + strings.Join(httpLicenseFiles, ", ") + `
+  note: no GitHub credentials: raw files only
+`
+	if plan.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, plan)
+	}
+
+	githubAuth = &BasicAuth{Username: "user", Token: "token"}
+	plan = planModule(context.Background(), Module{Path: "golang.org/x/text"}, o)
+	if (plan.Provider != "github api") || (plan.Requests[0].URL != githubAPI+"/repos/golang/text/license") {
+		t.Errorf("expected the GitHub API first but got %+v", plan)
+	}
+
+	if plan := planModule(context.Background(), Module{Path: "example.org/a"}, o); (plan.Provider != "override") || (len(plan.Requests) != 0) {
+		t.Errorf("expected an override but got %+v", plan)
+	}
+}
+
+func TestRunDryRunJSON(t *testing.T) {
+	var stdout bytes.Buffer
+	o := &options{Format: "json", overrides: overrides{"example.org/a": override{When: overrideAlways, Text: "License A"}}}
+	if err := runDryRun(context.Background(), o, []Module{{Path: "example.org/a"}}, &stdout); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"provider": "override"`) {
+		t.Errorf("unexpected plan %s", stdout.String())
+	}
+	if planOnly {
+		t.Errorf("expected planOnly to be reset")
+	}
+}
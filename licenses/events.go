@@ -0,0 +1,95 @@
+package licenses
+
+import "sync"
+
+// Event is a progress event from Scan: one of ModuleStarted, LicenseFound,
+// Warning or Failed. See Options.Events.
+type Event interface {
+	isEvent()
+}
+
+// ModuleStarted is sent when Scan starts work on a module.
+type ModuleStarted struct {
+	Module Module
+}
+
+// LicenseFound is sent when the license of a module has been found.
+type LicenseFound struct {
+	Module Module
+	Entry  Entry
+}
+
+// Warning is sent for a problem that doesn't stop a module from being
+// scanned, such as a failed request that a fallback recovered from. Module
+// is empty for a warning that isn't about one module.
+type Warning struct {
+	Module  string
+	Message string
+
+	// URL and Status are the URL and HTTP status code of a failed request,
+	// if the warning is about one.
+	URL    string
+	Status int
+}
+
+// Failed is sent when the license of a module couldn't be found.
+type Failed struct {
+	Module Module
+	Err    error
+}
+
+func (ModuleStarted) isEvent() {}
+func (LicenseFound) isEvent()  {}
+func (Warning) isEvent()       {}
+func (Failed) isEvent()        {}
+
+// eventHandler, if not nil, receives warnings instead of the log. See
+// logEmit.
+var eventHandler func(Event)
+
+// eventRelay queues the events for eventHandler that are emitted while
+// relaying (see relayEvents), so that they are sent from the goroutine that
+// called Scan rather than from the goroutine that emitted them.
+var eventRelay = struct {
+	sync.Mutex
+	relaying bool
+	queued   []Event
+}{}
+
+// relayEvents queues the events for eventHandler until the returned function
+// is called, which sends them. It is called by the goroutine that called
+// Scan, around work done on other goroutines.
+func relayEvents() func() {
+	eventRelay.Lock()
+	eventRelay.relaying = true
+	eventRelay.Unlock()
+
+	return func() {
+		eventRelay.Lock()
+		queued := eventRelay.queued
+		eventRelay.relaying, eventRelay.queued = false, nil
+		eventRelay.Unlock()
+
+		for _, ev := range queued {
+			eventHandler(ev)
+		}
+	}
+}
+
+// sendLogEvent sends a log event to eventHandler as a Warning, if it is a
+// warning or an error. Informational, debug and trace events are dropped.
+func sendLogEvent(ev logEvent) {
+	if (ev.Level != levelWarning) && (ev.Level != levelError) {
+		return
+	}
+	warning := Warning{Module: ev.Module, Message: ev.Message, URL: ev.URL, Status: ev.Status}
+
+	eventRelay.Lock()
+	if eventRelay.relaying {
+		eventRelay.queued = append(eventRelay.queued, warning)
+		eventRelay.Unlock()
+		return
+	}
+	eventRelay.Unlock()
+	eventHandler(warning)
+}
@@ -0,0 +1,198 @@
+package licenses
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// evidenceResponse is a response recorded for an evidence bundle.
+type evidenceResponse struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// evidenceLog records each successful response while a module is scanned,
+// with --evidence, so that the license files can be archived exactly as
+// fetched. It is nil otherwise.
+var evidenceLog *responseLog
+
+type responseLog struct {
+	mu        sync.Mutex
+	responses map[string]evidenceResponse // by URL
+}
+
+// recordResponse records a response in the evidenceLog, if any. The body is
+// the cached body for a 304 Not Modified response. Cookies aren't recorded.
+func recordResponse(rsc string, resp *http.Response, body string) {
+	if evidenceLog == nil {
+		return
+	}
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	evidenceLog.mu.Lock()
+	evidenceLog.responses[rsc] = evidenceResponse{resp.StatusCode, header, body}
+	evidenceLog.mu.Unlock()
+}
+
+// reset forgets every response, before the next module is scanned.
+func (l *responseLog) reset() {
+	l.mu.Lock()
+	l.responses = make(map[string]evidenceResponse)
+	l.mu.Unlock()
+}
+
+func (l *responseLog) get(rsc string) (evidenceResponse, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.responses[rsc]
+	return r, ok
+}
+
+// evidenceFile describes a license file in an evidence bundle's manifest.
+type evidenceFile struct {
+	Module    string `json:"module"`
+	Version   string `json:"version,omitempty"`
+	Path      string `json:"path"` // in the bundle
+	File      string `json:"file,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Retrieved string `json:"retrieved,omitempty"` // RFC 3339
+	SHA256    string `json:"sha256"`
+
+	// Raw is true if the file is the body of the response from SourceURL,
+	// exactly as fetched, with its HTTP status and headers. Otherwise, such
+	// as for a license from an API response, a module zip or a clone, it is
+	// the license text as extracted.
+	Raw    bool        `json:"raw"`
+	Status int         `json:"http_status,omitempty"`
+	Header http.Header `json:"http_headers,omitempty"`
+}
+
+// evidenceManifest is the manifest.json of an evidence bundle.
+type evidenceManifest struct {
+	Generated string         `json:"generated"` // RFC 3339
+	Files     []evidenceFile `json:"files"`
+}
+
+// evidenceBundle is a zip archive of every license file of a report, with
+// a manifest of where and when each came from, for audits that want the
+// original artifacts rather than the report's text.
+type evidenceBundle struct {
+	f        *os.File
+	zip      *zip.Writer
+	manifest evidenceManifest
+	paths    map[string]bool
+}
+
+// createEvidenceBundle creates the evidence bundle at path, and starts
+// recording responses for it.
+func createEvidenceBundle(path string, generated time.Time) (*evidenceBundle, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating evidence bundle: %v", err)
+	}
+	evidenceLog = &responseLog{responses: make(map[string]evidenceResponse)}
+	return &evidenceBundle{
+		f:        f,
+		zip:      zip.NewWriter(f),
+		manifest: evidenceManifest{Generated: generated.Format(time.RFC3339), Files: []evidenceFile{}},
+		paths:    make(map[string]bool),
+	}, nil
+}
+
+// add archives the license files of a module's entry: each of its Licenses,
+// or else its License. Responses recorded since the module started are used
+// for the raw files.
+func (b *evidenceBundle) add(m Module, e Entry) error {
+	parts := e.Licenses
+	if len(parts) == 0 {
+		parts = []licensePart{{File: "LICENSE", SourceURL: e.SourceURL, Revision: e.Revision, Text: e.License, Raw: e.raw}}
+	}
+
+	dir := m.Path
+	if m.Version != "" {
+		dir += "@" + m.Version
+	}
+	for _, part := range parts {
+		ef := evidenceFile{
+			Module:    m.Path,
+			Version:   m.Version,
+			File:      part.File,
+			SourceURL: part.SourceURL,
+			Ref:       e.Ref,
+			Revision:  part.Revision,
+			Retrieved: e.Retrieved,
+		}
+		data := part.Text
+		if part.Raw != "" {
+			data = part.Raw // as before --plain-text
+		}
+		if r, ok := evidenceLog.get(part.SourceURL); ok && (part.SourceURL != "") {
+			data = r.Body
+			ef.Raw, ef.Status, ef.Header = true, r.Status, r.Header
+		}
+		ef.SHA256 = textSHA256(data)
+		ef.Path = b.uniquePath(path.Join("modules", dir, path.Base("/"+part.File)))
+
+		header := &zip.FileHeader{Name: ef.Path, Method: zip.Deflate}
+		if t, err := time.Parse(time.RFC3339, e.Retrieved); err == nil {
+			header.Modified = t
+		}
+		w, err := b.zip.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("error writing evidence bundle: %v", err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			return fmt.Errorf("error writing evidence bundle: %v", err)
+		}
+		b.manifest.Files = append(b.manifest.Files, ef)
+	}
+	return nil
+}
+
+// uniquePath returns p, or if it's already in the bundle, p with a number.
+func (b *evidenceBundle) uniquePath(p string) string {
+	unique := p
+	for n := 2; b.paths[unique]; n++ {
+		ext := path.Ext(p)
+		unique = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(p, ext), n, ext)
+	}
+	b.paths[unique] = true
+	return unique
+}
+
+// Close writes the manifest, completes the bundle, and stops recording
+// responses.
+func (b *evidenceBundle) Close() error {
+	evidenceLog = nil
+
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	generated, _ := time.Parse(time.RFC3339, b.manifest.Generated)
+	w, err := b.zip.CreateHeader(&zip.FileHeader{Name: "manifest.json", Method: zip.Deflate, Modified: generated})
+	if err == nil {
+		_, err = w.Write(append(data, '\n'))
+	}
+	if err == nil {
+		err = b.zip.Close()
+	}
+	if cerr := b.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing evidence bundle: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package licenses
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvidenceBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprint(w, "License A\r\n")
+	}))
+	defer server.Close()
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	path := filepath.Join(t.TempDir(), "evidence.zip")
+	generated := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	b, err := createEvidenceBundle(path, generated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// fetched over HTTP, and extracted from elsewhere
+	evidenceLog.reset()
+	rsc := server.URL + "/LICENSE"
+	text, err := httpGet(context.Background(), rsc, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.add(Module{Path: "example.org/a", Version: "v1.0.0"}, Entry{
+		License:   text,
+		SourceURL: rsc,
+		Retrieved: "2021-01-01T00:00:00Z",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evidenceLog.reset()
+	if err := b.add(Module{Path: "example.org/b"}, Entry{
+		Licenses: []licensePart{
+			{File: "LICENSE-MIT", Text: "MIT"},
+			{File: "docs/LICENSE-MIT", Text: "MIT again"},
+		},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if evidenceLog != nil {
+		t.Errorf("expected responses to no longer be recorded")
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+
+	expected := map[string]string{
+		"modules/example.org/a@v1.0.0/LICENSE": "License A\r\n",
+		"modules/example.org/b/LICENSE-MIT":    "MIT",
+		"modules/example.org/b/LICENSE-MIT-2":  "MIT again",
+	}
+	for name, data := range expected {
+		if files[name] != data {
+			t.Errorf("expected %s to be %q but got %q", name, data, files[name])
+		}
+	}
+
+	var manifest evidenceManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest.Generated != "2021-01-02T03:04:05Z" {
+		t.Errorf("expected generated time but got %q", manifest.Generated)
+	}
+	if len(manifest.Files) != 3 {
+		t.Fatalf("expected 3 files but got %d", len(manifest.Files))
+	}
+
+	a := manifest.Files[0]
+	if !a.Raw || (a.Status != 200) || (a.SourceURL != rsc) || (a.SHA256 != textSHA256("License A\r\n")) {
+		t.Errorf("expected the raw response but got %+v", a)
+	}
+	if (a.Header.Get("ETag") != `"v1"`) || (a.Header.Get("Set-Cookie") != "") {
+		t.Errorf("expected headers without cookies but got %v", a.Header)
+	}
+	if b := manifest.Files[2]; b.Raw || (b.Path != "modules/example.org/b/LICENSE-MIT-2") || (b.File != "docs/LICENSE-MIT") {
+		t.Errorf("expected the extracted text but got %+v", b)
+	}
+}
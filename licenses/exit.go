@@ -0,0 +1,42 @@
+package licenses
+
+import "errors"
+
+// Exit statuses, so that CI can tell an incomplete report from a policy
+// violation.
+const (
+	exitOK         = 0
+	exitFatal      = 1 // an error stopped gocomply
+	exitIncomplete = 2 // a module's license is missing or unidentified
+	exitViolation  = 3 // a policy violation, an unapproved module or a stale report
+
+	exitInterrupted = 130 // stopped early by SIGINT or SIGTERM
+)
+
+// exitStatus returns the exit status for an error returned by a command.
+func exitStatus(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	if errors.Is(err, errInterrupted) {
+		return exitInterrupted
+	}
+	if errors.Is(err, errDeadline) {
+		return exitIncomplete
+	}
+
+	var stale *staleReportError
+	if errors.As(err, &stale) {
+		return exitViolation
+	}
+
+	var failed *checkFailedError
+	if errors.As(err, &failed) {
+		if failed.Missing == failed.Problems {
+			return exitIncomplete
+		}
+		return exitViolation
+	}
+	return exitFatal
+}
@@ -0,0 +1,25 @@
+package licenses
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, exitOK},
+		{fmt.Errorf("go list error"), exitFatal},
+		{&checkFailedError{Problems: 2, Missing: 2}, exitIncomplete},
+		{&checkFailedError{Problems: 2, Missing: 1}, exitViolation},
+		{fmt.Errorf("wrapped: %w", &checkFailedError{Problems: 1}), exitViolation},
+	}
+
+	for _, tt := range tests {
+		if status := exitStatus(tt.err); status != tt.expected {
+			t.Errorf("%v: expected exit status %d but got %d", tt.err, tt.expected, status)
+		}
+	}
+}
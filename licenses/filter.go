@@ -0,0 +1,140 @@
+package licenses
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// modulePattern matches module paths: a glob, matched against the module
+// path and its prefixes as in GOPRIVATE (see matchPrefixPatterns), or, with
+// a "re:" prefix, a regular expression matched against the whole path.
+type modulePattern struct {
+	glob string
+	re   *regexp.Regexp
+
+	// reason documents why modules are skipped, from an ignore file.
+	reason string
+}
+
+func parseModulePattern(s string) (modulePattern, error) {
+	if expr := strings.TrimPrefix(s, "re:"); expr != s {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return modulePattern{}, fmt.Errorf("invalid module pattern %q: %v", s, err)
+		}
+		return modulePattern{re: re}, nil
+	}
+	if strings.TrimSpace(s) == "" {
+		return modulePattern{}, fmt.Errorf("empty module pattern")
+	}
+	return modulePattern{glob: s}, nil
+}
+
+func (p modulePattern) String() string {
+	if p.re != nil {
+		return "re:" + strings.TrimSuffix(strings.TrimPrefix(p.re.String(), "^(?:"), ")$")
+	}
+	return p.glob
+}
+
+func (p modulePattern) match(module string) bool {
+	if p.re != nil {
+		return p.re.MatchString(module)
+	}
+	return matchPrefixPatterns(p.glob, module)
+}
+
+// patternFlags implements flag.Value for repeated module pattern arguments,
+// such as "--exclude 'github.com/mycorp/*'".
+type patternFlags []modulePattern
+
+func (p *patternFlags) String() string {
+	var parts []string
+	for _, pattern := range *p {
+		parts = append(parts, pattern.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *patternFlags) Set(value string) error {
+	pattern, err := parseModulePattern(value)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, pattern)
+	return nil
+}
+
+// matchAny returns the first pattern that matches a module, if any.
+func (p patternFlags) matchAny(module string) (modulePattern, bool) {
+	for _, pattern := range p {
+		if pattern.match(module) {
+			return pattern, true
+		}
+	}
+	return modulePattern{}, false
+}
+
+// parsePatterns parses the module patterns of the policy file.
+func parsePatterns(patterns []string) (patternFlags, error) {
+	var result patternFlags
+	for _, s := range patterns {
+		if err := result.Set(s); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// excludedModule is a module skipped by --exclude or the ignore file, as
+// listed in the report's appendix.
+type excludedModule struct {
+	Module  string `json:"module"`
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// moduleFilter skips modules that match any Exclude or Ignore pattern or,
+// if there are any Only patterns, that match none of them.
+type moduleFilter struct {
+	Exclude patternFlags
+	Only    patternFlags
+	Ignore  patternFlags // from the ignore file
+}
+
+// skip returns true if a module should not be scanned, with the Exclude or
+// Ignore pattern that excluded it, or nil if it was skipped by Only.
+func (f moduleFilter) skip(module string) (bool, *modulePattern) {
+	if p, ok := f.Exclude.matchAny(module); ok {
+		return true, &p
+	}
+	if p, ok := f.Ignore.matchAny(module); ok {
+		return true, &p
+	}
+	if len(f.Only) > 0 {
+		if _, ok := f.Only.matchAny(module); !ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// filter returns the modules that aren't skipped, logging each that is, and
+// the modules that were excluded by a pattern.
+func (f moduleFilter) filter(modules []Module) ([]Module, []excludedModule) {
+	var result []Module
+	var excluded []excludedModule
+	for _, m := range modules {
+		skip, p := f.skip(m.Path)
+		if !skip {
+			result = append(result, m)
+			continue
+		}
+		logf(levelInfo, phaseModule, m.Path, nil, "skipping %s (excluded)", m.Path)
+		if p != nil {
+			excluded = append(excluded, excludedModule{m.Path, p.String(), p.reason})
+		}
+	}
+	return result, excluded
+}
@@ -0,0 +1,42 @@
+package licenses
+
+import (
+	"testing"
+)
+
+func TestModuleFilter(t *testing.T) {
+	var f moduleFilter
+	for _, s := range []string{"github.com/mycorp/*", `re:golang\.org/x/.*`} {
+		if err := f.Exclude.Set(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := map[string]bool{
+		"github.com/mycorp/foo":     true,
+		"github.com/mycorp/foo/v2":  true,
+		"github.com/other/foo":      false,
+		"golang.org/x/text":         true,
+		"example.org/golang.org/x/": false,
+	}
+	for module, expected := range tests {
+		if got, _ := f.skip(module); got != expected {
+			t.Errorf("%s: expected %t but got %t", module, expected, got)
+		}
+	}
+
+	if err := f.Only.Set("github.com/*"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	modules := []Module{{Path: "github.com/mycorp/foo"}, {Path: "github.com/other/foo"}, {Path: "example.org/a"}}
+	if got, _ := f.filter(modules); (len(got) != 1) || (got[0].Path != "github.com/other/foo") {
+		t.Errorf("expected only github.com/other/foo but got %v", got)
+	}
+
+	if err := f.Only.Set("re:("); err == nil {
+		t.Errorf("expected an error for an invalid regular expression")
+	}
+	if s := f.Exclude.String(); s != `github.com/mycorp/* re:golang\.org/x/.*` {
+		t.Errorf("unexpected string %q", s)
+	}
+}
@@ -0,0 +1,100 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fixer implements --fix: when a module's license can't be found, the user
+// is asked for a license URL or file path on the spot, and whether to save
+// it to an overrides file.
+type fixer struct {
+	in   *bufio.Scanner
+	out  io.Writer
+	save string // the overrides file to offer to save answers to
+}
+
+// newFixer returns a fixer that prompts on stderr and reads answers from
+// stdin, or nil if either isn't a terminal, as prompting would then hang or
+// corrupt a CI log.
+func newFixer(save string) *fixer {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+		logf(levelWarning, phaseSetup, "", nil, "warning: --fix needs a terminal, so is ignored")
+		return nil
+	}
+	return &fixer{in: bufio.NewScanner(os.Stdin), out: os.Stderr, save: save}
+}
+
+// ask writes a prompt and returns the answer, trimmed, or false at the end
+// of the input.
+func (f *fixer) ask(format string, args ...interface{}) (string, bool) {
+	fmt.Fprintf(f.out, format, args...)
+	if !f.in.Scan() {
+		fmt.Fprintln(f.out)
+		return "", false
+	}
+	return strings.TrimSpace(f.in.Text()), true
+}
+
+// fix asks for the license of a module that failed, until one can be read
+// or the user skips it, and returns its entry. If the user agrees, it is
+// saved as an override.
+func (f *fixer) fix(ctx context.Context, m Module, cause error, o *options) (Entry, bool) {
+	logMu.Lock()
+	if logProgressShown {
+		fmt.Fprintln(f.out)
+		logProgressShown = false
+	}
+	logMu.Unlock()
+	fmt.Fprintf(f.out, "%s: %v\n", m.Path, cause)
+	for {
+		src, ok := f.ask("license URL or file path for %s (empty to skip): ", m.Path)
+		if !ok || (src == "") {
+			return Entry{}, false
+		}
+
+		text, err := readLicenseSource(ctx, src)
+		if err != nil {
+			fmt.Fprintf(f.out, "error: %v\n", err)
+			continue
+		}
+		license := licenseFile{Text: text, SourceURL: src, Retrieved: retrievalTime()}
+		entry := newEntry(ctx, m, license, o)
+		fmt.Fprintf(f.out, "%s: %s\n", m.Path, entry.spdxText())
+
+		ov := override{Module: m.Path, URL: src}
+		if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") {
+			ov = override{Module: m.Path, File: src}
+		}
+		if answer, _ := f.ask("save to %s? [y/N] ", f.save); strings.EqualFold(answer, "y") {
+			if err := saveOverride(f.save, ov); err != nil {
+				fmt.Fprintf(f.out, "error: %v\n", err)
+			} else {
+				fmt.Fprintf(f.out, "saved to %s\n", f.save)
+			}
+		}
+		return entry, true
+	}
+}
+
+// relativeTo returns a file path relative to dir, if it can, as a relative
+// File in an overrides file is relative to the file's directory.
+func relativeTo(dir string, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return abs
+	}
+	if rel, err := filepath.Rel(absDir, abs); err == nil {
+		return rel
+	}
+	return abs
+}
@@ -0,0 +1,73 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixerFix(t *testing.T) {
+	dir := t.TempDir()
+	license := filepath.Join(dir, "third_party", "LICENSE")
+	if err := os.MkdirAll(filepath.Dir(license), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(license, []byte(testMITLicense), 0644); err != nil {
+		t.Fatal(err)
+	}
+	save := filepath.Join(dir, ".gocomply.yaml")
+	if err := os.WriteFile(save, []byte("# our policy\nallow: [MIT]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a missing file is asked for again
+	input := filepath.Join(dir, "missing") + "\n" + license + "\ny\n"
+	var out bytes.Buffer
+	f := &fixer{in: bufio.NewScanner(strings.NewReader(input)), out: &out, save: save}
+
+	m := Module{Path: "example.org/a", Version: "v1.0.0"}
+	entry, ok := f.fix(context.Background(), m, errors.New("no license found"), &options{})
+	if !ok || (entry.SPDX != "MIT") || (entry.Module != "example.org/a") {
+		t.Fatalf("expected an MIT entry but got %t, %+v\n%s", ok, entry, out.String())
+	}
+
+	data, err := os.ReadFile(save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# our policy\n") {
+		t.Errorf("expected the policy file to be kept but got %q", data)
+	}
+	ovs, err := loadOverrides(save)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ov := ovs["example.org/a"]; ov.File != license {
+		t.Errorf("expected an override for %s but got %+v", license, ov)
+	}
+
+	// skipping
+	f = &fixer{in: bufio.NewScanner(strings.NewReader("\n")), out: &out, save: save}
+	if _, ok := f.fix(context.Background(), m, errors.New("no license found"), &options{}); ok {
+		t.Errorf("expected the module to be skipped")
+	}
+}
+
+func TestSaveOverrideNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	if err := saveOverride(path, override{Module: "example.org/a", URL: "https://example.org/LICENSE"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ovs, err := loadOverrides(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ov := ovs["example.org/a"]; (ov.URL != "https://example.org/LICENSE") || (ov.When != overrideAlways) {
+		t.Errorf("unexpected override %+v", ov)
+	}
+}
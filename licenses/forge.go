@@ -0,0 +1,115 @@
+package licenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// forgeLayout is how a kind of git forge serves the raw content of a file at
+// a ref: Format returns its URL given the repository URL, ref and file.
+type forgeLayout struct {
+	Name   string
+	Format func(root string, ref string, file string) string
+}
+
+var (
+	layoutGitea = forgeLayout{"gitea", func(root, ref, file string) string {
+		return fmt.Sprintf("%s/raw/branch/%s/%s", root, ref, file)
+	}}
+	layoutGitLab = forgeLayout{"gitlab", func(root, ref, file string) string {
+		return fmt.Sprintf("%s/-/raw/%s/%s", root, ref, file)
+	}}
+	layoutCgit = forgeLayout{"cgit", func(root, ref, file string) string {
+		return fmt.Sprintf("%s/plain/%s?h=%s", root, file, ref)
+	}}
+	layoutGogs = forgeLayout{"gogs", func(root, ref, file string) string {
+		return fmt.Sprintf("%s/raw/%s/%s", root, ref, file)
+	}}
+)
+
+// forgeFingerprints identify a forge from a marker in the HTML of its
+// repository page, checked in order.
+var forgeFingerprints = []struct {
+	marker string
+	layout forgeLayout
+}{
+	{"forgejo", layoutGitea},
+	{"gitea", layoutGitea},
+	{"gogs", layoutGogs},
+	{"gitlab", layoutGitLab},
+	{"cgit", layoutCgit},
+}
+
+// forgeLayouts caches the layouts to try for each repository URL
+var forgeLayouts = struct {
+	sync.Mutex
+	layouts map[string][]forgeLayout
+}{layouts: make(map[string][]forgeLayout)}
+
+// detectForgeLayouts returns the layouts to try for a repository on an
+// unknown host, such as a vanity domain that also hosts the repository
+// (e.g. "https://git.example.org/user/repo"). The repository page is fetched
+// once to identify the forge, unless its host is in forgeHosts (or only
+// planning); if it can't be identified, every layout is tried.
+func detectForgeLayouts(ctx context.Context, root string) []forgeLayout {
+	forgeLayouts.Lock()
+	layouts, ok := forgeLayouts.layouts[root]
+	forgeLayouts.Unlock()
+	if ok {
+		return layouts
+	}
+
+	layouts = []forgeLayout{layoutGitea, layoutGitLab, layoutCgit, layoutGogs}
+	if layout, ok := forgeHostLayout(root); ok {
+		layouts = []forgeLayout{layout}
+	} else if planOnly {
+		return layouts
+	} else if page, err := httpGet(ctx, root, nil); err == nil {
+		page = strings.ToLower(page)
+		for _, f := range forgeFingerprints {
+			if strings.Contains(page, f.marker) {
+				layouts = []forgeLayout{f.layout}
+				break
+			}
+		}
+	}
+
+	forgeLayouts.Lock()
+	forgeLayouts.layouts[root] = layouts
+	forgeLayouts.Unlock()
+	return layouts
+}
+
+// resolveForgeFileURL returns the URLs a file might be at in a repository on
+// an unknown host, using detectForgeLayouts.
+func resolveForgeFileURL(ctx context.Context, repoRoot string, file string) []FileURL {
+	root := strings.TrimSuffix(strings.TrimSuffix(repoRoot, "/"), ".git")
+
+	var urls []FileURL
+	for _, layout := range detectForgeLayouts(ctx, root) {
+		for _, ref := range []string{"main", "master"} {
+			urls = append(urls, FileURL{layout.Format(root, ref, file), ref})
+		}
+	}
+	return urls
+}
+
+// errNotAFile is returned by a decoder for a response that is an HTML page
+// rather than the raw file, as forges may return for a missing file or a
+// login page.
+var errNotAFile = errors.New("response is an HTML page, not a file")
+
+// stringDecoderNotHTML rejects an HTML page as errNotAFile.
+func stringDecoderNotHTML(str string) (string, error) {
+	start := strings.ToLower(strings.TrimSpace(str))
+	if len(start) > 64 {
+		start = start[:64]
+	}
+	if strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html") {
+		return "", errNotAFile
+	}
+	return str, nil
+}
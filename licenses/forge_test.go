@@ -0,0 +1,57 @@
+package licenses
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResolveForgeFileURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/gitea":
+			w.Write([]byte(`<!DOCTYPE html><html><body>Powered by Gitea</body></html>`))
+		case "/user/cgit":
+			w.Write([]byte(`<!DOCTYPE html><html><head><meta name='generator' content='cgit v1.2.3'/></head></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	old := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = old }()
+
+	tests := map[string][]FileURL{
+		server.URL + "/user/gitea.git": {
+			{server.URL + "/user/gitea/raw/branch/main/LICENSE", "main"},
+			{server.URL + "/user/gitea/raw/branch/master/LICENSE", "master"},
+		},
+		server.URL + "/user/cgit": {
+			{server.URL + "/user/cgit/plain/LICENSE?h=main", "main"},
+			{server.URL + "/user/cgit/plain/LICENSE?h=master", "master"},
+		},
+	}
+	for root, expected := range tests {
+		if got := resolveForgeFileURL(context.Background(), root, "LICENSE"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %v but got %v", root, expected, got)
+		}
+	}
+
+	// an unidentified forge gets every layout
+	if got := resolveForgeFileURL(context.Background(), server.URL+"/user/unknown", "LICENSE"); len(got) != 8 {
+		t.Errorf("expected every layout but got %v", got)
+	}
+}
+
+func TestStringDecoderNotHTML(t *testing.T) {
+	if _, err := stringDecoderNotHTML("\n<!DOCTYPE html>\n<html>Sign in</html>"); err != errNotAFile {
+		t.Errorf("expected errNotAFile for an HTML page but got %v", err)
+	}
+	if text, err := stringDecoderNotHTML(testMITLicense); (err != nil) || (text != testMITLicense) {
+		t.Errorf("expected a license text unchanged but got (%q, %v)", text, err)
+	}
+}
@@ -0,0 +1,142 @@
+package licenses
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// generateStamp records the inputs of a report written with --cache and
+// --output, so that a later run with the same inputs can skip the scan (see
+// --fresh). It is kept at the top of the cache directory, alongside the
+// cache statistics.
+type generateStamp struct {
+	Inputs  string `json:"inputs"`  // see generateInputs
+	Report  string `json:"report"`  // SHA-256 of the report
+	Written string `json:"written"` // RFC 3339
+}
+
+// stampPath returns the stamp for the report written to o.Output, or false
+// if there is no cache or output file.
+func (o *options) stampPath() (string, bool) {
+	if (o.Cache == "") || (o.Output == "") {
+		return "", false
+	}
+	abs, err := filepath.Abs(o.Output)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(o.Cache, "generate-"+hex.EncodeToString(sum[:8])+".json"), true
+}
+
+// findGoMod returns the go.mod of the module in the current directory, as
+// go:generate runs in a package's directory rather than the module root.
+func findGoMod() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "go.mod"
+	}
+	for {
+		path := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "go.mod"
+		}
+		dir = parent
+	}
+}
+
+// generateInputs returns a digest of everything that a report depends on,
+// apart from the network: gocomply's version, the arguments and options,
+// and the contents of go.mod, go.sum and the files named by the options.
+func generateInputs(o *options, args []string, reportOpts reportOptions) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%q\n%s\n", version(), args, o.journalOptions())
+	fmt.Fprintf(h, "format=%s checksums=%t trailer=%t provenance=%t\n",
+		reportOpts.Format, reportOpts.Checksums, reportOpts.Trailer, reportOpts.Provenance)
+	fmt.Fprintf(h, "exclude=%s only=%s products=%s strict=%t source_date_epoch=%s\n",
+		o.Exclude.String(), o.Only.String(), o.Products.String(), o.Strict, os.Getenv("SOURCE_DATE_EPOCH"))
+
+	goMod := findGoMod()
+	ignoreFile := o.IgnoreFile
+	if ignoreFile == "" {
+		ignoreFile = defaultIgnoreFile
+	}
+	for _, path := range []string{goMod, filepath.Join(filepath.Dir(goMod), "go.sum"),
+		o.policyPath(), o.Overrides, ignoreFile, o.Baseline, o.NewOnly, o.Bazel} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(h, "%s: missing\n", path)
+			continue
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(h, "%s: %x\n", path, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reportFresh returns true if the output file was written by a complete run
+// with the same inputs less than o.Fresh ago, and hasn't changed since.
+func reportFresh(o *options, inputs string, now time.Time) bool {
+	path, ok := o.stampPath()
+	if !ok || (o.Fresh <= 0) {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var stamp generateStamp
+	if json.Unmarshal(data, &stamp) != nil {
+		return false
+	}
+	written, err := time.Parse(time.RFC3339, stamp.Written)
+	if (err != nil) || (now.Sub(written) >= o.Fresh) || (stamp.Inputs != inputs) {
+		return false
+	}
+
+	report, err := os.ReadFile(o.Output)
+	return (err == nil) && (textSHA256(string(report)) == stamp.Report)
+}
+
+// writeStamp records the inputs of the report just written to the output
+// file, if there is a cache.
+func writeStamp(o *options, inputs string, now time.Time) error {
+	path, ok := o.stampPath()
+	if !ok {
+		return nil
+	}
+
+	report, err := os.ReadFile(o.Output)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(generateStamp{
+		Inputs:  inputs,
+		Report:  textSHA256(string(report)),
+		Written: now.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(o.Cache, 0755); err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	return nil
+}
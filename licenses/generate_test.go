@@ -0,0 +1,131 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunScanFresh(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "third_party_licenses.txt")
+	args := []string{"example.org/a@v1.0.0"}
+	scan := func(text string, fresh time.Duration) []byte {
+		o := &options{
+			Format: "text",
+			Output: output,
+			Cache:  filepath.Join(dir, "cache"),
+			Fresh:  fresh,
+			overrides: overrides{
+				"example.org/a": override{When: overrideAlways, Text: text},
+				stdlibModule:    override{When: overrideAlways, Text: "License Go"},
+			},
+		}
+		if err := runScan(context.Background(), o, args, &bytes.Buffer{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := scan("License A", time.Hour)
+	if !bytes.Contains(first, []byte("License A")) {
+		t.Fatalf("expected a report but got %q", first)
+	}
+
+	// skipped, as the report is fresh, so the new license isn't seen
+	if report := scan("License A, revised", time.Hour); !bytes.Equal(report, first) {
+		t.Errorf("expected the report to be skipped but got %q", report)
+	}
+
+	// without --fresh, the report is always regenerated
+	if report := scan("License A, revised", 0); !bytes.Contains(report, []byte("License A, revised")) {
+		t.Errorf("expected the report to be regenerated but got %q", report)
+	}
+
+	// a report edited since isn't fresh
+	if err := os.WriteFile(output, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if report := scan("License A", time.Hour); !bytes.Equal(report, first) {
+		t.Errorf("expected the report to be regenerated but got %q", report)
+	}
+}
+
+func TestReplaceFileUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "licenses.txt")
+	if err := os.WriteFile(path, []byte("report\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(text string) os.FileInfo {
+		f, err := createReplaceFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f.WriteString(text)
+		if err := f.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	if info := write("report\n"); !info.ModTime().Equal(old) {
+		t.Errorf("expected an unchanged report to keep its modification time but got %v", info.ModTime())
+	}
+	if info := write("changed\n"); info.ModTime().Equal(old) || (info.Mode().Perm() != 0600) {
+		t.Errorf("expected a changed report with its mode kept but got %v, %v", info.ModTime(), info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left but got %d files", len(entries))
+	}
+}
+
+func TestRunScanFailedKeepsOutput(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "third_party_licenses.txt")
+	previous := []byte("the previous report\n")
+	if err := os.WriteFile(output, previous, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries are written, but finding native libraries fails
+	o := &options{
+		Format:    "text",
+		Output:    output,
+		Cgo:       true,
+		CgoBinary: filepath.Join(dir, "missing"),
+		overrides: overrides{
+			"example.org/a": override{When: overrideAlways, Text: "License A"},
+			stdlibModule:    override{When: overrideAlways, Text: "License Go"},
+		},
+	}
+	if err := runScan(context.Background(), o, []string{"example.org/a@v1.0.0"}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected an error for the missing binary")
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, previous) {
+		t.Errorf("expected the previous report to be kept but got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left but got %d files", len(entries))
+	}
+}
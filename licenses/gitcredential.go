@@ -0,0 +1,106 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// useGitCredentials is true to ask git's credential helpers for credentials
+// for a host that requires them. See authorizeGitCredential.
+var useGitCredentials = true
+
+// gitCredentialFill runs "git credential fill" with the given input and
+// returns its output. Git is never allowed to prompt for credentials, so it
+// only answers from the user's credential helpers (such as osxkeychain or
+// Git Credential Manager).
+var gitCredentialFill = func(ctx context.Context, input string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	stdout, err := cmd.Output()
+	return string(stdout), err
+}
+
+// gitCredentials caches the credentials from git for each host, or nil if
+// git has none, so that git is asked at most once per host.
+var gitCredentials = struct {
+	sync.Mutex
+	auth map[string]*BasicAuth
+}{auth: make(map[string]*BasicAuth)}
+
+// parseGitCredential parses the output of "git credential fill", which has a
+// "key=value" line for each attribute, into credentials, or nil if there is
+// no username and password.
+func parseGitCredential(output string) *BasicAuth {
+	var auth BasicAuth
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		idx := strings.IndexByte(scanner.Text(), '=')
+		if idx < 0 {
+			continue
+		}
+		key, value := scanner.Text()[:idx], scanner.Text()[idx+1:]
+		switch key {
+		case "username":
+			auth.Username = value
+		case "password":
+			auth.Token = value
+		}
+	}
+	if !auth.IsSet() {
+		return nil
+	}
+	return &auth
+}
+
+// gitCredential returns the credentials that git's credential helpers have
+// for a https host, or nil. If ask is false, it only returns credentials
+// that git has already been asked for.
+func gitCredential(ctx context.Context, host string, ask bool) *BasicAuth {
+	gitCredentials.Lock()
+	defer gitCredentials.Unlock()
+
+	auth, ok := gitCredentials.auth[host]
+	if ok || !ask {
+		return auth
+	}
+
+	output, err := gitCredentialFill(ctx, "protocol=https\nhost="+host+"\n\n")
+	if err == nil {
+		auth = parseGitCredential(output)
+	}
+	gitCredentials.auth[host] = auth
+	return auth
+}
+
+// authorizeGitCredential adds the credentials from git's credential helpers
+// for the host of a https request, and returns true if it did. If ask is
+// false, git is not run, and only credentials that an earlier request asked
+// git for are used.
+func authorizeGitCredential(req *http.Request, ask bool) bool {
+	if !useGitCredentials || (req.URL.Scheme != "https") {
+		return false
+	}
+	auth := gitCredential(req.Context(), req.URL.Host, ask)
+	if auth == nil {
+		return false
+	}
+	req.SetBasicAuth(auth.Username, auth.Token)
+	return true
+}
+
+// isUnauthorized returns true if a request failed because it needs
+// credentials.
+func isUnauthorized(err error) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized)
+}
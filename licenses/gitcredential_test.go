@@ -0,0 +1,70 @@
+package licenses
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseGitCredential(t *testing.T) {
+	auth := parseGitCredential("protocol=https\nhost=git.example.org\nusername=a\npassword=p=w\n")
+	if (auth == nil) || (auth.Username != "a") || (auth.Token != "p=w") {
+		t.Errorf("unexpected credentials %+v", auth)
+	}
+	if auth := parseGitCredential("protocol=https\nhost=git.example.org\n"); auth != nil {
+		t.Errorf("expected no credentials but got %+v", auth)
+	}
+}
+
+func TestGitCredentialAfterUnauthorized(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || (username != "a") || (password != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("License"))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	oldLimiter, oldTransport, oldFill, oldNetrcs := rateLimiter, httpTransport, gitCredentialFill, netrcs
+	defer func() {
+		rateLimiter, httpTransport, gitCredentialFill, netrcs = oldLimiter, oldTransport, oldFill, oldNetrcs
+		gitCredentials.Lock()
+		delete(gitCredentials.auth, host)
+		gitCredentials.Unlock()
+	}()
+	rateLimiter = NoRateLimit
+	httpTransport = srv.Client().Transport
+	netrcs = nil
+
+	var asked []string
+	gitCredentialFill = func(ctx context.Context, input string) (string, error) {
+		asked = append(asked, input)
+		return input + "username=a\npassword=secret\n", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		body, err := httpGet(context.Background(), srv.URL+"/LICENSE", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if body != "License" {
+			t.Errorf("expected the license but got %q", body)
+		}
+	}
+
+	expected := "protocol=https\nhost=" + host + "\n\n"
+	if (len(asked) != 1) || (asked[0] != expected) {
+		t.Errorf("expected git to be asked once with %q but got %q", expected, asked)
+	}
+
+	// plain http is never authorized by git
+	req, _ := http.NewRequest("GET", (&url.URL{Scheme: "http", Host: host}).String(), nil)
+	if authorizeGitCredential(req, true) {
+		t.Errorf("expected no credentials over plain http")
+	}
+}
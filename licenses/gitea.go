@@ -0,0 +1,146 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// giteaHosts are hosts known to run Gitea (or Forgejo, a fork with the same
+// API), in lower case, without fetching a repository page to identify them.
+var giteaHosts = map[string]bool{
+	"codeberg.org": true,
+	"gitea.com":    true,
+}
+
+// giteaContentsEntry is a file or directory in a Gitea contents API listing
+type giteaContentsEntry struct {
+	Name        string
+	Path        string
+	Sha         string
+	Type        string // "file" or "dir", among others
+	DownloadURL string `json:"download_url"`
+}
+
+// giteaRepo returns the API URL of a repository on a Gitea host, e.g.
+// "https://codeberg.org/api/v1/repos/owner/repo", if it is on a known Gitea
+// host or its forge is identified as Gitea (see detectForgeLayouts).
+func giteaRepo(ctx context.Context, repoRoot string) (string, bool) {
+	root := strings.TrimSuffix(strings.TrimSuffix(repoRoot, "/"), ".git")
+	u, err := url.Parse(root)
+	if (err != nil) || (u.Scheme != "https") {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if (len(parts) != 2) || (parts[0] == "") || (parts[1] == "") {
+		return "", false
+	}
+
+	if !giteaHosts[strings.ToLower(u.Host)] {
+		layouts := detectForgeLayouts(ctx, root)
+		if (len(layouts) != 1) || (layouts[0].Name != layoutGitea.Name) {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("https://%s/api/v1/repos/%s/%s", u.Host, url.PathEscape(parts[0]), url.PathEscape(parts[1])), true
+}
+
+// giteaGetContents lists a directory of a repository at its default branch,
+// or the top level if dir is empty, with the contents API.
+func giteaGetContents(ctx context.Context, api string, dir string) ([]giteaContentsEntry, error) {
+	rsc := api + "/contents"
+	if dir != "" {
+		rsc += "/" + url.PathEscape(dir)
+	}
+	data, err := httpGet(ctx, rsc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents []giteaContentsEntry
+	if err := json.Unmarshal([]byte(data), &contents); err != nil {
+		return nil, fmt.Errorf("json decode error: %v", err)
+	}
+	return contents, nil
+}
+
+// getGiteaLicense uses the Gitea API of a repository (see giteaRepo) to get
+// its license: it lists the top level of the repository and fetches every
+// license file, matching names case insensitively, as getGitHubLicense
+// does, rather than trying each of a short list of names. REUSE compliant
+// repositories also have their LICENSES directory and .reuse/dep5 file
+// included.
+//
+// If the API worked but there are no license files, missing is true.
+func getGiteaLicense(ctx context.Context, gi GoImport, api string) (license licenseFile, missing bool, err error) {
+	contents, err := giteaGetContents(ctx, api, "")
+	if err != nil {
+		return licenseFile{}, false, fmt.Errorf("trouble getting listing for %s: %w", gi.RepoRoot, err)
+	}
+
+	// every license file, in order of precedence
+	var files []giteaContentsEntry
+	for _, c := range contents {
+		if c.Type != "file" {
+			continue
+		}
+		if _, ok := licenseFileRank(c.Name, repoLicenseFiles); ok {
+			files = append(files, c)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, _ := licenseFileRank(files[i].Name, repoLicenseFiles)
+		b, _ := licenseFileRank(files[j].Name, repoLicenseFiles)
+		return a < b
+	})
+
+	// REUSE (https://reuse.software) license texts and dep5 file
+	for _, c := range contents {
+		if (c.Type != "dir") || ((c.Name != reuseLicensesDir) && (c.Name != reuseDir)) {
+			continue
+		}
+		subdir, err := giteaGetContents(ctx, api, c.Path)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting listing of %s for %s: %w", c.Path, gi.RepoRoot, err)
+		}
+		for _, s := range subdir {
+			if (s.Type == "file") && ((c.Name == reuseLicensesDir) || (s.Name == reuseDep5)) {
+				files = append(files, s)
+			}
+		}
+	}
+
+	var parts []licensePart
+	for _, c := range files {
+		text, err := httpGet(ctx, c.DownloadURL, nil)
+		if err == nil {
+			text, err = stringDecoderNotHTML(text)
+		}
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting %s for %s: %w", c.Path, gi.RepoRoot, err)
+		}
+		text, ok := licenseText(c.Path, text)
+		if !ok {
+			continue
+		}
+
+		parts = append(parts, licensePart{
+			File:      c.Path,
+			SourceURL: c.DownloadURL,
+			Revision:  c.Sha,
+			Text:      text,
+		})
+	}
+
+	if len(parts) > 0 {
+		license := combineLicenseParts(parts)
+		license.Ref = "HEAD"
+		license.Retrieved = retrievalTime()
+		return license, false, nil
+	}
+
+	return licenseFile{}, true, fmt.Errorf("no license found")
+}
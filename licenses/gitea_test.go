@@ -0,0 +1,59 @@
+package licenses
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGiteaRepo(t *testing.T) {
+	tests := map[string]string{
+		"https://codeberg.org/owner/repo.git": "https://codeberg.org/api/v1/repos/owner/repo",
+		"https://Gitea.com/owner/repo/":       "https://Gitea.com/api/v1/repos/owner/repo",
+		"https://codeberg.org/owner":          "",
+		"https://codeberg.org/owner/repo/sub": "",
+		"http://codeberg.org/owner/repo":      "",
+	}
+	for root, expected := range tests {
+		api, ok := giteaRepo(context.Background(), root)
+		if (api != expected) || (ok != (expected != "")) {
+			t.Errorf("%s: expected %q but got %q (%t)", root, expected, api, ok)
+		}
+	}
+}
+
+func TestGetGiteaLicense(t *testing.T) {
+	oldFetcher, oldLimiter := httpFetcher, rateLimiter
+	defer func() { httpFetcher, rateLimiter = oldFetcher, oldLimiter }()
+	rateLimiter = NoRateLimit
+
+	api := "https://codeberg.org/api/v1/repos/owner/repo"
+	raw := "https://codeberg.org/owner/repo/raw/branch/main/"
+	httpFetcher = replayFetcher{
+		api + "/contents": `[
+			{"name": "copying", "path": "copying", "sha": "abc123", "type": "file", "download_url": "` + raw + `copying"},
+			{"name": "main.go", "path": "main.go", "sha": "def456", "type": "file", "download_url": "` + raw + `main.go"},
+			{"name": ".reuse", "path": ".reuse", "sha": "fed789", "type": "dir"}]`,
+		api + "/contents/.reuse": `[
+			{"name": "dep5", "path": ".reuse/dep5", "sha": "bcd234", "type": "file", "download_url": "` + raw + `.reuse/dep5"},
+			{"name": "other", "path": ".reuse/other", "sha": "cde345", "type": "file", "download_url": "` + raw + `.reuse/other"}]`,
+		raw + "copying":     testMITLicense,
+		raw + ".reuse/dep5": "Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/\n\nFiles: *\nLicense: MIT",
+	}
+
+	gi := GoImport{Vcs: "git", RepoRoot: "https://codeberg.org/owner/repo"}
+	license, missing, err := getGiteaLicense(context.Background(), gi, api)
+	if (err != nil) || missing {
+		t.Fatalf("unexpected error: %v (missing: %t)", err, missing)
+	}
+	if (len(license.Parts) != 2) || (license.Parts[0].File != "copying") || (license.Parts[1].File != ".reuse/dep5") {
+		t.Fatalf("expected copying and the dep5 file but got %+v", license.Parts)
+	}
+	if (license.Revision != "abc123") || (license.SourceURL != raw+"copying") {
+		t.Errorf("unexpected provenance %+v", license)
+	}
+
+	httpFetcher = replayFetcher{api + "/contents": `[{"name": "main.go", "path": "main.go", "sha": "def456", "type": "file"}]`}
+	if _, missing, err := getGiteaLicense(context.Background(), gi, api); (err == nil) || !missing {
+		t.Errorf("expected a missing license but got %v (missing: %t)", err, missing)
+	}
+}
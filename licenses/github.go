@@ -0,0 +1,374 @@
+package licenses
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// githubAPI is the base URL of the GitHub API
+var githubAPI = "https://api.github.com"
+
+// githubTreeEntry is a file or directory in a GitHub git trees API listing
+type githubTreeEntry struct {
+	Path string
+	Type string // "blob" for a file, "tree" for a directory
+	Sha  string
+	Url  string
+}
+
+type githubTree struct {
+	Tree []githubTreeEntry
+}
+
+type githubBlob struct {
+	Content  string
+	Encoding string
+}
+
+// githubRepoLicense is the response of the GitHub repository license API
+type githubRepoLicense struct {
+	Path     string
+	Sha      string
+	GitURL   string `json:"git_url"`
+	HTMLURL  string `json:"html_url"`
+	Content  string
+	Encoding string
+	License  struct {
+		SPDXID string `json:"spdx_id"`
+	}
+}
+
+// githubGetTree lists a directory given its git trees API URL
+func githubGetTree(ctx context.Context, rsc string) ([]githubTreeEntry, error) {
+	data, err := httpGet(ctx, rsc, githubAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	var response githubTree
+	err = json.Unmarshal([]byte(data), &response)
+	if err != nil {
+		return nil, fmt.Errorf("json decode error: %v", err)
+	}
+
+	return response.Tree, nil
+}
+
+// githubGetBlob returns the contents of a file given its git blobs API URL
+func githubGetBlob(ctx context.Context, rsc string) (string, error) {
+	data, err := httpGet(ctx, rsc, githubAuth)
+	if err != nil {
+		return "", err
+	}
+
+	var blob githubBlob
+	err = json.Unmarshal([]byte(data), &blob)
+	if err != nil {
+		return "", fmt.Errorf("json decode error: %v", err)
+	}
+
+	return githubDecode(blob.Content, blob.Encoding)
+}
+
+// githubDecode decodes the content of a file from the GitHub API
+func githubDecode(content string, encoding string) (string, error) {
+	if strings.EqualFold(encoding, "utf-8") {
+		return content, nil
+	} else if strings.EqualFold(encoding, "base64") {
+		raw, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return "", fmt.Errorf("base64 decode error: %v", err)
+		}
+		return string(raw), nil
+	} else {
+		return "", fmt.Errorf("unknown encoding type %q", encoding)
+	}
+}
+
+// githubGetRepoLicense uses the GitHub repository license API to fetch the
+// license file that GitHub detected in a repository (given as "owner/repo"),
+// in a single request.
+//
+// If GitHub didn't identify the license, ok is false, as there may be other
+// license files that a listing would find. If GitHub identified it but
+// licensecheck can't, GitHub's SPDX identifier is used.
+func githubGetRepoLicense(ctx context.Context, dir string) (license licenseFile, ok bool, err error) {
+	data, err := httpGet(ctx, fmt.Sprintf("%s/repos/%s/license", githubAPI, dir), githubAuth)
+	if err != nil {
+		return licenseFile{}, false, err
+	}
+
+	var response githubRepoLicense
+	err = json.Unmarshal([]byte(data), &response)
+	if err != nil {
+		return licenseFile{}, false, fmt.Errorf("json decode error: %v", err)
+	}
+
+	id := response.License.SPDXID
+	if (id == "") || (id == spdxNoAssertion) {
+		return licenseFile{}, false, nil
+	}
+
+	text, err := githubDecode(response.Content, response.Encoding)
+	if err != nil {
+		return licenseFile{}, false, err
+	}
+	text, ok = licenseText(response.Path, text)
+	if !ok {
+		return licenseFile{}, false, nil
+	}
+
+	license = combineLicenseParts([]licensePart{{
+		File:      response.Path,
+		SourceURL: response.GitURL,
+		Revision:  response.Sha,
+		Text:      text,
+	}})
+	if classifyLicense(license.Text).SPDX == "" {
+		license.SPDX = id
+	}
+	license.Ref = "HEAD"
+	license.Retrieved = retrievalTime()
+
+	// the API follows a renamed or transferred repository to where it is now
+	if repo := githubURLRepo(response.HTMLURL); (repo != "") && !strings.EqualFold(repo, dir) {
+		license.MovedTo = "https://github.com/" + repo
+	}
+	return license, true, nil
+}
+
+// githubURLRepo returns the "owner/repo" of a URL of a page in a GitHub
+// repository, or an empty string if it isn't one.
+func githubURLRepo(rsc string) string {
+	rest := strings.TrimPrefix(rsc, "https://github.com/")
+	if rest == rsc {
+		return ""
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if (len(parts) < 2) || (parts[0] == "") || (parts[1] == "") {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// githubRepo is the response of the GitHub repository API
+type githubRepo struct {
+	FullName string `json:"full_name"`
+}
+
+// githubMovedRepo returns the URL of the repository that a GitHub repository
+// has moved to, if it was renamed or transferred. The API redirects requests
+// for the old name, but raw.githubusercontent.com doesn't, so that its
+// license files aren't found at the old name.
+func githubMovedRepo(ctx context.Context, gi GoImport) (string, bool) {
+	if (gi.Vcs != "git") || !strings.HasPrefix(gi.RepoRoot, "https://github.com/") {
+		return "", false
+	}
+	dir := strings.TrimPrefix(gi.RepoRoot, "https://github.com/")
+	dir = strings.TrimSuffix(dir, ".git")
+
+	data, err := httpGet(ctx, fmt.Sprintf("%s/repos/%s", githubAPI, dir), githubAuth)
+	if err != nil {
+		return "", false
+	}
+	var response githubRepo
+	if (json.Unmarshal([]byte(data), &response) != nil) || (response.FullName == "") {
+		return "", false
+	}
+	if strings.EqualFold(response.FullName, dir) {
+		return "", false
+	}
+	return "https://github.com/" + response.FullName, true
+}
+
+// getGitHubLicense uses the GitHub API to get a repository's license. It
+// lists the top level of the repository and fetches every license file,
+// matching names case insensitively. REUSE compliant repositories also have
+// their LICENSES directory and .reuse/dep5 file included.
+//
+// GitHub identifies the license of only one file (see githubGetRepoLicense),
+// so that is the license only if the listing finds no other license files.
+// Otherwise, it just gives the SPDX identifier of that file, if licensecheck
+// can't identify it.
+//
+// If the API worked but there are no license files, missing is true.
+func getGitHubLicense(ctx context.Context, gi GoImport) (license licenseFile, missing bool, err error) {
+	// TODO if we refactor resolveFileURL to make it more general purpose
+	//   then this could work for gopkg.in too
+
+	// TODO make this a method on gi to stop repeating this
+	dir := strings.TrimPrefix(gi.RepoRoot, "https://github.com/")
+	dir = strings.TrimSuffix(dir, ".git")
+
+	if p, ok := githubPrefetchedLicense(dir); ok {
+		if p.missing {
+			return licenseFile{}, true, fmt.Errorf("no license found")
+		}
+		return p.license, false, nil
+	}
+
+	identified, ok, err := githubGetRepoLicense(ctx, dir)
+	if limited, _ := rateLimitReset(err); limited {
+		return licenseFile{}, false, fmt.Errorf("trouble getting license for %s: %w", gi.RepoRoot, err)
+	}
+
+	tree, err := githubGetTree(ctx, fmt.Sprintf("%s/repos/%s/git/trees/HEAD", githubAPI, dir))
+	if (err != nil) && ok {
+		return identified, false, nil
+	} else if err != nil {
+		return licenseFile{}, false, fmt.Errorf("trouble getting listing for %s: %w", gi.RepoRoot, err)
+	}
+
+	// every license file, in order of precedence
+	var files []githubTreeEntry
+	for _, t := range tree {
+		if t.Type != "blob" {
+			continue
+		}
+		if _, ok := licenseFileRank(t.Path, repoLicenseFiles); !ok {
+			continue
+		}
+		files = append(files, t)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, _ := licenseFileRank(files[i].Path, repoLicenseFiles)
+		b, _ := licenseFileRank(files[j].Path, repoLicenseFiles)
+		return a < b
+	})
+
+	// REUSE (https://reuse.software) license texts and dep5 file
+	var reuseTrees []githubTreeEntry
+	for _, t := range tree {
+		if (t.Type == "tree") && ((t.Path == reuseLicensesDir) || (t.Path == reuseDir)) {
+			reuseTrees = append(reuseTrees, t)
+		}
+	}
+
+	if ok && (len(files) <= 1) && (len(reuseTrees) == 0) {
+		return identified, false, nil
+	}
+
+	for _, t := range reuseTrees {
+		match := func(name string) bool { return true }
+		if t.Path == reuseDir {
+			match = func(name string) bool { return name == reuseDep5 }
+		}
+
+		subtree, err := githubGetTree(ctx, t.Url)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting listing of %s for %s: %w", t.Path, gi.RepoRoot, err)
+		}
+		for _, s := range subtree {
+			if (s.Type == "blob") && match(s.Path) {
+				s.Path = path.Join(t.Path, s.Path)
+				files = append(files, s)
+			}
+		}
+	}
+
+	var parts []licensePart
+	for _, t := range files {
+		text, err := githubGetBlob(ctx, t.Url)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting blob for %s: %w", gi.RepoRoot, err)
+		}
+		text, ok := licenseText(t.Path, text)
+		if !ok {
+			continue
+		}
+
+		parts = append(parts, licensePart{
+			File:      t.Path,
+			SourceURL: t.Url,
+			Revision:  t.Sha,
+			Text:      text,
+		})
+	}
+
+	if (len(parts) == 0) && ok {
+		return identified, false, nil
+	} else if len(parts) > 0 {
+		license := combineLicenseParts(parts)
+		license.Ref = "HEAD"
+		license.Retrieved = retrievalTime()
+		if ok {
+			license.MovedTo = identified.MovedTo
+			for i := range license.Parts {
+				if (license.Parts[i].File == identified.Parts[0].File) && (license.Parts[i].SPDX == "") {
+					license.Parts[i].SPDX = identified.SPDX
+				}
+			}
+		}
+		return license, false, nil
+	}
+
+	return licenseFile{}, true, fmt.Errorf("no license found")
+}
+
+// getGitHubSubdirLicense uses the GitHub API to get the license files in a
+// directory of a repository, as for a module in a subdirectory (see
+// getSubdirLicense). If the directory has no license files, or doesn't exist
+// at HEAD, found is false.
+func getGitHubSubdirLicense(ctx context.Context, gi GoImport, subdir string) (license licenseFile, found bool, err error) {
+	dir := strings.TrimPrefix(gi.RepoRoot, "https://github.com/")
+	dir = strings.TrimSuffix(dir, ".git")
+
+	tree, err := githubGetTree(ctx, fmt.Sprintf("%s/repos/%s/git/trees/HEAD:%s", githubAPI, dir, subdir))
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound) {
+		return licenseFile{}, false, nil
+	} else if err != nil {
+		return licenseFile{}, false, fmt.Errorf("trouble getting listing of %s for %s: %w", subdir, gi.RepoRoot, err)
+	}
+
+	// every license file, in order of precedence
+	var files []githubTreeEntry
+	for _, t := range tree {
+		if t.Type != "blob" {
+			continue
+		}
+		if _, ok := licenseFileRank(t.Path, repoLicenseFiles); ok {
+			files = append(files, t)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, _ := licenseFileRank(files[i].Path, repoLicenseFiles)
+		b, _ := licenseFileRank(files[j].Path, repoLicenseFiles)
+		return a < b
+	})
+
+	var parts []licensePart
+	for _, t := range files {
+		text, err := githubGetBlob(ctx, t.Url)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting blob for %s: %w", gi.RepoRoot, err)
+		}
+		file := path.Join(subdir, t.Path)
+		text, ok := licenseText(file, text)
+		if !ok {
+			continue
+		}
+
+		parts = append(parts, licensePart{
+			File:      file,
+			SourceURL: t.Url,
+			Revision:  t.Sha,
+			Text:      text,
+		})
+	}
+	if len(parts) == 0 {
+		return licenseFile{}, false, nil
+	}
+
+	license = combineLicenseParts(parts)
+	license.Ref = "HEAD"
+	license.Retrieved = retrievalTime()
+	return license, true, nil
+}
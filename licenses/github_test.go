@@ -0,0 +1,163 @@
+package licenses
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubGetRepoLicense(t *testing.T) {
+	text := "MIT License\n\n" + testMITLicenseBody
+	responses := map[string]string{
+		"/repos/example/mit/license": fmt.Sprintf(`{"path": "LICENSE", "sha": "abc123",
+			"git_url": "https://api.github.com/repos/example/mit/git/blobs/abc123",
+			"content": %q, "encoding": "base64", "license": {"spdx_id": "MIT"}}`,
+			base64.StdEncoding.EncodeToString([]byte(text))),
+		"/repos/example/other/license": `{"path": "LICENSE", "sha": "def456",
+			"content": "", "encoding": "base64", "license": {"spdx_id": "NOASSERTION"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			fmt.Fprint(w, response)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	oldAPI, oldLimiter := githubAPI, rateLimiter
+	githubAPI, rateLimiter = server.URL, NoRateLimit
+	defer func() { githubAPI, rateLimiter = oldAPI, oldLimiter }()
+
+	license, ok, err := githubGetRepoLicense(context.Background(), "example/mit")
+	if err != nil || !ok {
+		t.Fatalf("expected a license but got (%t, %v)", ok, err)
+	}
+	if (license.Text != text) || (license.Revision != "abc123") || (license.SPDX != "") {
+		t.Errorf("unexpected license %+v", license)
+	}
+
+	// GitHub didn't identify it, so a listing is needed
+	if _, ok, err := githubGetRepoLicense(context.Background(), "example/other"); ok || (err != nil) {
+		t.Errorf("expected no license and no error but got (%t, %v)", ok, err)
+	}
+
+	if _, ok, err := githubGetRepoLicense(context.Background(), "example/missing"); ok || (err == nil) {
+		t.Errorf("expected an error but got (%t, %v)", ok, err)
+	}
+}
+
+func TestGetLicenseMovedRepo(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldAPI, oldFetcher, oldLimiter, oldAuth := githubAPI, httpFetcher, rateLimiter, githubAuth
+	githubAPI, rateLimiter, githubAuth = "https://api.github.test", NoRateLimit, &BasicAuth{}
+	httpFetcher = replayFetcher{
+		// the API follows the rename, but raw files aren't at the old name
+		"https://api.github.test/repos/example/old":                  `{"full_name": "example/new"}`,
+		"https://api.github.test/repos/example/gone":                 `{"full_name": "example/gone"}`,
+		"https://raw.githubusercontent.com/example/new/main/LICENSE": testMITLicense,
+	}
+	defer func() { githubAPI, httpFetcher, rateLimiter, githubAuth = oldAPI, oldFetcher, oldLimiter, oldAuth }()
+
+	gi := GoImport{ImportPrefix: "github.com/example/old", Vcs: "git", RepoRoot: "https://github.com/example/old"}
+	license, err := getLicense(context.Background(), "github.com/example/old", gi, GoSource{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (license.MovedTo != "https://github.com/example/new") || (license.SourceURL != "https://raw.githubusercontent.com/example/new/main/LICENSE") {
+		t.Errorf("expected the license from the moved repository but got %+v", license)
+	}
+
+	gi = GoImport{ImportPrefix: "github.com/example/gone", Vcs: "git", RepoRoot: "https://github.com/example/gone"}
+	if license, err := getLicense(context.Background(), "github.com/example/gone", gi, GoSource{}); (err == nil) || (license.MovedTo != "") {
+		t.Errorf("expected an error for a repository that hasn't moved but got %+v, %v", license, err)
+	}
+}
+
+func TestGitHubURLRepo(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/example/new/blob/main/LICENSE": "example/new",
+		"https://github.com/example/new":                   "example/new",
+		"https://github.com/example":                       "",
+		"https://gitlab.com/example/new":                   "",
+	}
+	for rsc, expected := range tests {
+		if repo := githubURLRepo(rsc); repo != expected {
+			t.Errorf("%s: expected %q but got %q", rsc, expected, repo)
+		}
+	}
+}
+
+func TestGetGitHubLicenseFiles(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	mit := "MIT License\n\n" + testMITLicenseBody
+	var blobs int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tree := func(files ...string) string {
+			var entries []string
+			for _, file := range files {
+				entries = append(entries, fmt.Sprintf(`{"path": %q, "type": "blob", "sha": "sha-%s", "url": "%s/blobs/%s"}`,
+					file, file, server.URL, file))
+			}
+			return `{"tree": [` + strings.Join(entries, ",") + `]}`
+		}
+		blob := func(text string) string {
+			blobs++
+			return fmt.Sprintf(`{"content": %q, "encoding": "utf-8"}`, text)
+		}
+
+		switch r.URL.Path {
+		case "/repos/example/dual/license", "/repos/example/single/license":
+			fmt.Fprintf(w, `{"path": "LICENSE-MIT", "sha": "sha-LICENSE-MIT", "git_url": "%s/blobs/LICENSE-MIT",
+				"content": %q, "encoding": "utf-8", "license": {"spdx_id": "MIT"}}`, server.URL, mit)
+		case "/repos/example/dual/git/trees/HEAD":
+			fmt.Fprint(w, tree("LICENSE-APACHE", "LICENSE-MIT", "NOTICE", "main.go"))
+		case "/repos/example/single/git/trees/HEAD":
+			fmt.Fprint(w, tree("LICENSE-MIT", "main.go"))
+		case "/blobs/LICENSE-APACHE":
+			fmt.Fprint(w, blob("Apache License\nVersion 2.0, January 2004"))
+		case "/blobs/LICENSE-MIT":
+			fmt.Fprint(w, blob(mit))
+		case "/blobs/NOTICE":
+			fmt.Fprint(w, blob("Copyright 2024 The Authors"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldAPI, oldLimiter, oldAuth := githubAPI, rateLimiter, githubAuth
+	githubAPI, rateLimiter, githubAuth = server.URL, NoRateLimit, &BasicAuth{Username: "user", Token: "token"}
+	defer func() { githubAPI, rateLimiter, githubAuth = oldAPI, oldLimiter, oldAuth }()
+
+	// GitHub identifies one file, but every license file is included
+	license, missing, err := getGitHubLicense(context.Background(), GoImport{Vcs: "git", RepoRoot: "https://github.com/example/dual"})
+	if (err != nil) || missing {
+		t.Fatalf("unexpected error: %v (missing: %t)", err, missing)
+	}
+	var files []string
+	for _, part := range license.Parts {
+		files = append(files, part.File)
+	}
+	expected := []string{"NOTICE", "LICENSE-APACHE", "LICENSE-MIT"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v but got %v", expected, files)
+	}
+
+	// the only license file is the one GitHub identified
+	blobs = 0
+	license, missing, err = getGitHubLicense(context.Background(), GoImport{Vcs: "git", RepoRoot: "https://github.com/example/single"})
+	if (err != nil) || missing || (license.Text != mit) {
+		t.Fatalf("expected the MIT license but got %+v, %v (missing: %t)", license, err, missing)
+	}
+	if blobs != 0 {
+		t.Errorf("expected no blobs to be fetched but got %d", blobs)
+	}
+}
@@ -0,0 +1,64 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// githubTokenUser is the username sent with a GitHub token that was given
+// without one. GitHub accepts any username with a token.
+const githubTokenUser = "x-access-token"
+
+// githubCredentials returns the GitHub credentials to use, from the first of:
+//
+//  1. the GH_TOKEN environment variable;
+//  2. the GITHUB_TOKEN environment variable (as set in GitHub Actions);
+//  3. the github.com entry of a netrc file;
+//  4. on Windows, the github.com credentials in the Credential Manager, as
+//     stored by Git Credential Manager;
+//  5. the token of the GitHub CLI, from "gh auth token", if gh is installed.
+//
+// It returns nil if there are none.
+func githubCredentials(getenv func(string) string, ghToken func() string) *BasicAuth {
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		if token := strings.TrimSpace(getenv(name)); token != "" {
+			return &BasicAuth{Username: githubTokenUser, Token: token}
+		}
+	}
+
+	if auth := netrcAuth("github.com"); (auth != nil) && auth.IsSet() {
+		return auth
+	}
+
+	if auth := storedCredential("github.com"); auth != nil {
+		return auth
+	}
+
+	if token := ghToken(); token != "" {
+		return &BasicAuth{Username: githubTokenUser, Token: token}
+	}
+	return nil
+}
+
+// ghAuthToken returns the token of the GitHub CLI, or an empty string if gh
+// isn't installed or isn't logged in.
+func ghAuthToken(ctx context.Context) string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	stdout, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", "github.com").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(stdout))
+}
+
+// configureGitHubAuth sets githubAuth from githubCredentials.
+func configureGitHubAuth(ctx context.Context) {
+	ghToken := func() string { return ghAuthToken(ctx) }
+	if auth := githubCredentials(os.Getenv, ghToken); auth != nil {
+		githubAuth = auth
+	}
+}
@@ -0,0 +1,42 @@
+package licenses
+
+import "testing"
+
+func TestGitHubCredentials(t *testing.T) {
+	oldNetrcs := netrcs
+	netrcs = nil
+	defer func() { netrcs = oldNetrcs }()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	gh := func(token string) func() string {
+		return func() string { return token }
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		gh       string
+		expected string
+	}{
+		{"GH_TOKEN first", map[string]string{"GH_TOKEN": "a", "GITHUB_TOKEN": "b"}, "c", "a"},
+		{"then GITHUB_TOKEN", map[string]string{"GITHUB_TOKEN": "b"}, "c", "b"},
+		{"then gh", nil, "c", "c"},
+		{"none", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		auth := githubCredentials(env(tt.env), gh(tt.gh))
+		token := ""
+		if auth != nil {
+			token = auth.Token
+			if !auth.IsSet() {
+				t.Errorf("%s: expected credentials with a username", tt.name)
+			}
+		}
+		if token != tt.expected {
+			t.Errorf("%s: expected token %q but got %q", tt.name, tt.expected, token)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// gitlabDotCom is the host of GitLab's own instance, which is always a
+// GitLab host.
+const gitlabDotCom = "gitlab.com"
+
+// gitlabAPIPath is the path prefix of the GitLab REST API.
+const gitlabAPIPath = "/api/v4/"
+
+// gitlabConfig configures self-hosted GitLab instances in the policy file:
+//
+//	gitlab:
+//	  hosts: [gitlab.example.org]
+type gitlabConfig struct {
+	// Hosts lists the hosts, other than gitlab.com, that run GitLab.
+	Hosts []string `yaml:"hosts"`
+}
+
+// gitlabToken is a credential for the GitLab API: a personal, project or
+// group access token ("PRIVATE-TOKEN") or a CI job token ("JOB-TOKEN").
+type gitlabToken struct {
+	Header string
+	Value  string
+}
+
+// gitlabHosts are the known GitLab hosts, in lower case, and gitlabTokens
+// are the credentials for any of them. See configureGitLab.
+var (
+	gitlabHosts  = map[string]bool{gitlabDotCom: true}
+	gitlabTokens = map[string]gitlabToken{}
+)
+
+// envHost returns the host of an environment variable that may be a host or
+// a URL, such as GITLAB_HOST, in lower case.
+func envHost(value string) string {
+	value = strings.TrimSpace(value)
+	if u, err := url.Parse(value); (err == nil) && (u.Host != "") {
+		value = u.Hostname()
+	}
+	return strings.ToLower(value)
+}
+
+// gitlabCredentials returns the known GitLab hosts, which are gitlab.com, the
+// given hosts, $GITLAB_HOST (as used by the glab CLI) and $CI_SERVER_HOST (as
+// set by GitLab CI), and the credentials for each, from the first of:
+//
+//  1. the GITLAB_TOKEN environment variable, for gitlab.com or $GITLAB_HOST
+//     if it is set;
+//  2. the CI_JOB_TOKEN environment variable, for $CI_SERVER_HOST;
+//  3. the password of the host's entry in a netrc file.
+func gitlabCredentials(hosts []string, getenv func(string) string) (map[string]bool, map[string]gitlabToken) {
+	known := map[string]bool{gitlabDotCom: true}
+	tokens := map[string]gitlabToken{}
+
+	tokenHost := gitlabDotCom
+	if host := envHost(getenv("GITLAB_HOST")); host != "" {
+		tokenHost = host
+	}
+	ciHost := envHost(getenv("CI_SERVER_HOST"))
+
+	for _, host := range append(hosts, tokenHost, ciHost) {
+		if host != "" {
+			known[strings.ToLower(host)] = true
+		}
+	}
+
+	for host := range known {
+		if token := strings.TrimSpace(getenv("GITLAB_TOKEN")); (token != "") && (host == tokenHost) {
+			tokens[host] = gitlabToken{"PRIVATE-TOKEN", token}
+		} else if token := strings.TrimSpace(getenv("CI_JOB_TOKEN")); (token != "") && (host == ciHost) {
+			tokens[host] = gitlabToken{"JOB-TOKEN", token}
+		} else if auth := netrcAuth(host); (auth != nil) && (auth.Token != "") {
+			tokens[host] = gitlabToken{"PRIVATE-TOKEN", auth.Token}
+		}
+	}
+
+	return known, tokens
+}
+
+// configureGitLab sets gitlabHosts and gitlabTokens from gitlabCredentials.
+func configureGitLab(c gitlabConfig, getenv func(string) string) {
+	gitlabHosts, gitlabTokens = gitlabCredentials(c.Hosts, getenv)
+}
+
+// gitlabProject splits the URL of a repository on a known GitLab host into
+// its host and project path (e.g. "group/subgroup/project").
+func gitlabProject(repoRoot string) (host string, project string, ok bool) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(repoRoot, "/"), ".git"))
+	if (err != nil) || (u.Scheme != "https") || !gitlabHosts[strings.ToLower(u.Host)] {
+		return "", "", false
+	}
+	project = strings.Trim(u.Path, "/")
+	if project == "" {
+		return "", "", false
+	}
+	return strings.ToLower(u.Host), project, true
+}
+
+// gitlabFileURLs returns the URLs of a file in a GitLab project. With a
+// token for the host, these are GitLab API URLs, which work for private
+// projects, and otherwise they are the raw file URLs of the web interface.
+func gitlabFileURLs(host string, project string, file string) []FileURL {
+	var urls []FileURL
+	for _, ref := range []string{"main", "master"} { // master is historical
+		u := fmt.Sprintf("https://%s/%s/-/raw/%s/%s", host, project, ref, file)
+		if _, ok := gitlabTokens[host]; ok {
+			u = fmt.Sprintf("https://%s%sprojects/%s/repository/files/%s/raw?ref=%s",
+				host, gitlabAPIPath, url.PathEscape(project), url.PathEscape(file), url.QueryEscape(ref))
+		}
+		urls = append(urls, FileURL{u, ref})
+	}
+	return urls
+}
+
+// authorizeGitLab adds the token for the host of a GitLab API request, if
+// there is one, and returns true if it did.
+func authorizeGitLab(req *http.Request) bool {
+	if !strings.HasPrefix(req.URL.Path, gitlabAPIPath) {
+		return false
+	}
+	token, ok := gitlabTokens[strings.ToLower(req.URL.Hostname())]
+	if ok {
+		req.Header.Set(token.Header, token.Value)
+	}
+	return ok
+}
+
+// gitlabTreeEntry is a file or directory in a GitLab repository tree API
+// listing
+type gitlabTreeEntry struct {
+	ID   string // the git object id
+	Name string
+	Type string // "blob" for a file, "tree" for a directory
+	Path string
+}
+
+// gitlabAPIURL returns the URL of a GitLab API endpoint of a project.
+func gitlabAPIURL(host string, project string, endpoint string) string {
+	return fmt.Sprintf("https://%s%sprojects/%s/%s", host, gitlabAPIPath, url.PathEscape(project), endpoint)
+}
+
+// gitlabTreePageSize is the most entries in each page of a repository tree
+const gitlabTreePageSize = 100
+
+// gitlabGetTree lists a directory of a project at its default branch, or
+// the top level if dir is empty, with the repository tree API. This works
+// without a token for public projects.
+//
+// The tree is listed a page at a time, directories first, until a page isn't
+// full. Pages are requested by number, rather than by following the
+// X-Next-Page header, as a cached response doesn't keep its headers.
+func gitlabGetTree(ctx context.Context, host string, project string, dir string) ([]gitlabTreeEntry, error) {
+	endpoint := fmt.Sprintf("repository/tree?per_page=%d", gitlabTreePageSize)
+	if dir != "" {
+		endpoint += "&path=" + url.QueryEscape(dir)
+	}
+
+	var tree []gitlabTreeEntry
+	for page := 1; ; page++ {
+		pageEndpoint := endpoint
+		if page > 1 {
+			pageEndpoint += fmt.Sprintf("&page=%d", page)
+		}
+		data, err := httpGet(ctx, gitlabAPIURL(host, project, pageEndpoint), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []gitlabTreeEntry
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return nil, fmt.Errorf("json decode error: %v", err)
+		}
+		tree = append(tree, entries...)
+		if len(entries) < gitlabTreePageSize {
+			return tree, nil
+		}
+	}
+}
+
+// getGitLabLicense uses the GitLab API to get the license of a repository on
+// a known GitLab host: it lists the top level of the repository and fetches
+// every license file, matching names case insensitively, as
+// getGitHubLicense does. REUSE compliant repositories also have their
+// LICENSES directory and .reuse/dep5 file included.
+//
+// If the API worked but there are no license files, missing is true.
+func getGitLabLicense(ctx context.Context, gi GoImport) (license licenseFile, missing bool, err error) {
+	host, project, ok := gitlabProject(gi.RepoRoot)
+	if !ok {
+		return licenseFile{}, false, fmt.Errorf("%s is not a GitLab project", gi.RepoRoot)
+	}
+
+	tree, err := gitlabGetTree(ctx, host, project, "")
+	if err != nil {
+		return licenseFile{}, false, fmt.Errorf("trouble getting listing for %s: %w", gi.RepoRoot, err)
+	}
+
+	// every license file, in order of precedence
+	var files []gitlabTreeEntry
+	for _, t := range tree {
+		if t.Type != "blob" {
+			continue
+		}
+		if _, ok := licenseFileRank(t.Name, repoLicenseFiles); ok {
+			files = append(files, t)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, _ := licenseFileRank(files[i].Name, repoLicenseFiles)
+		b, _ := licenseFileRank(files[j].Name, repoLicenseFiles)
+		return a < b
+	})
+
+	// REUSE (https://reuse.software) license texts and dep5 file
+	for _, t := range tree {
+		if (t.Type != "tree") || ((t.Name != reuseLicensesDir) && (t.Name != reuseDir)) {
+			continue
+		}
+		subtree, err := gitlabGetTree(ctx, host, project, t.Path)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting listing of %s for %s: %w", t.Path, gi.RepoRoot, err)
+		}
+		for _, s := range subtree {
+			if (s.Type == "blob") && ((t.Name == reuseLicensesDir) || (s.Name == reuseDep5)) {
+				files = append(files, s)
+			}
+		}
+	}
+
+	var parts []licensePart
+	for _, t := range files {
+		rsc := gitlabAPIURL(host, project, "repository/blobs/"+url.PathEscape(t.ID)+"/raw")
+		text, err := httpGet(ctx, rsc, nil)
+		if err != nil {
+			return licenseFile{}, false, fmt.Errorf("trouble getting blob for %s: %w", gi.RepoRoot, err)
+		}
+		text, ok := licenseText(t.Path, text)
+		if !ok {
+			continue
+		}
+
+		parts = append(parts, licensePart{
+			File:      t.Path,
+			SourceURL: rsc,
+			Revision:  t.ID,
+			Text:      text,
+		})
+	}
+
+	if len(parts) > 0 {
+		license := combineLicenseParts(parts)
+		license.Ref = "HEAD"
+		license.Retrieved = retrievalTime()
+		return license, false, nil
+	}
+
+	return licenseFile{}, true, fmt.Errorf("no license found")
+}
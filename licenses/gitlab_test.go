@@ -0,0 +1,141 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGitLabCredentials(t *testing.T) {
+	oldNetrcs := netrcs
+	netrcs = nil
+	defer func() { netrcs = oldNetrcs }()
+
+	env := map[string]string{
+		"GITLAB_TOKEN":   "personal",
+		"CI_JOB_TOKEN":   "job",
+		"CI_SERVER_HOST": "gitlab.example.org",
+	}
+	hosts, tokens := gitlabCredentials([]string{"Git.Example.net"}, func(name string) string { return env[name] })
+
+	expectedHosts := map[string]bool{"gitlab.com": true, "gitlab.example.org": true, "git.example.net": true}
+	if !reflect.DeepEqual(hosts, expectedHosts) {
+		t.Errorf("expected hosts %v but got %v", expectedHosts, hosts)
+	}
+
+	expectedTokens := map[string]gitlabToken{
+		"gitlab.com":         {"PRIVATE-TOKEN", "personal"},
+		"gitlab.example.org": {"JOB-TOKEN", "job"},
+	}
+	if !reflect.DeepEqual(tokens, expectedTokens) {
+		t.Errorf("expected tokens %v but got %v", expectedTokens, tokens)
+	}
+}
+
+func TestGitLabFileURLs(t *testing.T) {
+	oldHosts, oldTokens := gitlabHosts, gitlabTokens
+	defer func() { gitlabHosts, gitlabTokens = oldHosts, oldTokens }()
+	gitlabHosts = map[string]bool{"gitlab.com": true, "gitlab.example.org": true}
+	gitlabTokens = map[string]gitlabToken{"gitlab.example.org": {"PRIVATE-TOKEN", "secret"}}
+
+	gi := GoImport{Vcs: "git", RepoRoot: "https://gitlab.com/group/project.git"}
+	urls, _, err := resolveFileURL(context.Background(), gi, GoSource{}, "LICENSE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if urls[0].URL != "https://gitlab.com/group/project/-/raw/main/LICENSE" {
+		t.Errorf("unexpected public URL %q", urls[0].URL)
+	}
+
+	gi = GoImport{Vcs: "git", RepoRoot: "https://gitlab.example.org/group/sub/project"}
+	urls, _, err = resolveFileURL(context.Background(), gi, GoSource{}, "LICENSE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "https://gitlab.example.org/api/v4/projects/group%2Fsub%2Fproject/repository/files/LICENSE/raw?ref=master"
+	if (len(urls) != 2) || (urls[1].URL != expected) || (urls[1].Ref != "master") {
+		t.Fatalf("expected %q but got %+v", expected, urls)
+	}
+
+	req, err := http.NewRequest("GET", urls[1].URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	authorizeGitLab(req)
+	if req.Header.Get("PRIVATE-TOKEN") != "secret" {
+		t.Errorf("expected the token to be sent with %s", req.URL)
+	}
+	if req.URL.String() != expected {
+		t.Errorf("expected the escaped project path to be kept, got %s", req.URL)
+	}
+}
+
+func TestGetGitLabLicense(t *testing.T) {
+	oldFetcher, oldLimiter := httpFetcher, rateLimiter
+	defer func() { httpFetcher, rateLimiter = oldFetcher, oldLimiter }()
+	rateLimiter = NoRateLimit
+
+	api := "https://gitlab.com/api/v4/projects/group%2Fproject/repository/"
+	httpFetcher = replayFetcher{
+		api + "tree?per_page=100": `[
+			{"id": "abc123", "name": "License.md", "type": "blob", "path": "License.md"},
+			{"id": "def456", "name": "main.go", "type": "blob", "path": "main.go"},
+			{"id": "fed789", "name": "LICENSES", "type": "tree", "path": "LICENSES"}]`,
+		api + "tree?per_page=100&path=LICENSES": `[
+			{"id": "bcd234", "name": "Apache-2.0.txt", "type": "blob", "path": "LICENSES/Apache-2.0.txt"}]`,
+		api + "blobs/abc123/raw": testMITLicense,
+		api + "blobs/bcd234/raw": "Apache License\nVersion 2.0, January 2004",
+	}
+
+	gi := GoImport{Vcs: "git", RepoRoot: "https://gitlab.com/group/project.git"}
+	license, missing, err := getGitLabLicense(context.Background(), gi)
+	if (err != nil) || missing {
+		t.Fatalf("unexpected error: %v (missing: %t)", err, missing)
+	}
+	if (len(license.Parts) != 2) || (license.Parts[0].File != "License.md") || (license.Parts[1].File != "LICENSES/Apache-2.0.txt") {
+		t.Fatalf("expected License.md and the REUSE license but got %+v", license.Parts)
+	}
+	if (license.Revision != "abc123") || (license.SourceURL != api+"blobs/abc123/raw") || (license.Ref != "HEAD") {
+		t.Errorf("unexpected provenance %+v", license)
+	}
+
+	httpFetcher = replayFetcher{api + "tree?per_page=100": `[{"id": "def456", "name": "main.go", "type": "blob", "path": "main.go"}]`}
+	if _, missing, err := getGitLabLicense(context.Background(), gi); (err == nil) || !missing {
+		t.Errorf("expected a missing license but got %v (missing: %t)", err, missing)
+	}
+
+	httpFetcher = replayFetcher{}
+	if _, missing, err := getGitLabLicense(context.Background(), gi); (err == nil) || missing {
+		t.Errorf("expected an error, without the license being missing, but got %v (missing: %t)", err, missing)
+	}
+}
+
+func TestGetGitLabLicensePaginated(t *testing.T) {
+	oldFetcher, oldLimiter := httpFetcher, rateLimiter
+	defer func() { httpFetcher, rateLimiter = oldFetcher, oldLimiter }()
+	rateLimiter = NoRateLimit
+
+	// directories are listed first, so the license is on the second page
+	var dirs []string
+	for i := 0; i < gitlabTreePageSize; i++ {
+		dirs = append(dirs, fmt.Sprintf(`{"id": "d%d", "name": "dir%d", "type": "tree", "path": "dir%d"}`, i, i, i))
+	}
+	api := "https://gitlab.com/api/v4/projects/group%2Fmany/repository/"
+	httpFetcher = replayFetcher{
+		api + "tree?per_page=100":        "[" + strings.Join(dirs, ",") + "]",
+		api + "tree?per_page=100&page=2": `[{"id": "abc123", "name": "LICENSE", "type": "blob", "path": "LICENSE"}]`,
+		api + "blobs/abc123/raw":         testMITLicense,
+	}
+
+	gi := GoImport{Vcs: "git", RepoRoot: "https://gitlab.com/group/many"}
+	license, missing, err := getGitLabLicense(context.Background(), gi)
+	if (err != nil) || missing {
+		t.Fatalf("unexpected error: %v (missing: %t)", err, missing)
+	}
+	if (len(license.Parts) != 1) || (license.Parts[0].File != "LICENSE") {
+		t.Errorf("expected the LICENSE on the second page but got %+v", license.Parts)
+	}
+}
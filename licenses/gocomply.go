@@ -0,0 +1,1071 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var divider = strings.Repeat("-", 80)
+
+// DefaultHTTPTimeout is the default time limit for each HTTP request
+const DefaultHTTPTimeout = 10 * time.Second
+
+// httpTimeout is the time limit for each HTTP request
+var httpTimeout = DefaultHTTPTimeout
+
+
+// httpLicenseFiles to check, in order. For GitHub repos we have a more
+// efficient way of detecting licenses. These are case sensitive if the remote
+// server is case sensitive. This should be as small a list as possible.
+var httpLicenseFiles = []string{
+	"NOTICE", // apache, must come first
+	"LICENSE",
+	"LICENSE.txt",
+	"LICENSE.md",
+	"COPYING",
+	"COPYING.txt",
+	"COPYING.md",
+	"LICENSE-MIT", // dual-licensed, like many rust-influenced projects
+	"LICENSE-APACHE",
+}
+
+// repoLicensesFiles, in order of precedence for checking in a remote
+// repository. Unlike the httpLicenseFiles, we can check this case
+// insensitively.
+//
+// This sorting is informed by the go-license-detector dataset.zip:
+// `find | xargs -L1 -I{} basename "{}" | sort |  uniq -c > all.txt`
+// and https://pkg.go.dev/license-policy - but we want the actual copyright
+// notice and to exclude anything that's just a full copy of the GPL verbatim.
+//
+var repoLicenseFiles = []string{
+	"NOTICE", // apache, must come first
+	"NOTICE.txt", // apache, rarely
+	"LICENSE",
+	"LICENSE.txt",
+	"LICENSE.md",
+	"LICENSE.markdown",
+	"LICENSE.rst",
+	"LICENCE", // uncommon
+	"LICENCE.txt", // uncommon
+	"LICENCE.md", // uncommon
+	"LICENCE.markdown", // uncommon
+	"LICENCE.rst", // uncommon
+	"COPYING",
+	"COPYING.txt",
+	"COPYRIGHT",
+	"COPYRIGHT.txt",
+	"MIT-LICENSE",
+	"MIT-LICENSE.txt",
+	"MIT-LICENCE", // uncommon
+	"MIT-LICENCE.txt", // uncommon
+}
+
+type BasicAuth struct {
+	Username string
+	Token    string
+}
+var githubAuth = &BasicAuth{}
+
+func (a BasicAuth) IsSet() bool {
+	return a.Username != "" && a.Token != ""
+}
+
+func httpGet(ctx context.Context, rsc string, auth *BasicAuth) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rsc, nil)
+	if err != nil {
+		return "", err
+	}
+	return httpDo(req, auth)
+}
+
+// httpDo makes a request, waiting for rateLimiter, and returns the response
+// body. Any status but 200 is an error. Transient failures are retried (see
+// retryable) until the request's context is done. Without auth, the request
+// uses the credentials for its host, if any (see authorizeGitLab,
+// authorizeNetrc and authorizeStoredCredential), and is made again with the
+// credentials from git if the host requires them (see
+// authorizeGitCredential).
+func httpDo(req *http.Request, auth *BasicAuth) (string, error) {
+	authorized := true
+	if (auth != nil) && auth.IsSet() {
+		req.SetBasicAuth(
+			url.QueryEscape(auth.Username),
+			url.QueryEscape(auth.Token),
+		)
+	} else if !authorizeGitLab(req) && !authorizeNetrc(req) && !authorizeStoredCredential(req) {
+		authorized = authorizeGitCredential(req, false)
+	}
+
+	cached, revalidating := revalidate(req)
+
+	for attempt := 0; ; attempt++ {
+		body, err := httpAttempt(req, cached, revalidating)
+		if !authorized && isUnauthorized(err) {
+			authorized = true // ask git at most once
+			if authorizeGitCredential(req, true) {
+				if err := rewind(req); err != nil {
+					return "", err
+				}
+				attempt--
+				continue
+			}
+		}
+		if (err == nil) || (attempt >= httpRetries) || !retryable(err) {
+			return body, err
+		}
+
+		delay := retryDelay(attempt)
+		logf(levelInfo, "", "", err, "retrying in %s: %v", delay.Round(time.Millisecond), err)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return "", req.Context().Err()
+		}
+
+		if err := rewind(req); err != nil {
+			return "", err
+		}
+	}
+}
+
+// rewind replaces the body of a request that has been sent, so that it can
+// be sent again.
+func rewind(req *http.Request) (err error) {
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+	}
+	return err
+}
+
+// httpAttempt makes a single attempt at a request for httpDo.
+func httpAttempt(req *http.Request, cached cacheEntry, revalidating bool) (string, error) {
+	out := &bytes.Buffer{}
+	rsc := req.URL.String()
+
+	// be a good citizen
+	if err := waitForHost(req.Context(), rsc); err != nil {
+		return "", err
+	}
+	if err := req.Context().Err(); err != nil {
+		return "", err
+	}
+
+	setUserAgent(req)
+	start := time.Now()
+	resp, err := fetcher().Do(req)
+	logRequest(req, resp, err, time.Since(start))
+	if err != nil {
+		warnTLSError(rsc, err)
+		return "", err
+	}
+	defer drainAndClose(resp.Body)
+	observeHost(rsc, resp)
+
+	if revalidating && (resp.StatusCode == http.StatusNotModified) {
+		countCache(&cacheCounts.Hits)
+		recordResponse(rsc, resp, cached.Body)
+		return cached.Body, nil
+	} else if revalidating {
+		countCache(&cacheCounts.Refreshed)
+	}
+
+	if resp.StatusCode != 200 {
+		limited, reset := parseRateLimit(resp, time.Now())
+		return "", &httpStatusError{
+			URL:         rsc,
+			StatusCode:  resp.StatusCode,
+			RateLimited: limited,
+			Reset:       reset,
+		}
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	storeResponse(req, resp, out.String())
+	recordResponse(rsc, resp, out.String())
+	return out.String(), nil
+}
+
+// logRequest logs a request's URL and HTTP status (or error) with -v, and
+// also how long it took with -vv.
+func logRequest(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	var result string
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		result = urlErr.Err.Error() // without repeating the URL
+	} else if err != nil {
+		result = err.Error()
+	} else {
+		result = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if logEnabled(levelTrace) {
+		logf(levelTrace, "", "", err, "%s %s: %s (%s)", req.Method, req.URL, result, elapsed.Round(time.Millisecond))
+	} else {
+		logf(levelDebug, "", "", err, "%s %s: %s", req.Method, req.URL, result)
+	}
+}
+
+type GoImport struct {
+	ImportPrefix string
+	Vcs          string
+	RepoRoot     string
+}
+
+type GoSource struct {
+	ImportPrefix string
+	Home         string
+	Directory    string
+	File         string
+}
+
+// spacedMetaTag matches the start of a <meta> tag with whitespace after the
+// "<", e.g. "<  meta", which HTML doesn't treat as a tag, but which gocomply
+// has always accepted.
+var spacedMetaTag = regexp.MustCompile(`(?i)<\s+meta\b`)
+
+// metaContents returns the content of each <meta> tag with the given name in
+// an HTML page, split into fields. Attributes may be in any order and quoted
+// either way, and entities in them are decoded, as with any HTML.
+func metaContents(data string, name string) [][]string {
+	var contents [][]string
+	data = spacedMetaTag.ReplaceAllString(data, "<meta")
+	z := html.NewTokenizer(strings.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return contents // io.EOF, or a page too broken to read further
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, hasAttr := z.TagName()
+			if (string(tag) != "meta") || !hasAttr {
+				continue
+			}
+			var metaName, content string
+			hasContent := false
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "name":
+					metaName = string(value)
+				case "content":
+					content, hasContent = string(value), true
+				}
+			}
+			if strings.EqualFold(metaName, name) && hasContent {
+				contents = append(contents, strings.Fields(content))
+			}
+		}
+	}
+}
+
+// parseGoImport returns the go-import meta tag for a module. A page may have
+// several, for different import prefixes, so as "go get" does, the tag whose
+// prefix is the longest that matches the module path is used.
+func parseGoImport(data string, module string) (GoImport, bool) {
+	var result GoImport
+	found := false
+
+	for _, fields := range metaContents(data, "go-import") {
+		if len(fields) != 3 {
+			continue
+		}
+		gi := GoImport{ImportPrefix: fields[0], Vcs: fields[1], RepoRoot: fields[2]}
+		if !importPrefixMatches(gi.ImportPrefix, module) {
+			continue
+		}
+		if !found || (len(gi.ImportPrefix) > len(result.ImportPrefix)) {
+			result, found = gi, true
+		}
+	}
+
+	return result, found
+}
+
+// importPrefixMatches returns true if a module path is at or under an import
+// prefix, e.g. "example.org/foo" matches "example.org/foo/bar" but not
+// "example.org/foobar".
+func importPrefixMatches(prefix string, module string) bool {
+	return (module == prefix) || strings.HasPrefix(module, prefix+"/")
+}
+
+// parseGoSource returns the go-source meta tag for a module: as for
+// parseGoImport, the one whose prefix is the longest that matches.
+func parseGoSource(data string, module string) (GoSource, bool) {
+	var result GoSource
+	found := false
+
+	for _, fields := range metaContents(data, "go-source") {
+		if len(fields) != 4 {
+			continue
+		}
+		gs := GoSource{ImportPrefix: fields[0], Home: fields[1], Directory: fields[2], File: fields[3]}
+		if !importPrefixMatches(gs.ImportPrefix, module) {
+			continue
+		}
+		if !found || (len(gs.ImportPrefix) > len(result.ImportPrefix)) {
+			result, found = gs, true
+		}
+	}
+
+	return result, found
+}
+
+// Module is a module path and, if known, its version.
+type Module struct {
+	Path    string
+	Version string
+
+	// Replace is the module path or local directory that replaces this
+	// module, if go.mod has a replace directive for it, with ReplaceVersion
+	// the version of a replacement module, or ReplaceDir the absolute path
+	// of a replacement directory.
+	Replace        string
+	ReplaceVersion string
+	ReplaceDir     string
+
+	// Indirect is true if the module is only required by other modules, not
+	// the main module's go.mod directly.
+	Indirect bool
+
+	// Tool is true if the module is only needed by the main module's tools,
+	// at build time, with --tools=annotate.
+	Tool bool
+
+	// Retracted is the reason the module's author gave for retracting its
+	// version, if they did.
+	Retracted string
+}
+
+// source returns the module whose code is actually used: its replacement
+// module, if it has one, or else the module itself.
+func (m Module) source() Module {
+	if (m.Replace != "") && (m.ReplaceDir == "") {
+		return Module{Path: m.Replace, Version: m.ReplaceVersion}
+	}
+	return Module{Path: m.Path, Version: m.Version}
+}
+
+// replacement describes the module's replacement, if any, as in go.mod: e.g.
+// "example.org/fork v1.1.0" or "../fork".
+func (m Module) replacement() string {
+	if m.ReplaceVersion != "" {
+		return m.Replace + " " + m.ReplaceVersion
+	}
+	return m.Replace
+}
+
+// parseModuleArg parses a module given on the command line, either as a bare
+// module path or as "path@version".
+func parseModuleArg(arg string) Module {
+	idx := strings.LastIndexByte(arg, '@')
+	if idx < 0 {
+		return Module{Path: arg}
+	}
+	return Module{Path: arg[:idx], Version: arg[idx+1:]}
+}
+
+// listModules returns every module required by the main module, with one
+// "go list" and one "go mod why" command, rather than one per module.
+func listModules(ctx context.Context) ([]Module, error) {
+	stdout, err := exec.CommandContext(ctx, "go", "list", "-m", "-json", "all").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+
+	candidates, err := parseModuleList(stdout)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range candidates {
+		if m.Retracted != "" {
+			logf(levelWarning, phaseSetup, m.Path, nil,
+				"warning: %s@%s has been retracted by its author: %s", m.Path, m.Version, m.Retracted)
+		}
+	}
+
+	names := make([]string, len(candidates))
+	for i, m := range candidates {
+		names[i] = m.Path
+	}
+	required, err := requiredModules(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make([]Module, 0)
+	for _, m := range candidates {
+		if required[m.Path] {
+			modules = append(modules, m)
+		}
+	}
+
+	return modules, nil
+}
+
+// parseModuleList parses the output of "go list -m -json all", skipping
+// the main module.
+func parseModuleList(stdout []byte) ([]Module, error) {
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	modules := make([]Module, 0)
+	for {
+		var m struct {
+			Path      string
+			Version   string
+			Main      bool
+			Indirect  bool
+			Retracted []string
+			Replace   *struct {
+				Path    string
+				Version string
+				Dir     string
+			}
+		}
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid go list output format: %v", err)
+		}
+		if m.Main { continue }
+
+		module := Module{
+			Path:      m.Path,
+			Version:   m.Version,
+			Indirect:  m.Indirect,
+			Retracted: strings.Join(m.Retracted, "; "),
+		}
+		if m.Replace != nil {
+			module.Replace = m.Replace.Path
+			module.ReplaceVersion = m.Replace.Version
+			if m.Replace.Version == "" {
+				// a local directory
+				module.ReplaceDir = m.Replace.Dir
+				if module.ReplaceDir == "" {
+					module.ReplaceDir = m.Replace.Path
+				}
+			}
+		}
+		modules = append(modules, module)
+	}
+
+	return modules, nil
+}
+
+// exitErrorStderr returns any stderr captured from a failed command, or nil
+// if the command could not be run at all (e.g. go is not on the PATH).
+func exitErrorStderr(err error) []byte {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Stderr
+	}
+	return nil
+}
+
+// modWhyBatch is the number of modules given to each "go mod why" command,
+// to stay well within command-line length limits.
+const modWhyBatch = 500
+
+// requiredModules returns which of the named modules are required by the
+// main module.
+func requiredModules(ctx context.Context, names []string) (map[string]bool, error) {
+	// "download is split into two parts: downloading the go.mod and
+	// downloading the actual code. If you have dependencies only needed for
+	// tests, then they will show up in your go.mod, and go get will download
+	// their go.mods, but it will not download their code."
+	//
+	// "This applies not just to test-only dependencies but also os-specific
+	// dependencies."
+	//
+	// -- https://github.com/golang/go/issues/26913#issuecomment-411976222
+	//
+	// "The -vendor flag causes why to exclude tests of dependencies.
+	//
+	// "If the package or module is not
+	//  referenced from the main module, the stanza will display a single
+	//  parenthesized note indicating that fact."
+
+	required := make(map[string]bool)
+	for start := 0; start < len(names); start += modWhyBatch {
+		end := start + modWhyBatch
+		if end > len(names) { end = len(names) }
+
+		args := append([]string{"mod", "why", "-m", "-vendor"}, names[start:end]...)
+		stdout, err := exec.CommandContext(ctx, "go", args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("go why error: %+v: %s", err, exitErrorStderr(err))
+		}
+
+		if err := parseModWhy(stdout, required); err != nil {
+			return nil, err
+		}
+	}
+	return required, nil
+}
+
+// parseModWhy parses the output of "go mod why -m", with a stanza for each
+// module, recording whether each module is required.
+func parseModWhy(stdout []byte, required map[string]bool) error {
+	stanzas := bytes.Split(bytes.TrimSpace(stdout), []byte("\n\n"))
+	for _, stanza := range stanzas {
+		lines := bytes.Split(stanza, []byte{'\n'})
+		if len(lines) < 2 {
+			return fmt.Errorf("unexpected go why output format")
+		}
+
+		// "# golang.org/x/text/encoding"
+		header := bytes.TrimSpace(lines[0])
+		if !bytes.HasPrefix(header, []byte("# ")) {
+			return fmt.Errorf("unexpected go why output format")
+		}
+		name := string(header[2:])
+
+		// "(main module does not need package golang.org/x/text/encoding)"
+		line := bytes.TrimSpace(lines[1])
+		if (len(line) > 2) && line[0] == '(' && line[len(line)-1] == ')' {
+			required[name] = false
+			continue
+		}
+
+		// any other result means its used
+		required[name] = true
+	}
+	return nil
+}
+
+func stringDecoderIdentity(str string) (string, error) {
+	return str, nil
+}
+
+func stringDecoderBase64(str string) (string, error) {
+	bytes, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// FileURL is a URL of a file in a repository at a given ref (e.g. a branch).
+type FileURL struct {
+	URL string
+	Ref string
+}
+
+func resolveFileURL(ctx context.Context, gi GoImport, gs GoSource, file string) ([]FileURL, func(string) (string, error), error) {
+	if urls, ok := resolverFileURLs(ctx, gi, file); ok {
+		return urls, stringDecoderNotHTML, nil
+	}
+
+	vcs := gi.Vcs
+	repoRoot := gi.RepoRoot
+
+	if vcs != "git" {
+		return nil, nil, fmt.Errorf("vcs %q not implemented", vcs)
+	}
+
+	if strings.HasPrefix(repoRoot, "https://go.googlesource.com/") {
+		return []FileURL{{fmt.Sprintf("%s/+/refs/heads/master/%s?format=text", repoRoot, file), "master"}},
+			stringDecoderBase64, nil
+	}
+
+	if strings.HasPrefix(repoRoot, "https://git.sr.ht/") {
+		dir := strings.TrimSuffix(repoRoot, ".git")
+		return []FileURL{{fmt.Sprintf("%s/blob/master/%s", dir, file), "master"}},
+			stringDecoderIdentity, nil
+	}
+
+	if strings.HasPrefix(repoRoot, "https://gopkg.in/") {
+		// gopkg.in/pkg.v1 or gopkg.in/user/pkg.v1, served from a tag or
+		// branch of its GitHub repository (see gopkgInRef)
+		g, ok := parseGopkgIn(repoRoot)
+		if !ok {
+			return nil, nil, fmt.Errorf("gopkg.in parse error")
+		}
+		ref, err := gopkgInRef(ctx, g)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gopkg.in error: %v", err)
+		}
+
+		return []FileURL{
+				{fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", g.Owner, g.Repo, ref, file), ref},
+			},
+			stringDecoderIdentity, nil
+	}
+
+	if strings.HasPrefix(repoRoot, "https://github.com/") {
+		dir := strings.TrimPrefix(repoRoot, "https://github.com/")
+		dir = strings.TrimSuffix(dir, ".git")
+
+		return []FileURL{
+				{fmt.Sprintf("https://raw.githubusercontent.com/%s/main/%s", dir, file), "main"},
+				{fmt.Sprintf("https://raw.githubusercontent.com/%s/master/%s", dir, file), "master"}, // historical
+			},
+			stringDecoderIdentity, nil
+	}
+
+	if host, project, ok := gitlabProject(repoRoot); ok {
+		// a private project without a token redirects to a login page
+		return gitlabFileURLs(host, project, file), stringDecoderNotHTML, nil
+	}
+
+	if strings.HasPrefix(repoRoot, "https://") {
+		// e.g. a vanity domain that also hosts the repository
+		return resolveForgeFileURL(ctx, repoRoot, file), stringDecoderNotHTML, nil
+	}
+
+	return nil, nil, fmt.Errorf("repo %q not supported (please open an issue)", repoRoot)
+}
+
+// licenseFile is a license text and a record of where it came from.
+//
+// If a repository has several license files, such as LICENSE-MIT and
+// LICENSE-APACHE, Text combines all of them, the provenance fields describe
+// the first, and Parts has each individually.
+type licenseFile struct {
+	Text      string
+	SourceURL string    // the exact URL the text was fetched from
+	Ref       string    // the branch or other ref it was fetched at, if known
+	Revision  string    // an object id (e.g. git blob SHA) for the text, if known
+	Retrieved time.Time // retrieval time, clamped to SOURCE_DATE_EPOCH
+
+	Parts []licensePart
+
+	// Inferred describes where the license was inferred from, if Text isn't
+	// from a license file (e.g. inferredFromSource), and SPDX is the license
+	// expression it states, if any, instead of identifying Text.
+	// LowConfidence is true if Text is only an excerpt that may not be the
+	// whole license.
+	Inferred      string
+	SPDX          string
+	LowConfidence bool
+
+	// MovedTo is the repository's current URL, if it has moved from where
+	// the module's import path points (see getLicense).
+	MovedTo string
+
+	// raw is Text before it was converted to plain text, if it was (see
+	// plainLicense).
+	raw string
+
+	// Dir is the directory of the repository that the license is from, for
+	// a module in a subdirectory of its repository: its own directory, or
+	// "." if it has no license of its own. It is empty for other modules.
+	Dir string
+}
+
+// getLicense returns a module's license from its repository. If that fails
+// because the repository has moved, as GitHub repositories that are renamed
+// or transferred do, it is fetched from where the repository is now, and a
+// warning is logged that the module's path points at a moved repository.
+func getLicense(ctx context.Context, module string, gi GoImport, gs GoSource) (licenseFile, error) {
+	license, err := getModuleLicense(ctx, module, gi, gs)
+	if (err != nil) && (license.MovedTo == "") {
+		if movedTo, ok := githubMovedRepo(ctx, gi); ok {
+			gi.RepoRoot = movedTo
+			license, err = getModuleLicense(ctx, module, gi, gs)
+			license.MovedTo = movedTo
+		}
+	}
+	if err == nil {
+		license = withGoProjectNotices(ctx, module, gi, gs, license)
+	}
+	if license.MovedTo != "" {
+		logf(levelWarning, phaseLicense, module, nil,
+			"warning: module %q is in a repository that has moved to %s; its import path still points at the old location", module, license.MovedTo)
+	}
+	return license, err
+}
+
+// getModuleLicense returns a module's license from its repository. For a
+// module in a subdirectory of its repository, a license in that subdirectory
+// is used in preference to the repository's (see getSubdirLicense), and Dir
+// records which was used.
+func getModuleLicense(ctx context.Context, module string, gi GoImport, gs GoSource) (licenseFile, error) {
+	subdir := moduleSubdir(module, gi)
+	if subdir == "" {
+		return getRepoLicense(ctx, module, gi, gs)
+	}
+
+	license, found, err := getSubdirLicense(ctx, module, gi, gs, subdir)
+	if err != nil {
+		return licenseFile{}, err
+	}
+	if found {
+		logf(levelDebug, phaseLicense, module, nil, "%s: license from the %s directory of its repository", module, subdir)
+		license.Dir = subdir
+		return license, nil
+	}
+
+	logf(levelDebug, phaseLicense, module, nil, "%s: no license in the %s directory, so using its repository's", module, subdir)
+	license, err = getRepoLicense(ctx, module, gi, gs)
+	license.Dir = "."
+	return license, err
+}
+
+// getRepoLicense returns the license at the root of a repository.
+func getRepoLicense(ctx context.Context, module string, gi GoImport, gs GoSource) (licenseFile, error) {
+	var apiErr error
+
+	// try API
+	if gi.Vcs == "git" && strings.HasPrefix(gi.RepoRoot, "https://github.com/") && githubAuth.IsSet() {
+		// rateLimiter paces requests as the rate limit runs low (see
+		// PoliteRateLimiter)
+		license, missing, err := getGitHubLicense(ctx, gi)
+
+		if err == nil {
+			return license, nil
+		} else {
+			err = fmt.Errorf("api.github.com error: %w", err)
+
+			if missing {
+				if license, ok := tryGetInferredLicense(ctx, gi, gs); ok {
+					return license, nil
+				}
+				return licenseFile{}, err
+			} else {
+				logf(levelWarning, phaseLicense, module, err, "%s", err)
+				apiErr = err
+				// proceed to fallback
+			}
+		}
+	}
+
+	// a GitLab or Gitea listing, unless a resolver knows the repository's
+	// files
+	if _, resolved := resolverFileURLs(ctx, gi, "LICENSE"); !resolved && (gi.Vcs == "git") {
+		var listing func() (licenseFile, bool, error)
+		forge := ""
+		if _, _, ok := gitlabProject(gi.RepoRoot); ok {
+			listing = func() (licenseFile, bool, error) { return getGitLabLicense(ctx, gi) }
+			forge = "GitLab"
+		} else if providerName(gi) == "forge" {
+			if api, ok := giteaRepo(ctx, gi.RepoRoot); ok {
+				listing = func() (licenseFile, bool, error) { return getGiteaLicense(ctx, gi, api) }
+				forge = "Gitea"
+			}
+		}
+
+		if listing != nil {
+			license, missing, err := listing()
+			if err == nil {
+				return license, nil
+			}
+			if missing {
+				if license, ok := tryGetInferredLicense(ctx, gi, gs); ok {
+					return license, nil
+				}
+				return licenseFile{}, fmt.Errorf("%s API error: %w", forge, err)
+			}
+			// e.g. a private project without a token
+			logf(levelInfo, phaseLicense, module, err, "%s API error: %v (trying raw files)", forge, err)
+		}
+	}
+
+	license, err := tryGetLicense(ctx, module, gi, gs, httpLicenseFiles)
+	if err != nil {
+		// if rate limiting was the reason the API failed, keep that
+		if limited, _ := rateLimitReset(apiErr); limited {
+			return licenseFile{}, fmt.Errorf("%v (after %w)", err, apiErr)
+		}
+	}
+	return license, err
+}
+
+// licenseFetch is the result of fetching a repository's license files.
+type licenseFetch struct {
+	parts []licensePart
+	ref   string
+
+	rateLimitErr error // the most recent request that was rate limited
+	transientErr error // the most recent request that failed even after retries
+}
+
+// fetchLicenseFiles fetches each of files that a repository has. Once a
+// license file is found, the ref it was found at is fixed and the remaining
+// files are checked on that ref only. The files are requested concurrently,
+// likeliest first (see licenseProbes).
+func fetchLicenseFiles(ctx context.Context, module string, gi GoImport, gs GoSource, files []string) (licenseFetch, error) {
+	p := &licenseProbes{byFile: make([][]*licenseProbe, len(files))}
+
+	for i, license := range files {
+		licenseUrls, decoder, err := resolveFileURL(ctx, gi, gs, license)
+		if err != nil {
+			return licenseFetch{}, fmt.Errorf("no known license URL for module %q: %v", module, err)
+		}
+
+		for _, licenseUrl := range licenseUrls {
+			p.byFile[i] = append(p.byFile[i], &licenseProbe{
+				file:    license,
+				url:     licenseUrl,
+				decoder: decoder,
+			})
+		}
+	}
+
+	p.run(ctx)
+	if p.err != nil {
+		return licenseFetch{}, p.err
+	}
+	return p.result(), nil
+}
+
+func tryGetLicense(ctx context.Context, module string, gi GoImport, gs GoSource, files []string) (licenseFile, error) {
+	fetched, err := fetchLicenseFiles(ctx, module, gi, gs, files)
+	if err != nil {
+		return licenseFile{}, err
+	}
+
+	if len(fetched.parts) > 0 {
+		result := combineLicenseParts(fetched.parts)
+		result.Ref = fetched.ref
+		result.Retrieved = retrievalTime()
+		return result, nil
+	}
+
+	// a license file may have been missed, so don't infer one instead
+	if fetched.transientErr != nil {
+		return licenseFile{}, fmt.Errorf("no license found for module %q (after a failed request: %w)", module, fetched.transientErr)
+	}
+
+	if license, ok := tryGetREUSELicense(ctx, gi, gs); ok {
+		return license, nil
+	}
+
+	if license, ok := tryGetInferredLicense(ctx, gi, gs); ok {
+		return license, nil
+	}
+
+	if fetched.rateLimitErr != nil {
+		return licenseFile{}, fmt.Errorf("no license found for module %q (rate limited: %w)", module, fetched.rateLimitErr)
+	}
+	return licenseFile{}, fmt.Errorf("no license found for module %q", module)
+}
+
+// getSubdirLicense returns the license in a module's subdirectory of its
+// repository, such as cloud.google.com/go/storage in the storage directory
+// of its repository, which may differ from the repository's own license. If
+// there is no license file there, found is false, and the repository root's
+// license applies instead. If a request fails, so that the subdirectory
+// might have been missed, there is an error instead.
+func getSubdirLicense(ctx context.Context, module string, gi GoImport, gs GoSource, subdir string) (license licenseFile, found bool, err error) {
+	if gi.Vcs == "git" && strings.HasPrefix(gi.RepoRoot, "https://github.com/") && githubAuth.IsSet() {
+		license, found, err := getGitHubSubdirLicense(ctx, gi, subdir)
+		if err == nil {
+			return license, found, nil
+		}
+		logf(levelWarning, phaseLicense, module, err, "api.github.com error: %v", err)
+		// proceed to fallback
+	}
+
+	files := make([]string, len(httpLicenseFiles))
+	for i, f := range httpLicenseFiles {
+		files[i] = path.Join(subdir, f)
+	}
+	fetched, err := fetchLicenseFiles(ctx, module, gi, gs, files)
+	if err != nil {
+		return licenseFile{}, false, err
+	}
+	if len(fetched.parts) > 0 {
+		license := combineLicenseParts(fetched.parts)
+		license.Ref = fetched.ref
+		license.Retrieved = retrievalTime()
+		return license, true, nil
+	}
+	if fetched.transientErr != nil {
+		return licenseFile{}, false, fmt.Errorf("no license found in %s for module %q (after a failed request: %w)", subdir, module, fetched.transientErr)
+	}
+	if fetched.rateLimitErr != nil {
+		return licenseFile{}, false, fmt.Errorf("no license found in %s for module %q (rate limited: %w)", subdir, module, fetched.rateLimitErr)
+	}
+	return licenseFile{}, false, nil
+}
+
+// lookup resolves a module to its repository from its go-import meta tag.
+// If that fails, the module is assumed to be in a private git repository at
+// its path. Unless the module is known to be private (see privatePatterns),
+// its module root is tried first, and a warning is logged for the guess.
+func lookup(ctx context.Context, module string, private bool) (gi GoImport, gs GoSource, err error) {
+	var data string
+	var ok bool
+
+	data, err = httpGet(ctx, fmt.Sprintf("https://%s?go-get=1", module), nil)
+	if err != nil {
+		// Attempt module root, for example:
+		// https://github.com/go-gl/glfw/v3.3/glfw -> https://github.com/go-gl/glfw
+		// https://github.com/russross/blackfriday/v2 -> https://github.com/russross/blackfriday
+		parts := strings.Split(module, "/")
+		if (len(parts) > 3) && !private {
+			moduleroot := strings.Join(parts[:3], "/")
+			data, err = httpGet(ctx, fmt.Sprintf("https://%s?go-get=1", moduleroot), nil)
+		}
+
+		if err != nil {
+			// Assume its a private repo
+			if !private {
+				logf(levelWarning, phaseLookup, module, err,
+					"warning: assuming module %q is a private git repository (add it to GOPRIVATE to skip public lookups): %v", module, err)
+			}
+			gi = GoImport{
+				ImportPrefix: module,
+				Vcs:          "git",
+				RepoRoot:     fmt.Sprintf("https://%s.git", module),
+			}
+			return gi, gs, nil
+		}
+	}
+
+	gi, ok = parseGoImport(data, module)
+	if !ok {
+		err = fmt.Errorf("unrecognised import %q (no go-import meta tag matching it)", module)
+		return
+	}
+
+	gs, _ = parseGoSource(data, module)
+
+	return gi.normalize(), gs.normalize(), nil
+}
+
+// Main runs the gocomply command with its command-line arguments (without
+// the program name), and returns its exit status (see exitStatus).
+func Main(args []string) int {
+
+	// Only the report may ever be written to stdout. Keep hold of the real
+	// stdout for the report writer and point os.Stdout at stderr so that
+	// nothing else (including any stray print) can bleed into the report.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	command := "scan"
+	if len(args) > 0 && isCommand(args[0]) {
+		command, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("gocomply", flag.ContinueOnError)
+	var opts options
+	opts.register(fs)
+	showVersion := fs.Bool("version", false, "print gocomply's version and exit")
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() { usage(fs, os.Stderr) }
+	args, err := parseFlags(fs, expandOptionalValues(args))
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	} else if err != nil {
+		return exitFatal
+	}
+	opts.parsed(fs)
+
+	if *showVersion {
+		fmt.Fprintln(stdout, version())
+		return exitOK
+	}
+
+	if err := validateLogFormat(logFormat); err != nil {
+		logFormat = "text"
+		logf(levelError, phaseSetup, "", err, "error: %v", err)
+		return exitFatal
+	}
+	if err := validateCI(ciMode); err != nil {
+		logf(levelError, phaseSetup, "", err, "error: %v", err)
+		return exitFatal
+	}
+	logVerbosity, err = opts.verbosity()
+	if err != nil {
+		logf(levelError, phaseSetup, "", err, "error: %v", err)
+		return exitFatal
+	}
+
+	if command == "diff" || (command == "verify" && !opts.Refetch) {
+		// verifying or comparing reports needs no credentials or network
+		var err error
+		if command == "diff" {
+			err = runDiff(&opts, args, stdout)
+		} else {
+			err = runVerify(context.Background(), &opts, args, stdout)
+		}
+		if err != nil {
+			logf(levelError, "", "", err, "error: %v", err)
+		}
+		return exitStatus(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if command == "scan" || command == "check" || command == "list" || command == "cache" || command == "serve" {
+		handleInterrupts(cancel, command == "serve")
+	}
+	if opts.Deadline > 0 {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithTimeout(ctx, opts.Deadline)
+		defer cancelDeadline()
+	}
+
+	if err := parseNetrc(); err != nil {
+		logf(levelWarning, phaseSetup, "", err, "warning: %v", err)
+	}
+	configureGitHubAuth(ctx)
+
+	if githubAuth == nil || !githubAuth.IsSet() {
+		logf(levelWarning, phaseSetup, "", nil, "warning: no credentials set for GitHub API\n -- gocomply may be slower and less accurate")
+	}
+
+	if command == "list" {
+		opts.List = true
+	}
+
+	err = opts.load(ctx)
+	if err == nil {
+		switch command {
+		case "cache":
+			err = runCache(ctx, &opts, args, stdout)
+		case "check":
+			err = runCheck(ctx, &opts, args, stdout)
+		case "compat":
+			err = runCompat(ctx, &opts, args, stdout)
+		case "quick":
+			err = runQuick(ctx, &opts, args, stdout)
+		case "verify":
+			err = runVerify(ctx, &opts, args, stdout)
+		case "why":
+			err = runWhy(ctx, &opts, args, stdout)
+		case "rpc":
+			err = runRPC(ctx, &opts, os.Stdin, stdout, scanModule)
+		case "serve":
+			err = runServe(ctx, &opts, args, scanModule)
+		case "tui":
+			err = runTUI(ctx, &opts, args, os.Stdin, os.Stderr, scanModule)
+		default:
+			err = runScan(ctx, &opts, args, stdout)
+		}
+	}
+
+	if opts.Cache != "" {
+		if serr := (dirCache{dir: opts.Cache}).addStats(); serr != nil {
+			logf(levelWarning, "", "", serr, "warning: %v", serr)
+		}
+	}
+
+	if err != nil {
+		logf(levelError, "", "", err, "error: %v", err)
+	}
+	return exitStatus(err)
+}
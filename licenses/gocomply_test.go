@@ -0,0 +1,344 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoImport(t *testing.T) {
+	type row struct {
+		input      string
+		module     string // if not the expected import prefix
+		expected   GoImport
+		expectedOK bool
+	}
+	tests := []row{
+		{
+			// several prefixes: the longest that matches
+			input:  `<html><meta name="go-import" content="example.org/x git https://github.com/example/x"><meta name="go-import" content="example.org/x/sub git https://github.com/example/sub"></html>`,
+			module: "example.org/x/sub/pkg",
+			expected: GoImport{
+				ImportPrefix: "example.org/x/sub",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/sub",
+			},
+			expectedOK: true,
+		},
+		{
+			// a prefix only matches whole path elements
+			input:  `<html><meta name="go-import" content="example.org/x git https://github.com/example/x"><meta name="go-import" content="example.org/x/sub git https://github.com/example/sub"></html>`,
+			module: "example.org/x/subpkg",
+			expected: GoImport{
+				ImportPrefix: "example.org/x",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/x",
+			},
+			expectedOK: true,
+		},
+		{
+			// no prefix matches
+			input:      `<html><meta name="go-import" content="example.org/x git https://github.com/example/x"><meta name="go-import" content="example.org/x/sub git https://github.com/example/sub"></html>`,
+			module:     "example.org/other",
+			expectedOK: false,
+		},
+		{
+			// ending in ">"
+			input: `<html><meta name="go-import" content="example.org/vanity git https://github.com/example/vanity"></html>`,
+			expected: GoImport{
+				ImportPrefix: "example.org/vanity",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/vanity",
+			},
+			expectedOK: true,
+		},
+		{
+			// ending in "/>"
+			input: `<html><meta name="go-import" content="example.org/vanity git https://github.com/example/vanity" /></html>`,
+			expected: GoImport{
+				ImportPrefix: "example.org/vanity",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/vanity",
+			},
+			expectedOK: true,
+		},
+		{
+			// unicode
+			input: `<html><meta name="go-import" content="example.org/本 git https://github.com/example/本" /></html>`,
+			expected: GoImport{
+				ImportPrefix: "example.org/本",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/本",
+			},
+			expectedOK: true,
+		},
+		{
+			// awkward whitespace but still valid
+			input: `<html>
+    <  MeTa  NaMe  =  "go-import"
+        CoNtEnT  =  "example.org/foo git https://github.com/example/foo"  /></html>`,
+			expected: GoImport{
+				ImportPrefix: "example.org/foo",
+				Vcs:          "git",
+				RepoRoot:     "https://github.com/example/foo",
+			},
+			expectedOK: true,
+		},
+		{
+			// single-quoted, unquoted and reordered attributes
+			input: `<head><meta content='example.org/foo git https://git.sr.ht/~example/foo' name=go-import></head>`,
+			expected: GoImport{
+				ImportPrefix: "example.org/foo",
+				Vcs:          "git",
+				RepoRoot:     "https://git.sr.ht/~example/foo",
+			},
+			expectedOK: true,
+		},
+		{
+			// entities
+			input: `<meta name="go-import" content="example.org/foo git https://example.org/git?repo=foo&amp;x=1">`,
+			expected: GoImport{
+				ImportPrefix: "example.org/foo",
+				Vcs:          "git",
+				RepoRoot:     "https://example.org/git?repo=foo&x=1",
+			},
+			expectedOK: true,
+		},
+		{
+			// not a meta tag
+			input:      `<p>name="go-import" content="example.org/foo git https://github.com/example/foo"</p>`,
+			module:     "example.org/foo",
+			expectedOK: false,
+		},
+	}
+
+	for i, test := range tests {
+		module := test.module
+		if module == "" {
+			module = test.expected.ImportPrefix
+		}
+		gi, ok := parseGoImport(test.input, module)
+		if ok != test.expectedOK {
+			t.Errorf("test %d failed: parse error", i)
+		} else if gi != test.expected {
+			t.Errorf("test %d failed: expected %+v but got %+v",
+				i, test.expected, gi)
+		}
+	}
+}
+
+func TestParseGoSource(t *testing.T) {
+	type row struct {
+		input      string
+		module     string // if not the expected import prefix
+		expected   GoSource
+		expectedOK bool
+	}
+	tests := []row{
+		{
+			// several prefixes: the longest that matches
+			input:  `<html><meta name="go-source" content="a b c d"><meta name="go-source" content="a/b e f g"></html>`,
+			module: "a/b/c",
+			expected: GoSource{
+				ImportPrefix: "a/b",
+				Home:         "e",
+				Directory:    "f",
+				File:         "g",
+			},
+			expectedOK: true,
+		},
+		{
+			// real-world example
+			input: `<html><meta name="go-source" content="a b c d"></html>`,
+			expected: GoSource{
+				ImportPrefix: "a",
+				Home:         "b",
+				Directory:    "c",
+				File:         "d",
+			},
+			expectedOK: true,
+		},
+		{
+			// real-world example
+			input: `<html><meta name="go-source" content="gopkg.in/natefinch/lumberjack.v2 _ https://github.com/natefinch/lumberjack/tree/v2.1{/dir} https://github.com/natefinch/lumberjack/blob/v2.1{/dir}/{file}#L{line}"></html>`,
+			expected: GoSource{
+				ImportPrefix: "gopkg.in/natefinch/lumberjack.v2",
+				Home:         "_",
+				Directory:    "https://github.com/natefinch/lumberjack/tree/v2.1{/dir}",
+				File:         "https://github.com/natefinch/lumberjack/blob/v2.1{/dir}/{file}#L{line}",
+			},
+			expectedOK: true,
+		},
+	}
+
+	for i, test := range tests {
+		module := test.module
+		if module == "" {
+			module = test.expected.ImportPrefix
+		}
+		gs, ok := parseGoSource(test.input, module)
+		if ok != test.expectedOK {
+			t.Errorf("test %d failed: parse error", i)
+		} else if gs != test.expected {
+			t.Errorf("test %d failed: expected %+v but got %+v",
+				i, test.expected, gs)
+		}
+	}
+}
+
+func TestParseModuleArg(t *testing.T) {
+	tests := map[string]Module{
+		"example.org/a":        {Path: "example.org/a"},
+		"example.org/a@v1.2.3": {Path: "example.org/a", Version: "v1.2.3"},
+	}
+
+	for input, expected := range tests {
+		if m := parseModuleArg(input); m != expected {
+			t.Errorf("parseModuleArg(%q): expected %+v but got %+v", input, expected, m)
+		}
+	}
+}
+
+func TestParseModuleList(t *testing.T) {
+	stdout := []byte(`{"Path": "example.org/main", "Main": true}
+{"Path": "example.org/a", "Version": "v1.0.0"}
+{"Path": "example.org/b", "Version": "v0.2.0", "Indirect": true}
+{"Path": "example.org/c", "Version": "v1.0.0", "Replace": {"Path": "example.org/fork", "Version": "v1.1.0"}}
+{"Path": "example.org/d", "Version": "v1.0.0", "Replace": {"Path": "../d", "Dir": "/src/d"}}
+{"Path": "example.org/e", "Version": "v1.0.1", "Retracted": ["broken", "use v1.0.2"]}
+`)
+
+	modules, err := parseModuleList(stdout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Module{
+		{Path: "example.org/a", Version: "v1.0.0"},
+		{Path: "example.org/b", Version: "v0.2.0", Indirect: true},
+		{Path: "example.org/c", Version: "v1.0.0", Replace: "example.org/fork", ReplaceVersion: "v1.1.0"},
+		{Path: "example.org/d", Version: "v1.0.0", Replace: "../d", ReplaceDir: "/src/d"},
+		{Path: "example.org/e", Version: "v1.0.1", Retracted: "broken; use v1.0.2"},
+	}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("expected %+v but got %+v", expected, modules)
+	}
+
+	if src := modules[2].source(); src != (Module{Path: "example.org/fork", Version: "v1.1.0"}) {
+		t.Errorf("expected the replacement but got %+v", src)
+	}
+	if r := modules[2].replacement(); r != "example.org/fork v1.1.0" {
+		t.Errorf("expected %q but got %q", "example.org/fork v1.1.0", r)
+	}
+	if src := modules[3].source(); src != (Module{Path: "example.org/d", Version: "v1.0.0"}) {
+		t.Errorf("expected the module itself but got %+v", src)
+	}
+}
+
+func TestScanReplacedModule(t *testing.T) {
+	oldResolvers, oldFetcher, oldLimiter := resolvers, httpFetcher, rateLimiter
+	resolvers, rateLimiter = []Resolver{forgeResolver{}}, NoRateLimit
+	httpFetcher = replayFetcher{"https://forge.test/example.org/fork/raw/LICENSE": testMITLicense}
+	defer func() { resolvers, httpFetcher, rateLimiter = oldResolvers, oldFetcher, oldLimiter }()
+
+	m := Module{Path: "example.org/c", Version: "v1.0.0", Replace: "example.org/fork", ReplaceVersion: "v1.1.0"}
+	entry, err := scanModule(context.Background(), m, &options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (entry.Module != "example.org/c") || (entry.ReplacedBy != "example.org/fork v1.1.0") || (entry.SPDX != "MIT") {
+		t.Errorf("expected example.org/c with the MIT license of its replacement but got %+v", entry)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "LICENSE"), []byte(testMITLicense), 0644)
+	m = Module{Path: "example.org/d", Version: "v1.0.0", Replace: "../d", ReplaceDir: dir}
+	entry, err = scanModule(context.Background(), m, &options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (entry.ReplacedBy != "../d") || (entry.SPDX != "MIT") {
+		t.Errorf("expected the MIT license from ../d but got %+v", entry)
+	}
+}
+
+func TestParseModWhy(t *testing.T) {
+	stdout := []byte(`# example.org/a
+example.org/main
+example.org/a
+
+# example.org/b
+(main module does not need module example.org/b)
+`)
+
+	required := make(map[string]bool)
+	if err := parseModWhy(stdout, required); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]bool{"example.org/a": true, "example.org/b": false}
+	if !reflect.DeepEqual(required, expected) {
+		t.Errorf("expected %v but got %v", expected, required)
+	}
+
+	if err := parseModWhy([]byte("nonsense\nhere"), required); err == nil {
+		t.Errorf("expected an error for unexpected output")
+	}
+}
+
+func TestGetLicenseSubdir(t *testing.T) {
+	oldResolvers, oldFetcher, oldLimiter := resolvers, httpFetcher, rateLimiter
+	resolvers, rateLimiter = []Resolver{forgeResolver{}}, NoRateLimit
+	httpFetcher = replayFetcher{
+		"https://forge.test/mono/raw/LICENSE":     testMITLicense,
+		"https://forge.test/mono/raw/sub/LICENSE": "Sub license",
+	}
+	defer func() { resolvers, httpFetcher, rateLimiter = oldResolvers, oldFetcher, oldLimiter }()
+
+	gi := GoImport{ImportPrefix: "example.org/mono", Vcs: "git", RepoRoot: "https://forge.test/mono"}
+	tests := []struct {
+		module string
+		text   string
+		dir    string
+		file   string
+	}{
+		{"example.org/mono", testMITLicense, "", "LICENSE"},
+		{"example.org/mono/v2", testMITLicense, "", "LICENSE"},
+		{"example.org/mono/sub", "Sub license", "sub", "sub/LICENSE"},
+		{"example.org/mono/sub/v3", "Sub license", "sub", "sub/LICENSE"},
+		{"example.org/mono/other", testMITLicense, ".", "LICENSE"},
+	}
+	for _, test := range tests {
+		license, err := getLicense(context.Background(), test.module, gi, GoSource{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.module, err)
+			continue
+		}
+		if (license.Text != strings.TrimSpace(test.text)) || (license.Dir != test.dir) {
+			t.Errorf("%s: expected %q from %q but got %q from %q", test.module, test.text, test.dir, license.Text, license.Dir)
+		}
+		if (len(license.Parts) != 1) || (license.Parts[0].File != test.file) {
+			t.Errorf("%s: expected %s but got %+v", test.module, test.file, license.Parts)
+		}
+	}
+}
+
+func TestGetLicenseHTMLPage(t *testing.T) {
+	oldResolvers, oldFetcher, oldLimiter := resolvers, httpFetcher, rateLimiter
+	resolvers, rateLimiter = []Resolver{forgeResolver{}}, NoRateLimit
+	httpFetcher = replayFetcher{
+		"https://forge.test/example.org/a/raw/LICENSE": "<!DOCTYPE html><html><body>Please enable JavaScript</body></html>",
+		"https://forge.test/example.org/a/raw/COPYING": testMITLicense,
+	}
+	defer func() { resolvers, httpFetcher, rateLimiter = oldResolvers, oldFetcher, oldLimiter }()
+
+	gi := GoImport{ImportPrefix: "example.org/a", Vcs: "git", RepoRoot: "https://forge.test/example.org/a"}
+	license, err := getLicense(context.Background(), "example.org/a", gi, GoSource{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (len(license.Parts) != 1) || (license.Parts[0].File != "COPYING") {
+		t.Errorf("expected only COPYING but got %+v", license.Parts)
+	}
+}
@@ -0,0 +1,122 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// packageBasedTitle heads a report of the repositories of imported packages
+// rather than of modules (see listPackageModules)
+const packageBasedTitle = "GOPATH mode: no go.mod, so each module is guessed from the packages imported"
+
+// hasGoMod returns true if the current directory is in a module.
+func hasGoMod() bool {
+	_, err := os.Stat(findGoMod())
+	return err == nil
+}
+
+// listPackageModules returns the repositories of the packages imported by
+// the packages in the current directory, for a project without a go.mod
+// (GOPATH mode), where "go list -m" doesn't work. Each is guessed from the
+// import paths of its packages (see packageRoot), and has no version.
+func listPackageModules(ctx context.Context) ([]Module, error) {
+	own, err := goPathList(ctx, "-f", "{{.ImportPath}}", "./...")
+	if err != nil {
+		return nil, err
+	}
+	deps, err := goPathList(ctx, "-deps", "-f", "{{if not .Standard}}{{.ImportPath}}{{end}}", "./...")
+	if err != nil {
+		return nil, err
+	}
+	return parsePackageModules(deps, own), nil
+}
+
+// goPathList runs "go list" in GOPATH mode.
+func goPathList(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list"}, args...)...)
+	cmd.Env = append(os.Environ(), "GO111MODULE=off")
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+	return stdout, nil
+}
+
+// parsePackageModules returns the repository root of each imported package
+// in deps, one per line, apart from the project's own packages in own. Each
+// root is given once, in order, and not if another root contains it.
+func parsePackageModules(deps []byte, own []byte) []Module {
+	ownRoots := make(map[string]bool)
+	for _, pkg := range lines(own) {
+		ownRoots[packageRoot(pkg)] = true
+	}
+
+	seen := make(map[string]bool)
+	var roots []string
+	for _, pkg := range lines(deps) {
+		root := packageRoot(pkg)
+		if ownRoots[root] || seen[root] || strings.HasPrefix(root, "_/") {
+			continue
+		}
+		seen[root] = true
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	var modules []Module
+	for i, root := range roots {
+		if (i > 0) && strings.HasPrefix(root, modules[len(modules)-1].Path+"/") {
+			continue // e.g. a subpackage of a vanity import path
+		}
+		modules = append(modules, Module{Path: root})
+	}
+	return modules
+}
+
+// lines returns the non-empty lines of command output.
+func lines(stdout []byte) []string {
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// packageRoot guesses the root of the repository of a package from its
+// import path: on the hosts whose layout is known, such as
+// "github.com/user/repo", its first few path elements, or otherwise the whole
+// path, which a go-import lookup resolves to its repository in any case. A
+// vendored package is the package it is a copy of.
+func packageRoot(pkg string) string {
+	if idx := strings.LastIndex(pkg, "/vendor/"); idx >= 0 {
+		pkg = pkg[idx+len("/vendor/"):]
+	}
+	elems := strings.Split(pkg, "/")
+
+	n := len(elems)
+	switch elems[0] {
+	case "github.com", "gitlab.com", "bitbucket.org", "git.sr.ht", "golang.org":
+		n = 3 // e.g. golang.org/x/text
+	case "google.golang.org", "go.uber.org", "go.opencensus.io":
+		n = 2 // e.g. google.golang.org/grpc
+	case "gopkg.in":
+		// gopkg.in/pkg.v1 or gopkg.in/user/pkg.v1
+		n = 2
+		if (len(elems) > 2) && !strings.Contains(elems[1], ".v") {
+			n = 3
+		}
+	}
+	if n > len(elems) {
+		n = len(elems)
+	}
+	return strings.Join(elems[:n], "/")
+}
@@ -0,0 +1,45 @@
+package licenses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageRoot(t *testing.T) {
+	tests := map[string]string{
+		"github.com/user/repo":                    "github.com/user/repo",
+		"github.com/user/repo/sub/pkg":            "github.com/user/repo",
+		"golang.org/x/text/unicode/norm":          "golang.org/x/text",
+		"google.golang.org/grpc/codes":            "google.golang.org/grpc",
+		"gopkg.in/yaml.v2":                        "gopkg.in/yaml.v2",
+		"gopkg.in/user/pkg.v1/sub":                "gopkg.in/user/pkg.v1",
+		"example.org/vanity/pkg":                  "example.org/vanity/pkg",
+		"example.org/app/vendor/github.com/a/b/c": "github.com/a/b",
+		"github.com/user":                         "github.com/user",
+	}
+	for input, expected := range tests {
+		if root := packageRoot(input); root != expected {
+			t.Errorf("%s: expected %q but got %q", input, expected, root)
+		}
+	}
+}
+
+func TestParsePackageModules(t *testing.T) {
+	own := []byte("github.com/me/app\ngithub.com/me/app/cmd/app\n")
+	deps := []byte(`github.com/me/app/internal
+github.com/a/b
+github.com/a/b/sub
+github.com/me/app
+example.org/vanity
+example.org/vanity/sub
+golang.org/x/text/unicode/norm
+golang.org/x/text/transform
+_/home/me/scratch
+`)
+
+	modules := parsePackageModules(deps, own)
+	expected := []Module{{Path: "example.org/vanity"}, {Path: "github.com/a/b"}, {Path: "golang.org/x/text"}}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("expected %+v but got %+v", expected, modules)
+	}
+}
@@ -0,0 +1,215 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gopkgIn is a repository served by gopkg.in: a major version of a GitHub
+// repository. gopkg.in serves it from the repository's highest tag or branch
+// of that major version, e.g. "v2.1" for ".v2" if it has the tags "v2",
+// "v2.0.3" and "v2.1".
+type gopkgIn struct {
+	Owner    string
+	Repo     string
+	Major    int
+	Unstable bool // only tags and branches with a "-unstable" suffix, e.g. "v1.2-unstable"
+}
+
+// gopkgInRefs caches the ref that gopkg.in serves each repository from
+var gopkgInRefs = struct {
+	sync.Mutex
+	refs map[gopkgIn]string
+}{refs: make(map[gopkgIn]string)}
+
+// parseGopkgIn parses the repository root of a gopkg.in module, such as
+// "https://gopkg.in/yaml.v3" (github.com/go-yaml/yaml, at v3) or
+// "https://gopkg.in/user/pkg.v1-unstable" (github.com/user/pkg, at an
+// unstable v1).
+func parseGopkgIn(repoRoot string) (gopkgIn, bool) {
+	if !strings.HasPrefix(repoRoot, "https://gopkg.in/") {
+		return gopkgIn{}, false
+	}
+	elems := strings.Split(strings.Trim(strings.TrimPrefix(repoRoot, "https://gopkg.in/"), "/"), "/")
+	if (len(elems) > 2) || (elems[0] == "") {
+		return gopkgIn{}, false
+	}
+
+	var g gopkgIn
+	name := elems[len(elems)-1]
+	if len(elems) == 2 {
+		g.Owner = elems[0]
+	}
+	if strings.HasSuffix(name, "-unstable") {
+		g.Unstable = true
+		name = strings.TrimSuffix(name, "-unstable")
+	}
+
+	idx := strings.LastIndex(name, ".v")
+	if idx <= 0 {
+		return gopkgIn{}, false
+	}
+	major, ok := parseVersionNumber(name[idx+2:])
+	if !ok {
+		return gopkgIn{}, false
+	}
+	g.Repo, g.Major = name[:idx], major
+	if g.Owner == "" {
+		g.Owner = "go-" + g.Repo
+	}
+	return g, true
+}
+
+// parseVersionNumber parses one number of a version, which must be only
+// digits.
+func parseVersionNumber(s string) (int, bool) {
+	if (s == "") || (strings.Trim(s, "0123456789") != "") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// version parses the name of a tag or branch as a version of the
+// repository's major version, e.g. "v2", "v2.1" or "v2.1.3", with missing
+// numbers as zero.
+func (g gopkgIn) version(name string) (version [3]int, ok bool) {
+	if g.Unstable {
+		if !strings.HasSuffix(name, "-unstable") {
+			return version, false
+		}
+		name = strings.TrimSuffix(name, "-unstable")
+	}
+	if !strings.HasPrefix(name, "v") {
+		return version, false
+	}
+
+	parts := strings.Split(name[1:], ".")
+	if len(parts) > len(version) {
+		return version, false
+	}
+	for i, part := range parts {
+		if version[i], ok = parseVersionNumber(part); !ok {
+			return version, false
+		}
+	}
+	return version, version[0] == g.Major
+}
+
+// gopkgInRef returns the tag or branch that gopkg.in serves a repository
+// from, as it chooses it, from the refs of its GitHub repository. This is
+// one request to GitHub, which needs no credentials and doesn't count
+// against the GitHub API's rate limit.
+//
+// When only planning, no request is made, and the ref is assumed to be the
+// major version itself (e.g. "v2").
+func gopkgInRef(ctx context.Context, g gopkgIn) (string, error) {
+	gopkgInRefs.Lock()
+	ref, ok := gopkgInRefs.refs[g]
+	gopkgInRefs.Unlock()
+	if ok {
+		return ref, nil
+	}
+
+	if planOnly {
+		ref = fmt.Sprintf("v%d", g.Major)
+		if g.Unstable {
+			ref += "-unstable"
+		}
+		return ref, nil
+	}
+
+	data, err := httpGet(ctx, fmt.Sprintf("https://github.com/%s/%s.git/info/refs?service=git-upload-pack", g.Owner, g.Repo), nil)
+	if err != nil {
+		return "", err
+	}
+	ref, ok = selectGopkgInRef(parseGitRefs(data), g)
+	if !ok {
+		return "", fmt.Errorf("github.com/%s/%s has no tag or branch for v%d", g.Owner, g.Repo, g.Major)
+	}
+
+	gopkgInRefs.Lock()
+	gopkgInRefs.refs[g] = ref
+	gopkgInRefs.Unlock()
+	return ref, nil
+}
+
+// selectGopkgInRef returns the name of the highest tag or branch that is a
+// version of the repository's major version. Where a tag and a branch are
+// the same version, the branch is used.
+func selectGopkgInRef(refs []string, g gopkgIn) (string, bool) {
+	var best string
+	var bestVersion [3]int
+	var bestBranch bool
+
+	for _, ref := range refs {
+		var name string
+		branch := strings.HasPrefix(ref, "refs/heads/")
+		if branch {
+			name = strings.TrimPrefix(ref, "refs/heads/")
+		} else if strings.HasPrefix(ref, "refs/tags/") {
+			name = strings.TrimPrefix(ref, "refs/tags/")
+		} else {
+			continue
+		}
+
+		version, ok := g.version(name)
+		if !ok {
+			continue
+		}
+		cmp := compareVersions(version, bestVersion)
+		if (best == "") || (cmp > 0) || ((cmp == 0) && branch && !bestBranch) {
+			best, bestVersion, bestBranch = name, version, branch
+		}
+	}
+	return best, best != ""
+}
+
+// compareVersions returns -1, 0 or 1 as a is lower than, the same as, or
+// higher than b.
+func compareVersions(a [3]int, b [3]int) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseGitRefs parses the refs advertised by a git server over the smart
+// HTTP protocol (the response to info/refs?service=git-upload-pack), a
+// series of pkt-lines such as "003f<sha> refs/heads/master\n".
+func parseGitRefs(data string) []string {
+	var refs []string
+	for len(data) >= 4 {
+		n, err := strconv.ParseUint(data[:4], 16, 16)
+		if err != nil {
+			break
+		}
+		if n == 0 {
+			data = data[4:] // a flush-pkt
+			continue
+		}
+		if (n < 4) || (int(n) > len(data)) {
+			break
+		}
+		line := data[4:n]
+		data = data[n:]
+
+		// the first ref is followed by the server's capabilities
+		if idx := strings.IndexByte(line, 0); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if (len(fields) == 2) && strings.HasPrefix(fields[1], "refs/") {
+			refs = append(refs, fields[1])
+		}
+	}
+	return refs
+}
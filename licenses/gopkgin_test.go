@@ -0,0 +1,119 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testGitRefs returns a git smart HTTP ref advertisement of refs.
+func testGitRefs(refs ...string) string {
+	pkt := func(line string) string {
+		return fmt.Sprintf("%04x%s", len(line)+4, line)
+	}
+
+	var b strings.Builder
+	b.WriteString(pkt("# service=git-upload-pack\n"))
+	b.WriteString("0000")
+	for i, ref := range refs {
+		line := strings.Repeat("a", 40) + " " + ref
+		if i == 0 {
+			line += "\x00multi_ack side-band-64k"
+		}
+		b.WriteString(pkt(line + "\n"))
+	}
+	b.WriteString("0000")
+	return b.String()
+}
+
+func TestParseGopkgIn(t *testing.T) {
+	tests := map[string]gopkgIn{
+		"https://gopkg.in/yaml.v3":                 {Owner: "go-yaml", Repo: "yaml", Major: 3},
+		"https://gopkg.in/natefinch/lumberjack.v2": {Owner: "natefinch", Repo: "lumberjack", Major: 2},
+		"https://gopkg.in/user/pkg.v1-unstable":    {Owner: "user", Repo: "pkg", Major: 1, Unstable: true},
+		"https://gopkg.in/go.v10/":                 {Owner: "go-go", Repo: "go", Major: 10},
+		"https://gopkg.in/yaml":                    {},
+		"https://gopkg.in/yaml.vx":                 {},
+		"https://gopkg.in/a/b/c.v1":                {},
+		"https://github.com/go-yaml/yaml":          {},
+	}
+	for root, expected := range tests {
+		g, ok := parseGopkgIn(root)
+		if (g != expected) || (ok != (expected != gopkgIn{})) {
+			t.Errorf("%s: expected %+v but got %+v (%t)", root, expected, g, ok)
+		}
+	}
+}
+
+func TestSelectGopkgInRef(t *testing.T) {
+	refs := []string{
+		"HEAD",
+		"refs/heads/master",
+		"refs/heads/v2",
+		"refs/heads/v2.1",
+		"refs/heads/v20",
+		"refs/heads/v3-unstable",
+		"refs/tags/v2.0.3",
+		"refs/tags/v2.1",
+		"refs/tags/v2.1^{}",
+		"refs/tags/v2.1.0-rc1",
+		"refs/tags/v3.0.1",
+		"refs/tags/v3.2-unstable",
+	}
+	tests := []struct {
+		g        gopkgIn
+		expected string
+	}{
+		{gopkgIn{Major: 2}, "v2.1"},
+		{gopkgIn{Major: 3}, "v3.0.1"},
+		{gopkgIn{Major: 3, Unstable: true}, "v3.2-unstable"},
+		{gopkgIn{Major: 20}, "v20"},
+		{gopkgIn{Major: 4}, ""},
+	}
+	for _, tt := range tests {
+		ref, ok := selectGopkgInRef(refs, tt.g)
+		if (ref != tt.expected) || (ok != (tt.expected != "")) {
+			t.Errorf("%+v: expected %q but got %q (%t)", tt.g, tt.expected, ref, ok)
+		}
+	}
+}
+
+func TestParseGitRefs(t *testing.T) {
+	refs := parseGitRefs(testGitRefs("HEAD", "refs/heads/main", "refs/tags/v1.0.0"))
+	if (len(refs) != 2) || (refs[0] != "refs/heads/main") || (refs[1] != "refs/tags/v1.0.0") {
+		t.Errorf("expected the branch and tag but got %v", refs)
+	}
+
+	if refs := parseGitRefs("<html>Not Found</html>"); len(refs) != 0 {
+		t.Errorf("expected no refs from an HTML page but got %v", refs)
+	}
+}
+
+func TestResolveGopkgInFileURL(t *testing.T) {
+	oldFetcher, oldLimiter, oldRefs := httpFetcher, rateLimiter, gopkgInRefs.refs
+	defer func() { httpFetcher, rateLimiter, gopkgInRefs.refs = oldFetcher, oldLimiter, oldRefs }()
+	rateLimiter = NoRateLimit
+	gopkgInRefs.refs = make(map[gopkgIn]string)
+
+	// without a go-source meta tag
+	httpFetcher = replayFetcher{
+		"https://github.com/natefinch/lumberjack.git/info/refs?service=git-upload-pack": testGitRefs(
+			"refs/heads/master", "refs/heads/v2.0", "refs/tags/v2.1.0", "refs/tags/v2.0.0"),
+	}
+	gi := GoImport{Vcs: "git", RepoRoot: "https://gopkg.in/natefinch/lumberjack.v2"}
+
+	urls, _, err := resolveFileURL(context.Background(), gi, GoSource{}, "LICENSE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "https://raw.githubusercontent.com/natefinch/lumberjack/v2.1.0/LICENSE"
+	if (len(urls) != 1) || (urls[0].URL != expected) || (urls[0].Ref != "v2.1.0") {
+		t.Errorf("expected %q but got %v", expected, urls)
+	}
+
+	gi.RepoRoot = "https://gopkg.in/natefinch/lumberjack.v3"
+	if _, _, err := resolveFileURL(context.Background(), gi, GoSource{}, "LICENSE"); err == nil {
+		t.Errorf("expected an error for a major version without a tag or branch")
+	}
+}
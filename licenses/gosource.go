@@ -0,0 +1,124 @@
+package licenses
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goSourceHeader begins a generated Go source report
+const goSourceHeader = "// Code generated by gocomply; DO NOT EDIT.\n"
+
+// goSourceReportWriter writes the report as a generated Go source file, so
+// that an application can embed its third-party notices, such as to show
+// them in an about dialog, without a separate asset pipeline. Entries are
+// buffered and the file is written, formatted, on Close.
+type goSourceReportWriter struct {
+	w       io.Writer
+	pkg     string
+	entries []Entry
+}
+
+func (r *goSourceReportWriter) WriteEntry(e Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *goSourceReportWriter) Close() error {
+	var notices bytes.Buffer
+	text := newTextReportWriter(&notices, reportOptions{})
+	for _, e := range r.entries {
+		if err := text.WriteEntry(e); err != nil {
+			return err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(goSourceHeader + "\n")
+	fmt.Fprintf(&b, "package %s\n\n", r.pkg)
+	b.WriteString(`// ThirdPartyLicense is the license of a third-party module.
+type ThirdPartyLicense struct {
+	Module  string
+	Version string
+	PURL    string // the package URL, e.g. pkg:golang/example.org/a@v1.0.0
+	SPDX    string // the SPDX license expression, if identified
+	Source  string // where the license was obtained from
+	License string // the license text
+}
+
+// ThirdPartyLicenses lists the license of each module used, in order.
+var ThirdPartyLicenses = []ThirdPartyLicense{
+`)
+	for _, e := range r.entries {
+		b.WriteString("{\n")
+		field := func(name string, value string) {
+			if value != "" {
+				fmt.Fprintf(&b, "%s: %s,\n", name, goStringLiteral(value))
+			}
+		}
+		field("Module", e.Module)
+		field("Version", e.Version)
+		field("PURL", e.purl())
+		field("SPDX", e.SPDX)
+		field("Source", e.SourceURL)
+		field("License", e.License)
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// ThirdPartyNotices is every license as a single text, for display.\n")
+	fmt.Fprintf(&b, "const ThirdPartyNotices = %s\n", goStringLiteral(notices.String()))
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		// should never happen, with quoted strings and a valid package name
+		return fmt.Errorf("error formatting Go source: %v", err)
+	}
+	if _, err := r.w.Write(src); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// goStringLiteral returns a Go string literal for s: for text of several
+// lines, a raw string, which is more readable, where possible.
+func goStringLiteral(s string) string {
+	if !strings.Contains(s, "\n") || !strconv.CanBackquote(strings.ReplaceAll(s, "\n", "")) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// goPackageName returns the package of a generated Go source file: the
+// package given, or else the package running go:generate ($GOPACKAGE), or
+// else one named after the output file's directory.
+func goPackageName(pkg string, output string) (string, error) {
+	if pkg == "" {
+		pkg = os.Getenv("GOPACKAGE")
+	}
+	if pkg == "" {
+		dir, err := filepath.Abs(filepath.Dir(output))
+		if err != nil {
+			return "", err
+		}
+		pkg = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || (r == '_') {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, filepath.Base(dir))
+		if (pkg == "") || unicode.IsDigit(rune(pkg[0])) || token.IsKeyword(pkg) {
+			pkg = "licenses"
+		}
+	}
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid Go package name %q", pkg)
+	}
+	return pkg, nil
+}
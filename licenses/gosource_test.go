@@ -0,0 +1,62 @@
+package licenses
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoSourceReportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	report, err := newReportWriter(&buf, reportOptions{Format: "gosource", Package: "about"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report.WriteEntry(Entry{Module: "example.org/a", Version: "v1.0.0", SPDX: "MIT", License: "License A\n"})
+	report.WriteEntry(Entry{Module: "example.org/b", License: "Use `b` freely\r\n"})
+	if err := report.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	src := buf.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "licenses_gen.go", src, 0); err != nil {
+		t.Fatalf("expected valid Go source but got %v:\n%s", err, src)
+	}
+	for _, expected := range []string{
+		"// Code generated by gocomply; DO NOT EDIT.\n\npackage about\n",
+		"\t\tLicense: `License A\n`,\n",
+		"\t\tLicense: \"Use `b` freely\\r\\n\",\n",
+		"\t\tModule:  \"example.org/a\",\n",
+		"const ThirdPartyNotices = \"example.org/a\\n\\nLicense A\\n",
+	} {
+		if !strings.Contains(src, expected) {
+			t.Errorf("expected %q in:\n%s", expected, src)
+		}
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		pkg      string
+		output   string
+		expected string
+	}{
+		{"about", "licenses_gen.go", "about"},
+		{"", filepath.Join(dir, "My-App", "licenses_gen.go"), "myapp"},
+		{"", filepath.Join(dir, "2d", "licenses_gen.go"), "licenses"},
+		{"", filepath.Join(dir, "func", "licenses_gen.go"), "licenses"},
+	}
+	for _, tt := range tests {
+		if pkg, err := goPackageName(tt.pkg, tt.output); (err != nil) || (pkg != tt.expected) {
+			t.Errorf("%q, %q: expected %q but got %q, %v", tt.pkg, tt.output, tt.expected, pkg, err)
+		}
+	}
+
+	if _, err := goPackageName("my-app", ""); err == nil {
+		t.Errorf("expected an invalid package name to fail")
+	}
+}
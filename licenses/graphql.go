@@ -0,0 +1,292 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// githubGraphQLBatch is the number of repositories in each GraphQL query
+const githubGraphQLBatch = 50
+
+// githubPrefetch is a repository's license fetched ahead of time with the
+// GitHub GraphQL API. If the repository has no license files, missing is
+// true.
+type githubPrefetch struct {
+	license licenseFile
+	missing bool
+}
+
+// githubPrefetched maps each prefetched repository ("owner/repo", in lower
+// case) to its license. See prefetchGitHubLicenses.
+var githubPrefetched = struct {
+	sync.Mutex
+	repos map[string]githubPrefetch
+}{repos: make(map[string]githubPrefetch)}
+
+// githubPrefetchedLicense returns a repository's prefetched license, if any.
+func githubPrefetchedLicense(dir string) (githubPrefetch, bool) {
+	githubPrefetched.Lock()
+	defer githubPrefetched.Unlock()
+	p, ok := githubPrefetched.repos[strings.ToLower(dir)]
+	return p, ok
+}
+
+// githubRepos returns the GitHub repositories ("owner/repo") of the modules
+// that can be determined without a remote request, without duplicates.
+func githubRepos(modules []Module, o *options) []string {
+	var repos []string
+	seen := make(map[string]bool)
+	for _, m := range modules {
+		if ov, ok := o.overrides[m.Path]; ok && (ov.When == overrideAlways) {
+			continue
+		}
+
+		root := ""
+		if strings.HasPrefix(m.Path, "github.com/") {
+			root = "https://" + m.Path
+		} else if gi, _, ok := lookupKnownModule(m.Path); ok && !o.NoKnown {
+			root = gi.RepoRoot
+		}
+		if !strings.HasPrefix(root, "https://github.com/") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(root, "https://github.com/"), "/", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		dir := strings.TrimSuffix(parts[0]+"/"+parts[1], ".git")
+		if key := strings.ToLower(dir); !seen[key] {
+			seen[key] = true
+			repos = append(repos, dir)
+		}
+	}
+	return repos
+}
+
+// prefetchGitHubLicenses uses the GitHub GraphQL API to fetch the licenses of
+// many repositories ("owner/repo") in a handful of queries, rather than
+// several REST requests each. getGitHubLicense then uses the prefetched
+// license, if any.
+//
+// Repositories that are REUSE compliant, or that GraphQL can't answer for,
+// are left to the REST API. Failures are only warnings for the same reason.
+func prefetchGitHubLicenses(ctx context.Context, repos []string) {
+	for start := 0; start < len(repos); start += githubGraphQLBatch {
+		end := start + githubGraphQLBatch
+		if end > len(repos) {
+			end = len(repos)
+		}
+		if err := prefetchGitHubBatch(ctx, repos[start:end]); err != nil {
+			logf(levelWarning, phaseLicense, "", err, "warning: GitHub GraphQL query failed: %v", err)
+		}
+	}
+}
+
+// graphqlTreeEntry is a file or directory in a GraphQL tree
+type graphqlTreeEntry struct {
+	Name string
+	Type string // "blob" for a file, "tree" for a directory
+	Oid  string
+}
+
+// graphqlRepoListing is one repository from the listing query
+type graphqlRepoListing struct {
+	LicenseInfo *struct {
+		SpdxID string `json:"spdxId"`
+	}
+	Object *struct {
+		Entries []graphqlTreeEntry
+	}
+}
+
+// graphqlBlob is one file from the blob query
+type graphqlBlob struct {
+	Text     *string
+	Oid      string
+	IsBinary bool
+}
+
+func prefetchGitHubBatch(ctx context.Context, repos []string) error {
+	// first, list the top level of each repository
+	vars := make(map[string]interface{})
+	var params, fields []string
+	for i, dir := range repos {
+		parts := strings.SplitN(dir, "/", 2)
+		vars[fmt.Sprintf("o%d", i)], vars[fmt.Sprintf("n%d", i)] = parts[0], parts[1]
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		fields = append(fields, fmt.Sprintf(`r%d: repository(owner: $o%d, name: $n%d) {
+    licenseInfo { spdxId }
+    object(expression: "HEAD:") { ... on Tree { entries { name type oid } } }
+  }`, i, i, i))
+	}
+
+	var listings map[string]*graphqlRepoListing
+	if err := githubGraphQL(ctx, graphqlQuery(params, fields), vars, &listings); err != nil {
+		return err
+	}
+
+	// then fetch every license file, in order of precedence
+	files := make(map[int][]string)
+	vars = make(map[string]interface{})
+	params, fields = nil, nil
+	for i, dir := range repos {
+		listing := listings[fmt.Sprintf("r%d", i)]
+		if (listing == nil) || (listing.Object == nil) {
+			continue
+		}
+
+		var names []string
+		reuse := false
+		for _, e := range listing.Object.Entries {
+			if (e.Type == "tree") && ((e.Name == reuseLicensesDir) || (e.Name == reuseDir)) {
+				reuse = true
+			} else if _, ok := licenseFileRank(e.Name, repoLicenseFiles); ok && (e.Type == "blob") {
+				names = append(names, e.Name)
+			}
+		}
+		if reuse {
+			continue
+		}
+		if len(names) == 0 {
+			githubPrefetched.Lock()
+			githubPrefetched.repos[strings.ToLower(dir)] = githubPrefetch{missing: true}
+			githubPrefetched.Unlock()
+			continue
+		}
+
+		sort.SliceStable(names, func(a, b int) bool {
+			x, _ := licenseFileRank(names[a], repoLicenseFiles)
+			y, _ := licenseFileRank(names[b], repoLicenseFiles)
+			return x < y
+		})
+		files[i] = names
+
+		parts := strings.SplitN(dir, "/", 2)
+		vars[fmt.Sprintf("o%d", i)], vars[fmt.Sprintf("n%d", i)] = parts[0], parts[1]
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		var objects []string
+		for j, name := range names {
+			vars[fmt.Sprintf("e%d_%d", i, j)] = "HEAD:" + name
+			params = append(params, fmt.Sprintf("$e%d_%d: String!", i, j))
+			objects = append(objects, fmt.Sprintf("f%d: object(expression: $e%d_%d) { ... on Blob { text oid isBinary } }", j, i, j))
+		}
+		fields = append(fields, fmt.Sprintf("r%d: repository(owner: $o%d, name: $n%d) {\n    %s\n  }",
+			i, i, i, strings.Join(objects, "\n    ")))
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var blobs map[string]map[string]*graphqlBlob
+	if err := githubGraphQL(ctx, graphqlQuery(params, fields), vars, &blobs); err != nil {
+		return err
+	}
+
+	for i, names := range files {
+		dir := repos[i]
+		repo := blobs[fmt.Sprintf("r%d", i)]
+		if repo == nil {
+			continue
+		}
+
+		var parts []licensePart
+		complete := true
+		for j, name := range names {
+			blob := repo[fmt.Sprintf("f%d", j)]
+			if (blob == nil) || (blob.Text == nil) || blob.IsBinary {
+				complete = false
+				break
+			}
+			text, ok := licenseText(name, *blob.Text)
+			if !ok {
+				complete = false
+				break
+			}
+			parts = append(parts, licensePart{
+				File:      name,
+				SourceURL: fmt.Sprintf("%s/repos/%s/git/blobs/%s", githubAPI, dir, blob.Oid),
+				Revision:  blob.Oid,
+				Text:      text,
+			})
+		}
+		if !complete {
+			continue
+		}
+
+		license := combineLicenseParts(parts)
+		if info := listings[fmt.Sprintf("r%d", i)].LicenseInfo; (info != nil) && (info.SpdxID != "") && (info.SpdxID != spdxNoAssertion) {
+			if classifyLicense(license.Text).SPDX == "" {
+				license.SPDX = info.SpdxID
+			}
+		}
+		license.Ref = "HEAD"
+		license.Retrieved = retrievalTime()
+
+		githubPrefetched.Lock()
+		githubPrefetched.repos[strings.ToLower(dir)] = githubPrefetch{license: license}
+		githubPrefetched.Unlock()
+	}
+
+	return nil
+}
+
+// graphqlQuery returns a GraphQL query with the given variable definitions
+// and fields.
+func graphqlQuery(params []string, fields []string) string {
+	return fmt.Sprintf("query(%s) {\n  %s\n}", strings.Join(params, ", "), strings.Join(fields, "\n  "))
+}
+
+// githubGraphQL runs a query with the GitHub GraphQL API and decodes its
+// data into v. Errors for individual fields, such as a repository that
+// doesn't exist, leave them null rather than failing the query.
+func githubGraphQL(ctx context.Context, query string, vars map[string]interface{}, v interface{}) error {
+	body, err := json.Marshal(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}{query, vars})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", githubAPI+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if githubAuth.IsSet() {
+		// GraphQL requires a token, rather than basic authentication
+		req.Header.Set("Authorization", "bearer "+githubAuth.Token)
+	}
+
+	data, err := httpDo(req, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage
+		Errors []struct {
+			Message string
+		}
+	}
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return fmt.Errorf("json decode error: %v", err)
+	}
+	if (len(response.Data) == 0) || (string(response.Data) == "null") {
+		if len(response.Errors) > 0 {
+			return fmt.Errorf("graphql error: %s", response.Errors[0].Message)
+		}
+		return fmt.Errorf("graphql response has no data")
+	}
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		return fmt.Errorf("json decode error: %v", err)
+	}
+	return nil
+}
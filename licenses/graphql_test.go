@@ -0,0 +1,96 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubRepos(t *testing.T) {
+	modules := []Module{
+		{Path: "github.com/Example/a/v2"},
+		{Path: "github.com/example/A"}, // same repository
+		{Path: "golang.org/x/text"},
+		{Path: "example.org/b"},
+	}
+	expected := []string{"Example/a", "golang/text"}
+
+	repos := githubRepos(modules, &options{})
+	if strings.Join(repos, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %q but got %q", expected, repos)
+	}
+}
+
+func TestPrefetchGitHubLicenses(t *testing.T) {
+	text := "MIT License\n\n" + testMITLicenseBody
+
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string
+			Variables map[string]string
+		}
+		if (r.URL.Path != "/graphql") || (json.NewDecoder(r.Body).Decode(&request) != nil) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		queries++
+
+		if strings.Contains(request.Query, "entries") {
+			fmt.Fprint(w, `{"data": {
+				"r0": {"licenseInfo": {"spdxId": "MIT"}, "object": {"entries": [
+					{"name": "LICENSE", "type": "blob", "oid": "abc123"},
+					{"name": "main.go", "type": "blob", "oid": "def456"}]}},
+				"r1": {"licenseInfo": null, "object": {"entries": [
+					{"name": "main.go", "type": "blob", "oid": "def456"}]}},
+				"r2": {"licenseInfo": null, "object": {"entries": [
+					{"name": "LICENSES", "type": "tree", "oid": "fed789"}]}},
+				"r3": null}}`)
+			return
+		}
+
+		if request.Variables["e0_0"] != "HEAD:LICENSE" {
+			http.Error(w, "unexpected variables", http.StatusBadRequest)
+			return
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"data": map[string]interface{}{
+				"r0": map[string]interface{}{
+					"f0": map[string]interface{}{"text": text, "oid": "abc123", "isBinary": false},
+				},
+			},
+		})
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	oldAPI, oldLimiter := githubAPI, rateLimiter
+	githubAPI, rateLimiter = server.URL, NoRateLimit
+	defer func() {
+		githubAPI, rateLimiter = oldAPI, oldLimiter
+		githubPrefetched.Lock()
+		githubPrefetched.repos = make(map[string]githubPrefetch)
+		githubPrefetched.Unlock()
+	}()
+
+	prefetchGitHubLicenses(context.Background(), []string{"example/mit", "example/none", "example/reuse", "example/gone"})
+	if queries != 2 {
+		t.Errorf("expected 2 queries but got %d", queries)
+	}
+
+	if p, ok := githubPrefetchedLicense("Example/MIT"); !ok || (p.license.Text != text) || (p.license.Revision != "abc123") {
+		t.Errorf("expected the MIT license to be prefetched but got (%+v, %t)", p, ok)
+	}
+	if p, ok := githubPrefetchedLicense("example/none"); !ok || !p.missing {
+		t.Errorf("expected a missing license but got (%+v, %t)", p, ok)
+	}
+	for _, dir := range []string{"example/reuse", "example/gone"} {
+		if _, ok := githubPrefetchedLicense(dir); ok {
+			t.Errorf("%s: expected no prefetched license", dir)
+		}
+	}
+}
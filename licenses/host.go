@@ -0,0 +1,83 @@
+package licenses
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// providerHosts are the hosts of the providers known to resolveFileURL and
+// the GitHub API. A "www." prefix is dropped from these, as the providers
+// redirect it to the bare host anyway.
+var providerHosts = []string{
+	"github.com",
+	"gitlab.com",
+	"git.sr.ht",
+	"gopkg.in",
+	"go.googlesource.com",
+}
+
+// normalizeURL normalises the scheme and host of a URL from a go-import or
+// go-source meta tag so that provider URLs, like
+// "HTTPS://www.GitHub.com/foo/bar", match their usual form
+// ("https://github.com/foo/bar"). The scheme and host are lower cased, an
+// internationalised host is converted to punycode and "www." is dropped from
+// providerHosts. The rest of the URL, which may contain go-source templates
+// like "{/dir}", is unchanged.
+func normalizeURL(rawURL string) string {
+	idx := strings.Index(rawURL, "://")
+	if idx < 0 {
+		return rawURL
+	}
+	scheme := strings.ToLower(rawURL[:idx])
+	rest := rawURL[idx+len("://"):]
+
+	authority, path := rest, ""
+	if end := strings.IndexAny(rest, "/?#"); end >= 0 {
+		authority, path = rest[:end], rest[end:]
+	}
+
+	userinfo := ""
+	if at := strings.LastIndexByte(authority, '@'); at >= 0 {
+		userinfo, authority = authority[:at+1], authority[at+1:]
+	}
+
+	host, port := authority, ""
+	if colon := strings.LastIndexByte(authority, ':'); (colon >= 0) && !strings.Contains(authority[colon:], "]") {
+		host, port = authority[:colon], authority[colon:]
+	}
+
+	return scheme + "://" + userinfo + normalizeHost(host) + port + path
+}
+
+// normalizeHost lower cases a host, converts it to punycode and drops "www."
+// from providerHosts.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+
+	if trimmed := strings.TrimPrefix(host, "www."); trimmed != host {
+		for _, provider := range providerHosts {
+			if trimmed == provider {
+				return trimmed
+			}
+		}
+	}
+	return host
+}
+
+// normalize normalises the URLs of a go-import meta tag. See normalizeURL.
+func (gi GoImport) normalize() GoImport {
+	gi.RepoRoot = normalizeURL(gi.RepoRoot)
+	return gi
+}
+
+// normalize normalises the URLs of a go-source meta tag. See normalizeURL.
+func (gs GoSource) normalize() GoSource {
+	gs.Home = normalizeURL(gs.Home)
+	gs.Directory = normalizeURL(gs.Directory)
+	gs.File = normalizeURL(gs.File)
+	return gs
+}
@@ -0,0 +1,26 @@
+package licenses
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://github.com/foo/bar", "https://github.com/foo/bar"},
+		{"HTTPS://GitHub.com/Foo/Bar", "https://github.com/Foo/Bar"},
+		{"https://www.github.com/foo/bar", "https://github.com/foo/bar"},
+		{"https://www.example.org/foo", "https://www.example.org/foo"},
+		{"https://GitHub.com./foo", "https://github.com/foo"},
+		{"https://bücher.example:8443/repo.git", "https://xn--bcher-kva.example:8443/repo.git"},
+		{"https://user@GitLab.com/foo", "https://user@gitlab.com/foo"},
+		{"https://www.GitHub.com/foo/bar/tree/v2.1{/dir}", "https://github.com/foo/bar/tree/v2.1{/dir}"},
+		{"not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeURL(tt.input); got != tt.expected {
+			t.Errorf("%q: expected %q but got %q", tt.input, tt.expected, got)
+		}
+	}
+}
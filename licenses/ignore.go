@@ -0,0 +1,59 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultIgnoreFile is read, if it exists, when no --ignore-file is given
+const defaultIgnoreFile = ".gocomplyignore"
+
+// ignoreComment separates a pattern from its reason in an ignore file
+var ignoreComment = regexp.MustCompile(`\s+#`)
+
+// loadIgnoreFile reads an ignore file: like a .gitignore, but of modules to
+// skip, one pattern per line (as for --exclude), each optionally followed by
+// a "# reason" comment that is listed in the report's appendix. Blank lines
+// and lines starting with "#" are ignored. If path is empty,
+// defaultIgnoreFile is read if it exists, and otherwise there are no
+// patterns.
+func loadIgnoreFile(path string) (patternFlags, error) {
+	optional := false
+	if path == "" {
+		path, optional = defaultIgnoreFile, true
+	}
+
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading ignore file: %v", err)
+	}
+
+	var patterns patternFlags
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if (line == "") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		reason := ""
+		if loc := ignoreComment.FindStringIndex(line); loc != nil {
+			line, reason = line[:loc[0]], strings.TrimSpace(line[loc[1]:])
+		}
+
+		p, err := parseModulePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ignore file %q, line %d: %v", path, n, err)
+		}
+		p.reason = reason
+		patterns = append(patterns, p)
+	}
+	return patterns, scanner.Err()
+}
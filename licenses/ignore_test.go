@@ -0,0 +1,84 @@
+package licenses
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadIgnoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gocomplyignore")
+	data := `# first-party modules
+github.com/mycorp/*   # our own code
+
+re:example\.org/fork-.*  # forks we maintain
+example.org/reviewed
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := loadIgnoreFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	modules := []Module{{Path: "github.com/mycorp/a"}, {Path: "example.org/fork-b"}, {Path: "example.org/reviewed"}, {Path: "example.org/c"}}
+	kept, excluded := moduleFilter{Ignore: patterns}.filter(modules)
+
+	if (len(kept) != 1) || (kept[0].Path != "example.org/c") {
+		t.Errorf("expected only example.org/c but got %v", kept)
+	}
+	expected := []excludedModule{
+		{"github.com/mycorp/a", "github.com/mycorp/*", "our own code"},
+		{"example.org/fork-b", `re:example\.org/fork-.*`, "forks we maintain"},
+		{"example.org/reviewed", "example.org/reviewed", ""},
+	}
+	if !reflect.DeepEqual(excluded, expected) {
+		t.Errorf("expected %+v but got %+v", expected, excluded)
+	}
+
+	if err := os.WriteFile(path, []byte("re:(\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadIgnoreFile(path); (err == nil) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected an error for line 1 but got %v", err)
+	}
+}
+
+func TestWriteExcluded(t *testing.T) {
+	excluded := []excludedModule{{"github.com/mycorp/a", "github.com/mycorp/*", "our own code"}}
+
+	var buf bytes.Buffer
+	w := newTextReportWriter(&buf, reportOptions{Trailer: true})
+	if err := w.WriteExcluded(excluded); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "github.com/mycorp/a (github.com/mycorp/*): our own code\n") {
+		t.Errorf("expected the excluded module in the appendix but got %q", buf.String())
+	}
+	if _, err := verifyReport(buf.Bytes()); err != nil {
+		t.Errorf("expected the report to verify but got %v", err)
+	}
+
+	buf.Reset()
+	jw, err := newReportWriter(&buf, reportOptions{Format: "json", Generated: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jw.(excludedWriter).WriteExcluded(excluded); err != nil {
+		t.Fatal(err)
+	}
+	if err := jw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"reason": "our own code"`) {
+		t.Errorf("expected the excluded module in the report but got %q", buf.String())
+	}
+}
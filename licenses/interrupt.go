@@ -0,0 +1,78 @@
+package licenses
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// errInterrupted is returned by a scan that stopped early after SIGINT or
+// SIGTERM. See handleInterrupts.
+var errInterrupted = errors.New("interrupted")
+
+// interrupted is non-zero once a SIGINT or SIGTERM has been received
+var interrupted int32
+
+// errDeadline is returned by a scan that stopped early at the -deadline.
+var errDeadline = errors.New("deadline exceeded")
+
+// handleInterrupts makes the first SIGINT or SIGTERM stop a scan gracefully,
+// once the module being scanned is done (see stopping): the completed
+// entries are written as a well-formed (but incomplete) report, and the
+// summary lists the modules that weren't scanned. A second signal cancels
+// the scan's context, abandoning the requests in flight, and a third exits
+// immediately.
+//
+// With immediate, such as for serve, which has no module to finish, the
+// first signal cancels the context.
+func handleInterrupts(cancel context.CancelFunc, immediate bool) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go relayInterrupts(c, cancel, immediate)
+}
+
+// relayInterrupts implements handleInterrupts for the signals received on c.
+func relayInterrupts(c chan os.Signal, cancel context.CancelFunc, immediate bool) {
+	<-c
+	atomic.StoreInt32(&interrupted, 1)
+	if !immediate {
+		logf(levelWarning, "", "", nil, "interrupted: stopping after this module (interrupt again to stop now)")
+		<-c
+	}
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+	logf(levelWarning, "", "", nil, "interrupted: stopping (interrupt again to quit immediately)")
+	cancel()
+}
+
+// isInterrupted returns true once a SIGINT or SIGTERM has been received.
+func isInterrupted() bool {
+	return atomic.LoadInt32(&interrupted) != 0
+}
+
+// stopping returns true if a scan should stop before its next module,
+// because ctx is done or a SIGINT or SIGTERM has been received.
+func stopping(ctx context.Context) bool {
+	return (ctx.Err() != nil) || isInterrupted()
+}
+
+// stopped returns the error for a scan that stopped early because its
+// context is done: errInterrupted, errDeadline or the context's error.
+func stopped(ctx context.Context) error {
+	switch {
+	case isInterrupted():
+		return errInterrupted
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errDeadline
+	default:
+		return ctx.Err()
+	}
+}
+
+// isStopped returns true if err is from a scan that stopped early (see
+// stopped).
+func isStopped(err error) bool {
+	return errors.Is(err, errInterrupted) || errors.Is(err, errDeadline) || errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,86 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunScanInterrupted(t *testing.T) {
+	atomic.StoreInt32(&interrupted, 1)
+	defer atomic.StoreInt32(&interrupted, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var stdout bytes.Buffer
+	err := runScan(ctx, &options{Format: "json"}, []string{"example.org/a@v1.0.0"}, &stdout)
+	if !errors.Is(err, errInterrupted) || (exitStatus(err) != exitInterrupted) {
+		t.Fatalf("expected an interrupted error but got %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("expected a well-formed report but got %v: %q", err, stdout.String())
+	}
+	if !report.Incomplete || (len(report.Entries) != 0) {
+		t.Errorf("expected an empty, incomplete report but got %+v", report)
+	}
+}
+
+func TestRunScanDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	err := runScan(ctx, &options{Format: "json"}, []string{"example.org/a@v1.0.0"}, &stdout)
+	if !errors.Is(err, errDeadline) || (exitStatus(err) != exitIncomplete) {
+		t.Fatalf("expected a deadline error but got %v", err)
+	}
+}
+
+func TestRelayInterrupts(t *testing.T) {
+	defer atomic.StoreInt32(&interrupted, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		relayInterrupts(c, cancel, false)
+		close(done)
+	}()
+
+	// the first signal finishes the module being scanned
+	c <- os.Interrupt
+	c <- os.Interrupt // received once the first is handled
+	if !isInterrupted() {
+		t.Errorf("expected the first signal to stop after this module")
+	}
+	<-done
+	if ctx.Err() == nil {
+		t.Errorf("expected the second signal to cancel the context")
+	}
+}
+
+func TestRunScanStopsAfterModule(t *testing.T) {
+	atomic.StoreInt32(&interrupted, 1)
+	defer atomic.StoreInt32(&interrupted, 0)
+
+	var stdout bytes.Buffer
+	err := runScan(context.Background(), &options{Format: "json"}, []string{"example.org/a@v1.0.0"}, &stdout)
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("expected an interrupted error but got %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("expected a well-formed report but got %v: %q", err, stdout.String())
+	}
+	if !report.Incomplete {
+		t.Errorf("expected an incomplete report but got %+v", report)
+	}
+}
@@ -0,0 +1,147 @@
+package licenses
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// journalRecord is one line of a journal: the entry for a module that was
+// scanned successfully, with the options that affect it.
+type journalRecord struct {
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+	Options string `json:"options"`
+	Entry   Entry  `json:"entry"`
+}
+
+// journal records each module's entry as it completes, so that a run that
+// stops part way can be resumed without scanning those modules again.
+type journal struct {
+	path    string
+	f       *os.File
+	options string
+	done    map[Module]Entry // from a previous run, if resuming
+}
+
+// defaultJournalPath returns the journal for the current directory, in the
+// user's cache directory, or an empty string if there's nowhere to put it.
+func defaultJournalPath() string {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(wd))
+	return filepath.Join(cache, "gocomply", "journal-"+hex.EncodeToString(sum[:8])+".jsonl")
+}
+
+// journalOptions describes the options that affect an entry, so that
+// entries are only resumed by a run with the same options.
+func (o *options) journalOptions() string {
+	return fmt.Sprintf("list=%t identify=%t copyrights=%t obligations=%t provenance=%t known=%t plain=%t tools=%s",
+		o.List, o.Identify, o.Copyrights, o.Obligations, o.Provenance, !o.NoKnown, o.PlainText, o.Tools)
+}
+
+// openJournal opens the journal at path. If resume is true, the entries
+// recorded by a previous run with the same options are kept; otherwise, the
+// journal starts empty.
+func openJournal(path string, resume bool, options string) (*journal, error) {
+	j := &journal{path: path, options: options, done: make(map[Module]Entry)}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		if err := j.read(); err != nil {
+			return nil, err
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating journal: %v", err)
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error creating journal: %v", err)
+	}
+	j.f = f
+	return j, nil
+}
+
+// read loads the entries recorded by a previous run. A missing journal is
+// empty, and a truncated last line (from a run that was killed while
+// writing it) is ignored.
+func (j *journal) read() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading journal: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var r journalRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if r.Options == j.options {
+			j.done[Module{Path: r.Module, Version: r.Version}] = r.Entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading journal: %v", err)
+	}
+	return nil
+}
+
+// resumed returns the entry for a module from a previous run, if any. A nil
+// journal has none.
+func (j *journal) resumed(m Module) (Entry, bool) {
+	if j == nil {
+		return Entry{}, false
+	}
+	e, ok := j.done[Module{Path: m.Path, Version: m.Version}]
+	if ok && (e.ReplacedBy != m.replacement()) {
+		return Entry{}, false // go.mod's replace directive has changed
+	}
+	return e, ok
+}
+
+// record appends a module's entry to the journal.
+func (j *journal) record(m Module, e Entry) error {
+	data, err := json.Marshal(journalRecord{m.Path, m.Version, j.options, e})
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing journal: %v", err)
+	}
+	return nil
+}
+
+// close closes the journal, keeping it to resume from.
+func (j *journal) close() error {
+	return j.f.Close()
+}
+
+// finish closes and removes the journal after a complete run.
+func (j *journal) finish() error {
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(j.path); err != nil {
+		return fmt.Errorf("error removing journal: %v", err)
+	}
+	return nil
+}
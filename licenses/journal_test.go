@@ -0,0 +1,57 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	a := Module{Path: "example.org/a", Version: "v1.0.0"}
+	b := Module{Path: "example.org/b", Version: "v1.0.0"}
+
+	j, err := openJournal(path, false, "list=false")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := j.record(a, Entry{Module: a.Path, SPDX: "MIT"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j.close()
+
+	// a partial line, as from a run killed part way through writing it
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"module": "example.org/b", "opt`)
+	f.Close()
+
+	j, err = openJournal(path, true, "list=false")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, ok := j.resumed(a); !ok || (e.SPDX != "MIT") {
+		t.Errorf("expected to resume %s but got (%+v, %t)", a.Path, e, ok)
+	}
+	if _, ok := j.resumed(b); ok {
+		t.Errorf("expected not to resume %s", b.Path)
+	}
+	if _, ok := j.resumed(Module{Path: a.Path, Version: "v2.0.0"}); ok {
+		t.Errorf("expected not to resume a different version")
+	}
+	if err := j.finish(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the journal to be removed")
+	}
+
+	// different options don't resume
+	j, _ = openJournal(path, false, "list=false")
+	j.record(a, Entry{Module: a.Path})
+	j.close()
+	j, _ = openJournal(path, true, "list=true")
+	defer j.close()
+	if _, ok := j.resumed(a); ok {
+		t.Errorf("expected not to resume with different options")
+	}
+}
@@ -0,0 +1,77 @@
+package licenses
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownModule maps awkward, well-known module paths directly to their
+// repositories, so they resolve instantly and correctly without a go-import
+// lookup or other heuristics.
+//
+// If Prefix ends with "/", it matches any module below it, and "%s" in
+// RepoRoot is replaced with the next element of the module path (e.g.
+// "golang.org/x/" matches "golang.org/x/text/v2" and gives "text").
+// Otherwise, it matches that module and any module below it.
+type knownModule struct {
+	Prefix   string
+	RepoRoot string
+}
+
+var knownModules = []knownModule{
+	// golang.org/x/* subrepos are mirrored on GitHub, which is faster and
+	// supports the GitHub API
+	{Prefix: "golang.org/x/", RepoRoot: "https://github.com/golang/%s"},
+
+	// cloud.google.com/go submodules all live in one repository
+	{Prefix: "cloud.google.com/go", RepoRoot: "https://github.com/googleapis/google-cloud-go"},
+
+	{Prefix: "google.golang.org/api", RepoRoot: "https://github.com/googleapis/google-api-go-client"},
+	{Prefix: "google.golang.org/appengine", RepoRoot: "https://github.com/golang/appengine"},
+	{Prefix: "google.golang.org/genproto", RepoRoot: "https://github.com/googleapis/go-genproto"},
+	{Prefix: "google.golang.org/grpc", RepoRoot: "https://github.com/grpc/grpc-go"},
+	{Prefix: "google.golang.org/protobuf", RepoRoot: "https://github.com/protocolbuffers/protobuf-go"},
+
+	{Prefix: "go.uber.org/", RepoRoot: "https://github.com/uber-go/%s"},
+	{Prefix: "k8s.io/", RepoRoot: "https://github.com/kubernetes/%s"},
+	{Prefix: "sigs.k8s.io/", RepoRoot: "https://github.com/kubernetes-sigs/%s"},
+
+	// gopkg.in serves each major version from a tag or branch, and the
+	// license can differ between them (see gopkgInRef)
+	{Prefix: "gopkg.in/yaml.v2", RepoRoot: "https://gopkg.in/yaml.v2"},
+	{Prefix: "gopkg.in/yaml.v3", RepoRoot: "https://gopkg.in/yaml.v3"},
+	{Prefix: "gopkg.in/check.v1", RepoRoot: "https://gopkg.in/check.v1"},
+}
+
+// lookupKnownModule returns the repository of a module in knownModules.
+func lookupKnownModule(module string) (GoImport, GoSource, bool) {
+	for _, k := range knownModules {
+		var name string
+		if strings.HasSuffix(k.Prefix, "/") {
+			if !strings.HasPrefix(module, k.Prefix) {
+				continue
+			}
+			name = strings.SplitN(strings.TrimPrefix(module, k.Prefix), "/", 2)[0]
+			if name == "" {
+				continue
+			}
+		} else if (module != k.Prefix) && !strings.HasPrefix(module, k.Prefix+"/") {
+			continue
+		}
+
+		repoRoot := k.RepoRoot
+		if strings.Contains(repoRoot, "%s") {
+			repoRoot = fmt.Sprintf(repoRoot, name)
+		}
+
+		importPrefix := strings.TrimSuffix(k.Prefix, "/")
+		if name != "" {
+			importPrefix += "/" + name
+		}
+
+		gi := GoImport{ImportPrefix: importPrefix, Vcs: "git", RepoRoot: repoRoot}
+		gs := GoSource{ImportPrefix: importPrefix}
+		return gi, gs, true
+	}
+	return GoImport{}, GoSource{}, false
+}
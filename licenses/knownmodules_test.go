@@ -0,0 +1,53 @@
+package licenses
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLookupKnownModule(t *testing.T) {
+	tests := []struct {
+		module   string
+		repoRoot string
+		ok       bool
+	}{
+		{"golang.org/x/text", "https://github.com/golang/text", true},
+		{"golang.org/x/tools/gopls", "https://github.com/golang/tools", true},
+		{"golang.org/x", "", false},
+		{"cloud.google.com/go/storage", "https://github.com/googleapis/google-cloud-go", true},
+		{"cloud.google.com/go", "https://github.com/googleapis/google-cloud-go", true},
+		{"cloud.google.com/gopher", "", false},
+		{"google.golang.org/grpc", "https://github.com/grpc/grpc-go", true},
+		{"k8s.io/klog/v2", "https://github.com/kubernetes/klog", true},
+		{"gopkg.in/yaml.v3", "https://gopkg.in/yaml.v3", true},
+		{"example.org/foo", "", false},
+	}
+
+	for _, tt := range tests {
+		gi, _, ok := lookupKnownModule(tt.module)
+		if (ok != tt.ok) || (gi.RepoRoot != tt.repoRoot) {
+			t.Errorf("%s: expected (%q, %t) but got (%q, %t)", tt.module, tt.repoRoot, tt.ok, gi.RepoRoot, ok)
+		}
+	}
+}
+
+func TestKnownModuleGopkgBranch(t *testing.T) {
+	oldFetcher, oldLimiter, oldRefs := httpFetcher, rateLimiter, gopkgInRefs.refs
+	defer func() { httpFetcher, rateLimiter, gopkgInRefs.refs = oldFetcher, oldLimiter, oldRefs }()
+	rateLimiter = NoRateLimit
+	gopkgInRefs.refs = make(map[gopkgIn]string)
+	httpFetcher = replayFetcher{
+		"https://github.com/go-yaml/yaml.git/info/refs?service=git-upload-pack": testGitRefs("refs/heads/v2", "refs/heads/v3"),
+	}
+
+	gi, gs, _ := lookupKnownModule("gopkg.in/yaml.v2")
+	urls, _, err := resolveFileURL(context.Background(), gi, gs, "LICENSE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "https://raw.githubusercontent.com/go-yaml/yaml/v2/LICENSE"
+	if len(urls) != 1 || urls[0].URL != expected {
+		t.Errorf("expected %q but got %v", expected, urls)
+	}
+}
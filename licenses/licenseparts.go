@@ -0,0 +1,145 @@
+package licenses
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// licensePart is one license body: a whole license file or, where a file
+// contains several licenses one after the other, one of those licenses.
+type licensePart struct {
+	File      string `json:"file"`
+	SourceURL string `json:"source_url,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	SPDX      string `json:"spdx,omitempty"`
+	Text      string `json:"text"`
+
+	// Raw is the original text, if Text is converted to plain text (see
+	// plainLicense).
+	Raw string `json:"-"`
+}
+
+// licenseFilePrefixes match additional license files, like LICENSE-MIT and
+// LICENSE-APACHE in a dual-licensed repository, when checking a repository
+// listing case insensitively.
+var licenseFilePrefixes = []string{
+	"LICENSE-",
+	"LICENCE-",
+	"COPYING-",
+}
+
+// licenseFileRank returns true if name is a license file and, if so, its
+// order of precedence: its index in files or, for a name that only matches
+// licenseFilePrefixes, len(files). Matching is case insensitive.
+func licenseFileRank(name string, files []string) (int, bool) {
+	for i, f := range files {
+		if strings.EqualFold(name, f) {
+			return i, true
+		}
+	}
+
+	upper := strings.ToUpper(name)
+	for _, prefix := range licenseFilePrefixes {
+		if strings.HasPrefix(upper, prefix) && len(upper) > len(prefix) {
+			return len(files), true
+		}
+	}
+
+	return 0, false
+}
+
+// rankLicenseFiles returns the license files among the names of the files in
+// a directory, in order of precedence among candidates (see licenseFileRank
+// and repoLicenseFiles).
+func rankLicenseFiles(names []string, candidates []string) []string {
+	type rankedFile struct {
+		rank int
+		name string
+	}
+	var files []rankedFile
+	for _, name := range names {
+		if rank, ok := licenseFileRank(name, candidates); ok {
+			files = append(files, rankedFile{rank, name})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].rank < files[j].rank
+	})
+
+	result := make([]string, len(files))
+	for i, f := range files {
+		result[i] = f.name
+	}
+	return result
+}
+
+// combineLicenseParts returns a licenseFile for one or more license files.
+// When there are several, the combined text gives each file a header, and
+// provenance is taken from the first.
+//
+// Each file is also split into its separate license bodies (see
+// splitLicenseBodies), so that Parts has one element per license.
+func combineLicenseParts(files []licensePart) licenseFile {
+	var b strings.Builder
+	var parts []licensePart
+
+	for i, f := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			fmt.Fprintf(&b, "==> %s <==\n\n", f.File)
+		}
+		b.WriteString(f.Text)
+		parts = append(parts, splitLicenseBodies(f)...)
+	}
+
+	return licenseFile{
+		Text:      b.String(),
+		SourceURL: files[0].SourceURL,
+		Revision:  files[0].Revision,
+		Parts:     parts,
+	}
+}
+
+// splitLicenseBodies detects a file that contains several different licenses
+// concatenated together, and splits it into one part per license. Any text
+// before the first license (such as a copyright notice) is kept with it.
+func splitLicenseBodies(file licensePart) []licensePart {
+	cov := licensecheck.Scan([]byte(file.Text))
+
+	var matches []licensecheck.Match
+	for _, m := range cov.Match {
+		if m.IsURL {
+			continue
+		}
+		if (len(matches) > 0) && (matches[len(matches)-1].ID == m.ID) {
+			continue
+		}
+		matches = append(matches, m)
+	}
+
+	if len(matches) < 2 {
+		file.SPDX = classifyLicense(file.Text).SPDX
+		return []licensePart{file}
+	}
+
+	parts := make([]licensePart, len(matches))
+	for i, m := range matches {
+		start, end := 0, len(file.Text)
+		if i > 0 {
+			start = m.Start
+		}
+		if i < len(matches)-1 {
+			end = matches[i+1].Start
+		}
+
+		parts[i] = file
+		parts[i].SPDX = m.ID
+		parts[i].Text = strings.TrimSpace(file.Text[start:end])
+	}
+	return parts
+}
@@ -0,0 +1,86 @@
+package licenses
+
+import (
+	"strings"
+	"testing"
+)
+
+const testISCLicense = `ISC License
+
+Copyright (c) 2004-2010 by Internet Systems Consortium, Inc. ("ISC")
+
+Permission to use, copy, modify, and/or distribute this software for any
+purpose with or without fee is hereby granted, provided that the above
+copyright notice and this permission notice appear in all copies.
+
+THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.`
+
+func TestLicenseFileRank(t *testing.T) {
+	files := []string{"NOTICE", "LICENSE"}
+
+	tests := []struct {
+		name string
+		rank int
+		ok   bool
+	}{
+		{"NOTICE", 0, true},
+		{"license", 1, true},
+		{"LICENSE-MIT", 2, true},
+		{"licence-apache.txt", 2, true},
+		{"LICENSE-", 0, false},
+		{"README.md", 0, false},
+	}
+
+	for _, test := range tests {
+		rank, ok := licenseFileRank(test.name, files)
+		if rank != test.rank || ok != test.ok {
+			t.Errorf("%q: expected (%d, %t) but got (%d, %t)", test.name, test.rank, test.ok, rank, ok)
+		}
+	}
+}
+
+func TestCombineLicenseParts(t *testing.T) {
+	license := combineLicenseParts([]licensePart{
+		{File: "LICENSE-MIT", SourceURL: "https://example.org/LICENSE-MIT", Text: testMITLicense},
+		{File: "LICENSE-ISC", SourceURL: "https://example.org/LICENSE-ISC", Text: testISCLicense},
+	})
+
+	if !strings.HasPrefix(license.Text, "==> LICENSE-MIT <==\n\nMIT License") ||
+		!strings.Contains(license.Text, "\n\n==> LICENSE-ISC <==\n\nISC License") {
+		t.Errorf("unexpected combined text %q", license.Text)
+	}
+	if license.SourceURL != "https://example.org/LICENSE-MIT" {
+		t.Errorf("expected provenance of the first file but got %q", license.SourceURL)
+	}
+	if len(license.Parts) != 2 || license.Parts[0].SPDX != "MIT" || license.Parts[1].SPDX != "ISC" {
+		t.Errorf("unexpected parts %+v", license.Parts)
+	}
+}
+
+func TestSplitLicenseBodies(t *testing.T) {
+	parts := splitLicenseBodies(licensePart{
+		File: "LICENSE",
+		Text: testMITLicense + "\n\n" + testISCLicense,
+	})
+
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts but got %+v", parts)
+	}
+	if parts[0].SPDX != "MIT" || !strings.HasPrefix(parts[0].Text, "MIT License") || strings.Contains(parts[0].Text, "ISC") {
+		t.Errorf("unexpected first part %+v", parts[0])
+	}
+	if parts[1].SPDX != "ISC" || !strings.HasSuffix(parts[1].Text, "PERFORMANCE OF THIS SOFTWARE.") {
+		t.Errorf("unexpected second part %+v", parts[1])
+	}
+
+	single := splitLicenseBodies(licensePart{File: "LICENSE", Text: testMITLicense})
+	if len(single) != 1 || single[0].SPDX != "MIT" || single[0].Text != testMITLicense {
+		t.Errorf("unexpected single part %+v", single)
+	}
+}
@@ -0,0 +1,253 @@
+package licenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+)
+
+// licenseProbeWorkers is the number of candidate license files that
+// fetchLicenseFiles requests at once for a repository. Requests to the same
+// host are still paced by rateLimiter.
+const licenseProbeWorkers = 4
+
+// licenseCandidate is a license file name, without any subdirectory, at a
+// ref (e.g. "LICENSE" at "master").
+type licenseCandidate struct {
+	file string
+	ref  string
+}
+
+// licenseHits counts the license files found for each licenseCandidate, so
+// that the candidates most often found are requested first.
+var licenseHits = struct {
+	sync.Mutex
+	counts map[licenseCandidate]int
+}{counts: make(map[licenseCandidate]int)}
+
+// probeState is the progress of a licenseProbe.
+type probeState int
+
+const (
+	probePending   probeState = iota
+	probeRunning              // requested
+	probeFound                // the file is a license
+	probeMissing              // the file doesn't exist, isn't a license, or the request failed
+	probeCancelled            // no longer needed
+)
+
+// licenseProbe is a request for one candidate license file at one ref.
+type licenseProbe struct {
+	file    string
+	url     FileURL
+	decoder func(string) (string, error)
+
+	state  probeState
+	text   string // the license text, if found
+	err    error  // why the request failed, if it did
+	cancel context.CancelFunc
+}
+
+func (probe *licenseProbe) candidate() licenseCandidate {
+	return licenseCandidate{path.Base(probe.file), probe.url.Ref}
+}
+
+// fetch requests the probe's file, returning its license text, if any. A
+// request that fails is returned as failed, but a response that can't be
+// decoded is an error.
+func (probe *licenseProbe) fetch(ctx context.Context) (text string, failed error, err error) {
+	data, failed := httpGet(ctx, probe.url.URL, nil)
+	if failed != nil {
+		return "", failed, nil
+	}
+
+	data, err = probe.decoder(data)
+	if errors.Is(err, errNotAFile) {
+		return "", nil, nil
+	} else if err != nil {
+		return "", nil, fmt.Errorf("error decoding %q: %v", probe.url.URL, err)
+	}
+	text, _ = licenseText(probe.url.URL, data)
+	return text, nil, nil
+}
+
+// licenseProbes requests the candidate license files of a repository
+// concurrently, for fetchLicenseFiles. The result is the same as requesting
+// each file in turn, at each of its refs in turn: once a license file is
+// found, the ref it was found at is fixed, and the remaining files are only
+// needed on that ref. Requests that are no longer needed, because a file of
+// higher precedence decided the ref, are cancelled or never made.
+type licenseProbes struct {
+	mu     sync.Mutex
+	byFile [][]*licenseProbe // in order of precedence
+	err    error             // a response that couldn't be decoded
+}
+
+// ref returns the ref that license files are fetched at, once it is decided
+// by the first file, in order of precedence, that was found, or "" if none
+// were.
+func (p *licenseProbes) ref() (ref string, decided bool) {
+	for _, probes := range p.byFile {
+		for _, probe := range probes {
+			switch probe.state {
+			case probeFound:
+				return probe.url.Ref, true
+			case probeMissing:
+				continue
+			default:
+				return "", false
+			}
+		}
+	}
+	return "", true
+}
+
+// needed returns true if the result of a probe may still matter.
+func (p *licenseProbes) needed(probe *licenseProbe) bool {
+	if p.err != nil {
+		return false
+	}
+	ref, decided := p.ref()
+	return !decided || ((ref != "") && (probe.url.Ref == ref))
+}
+
+// order returns the probes with the candidates found most often first, but
+// otherwise in order of precedence.
+func (p *licenseProbes) order() []*licenseProbe {
+	var order []*licenseProbe
+	for _, probes := range p.byFile {
+		order = append(order, probes...)
+	}
+
+	licenseHits.Lock()
+	defer licenseHits.Unlock()
+	sort.SliceStable(order, func(i, j int) bool {
+		return licenseHits.counts[order[i].candidate()] > licenseHits.counts[order[j].candidate()]
+	})
+	return order
+}
+
+// next returns the next probe to request, or nil if there are none left
+// that are needed.
+func (p *licenseProbes) next(ctx context.Context, order []*licenseProbe) (*licenseProbe, context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, probe := range order {
+		if (probe.state == probePending) && p.needed(probe) {
+			var probeCtx context.Context
+			probeCtx, probe.cancel = context.WithCancel(ctx)
+			probe.state = probeRunning
+			return probe, probeCtx
+		}
+	}
+	return nil, nil
+}
+
+// done records the result of a probe, and cancels any requests that are no
+// longer needed.
+func (p *licenseProbes) done(probe *licenseProbe, text string, failed error, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	probe.cancel()
+	if probe.state == probeCancelled {
+		return
+	}
+
+	switch {
+	case err != nil:
+		if p.err == nil {
+			p.err = err
+		}
+		probe.state = probeMissing
+	case text != "":
+		probe.state = probeFound
+		probe.text = text
+	default:
+		probe.state = probeMissing
+		probe.err = failed
+	}
+
+	for _, probes := range p.byFile {
+		for _, other := range probes {
+			if (other.state == probeRunning) && !p.needed(other) {
+				other.state = probeCancelled
+				other.cancel()
+			}
+		}
+	}
+}
+
+// run requests the probes that are needed, licenseProbeWorkers at a time.
+// Any events for eventHandler are sent once every request is done, from the
+// calling goroutine.
+func (p *licenseProbes) run(ctx context.Context) {
+	order := p.order()
+	if eventHandler != nil {
+		defer relayEvents()()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < licenseProbeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				probe, probeCtx := p.next(ctx, order)
+				if probe == nil {
+					return
+				}
+				text, failed, err := probe.fetch(probeCtx)
+				p.done(probe, text, failed, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// result returns the license files found, in order of precedence, and
+// counts them in licenseHits.
+func (p *licenseProbes) result() licenseFetch {
+	var result licenseFetch
+
+	for _, probes := range p.byFile {
+		for _, probe := range probes {
+			if (result.ref != "") && (probe.url.Ref != result.ref) {
+				continue
+			}
+			if probe.err != nil {
+				if limited, _ := rateLimitReset(probe.err); limited {
+					result.rateLimitErr = probe.err
+				} else if retryable(probe.err) {
+					result.transientErr = probe.err
+				}
+			}
+			if probe.state != probeFound {
+				continue
+			}
+
+			result.ref = probe.url.Ref
+			result.parts = append(result.parts, licensePart{
+				File:      probe.file,
+				SourceURL: probe.url.URL,
+				Text:      probe.text,
+			})
+			break
+		}
+	}
+
+	licenseHits.Lock()
+	defer licenseHits.Unlock()
+	for _, probes := range p.byFile {
+		for _, probe := range probes {
+			if (probe.state == probeFound) && (probe.url.Ref == result.ref) {
+				licenseHits.counts[probe.candidate()]++
+			}
+		}
+	}
+	return result
+}
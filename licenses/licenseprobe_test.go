@@ -0,0 +1,196 @@
+package licenses
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetchLicenseFiles(t *testing.T) {
+	oldFetcher, oldLimiter, oldHits := httpFetcher, rateLimiter, licenseHits.counts
+	defer func() { httpFetcher, rateLimiter, licenseHits.counts = oldFetcher, oldLimiter, oldHits }()
+	rateLimiter = NoRateLimit
+	licenseHits.counts = make(map[licenseCandidate]int)
+
+	raw := "https://raw.githubusercontent.com/owner/repo/"
+	httpFetcher = replayFetcher{
+		// only the first license found fixes the ref
+		raw + "master/COPYING":        testMITLicense,
+		raw + "master/LICENSE-APACHE": "Apache License\nVersion 2.0, January 2004",
+		raw + "main/LICENSE-MIT":      testMITLicense,
+		raw + "master/LICENSE-MIT":    testMITLicense,
+		raw + "master/NOTICE":         "Copyright 2024 The Authors",
+	}
+
+	gi := GoImport{Vcs: "git", RepoRoot: "https://github.com/owner/repo"}
+	for i := 0; i < 2; i++ {
+		fetched, err := fetchLicenseFiles(context.Background(), "example.org/repo", gi, GoSource{}, httpLicenseFiles)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fetched.ref != "master" {
+			t.Errorf("expected ref master but got %q", fetched.ref)
+		}
+
+		var files []string
+		for _, part := range fetched.parts {
+			files = append(files, part.File)
+		}
+		if (len(files) != 4) || (files[0] != "NOTICE") || (files[1] != "COPYING") ||
+			(files[2] != "LICENSE-MIT") || (files[3] != "LICENSE-APACHE") {
+			t.Errorf("expected the files in order of precedence but got %v", files)
+		}
+		if fetched.parts[2].SourceURL != raw+"master/LICENSE-MIT" {
+			t.Errorf("expected LICENSE-MIT on master but got %s", fetched.parts[2].SourceURL)
+		}
+	}
+
+	if n := licenseHits.counts[licenseCandidate{"COPYING", "master"}]; n != 2 {
+		t.Errorf("expected 2 hits for COPYING but got %d", n)
+	}
+	if n := licenseHits.counts[licenseCandidate{"LICENSE-MIT", "main"}]; n != 0 {
+		t.Errorf("expected no hits for LICENSE-MIT on main but got %d", n)
+	}
+}
+
+func TestLicenseProbesOrder(t *testing.T) {
+	oldHits := licenseHits.counts
+	defer func() { licenseHits.counts = oldHits }()
+	licenseHits.counts = map[licenseCandidate]int{
+		{"LICENSE", "master"}: 3,
+		{"COPYING", "main"}:   1,
+	}
+
+	p := &licenseProbes{}
+	for _, file := range []string{"NOTICE", "sub/LICENSE", "COPYING"} {
+		p.byFile = append(p.byFile, []*licenseProbe{
+			{file: file, url: FileURL{Ref: "main"}},
+			{file: file, url: FileURL{Ref: "master"}},
+		})
+	}
+
+	expected := []string{
+		"sub/LICENSE@master", "COPYING@main",
+		"NOTICE@main", "NOTICE@master", "sub/LICENSE@main", "COPYING@master",
+	}
+	order := p.order()
+	for i, probe := range order {
+		if actual := probe.file + "@" + probe.url.Ref; actual != expected[i] {
+			t.Errorf("%d: expected %s but got %s", i, expected[i], actual)
+		}
+	}
+}
+
+func TestLicenseProbesNeeded(t *testing.T) {
+	notice := []*licenseProbe{{url: FileURL{Ref: "main"}}, {url: FileURL{Ref: "master"}}}
+	license := []*licenseProbe{{url: FileURL{Ref: "main"}}, {url: FileURL{Ref: "master"}}}
+	copying := []*licenseProbe{{url: FileURL{Ref: "main"}}, {url: FileURL{Ref: "master"}}}
+	p := &licenseProbes{byFile: [][]*licenseProbe{notice, license, copying}}
+
+	// LICENSE is on master, but NOTICE might still be found on main
+	license[0].state = probeMissing
+	license[1].state = probeFound
+	notice[0].state = probeRunning
+	if !p.needed(copying[0]) {
+		t.Errorf("expected COPYING on main to be needed until the ref is decided")
+	}
+
+	notice[0].state = probeMissing
+	notice[1].state = probeMissing
+	if ref, decided := p.ref(); (ref != "master") || !decided {
+		t.Errorf("expected master to be decided but got %q (%t)", ref, decided)
+	}
+	if p.needed(copying[0]) {
+		t.Errorf("expected COPYING on main not to be needed once master is decided")
+	}
+	if !p.needed(copying[1]) {
+		t.Errorf("expected COPYING on master to be needed")
+	}
+}
+
+// slowFetcher is a replayFetcher that takes a while to respond, and records
+// how many requests overlapped.
+type slowFetcher struct {
+	replay replayFetcher
+
+	mu      sync.Mutex
+	running int
+	overlap int // the most requests in flight at once
+}
+
+func (f *slowFetcher) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.running++
+	if f.running > f.overlap {
+		f.overlap = f.running
+	}
+	f.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	return f.replay.Do(req)
+}
+
+// githubResolver resolves example.org/ modules to a GitHub repository
+type githubResolver struct{}
+
+func (githubResolver) Lookup(ctx context.Context, module string) (GoImport, bool, error) {
+	if !strings.HasPrefix(module, "example.org/") {
+		return GoImport{}, false, nil
+	}
+	return GoImport{ImportPrefix: module, Vcs: "git", RepoRoot: "https://github.com/owner/probes"}, true, nil
+}
+
+func (githubResolver) FileURLs(ctx context.Context, gi GoImport, file string) ([]FileURL, bool) {
+	return nil, false
+}
+
+func TestLicenseProbesEvents(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldLimiter, oldHits, oldLicenses := rateLimiter, licenseHits.counts, licenses.byKey
+	defer func() { rateLimiter, licenseHits.counts, licenses.byKey = oldLimiter, oldHits, oldLicenses }()
+	rateLimiter = NoRateLimit
+	licenseHits.counts = make(map[licenseCandidate]int)
+	licenses.byKey = make(map[string]licenseFile)
+
+	raw := "https://raw.githubusercontent.com/owner/probes/"
+	fetcher := &slowFetcher{replay: replayFetcher{
+		raw + "master/LICENSE":  testMITLicense,
+		raw + "main/COPYING":    "\x00\x01 not a license",
+		raw + "master/COPYING":  "\x00\x01 not a license",
+		raw + "main/LICENSE.md": "\x00\x01 not a license",
+	}}
+
+	// not synchronized, so the race detector catches a call from another
+	// goroutine
+	var warnings []string
+	opts := Options{
+		Resolvers: []Resolver{githubResolver{}},
+		Fetcher:   fetcher,
+		Events: func(ev Event) {
+			if w, ok := ev.(Warning); ok {
+				warnings = append(warnings, w.Message)
+			}
+		},
+	}
+	results, err := Scan(context.Background(), ModuleList{{Path: "example.org/a"}}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (len(results) != 1) || (results[0].Err != nil) || (results[0].Entry.SPDX != "MIT") {
+		t.Fatalf("expected the MIT license but got %+v", results)
+	}
+	if fetcher.overlap < 2 {
+		t.Errorf("expected overlapping requests but got at most %d", fetcher.overlap)
+	}
+	if len(warnings) == 0 {
+		t.Errorf("expected warnings for the files that aren't licenses")
+	}
+}
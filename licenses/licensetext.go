@@ -0,0 +1,123 @@
+package licenses
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// errBinary is the error for a license file that isn't text
+var errBinary = errors.New("not a text file")
+
+// errHTMLPage is the error for a license file that is an HTML page, such as
+// a login page or an application shell asking to enable JavaScript, served
+// in place of the raw file
+var errHTMLPage = errors.New("an HTML page, not a license file")
+
+// binarySniffLen is how much of a file is checked for a NUL byte, as git
+// does, to tell if it is binary
+const binarySniffLen = 8000
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to their code
+// points. Other bytes above 0x7F are the same as in Latin-1 (ISO 8859-1),
+// of which Windows-1252 is a superset, and the few that Windows-1252 leaves
+// undefined are mapped to the same code point, as in Latin-1.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// licenseText returns the text of a license file, normalized so that the
+// report is consistently valid UTF-8 (see normalizeText), and trimmed. If
+// the file is binary or an HTML page (see isHTMLPage), a warning is logged
+// and ok is false.
+func licenseText(name string, data string) (text string, ok bool) {
+	text, err := normalizeText(data)
+	if (err == nil) && isHTMLPage(text) {
+		err = errHTMLPage
+	}
+	if err != nil {
+		logf(levelWarning, phaseLicense, "", err, "warning: ignoring %s: %v", name, err)
+		return "", false
+	}
+	return strings.TrimSpace(text), true
+}
+
+// normalizeText returns text as valid UTF-8 with Unix line endings. A byte
+// order mark is removed, UTF-16 (with a byte order mark) is transcoded, and
+// any bytes that aren't valid UTF-8 are taken to be Windows-1252, which
+// covers the licenses written in Latin-1. Binary data is an error.
+func normalizeText(data string) (string, error) {
+	switch {
+	case strings.HasPrefix(data, "\xef\xbb\xbf"):
+		data = data[3:]
+	case strings.HasPrefix(data, "\xff\xfe"):
+		data = decodeUTF16(data[2:], binary.LittleEndian)
+	case strings.HasPrefix(data, "\xfe\xff"):
+		data = decodeUTF16(data[2:], binary.BigEndian)
+	}
+
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if strings.IndexByte(sniff, 0) >= 0 {
+		return "", errBinary
+	}
+
+	if !utf8.ValidString(data) {
+		data = decodeWindows1252(data)
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	return strings.ReplaceAll(data, "\r", "\n"), nil
+}
+
+// isHTMLPage returns true for text that is a whole HTML document, rather
+// than a license that happens to contain some markup, such as a Markdown
+// license with an HTML comment: it is sniffed as HTML, as net/http does, and
+// has a document's <html>, <head> or <body> element, or a <script>.
+func isHTMLPage(text string) bool {
+	if !strings.HasPrefix(http.DetectContentType([]byte(text)), "text/html") {
+		return false
+	}
+	lower := strings.ToLower(text)
+	for _, tag := range []string{"<html", "<head", "<body", "<script"} {
+		if strings.Contains(lower, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeUTF16 returns UTF-16 data, without its byte order mark, as UTF-8.
+func decodeUTF16(data string, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16([]byte(data[2*i : 2*i+2]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeWindows1252 returns data as UTF-8, keeping any valid UTF-8 and
+// decoding every other byte as Windows-1252.
+func decodeWindows1252(data string) string {
+	var b strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRuneInString(data)
+		if (r == utf8.RuneError) && (size <= 1) {
+			c := data[0]
+			if (c >= 0x80) && (c < 0xA0) {
+				r = windows1252[c-0x80]
+			} else {
+				r = rune(c)
+			}
+			size = 1
+		}
+		b.WriteRune(r)
+		data = data[size:]
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package licenses
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "MIT License\n", "MIT License\n"},
+		{"BOM", "\xef\xbb\xbfMIT License\n", "MIT License\n"},
+		{"CRLF", "MIT License\r\n\r\nCopyright\r\n", "MIT License\n\nCopyright\n"},
+		{"CR", "MIT License\rCopyright\r", "MIT License\nCopyright\n"},
+		{"Latin-1", "Copyright \xa9 2001 Jos\xe9\n", "Copyright © 2001 José\n"},
+		{"Windows-1252", "\x93quoted\x94 \x96 \x80\n", "“quoted” – €\n"},
+		{"mixed", "Copyright © 2001 Jos\xe9\n", "Copyright © 2001 José\n"},
+		{"UTF-16LE", "\xff\xfeM\x00I\x00T\x00\r\x00\n\x00", "MIT\n"},
+		{"UTF-16BE", "\xfe\xff\x00M\x00I\x00T\x00\n", "MIT\n"},
+	}
+	for _, test := range tests {
+		text, err := normalizeText(test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if text != test.expected {
+			t.Errorf("%s: expected %q but got %q", test.name, test.expected, text)
+		} else if !utf8.ValidString(text) {
+			t.Errorf("%s: expected valid UTF-8 but got %q", test.name, text)
+		}
+	}
+
+	if _, err := normalizeText("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"); err != errBinary {
+		t.Errorf("expected %v but got %v", errBinary, err)
+	}
+}
+
+func TestLicenseText(t *testing.T) {
+	if text, ok := licenseText("LICENSE", "\xef\xbb\xbf  MIT License\r\n\r\n"); !ok || (text != "MIT License") {
+		t.Errorf("expected %q but got %q, %t", "MIT License", text, ok)
+	}
+	if _, ok := licenseText("LICENSE", "PK\x03\x04\x00\x00"); ok {
+		t.Errorf("expected a binary file to be ignored")
+	}
+}
+
+func TestIsHTMLPage(t *testing.T) {
+	tests := map[string]bool{
+		"<!DOCTYPE html>\n<html><head><title>Sign in</title></head><body></body></html>":           true,
+		"  <html>\n<body><noscript>Please enable JavaScript to continue.</noscript></body></html>": true,
+		"<!-- SPDX-License-Identifier: MIT -->\n<div id=app></div><script src=app.js></script>":    true,
+		"<!-- badges -->\n# MIT License\n\nCopyright (c) 2020 Example":                             false,
+		"MIT License\n\nSee <html> for details":                                                    false,
+		testMITLicense:                                                                             false,
+	}
+	for input, expected := range tests {
+		if isHTMLPage(input) != expected {
+			t.Errorf("%q: expected %t", input, expected)
+		}
+	}
+}
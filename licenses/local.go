@@ -0,0 +1,111 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// goEnvCache caches the output of "go env" for each variable
+var goEnvCache = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// goEnv returns the value of a go environment variable, such as GOMODCACHE,
+// or an empty string if it can't be determined.
+func goEnv(ctx context.Context, key string) string {
+	goEnvCache.Lock()
+	defer goEnvCache.Unlock()
+
+	if value, ok := goEnvCache.values[key]; ok {
+		return value
+	}
+
+	value := ""
+	if stdout, err := exec.CommandContext(ctx, "go", "env", key).Output(); err == nil {
+		value = strings.TrimSpace(string(stdout))
+	}
+	goEnvCache.values[key] = value
+	return value
+}
+
+// localModuleDirs returns the local directories that may hold a copy of a
+// module, in order: the module's directory in vendor, then in the module
+// cache (of its replacement, if go.mod replaces it). For the standard
+// library, it is GOROOT, and for a module replaced by a local directory,
+// that directory.
+func localModuleDirs(ctx context.Context, m Module) []string {
+	if m.Path == stdlibModule {
+		goroot := goEnv(ctx, "GOROOT")
+		if goroot == "" {
+			goroot = runtime.GOROOT()
+		}
+		return []string{goroot}
+	}
+	if m.ReplaceDir != "" {
+		return []string{m.ReplaceDir}
+	}
+
+	dirs := []string{filepath.Join("vendor", filepath.FromSlash(m.Path))}
+	src := m.source()
+	if modcache := goEnv(ctx, "GOMODCACHE"); (modcache != "") && (src.Version != "") {
+		dirs = append(dirs, filepath.Join(modcache,
+			filepath.FromSlash(escapeModulePath(src.Path))+"@"+escapeModulePath(src.Version)))
+	}
+	return dirs
+}
+
+// localLicense returns the license files of a module from a local copy,
+// without any network requests. See localModuleDirs.
+func localLicense(ctx context.Context, m Module) (licenseFile, bool) {
+	for _, dir := range localModuleDirs(ctx, m) {
+		if parts := dirLicenseParts(dir, licenseCandidates(m.Path)); len(parts) > 0 {
+			license := combineLicenseParts(parts)
+			license.Ref = m.Version
+			license.Retrieved = retrievalTime()
+			return license, true
+		}
+	}
+	return licenseFile{}, false
+}
+
+// dirLicenseParts returns the license files in a directory (but not its
+// subdirectories), in order of precedence among candidates (see
+// licenseCandidates).
+func dirLicenseParts(dir string, candidates []string) []licensePart {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	var parts []licensePart
+	for _, name := range rankLicenseFiles(names, candidates) {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		text, ok := licenseText(path, string(data))
+		if !ok {
+			continue
+		}
+		parts = append(parts, licensePart{
+			File:      name,
+			SourceURL: path,
+			Text:      text,
+		})
+	}
+	return parts
+}
@@ -0,0 +1,109 @@
+package licenses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// lockRecord is the license recorded for a module in a lock file.
+type lockRecord struct {
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+	SPDX    string `json:"spdx,omitempty"`
+	SHA256  string `json:"sha256,omitempty"` // of the license text
+}
+
+// lockFile records the license of each module, like a go.sum, so that a
+// module that is relicensed upstream, such as by a dependency bump, is
+// noticed. Modules that fail aren't updated, and records are never removed.
+type lockFile struct {
+	path    string
+	records map[string]lockRecord // by module
+	changed bool
+}
+
+// loadLock reads the lock file at path. A missing file has no records.
+func loadLock(path string) (*lockFile, error) {
+	l := &lockFile{path: path, records: make(map[string]lockRecord)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading lock file: %v", err)
+	}
+
+	var doc struct {
+		Modules []lockRecord `json:"modules"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %q: %v", path, err)
+	}
+	for _, r := range doc.Modules {
+		l.records[r.Module] = r
+	}
+	return l, nil
+}
+
+// update records a module's entry, and returns a description of how its
+// license changed since it was last recorded, if it did.
+func (l *lockFile) update(m Module, e Entry) string {
+	r := lockRecord{Module: m.Path, Version: m.Version, SPDX: e.SPDX, SHA256: textSHA256(e.License)}
+	old, ok := l.records[m.Path]
+	if ok && (old == r) {
+		return ""
+	}
+	l.records[m.Path] = r
+	l.changed = true
+
+	describe := func(r lockRecord) string {
+		spdx := r.SPDX
+		if spdx == "" {
+			spdx = spdxNoAssertion
+		}
+		if r.Version == "" {
+			return spdx
+		}
+		return fmt.Sprintf("%s at %s", spdx, r.Version)
+	}
+	switch {
+	case !ok:
+		return ""
+	case old.SPDX != r.SPDX:
+		return fmt.Sprintf("license changed from %s to %s", describe(old), describe(r))
+	case old.SHA256 != r.SHA256:
+		return fmt.Sprintf("license text changed from %s to %s", describe(old), describe(r))
+	}
+	return ""
+}
+
+// write writes the lock file, sorted by module, if any record changed.
+func (l *lockFile) write() error {
+	if !l.changed {
+		return nil
+	}
+
+	var doc struct {
+		Modules []lockRecord `json:"modules"`
+	}
+	doc.Modules = make([]lockRecord, 0, len(l.records))
+	for _, r := range l.records {
+		doc.Modules = append(doc.Modules, r)
+	}
+	sort.Slice(doc.Modules, func(i, j int) bool {
+		return doc.Modules[i].Module < doc.Modules[j].Module
+	})
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing lock file: %v", err)
+	}
+	l.changed = false
+	return nil
+}
@@ -0,0 +1,88 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gocomply.lock")
+	l, err := loadLock(path)
+	if err != nil {
+		t.Fatalf("expected a missing lock file to be empty but got %v", err)
+	}
+
+	b := Module{Path: "example.org/b", Version: "v1.0.0"}
+	a := Module{Path: "example.org/a", Version: "v1.0.0"}
+	if change := l.update(b, Entry{SPDX: "MIT", License: "License B"}); change != "" {
+		t.Errorf("expected no change but got %q", change)
+	}
+	if change := l.update(a, Entry{SPDX: "MIT", License: "License A"}); change != "" {
+		t.Errorf("expected no change but got %q", change)
+	}
+	if err := l.write(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Index(string(data), "example.org/a") > strings.Index(string(data), "example.org/b") {
+		t.Errorf("expected the lock file to be sorted by module but got:\n%s", data)
+	}
+
+	l, err = loadLock(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		module   Module
+		entry    Entry
+		expected string
+	}{
+		{Module{Path: "example.org/a", Version: "v1.1.0"}, Entry{SPDX: "MIT", License: "License A"}, ""},
+		{Module{Path: "example.org/b", Version: "v2.0.0"}, Entry{SPDX: "GPL-3.0-only", License: "License B"},
+			"license changed from MIT at v1.0.0 to GPL-3.0-only at v2.0.0"},
+		{Module{Path: "example.org/b", Version: "v2.0.0"}, Entry{SPDX: "GPL-3.0-only", License: "License B, revised"},
+			"license text changed from GPL-3.0-only at v2.0.0 to GPL-3.0-only at v2.0.0"},
+	}
+	for _, tt := range tests {
+		if change := l.update(tt.module, tt.entry); change != tt.expected {
+			t.Errorf("%s: expected %q but got %q", tt.module.Path, tt.expected, change)
+		}
+	}
+}
+
+func TestScanModulesLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gocomply.lock")
+	modules := []Module{{Path: "example.org/a", Version: "v1.0.0"}}
+
+	scan := func(text string) *runSummary {
+		lock, err := loadLock(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		o := &options{
+			Lock:      path,
+			lock:      lock,
+			overrides: overrides{"example.org/a": override{When: overrideAlways, Text: text}},
+		}
+		summary := newRunSummary()
+		if err := scanModules(context.Background(), o, modules, summary, func(e Entry) error { return nil }); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return summary
+	}
+
+	if s := scan("License A"); len(s.LicenseChanged) != 0 {
+		t.Errorf("expected no changes but got %v", s.LicenseChanged)
+	}
+	if s := scan("License A"); len(s.LicenseChanged) != 0 {
+		t.Errorf("expected no changes but got %v", s.LicenseChanged)
+	}
+	if s := scan("License A, revised"); (len(s.LicenseChanged) != 1) || !strings.Contains(s.String(), "LICENSE CHANGED: example.org/a") {
+		t.Errorf("expected a changed license but got %v", s)
+	}
+}
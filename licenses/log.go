@@ -0,0 +1,167 @@
+package licenses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Log levels
+const (
+	levelTrace   = "trace" // with -vv: the timing of each request
+	levelDebug   = "debug" // with -v: resolved repositories and each request
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelError   = "error"
+)
+
+// Log phases, describing what gocomply was doing when an event happened.
+const (
+	phaseSetup   = "setup"   // reading configuration and credentials
+	phaseModule  = "module"  // starting work on a module
+	phaseLookup  = "lookup"  // resolving a module to a repository
+	phaseLicense = "license" // fetching a license from a repository
+	phaseSummary = "summary" // reporting statistics at the end of a run
+)
+
+// logEvent is a single progress or warning message. With --log-format=json,
+// each event is written to stderr as one JSON object per line.
+type logEvent struct {
+	Level   string `json:"level"`
+	Phase   string `json:"phase,omitempty"`
+	Module  string `json:"module,omitempty"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+	Status  int    `json:"http_status,omitempty"`
+
+	Summary *runSummary `json:"summary,omitempty"`
+
+	// progress is true for a progress message, which a terminal shows on a
+	// single line that is updated in place.
+	progress bool
+}
+
+// logFormat is either "text" (default) or "json"
+var logFormat = "text"
+
+var logOutput io.Writer = os.Stderr
+
+// logProgressShown is true if the last text written to a terminal was a
+// progress message, without a newline.
+var logProgressShown bool
+
+// logMu serializes writes to logOutput, as events may be emitted from
+// several goroutines at once (see licenseProbes), and guards
+// logProgressShown.
+var logMu sync.Mutex
+
+// logVerbosity is -1 with -q (errors only), 0 by default (progress and
+// warnings), 1 with -v (also debug events) and 2 with -vv (also trace
+// events).
+var logVerbosity = 0
+
+// logEnabled returns true if events at a level are written at logVerbosity.
+func logEnabled(level string) bool {
+	switch level {
+	case levelError:
+		return true
+	case levelTrace:
+		return logVerbosity >= 2
+	case levelDebug:
+		return logVerbosity >= 1
+	default:
+		return logVerbosity >= 0
+	}
+}
+
+func validateLogFormat(format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// httpStatusError is returned for a HTTP response with an unexpected status.
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+
+	// RateLimited is true if the server was rate limiting requests, and Reset
+	// is when it expects requests to succeed again. See parseRateLimit.
+	RateLimited bool
+	Reset       time.Time
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http status code %d when downloading %q", e.StatusCode, e.URL)
+}
+
+// logf writes an event to the log. If err is not nil, it is inspected for a
+// URL and HTTP status code to attach to the event.
+func logf(level string, phase string, module string, err error, format string, args ...interface{}) {
+	ev := logEvent{
+		Level:   level,
+		Phase:   phase,
+		Module:  module,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	var statusErr *httpStatusError
+	var urlErr *url.Error
+	if errors.As(err, &statusErr) {
+		ev.URL = statusErr.URL
+		ev.Status = statusErr.StatusCode
+	} else if errors.As(err, &urlErr) {
+		ev.URL = urlErr.URL
+	}
+
+	logEmit(ev)
+}
+
+func logEmit(ev logEvent) {
+	if eventHandler != nil {
+		sendLogEvent(ev)
+		return
+	}
+	if !logEnabled(ev.Level) {
+		return
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if logFormat == "json" {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			// should never happen with a struct of strings and ints
+			panic(err)
+		}
+		fmt.Fprintf(logOutput, "%s\n", data)
+		return
+	}
+
+	if ev.progress && isTerminal(logOutput) {
+		fmt.Fprintf(logOutput, "\r\033[K%s", ev.Message)
+		logProgressShown = true
+		return
+	}
+	if logProgressShown {
+		// keep the progress message, as the context of this one
+		fmt.Fprintln(logOutput)
+		logProgressShown = false
+	}
+	if ciMode == "github" {
+		if annotation := githubAnnotation(ev); annotation != "" {
+			fmt.Fprintf(logOutput, "%s\n", annotation)
+			return
+		}
+	}
+	fmt.Fprintf(logOutput, "%s\n", ev.Message)
+}
@@ -0,0 +1,67 @@
+package licenses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	oldOutput, oldFormat := logOutput, logFormat
+	logOutput, logFormat = &buf, "json"
+	defer func() { logOutput, logFormat = oldOutput, oldFormat }()
+
+	err := fmt.Errorf("wrapped: %w", &httpStatusError{URL: "https://example.org/LICENSE", StatusCode: 404})
+	logf(levelWarning, phaseLicense, "example.org/a", err, "unable to find a license: %v", err)
+
+	var ev logEvent
+	if err := json.Unmarshal(buf.Bytes(), &ev); err != nil {
+		t.Fatalf("invalid json event %q: %v", buf.String(), err)
+	}
+
+	expected := logEvent{
+		Level:   levelWarning,
+		Phase:   phaseLicense,
+		Module:  "example.org/a",
+		Message: "unable to find a license: " + err.Error(),
+		URL:     "https://example.org/LICENSE",
+		Status:  404,
+	}
+	if ev != expected {
+		t.Errorf("expected %+v but got %+v", expected, ev)
+	}
+}
+
+func TestLogVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	oldOutput, oldVerbosity := logOutput, logVerbosity
+	logOutput = &buf
+	defer func() { logOutput, logVerbosity = oldOutput, oldVerbosity }()
+
+	logAll := func() {
+		for _, level := range []string{levelTrace, levelDebug, levelInfo, levelWarning, levelError} {
+			logf(level, "", "", nil, "%s", level)
+		}
+	}
+
+	expected := map[int]string{
+		-1: "error\n",
+		0:  "info\nwarning\nerror\n",
+		1:  "debug\ninfo\nwarning\nerror\n",
+		2:  "trace\ndebug\ninfo\nwarning\nerror\n",
+	}
+	for v, e := range expected {
+		buf.Reset()
+		logVerbosity = v
+		logAll()
+		if buf.String() != e {
+			t.Errorf("verbosity %d: expected %q but got %q", v, e, buf.String())
+		}
+	}
+
+	if _, err := (&options{Quiet: true, Verbose: true}).verbosity(); err == nil {
+		t.Errorf("expected an error for -q with -v")
+	}
+}
@@ -0,0 +1,233 @@
+package licenses
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// markupFormat returns "markdown" or "rst" for a license file written in
+// Markdown or reStructuredText, judging by its name, or an empty string.
+func markupFormat(file string) string {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".md", ".markdown":
+		return "markdown"
+	case ".rst":
+		return "rst"
+	default:
+		return ""
+	}
+}
+
+// plainText returns a license file's text with its Markdown or
+// reStructuredText formatting removed, for a report that is read as plain
+// text. Other files are unchanged.
+func plainText(file string, text string) string {
+	switch markupFormat(file) {
+	case "markdown":
+		return strings.TrimSpace(markdownToText(text))
+	case "rst":
+		return strings.TrimSpace(rstToText(text))
+	default:
+		return text
+	}
+}
+
+// combinedFileHeader matches the header of each file in a license combining
+// several files (see combineLicenseParts)
+var combinedFileHeader = regexp.MustCompile(`(?m)^==> (.+) <==\n\n`)
+
+// plainLicense removes the formatting of any Markdown or reStructuredText
+// files from a license (see plainText), keeping the original text of each
+// part as Raw.
+func plainLicense(license licenseFile) licenseFile {
+	changed := false
+	parts := make([]licensePart, len(license.Parts))
+	for i, part := range license.Parts {
+		if markupFormat(part.File) != "" {
+			part.Raw = part.Text
+			part.Text = plainText(part.File, part.Text)
+			changed = true
+		}
+		parts[i] = part
+	}
+	if !changed {
+		return license
+	}
+	license.Parts = parts
+
+	// the text combines several files, each with a header, or is one file
+	headers := combinedFileHeader.FindAllStringSubmatchIndex(license.Text, -1)
+	if len(headers) == 0 {
+		license.Text = plainText(parts[0].File, license.Text)
+		return license
+	}
+	var b strings.Builder
+	b.WriteString(license.Text[:headers[0][0]])
+	for i, h := range headers {
+		end := len(license.Text)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		file := license.Text[h[2]:h[3]]
+		body := license.Text[h[1]:end]
+		b.WriteString(license.Text[h[0]:h[1]])
+		if markupFormat(file) != "" {
+			b.WriteString(plainText(file, body))
+			if i+1 < len(headers) {
+				b.WriteString("\n\n")
+			}
+		} else {
+			b.WriteString(body)
+		}
+	}
+	license.Text = b.String()
+	return license
+}
+
+var (
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading     = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+	mdSetext      = regexp.MustCompile(`^\s{0,3}(=+|-+)\s*$`)
+	mdRule        = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	mdQuote       = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdBullet      = regexp.MustCompile(`^(\s*)[*+-]\s+`)
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(\s+"[^"]*")?\)`)
+	mdRefLink     = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	mdAutolink    = regexp.MustCompile(`<((https?|mailto):[^>\s]+)>`)
+	mdCode        = regexp.MustCompile("`([^`]+)`")
+	mdStrong      = regexp.MustCompile(`(\*\*|__)(\S(.*?\S)??)(\*\*|__)`)
+	mdEmphasis    = regexp.MustCompile(`(^|[^\w*\\])\*([^*\s]([^*]*?[^*\s\\])??)\*([^\w*]|$)`)
+	mdUnderscored = regexp.MustCompile(`(^|[^\w\\])_([^_\s]([^_]*?[^_\s\\])??)_(\W|$)`)
+	mdEscape      = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!<>])`)
+)
+
+// markdownToText removes the formatting of Markdown text: headings become
+// their text, links their text and URL, emphasis and code their content, and
+// rules and code fences are dropped.
+func markdownToText(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+
+	for i, line := range lines {
+		if mdFence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		prevText := (i > 0) && (strings.TrimSpace(lines[i-1]) != "")
+		switch {
+		case prevText && mdSetext.MatchString(line):
+			continue // underlines the heading before it
+		case mdRule.MatchString(line):
+			out = append(out, "")
+			continue
+		}
+
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdBullet.ReplaceAllString(line, "$1- ")
+		line = markdownInline(line)
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// markdownInline removes the inline formatting of a line of Markdown.
+func markdownInline(line string) string {
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdLink.ReplaceAllStringFunc(line, func(s string) string {
+		m := mdLink.FindStringSubmatch(s)
+		if (m[1] == m[2]) || (strings.TrimPrefix(m[2], "mailto:") == m[1]) {
+			return m[1]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	line = mdRefLink.ReplaceAllString(line, "$1")
+	line = mdAutolink.ReplaceAllString(line, "$1")
+	line = mdCode.ReplaceAllString(line, "$1")
+	line = mdStrong.ReplaceAllString(line, "$2")
+	for i := 0; i < 2; i++ { // as adjacent matches share a boundary
+		line = mdEmphasis.ReplaceAllString(line, "$1$2$4")
+		line = mdUnderscored.ReplaceAllString(line, "$1$2$4")
+	}
+	return mdEscape.ReplaceAllString(line, "$1")
+}
+
+var (
+	rstDirective = regexp.MustCompile(`^\.\.\s`)
+	rstLink      = regexp.MustCompile("`([^`<]+?)\\s*<([^>]+)>`__?")
+	rstRef       = regexp.MustCompile("`([^`]+)`__?")
+	rstLiteral   = regexp.MustCompile("``([^`]+)``")
+	rstRole      = regexp.MustCompile(":[a-z]+:`([^`]+)`")
+	rstStrong    = regexp.MustCompile(`\*\*(\S(.*?\S)??)\*\*`)
+	rstEmphasis  = regexp.MustCompile(`(^|[^\w*\\])\*([^*\s]([^*]*?[^*\s\\])??)\*([^\w*]|$)`)
+	rstInterpret = regexp.MustCompile("`([^`]+)`")
+)
+
+// rstToText removes the formatting of reStructuredText: section adornments,
+// directives and comments are dropped, links become their text and URL, and
+// inline markup becomes its content.
+func rstToText(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inDirective := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inDirective {
+			// a directive's or comment's content is indented
+			if (trimmed == "") || (line[0] == ' ') || (line[0] == '\t') {
+				continue
+			}
+			inDirective = false
+		}
+
+		switch {
+		case isRSTAdornment(trimmed):
+			continue
+		case rstDirective.MatchString(line) || (trimmed == ".."):
+			inDirective = true
+			continue
+		case trimmed == "::":
+			continue
+		case strings.HasSuffix(line, "::"):
+			line = strings.TrimSuffix(line, ":") // "Example::" reads "Example:"
+		}
+
+		line = rstLink.ReplaceAllStringFunc(line, func(s string) string {
+			m := rstLink.FindStringSubmatch(s)
+			if m[1] == m[2] {
+				return m[1]
+			}
+			return m[1] + " (" + m[2] + ")"
+		})
+		line = rstLiteral.ReplaceAllString(line, "$1")
+		line = rstRole.ReplaceAllString(line, "$1")
+		line = rstRef.ReplaceAllString(line, "$1")
+		line = rstStrong.ReplaceAllString(line, "$1")
+		for i := 0; i < 2; i++ { // as adjacent matches share a boundary
+			line = rstEmphasis.ReplaceAllString(line, "$1$2$4")
+		}
+		line = rstInterpret.ReplaceAllString(line, "$1")
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// isRSTAdornment returns true for a line that underlines or overlines a
+// section title: one punctuation character, repeated.
+func isRSTAdornment(line string) bool {
+	if (len(line) < 3) || !strings.ContainsRune(`=-~^"'`+"`"+`#*+:.`, rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
@@ -0,0 +1,69 @@
+package licenses
+
+import (
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		file     string
+		input    string
+		expected string
+	}{
+		{
+			"LICENSE.md",
+			"# The MIT License (MIT)\n\nCopyright (c) 2020 **Example**\n\n---\n\nPermission is *hereby* granted, see [the license](https://example.org/license) or <https://example.org>.",
+			"The MIT License (MIT)\n\nCopyright (c) 2020 Example\n\n\n\nPermission is hereby granted, see the license (https://example.org/license) or https://example.org.",
+		},
+		{
+			"LICENSE.markdown",
+			"MIT License\n===========\n\n* `a` and _b_\n* *c* *d*\n\n```\ncode *kept*\n```\n\n> quoted \\*text\\*",
+			"MIT License\n\n- a and b\n- c d\n\ncode *kept*\n\nquoted *text*",
+		},
+		{
+			"LICENSE.rst",
+			".. image:: badge.svg\n   :target: https://example.org\n\n===========\nMIT License\n===========\n\nCopyright **Example**, see `the site <https://example.org>`_ and ``LICENSE``.\n\nExample::\n\n    kept",
+			"MIT License\n\nCopyright Example, see the site (https://example.org) and LICENSE.\n\nExample:\n\n    kept",
+		},
+		{
+			"LICENSE",
+			"# Not *Markdown*",
+			"# Not *Markdown*",
+		},
+	}
+	for _, test := range tests {
+		if text := plainText(test.file, test.input); text != test.expected {
+			t.Errorf("%s: expected %q but got %q", test.file, test.expected, text)
+		}
+	}
+}
+
+func TestPlainLicense(t *testing.T) {
+	license := licenseFile{
+		Text: "==> LICENSE.md <==\n\n# License A\n\n==> NOTICE <==\n\n# Notice *B*",
+		Parts: []licensePart{
+			{File: "LICENSE.md", Text: "# License A"},
+			{File: "NOTICE", Text: "# Notice *B*"},
+		},
+	}
+	plain := plainLicense(license)
+
+	expected := "==> LICENSE.md <==\n\nLicense A\n\n==> NOTICE <==\n\n# Notice *B*"
+	if plain.Text != expected {
+		t.Errorf("expected %q but got %q", expected, plain.Text)
+	}
+	if (plain.Parts[0].Text != "License A") || (plain.Parts[0].Raw != "# License A") {
+		t.Errorf("expected the converted part to keep its raw text but got %+v", plain.Parts[0])
+	}
+	if (plain.Parts[1].Text != "# Notice *B*") || (plain.Parts[1].Raw != "") {
+		t.Errorf("expected a plain text part unchanged but got %+v", plain.Parts[1])
+	}
+	if license.Parts[0].Text != "# License A" {
+		t.Errorf("expected the original license unchanged")
+	}
+
+	single := licenseFile{Text: "**License**", Parts: []licensePart{{File: "LICENSE.md", Text: "**License**"}}}
+	if plain := plainLicense(single); plain.Text != "License" {
+		t.Errorf("expected %q but got %q", "License", plain.Text)
+	}
+}
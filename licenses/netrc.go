@@ -0,0 +1,114 @@
+package licenses
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jdxcode/netrc"
+)
+
+// netrcs are the parsed netrc files, in order of precedence. See netrcPaths.
+var netrcs []*netrc.Netrc
+
+// homeNetrcNames returns the names of the netrc files in the home directory
+// for an operating system. On Windows, the convention is "_netrc", as go
+// and curl use, but ".netrc" is also read.
+func homeNetrcNames(goos string) []string {
+	if goos == "windows" {
+		return []string{"_netrc", ".netrc"}
+	}
+	return []string{".netrc"}
+}
+
+// netrcPaths returns the netrc files to read, in order of precedence: each
+// file in the NETRC environment variable (a list separated like PATH), then
+// ~/.netrc (see homeNetrcNames), then gocomply/netrc in the user's
+// configuration directory ($XDG_CONFIG_HOME, or ~/.config).
+func netrcPaths(netrcEnv string, home string, configHome string) []string {
+	var paths []string
+	for _, path := range filepath.SplitList(netrcEnv) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	for _, name := range homeNetrcNames(runtime.GOOS) {
+		paths = append(paths, filepath.Join(home, name))
+	}
+	return append(paths, filepath.Join(configHome, "gocomply", "netrc"))
+}
+
+// loadNetrcs parses each netrc file that exists. Every file is read even if
+// an earlier one fails to parse, and the first error is returned.
+func loadNetrcs(paths []string) ([]*netrc.Netrc, error) {
+	var result []*netrc.Netrc
+	var firstErr error
+
+	for _, path := range paths {
+		n, err := netrc.Parse(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf(".netrc parse error in %q: %v", path, err)
+			}
+			continue
+		}
+		result = append(result, n)
+	}
+
+	return result, firstErr
+}
+
+// netrcAuth returns the credentials for a machine from the first netrc file
+// that has an entry for it, merging entries across every file, or nil.
+func netrcAuth(machine string) *BasicAuth {
+	for _, n := range netrcs {
+		if m := n.Machine(machine); m != nil {
+			return &BasicAuth{
+				Username: m.Get("login"),
+				Token:    m.Get("password"),
+			}
+		}
+	}
+	return nil
+}
+
+// authorizeNetrc adds the credentials of the netrc entry for the host of a
+// https request, if there is one, as go get does, and returns true if it did.
+// The entry may be for the host with its port (e.g. "git.example.org:8443")
+// or without.
+func authorizeNetrc(req *http.Request) bool {
+	if req.URL.Scheme != "https" {
+		return false
+	}
+
+	auth := netrcAuth(req.URL.Host)
+	if (auth == nil) && (req.URL.Host != req.URL.Hostname()) {
+		auth = netrcAuth(req.URL.Hostname())
+	}
+	if (auth == nil) || !auth.IsSet() {
+		return false
+	}
+	req.SetBasicAuth(auth.Username, auth.Token)
+	return true
+}
+
+func parseNetrc() error {
+	usr, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("user lookup error: %v", err)
+	}
+
+	paths := netrcPaths(os.Getenv("NETRC"), usr.HomeDir, os.Getenv("XDG_CONFIG_HOME"))
+	netrcs, err = loadNetrcs(paths)
+	return err
+}
@@ -0,0 +1,107 @@
+package licenses
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNetrcPaths(t *testing.T) {
+	env := strings.Join([]string{"/a/netrc", "", "/b/netrc"}, string(os.PathListSeparator))
+
+	var home []string
+	for _, name := range homeNetrcNames(runtime.GOOS) {
+		home = append(home, filepath.Join("/home/u", name))
+	}
+
+	got := netrcPaths(env, "/home/u", "")
+	expected := append(append([]string{"/a/netrc", "/b/netrc"}, home...),
+		filepath.Join("/home/u", ".config", "gocomply", "netrc"))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+
+	got = netrcPaths("", "/home/u", "/xdg")
+	expected = append(append([]string{}, home...), filepath.Join("/xdg", "gocomply", "netrc"))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+
+	if names := homeNetrcNames("windows"); !reflect.DeepEqual(names, []string{"_netrc", ".netrc"}) {
+		t.Errorf("expected _netrc first on Windows but got %q", names)
+	}
+}
+
+func TestNetrcMerge(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+
+	if err := os.WriteFile(first, []byte("machine github.com login a password one\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("machine github.com login b password two\nmachine gitlab.com login c password three\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old := netrcs
+	defer func() { netrcs = old }()
+
+	var err error
+	netrcs, err = loadNetrcs([]string{filepath.Join(dir, "missing"), first, second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]*BasicAuth{
+		"github.com":  {Username: "a", Token: "one"},
+		"gitlab.com":  {Username: "c", Token: "three"},
+		"example.org": nil,
+	}
+	for machine, expected := range tests {
+		if got := netrcAuth(machine); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %+v but got %+v", machine, expected, got)
+		}
+	}
+}
+
+func TestAuthorizeNetrc(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "netrc")
+	data := "machine git.example.org login a password one\nmachine git.example.net:8443 login b password two\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old := netrcs
+	defer func() { netrcs = old }()
+
+	var err error
+	netrcs, err = loadNetrcs([]string{path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]string{
+		"https://git.example.org/foo?go-get=1":   "a",
+		"https://git.example.org:8443/foo":       "a",
+		"https://git.example.net:8443/foo":       "b",
+		"https://git.example.net/foo":            "",
+		"http://git.example.org/foo":             "", // never over plain http
+		"https://example.org/git.example.org/..": "",
+	}
+	for rsc, expected := range tests {
+		req, err := http.NewRequest("GET", rsc, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authorizeNetrc(req)
+		if username, _, _ := req.BasicAuth(); username != expected {
+			t.Errorf("%s: expected login %q but got %q", rsc, expected, username)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package licenses
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source disclosure scopes: how much source code must be made available
+// when distributing (or, for network, serving) a work using the license.
+const (
+	disclosureNone    = ""
+	disclosureFile    = "file"    // modified files under the license, e.g. MPL-2.0
+	disclosureLibrary = "library" // the library, and the means to relink it, e.g. LGPL
+	disclosureProgram = "program" // the whole program, e.g. GPL
+	disclosureNetwork = "network" // the whole program, even to network users, e.g. AGPL
+)
+
+// licenseObligations summarises the standard obligations of a license, as a
+// checklist for humans. It is a rule of thumb, not legal advice.
+type licenseObligations struct {
+	SPDX string `json:"spdx"`
+
+	// Attribution requires reproducing the copyright notice and license text
+	Attribution bool `json:"attribution"`
+
+	// Notice requires reproducing any NOTICE file, e.g. Apache-2.0
+	Notice bool `json:"notice,omitempty"`
+
+	// StateChanges requires marking modified files as changed
+	StateChanges bool `json:"state_changes"`
+
+	// Disclosure is how much source code must be made available
+	Disclosure string `json:"source_disclosure,omitempty"`
+
+	// PatentGrant is true if the license grants a patent license
+	PatentGrant bool `json:"patent_grant"`
+}
+
+var knownObligations = map[string]licenseObligations{
+	"0BSD":         {},
+	"CC0-1.0":      {},
+	"MIT-0":        {},
+	"Unlicense":    {},
+	"BSD-1-Clause": {Attribution: true},
+	"BSD-2-Clause": {Attribution: true},
+	"BSD-3-Clause": {Attribution: true},
+	"BSL-1.0":      {Attribution: true},
+	"ISC":          {Attribution: true},
+	"MIT":          {Attribution: true},
+	"Zlib":         {Attribution: true, StateChanges: true},
+
+	"Apache-2.0": {Attribution: true, Notice: true, StateChanges: true, PatentGrant: true},
+
+	"CDDL-1.0":          {Attribution: true, StateChanges: true, Disclosure: disclosureFile, PatentGrant: true},
+	"EPL-1.0":           {Attribution: true, Disclosure: disclosureFile, PatentGrant: true},
+	"EPL-2.0":           {Attribution: true, Disclosure: disclosureFile, PatentGrant: true},
+	"MPL-2.0":           {Attribution: true, Disclosure: disclosureFile, PatentGrant: true},
+	"LGPL-2.1-only":     {Attribution: true, StateChanges: true, Disclosure: disclosureLibrary},
+	"LGPL-2.1-or-later": {Attribution: true, StateChanges: true, Disclosure: disclosureLibrary},
+	"LGPL-3.0-only":     {Attribution: true, StateChanges: true, Disclosure: disclosureLibrary, PatentGrant: true},
+	"LGPL-3.0-or-later": {Attribution: true, StateChanges: true, Disclosure: disclosureLibrary, PatentGrant: true},
+
+	"GPL-2.0-only":     {Attribution: true, StateChanges: true, Disclosure: disclosureProgram},
+	"GPL-2.0-or-later": {Attribution: true, StateChanges: true, Disclosure: disclosureProgram},
+	"GPL-3.0-only":     {Attribution: true, StateChanges: true, Disclosure: disclosureProgram, PatentGrant: true},
+	"GPL-3.0-or-later": {Attribution: true, StateChanges: true, Disclosure: disclosureProgram, PatentGrant: true},
+
+	"AGPL-3.0-only":     {Attribution: true, StateChanges: true, Disclosure: disclosureNetwork, PatentGrant: true},
+	"AGPL-3.0-or-later": {Attribution: true, StateChanges: true, Disclosure: disclosureNetwork, PatentGrant: true},
+}
+
+// obligationsOf returns the obligations of each known license in an SPDX
+// expression, in order, without duplicates.
+func obligationsOf(spdx string) []licenseObligations {
+	var result []licenseObligations
+	seen := make(map[string]bool)
+	for _, id := range spdxExpressionIDs(spdx) {
+		o, ok := knownObligations[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		o.SPDX = id
+		result = append(result, o)
+	}
+	return result
+}
+
+// String summarises the obligations, e.g. "attribution, state changes".
+func (o licenseObligations) String() string {
+	var items []string
+	if o.Attribution {
+		items = append(items, "attribution")
+	}
+	if o.Notice {
+		items = append(items, "include NOTICE")
+	}
+	if o.StateChanges {
+		items = append(items, "state changes")
+	}
+	if o.Disclosure != disclosureNone {
+		items = append(items, fmt.Sprintf("disclose source (%s)", o.Disclosure))
+	}
+	if len(items) == 0 {
+		items = append(items, "none")
+	}
+	if o.PatentGrant {
+		items = append(items, "patent grant")
+	}
+	return strings.Join(items, ", ")
+}
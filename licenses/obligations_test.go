@@ -0,0 +1,40 @@
+package licenses
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestObligationsOf(t *testing.T) {
+	got := obligationsOf("(Apache-2.0 OR MIT) AND Example-1.0 AND MIT")
+	if len(got) != 2 || got[0].SPDX != "Apache-2.0" || got[1].SPDX != "MIT" {
+		t.Fatalf("expected obligations for Apache-2.0 and MIT but got %+v", got)
+	}
+
+	tests := map[string]string{
+		"Apache-2.0": "attribution, include NOTICE, state changes, patent grant",
+		"MPL-2.0":    "attribution, disclose source (file), patent grant",
+		"CC0-1.0":    "none",
+	}
+	for id, expected := range tests {
+		if got := obligationsOf(id)[0].String(); got != expected {
+			t.Errorf("%s: expected %q but got %q", id, expected, got)
+		}
+	}
+}
+
+func TestTextReportObligations(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTextReportWriter(&buf, reportOptions{})
+	e := Entry{Module: "example.org/a", License: "License A", SPDX: "MIT", SPDXConfidence: 100}
+	e.Obligations = obligationsOf(e.SPDX)
+	if err := r.WriteEntry(e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "spdx: MIT (100.0% match)\nobligations (MIT): attribution\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected %q in %q", expected, buf.String())
+	}
+}
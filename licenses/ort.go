@@ -0,0 +1,228 @@
+package licenses
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ortHeader begins an ORT report, as it does an ORT result file
+const ortHeader = "---\nrepository:"
+
+// ortResult is an OSS Review Toolkit (ORT) result, with only an analyzer
+// result, in the layout of ORT's own ort-result.yml files. Each module is a
+// package of the project, with its SPDX expression as its declared license,
+// so that ORT's evaluator and reporters can use the report directly.
+type ortResult struct {
+	Repository ortRepository `yaml:"repository"`
+	Analyzer   ortAnalyzer   `yaml:"analyzer"`
+}
+
+type ortVCS struct {
+	Type     string `yaml:"type"`
+	URL      string `yaml:"url"`
+	Revision string `yaml:"revision"`
+	Path     string `yaml:"path"`
+}
+
+type ortRepository struct {
+	VCS          ortVCS                 `yaml:"vcs"`
+	VCSProcessed ortVCS                 `yaml:"vcs_processed"`
+	Config       map[string]interface{} `yaml:"config"`
+}
+
+type ortAnalyzer struct {
+	StartTime   string            `yaml:"start_time"`
+	EndTime     string            `yaml:"end_time"`
+	Environment ortEnvironment    `yaml:"environment"`
+	Config      ortAnalyzerConfig `yaml:"config"`
+	Result      ortAnalyzerResult `yaml:"result"`
+}
+
+type ortEnvironment struct {
+	ORTVersion   string            `yaml:"ort_version"`
+	JavaVersion  string            `yaml:"java_version"`
+	OS           string            `yaml:"os"`
+	Processors   int               `yaml:"processors"`
+	MaxMemory    int64             `yaml:"max_memory"`
+	Variables    map[string]string `yaml:"variables"`
+	ToolVersions map[string]string `yaml:"tool_versions"`
+}
+
+type ortAnalyzerConfig struct {
+	AllowDynamicVersions bool `yaml:"allow_dynamic_versions"`
+	SkipExcluded         bool `yaml:"skip_excluded"`
+}
+
+type ortAnalyzerResult struct {
+	Projects []ortProject `yaml:"projects"`
+	Packages []ortPackage `yaml:"packages"`
+}
+
+type ortLicensesProcessed struct {
+	SPDXExpression string `yaml:"spdx_expression,omitempty"`
+}
+
+type ortProject struct {
+	ID                        string               `yaml:"id"`
+	DefinitionFilePath        string               `yaml:"definition_file_path"`
+	DeclaredLicenses          []string             `yaml:"declared_licenses"`
+	DeclaredLicensesProcessed ortLicensesProcessed `yaml:"declared_licenses_processed"`
+	VCS                       ortVCS               `yaml:"vcs"`
+	VCSProcessed              ortVCS               `yaml:"vcs_processed"`
+	HomepageURL               string               `yaml:"homepage_url"`
+	Scopes                    []ortScope           `yaml:"scopes"`
+}
+
+type ortScope struct {
+	Name         string          `yaml:"name"`
+	Dependencies []ortDependency `yaml:"dependencies"`
+}
+
+type ortDependency struct {
+	ID string `yaml:"id"`
+}
+
+type ortRemoteArtifact struct {
+	URL  string  `yaml:"url"`
+	Hash ortHash `yaml:"hash"`
+}
+
+type ortHash struct {
+	Value     string `yaml:"value"`
+	Algorithm string `yaml:"algorithm"`
+}
+
+type ortPackage struct {
+	ID                        string               `yaml:"id"`
+	PURL                      string               `yaml:"purl"`
+	DeclaredLicenses          []string             `yaml:"declared_licenses"`
+	DeclaredLicensesProcessed ortLicensesProcessed `yaml:"declared_licenses_processed"`
+	Description               string               `yaml:"description"`
+	HomepageURL               string               `yaml:"homepage_url"`
+	BinaryArtifact            ortRemoteArtifact    `yaml:"binary_artifact"`
+	SourceArtifact            ortRemoteArtifact    `yaml:"source_artifact"`
+	VCS                       ortVCS               `yaml:"vcs"`
+	VCSProcessed              ortVCS               `yaml:"vcs_processed"`
+}
+
+// ortID returns the ORT identifier of a Go module, as ORT's GoMod package
+// manager names them.
+func ortID(kind string, path string, version string) string {
+	return kind + "::" + path + ":" + version
+}
+
+// ortModule returns the module of an ORT package identifier.
+func ortModule(id string) (string, bool) {
+	rest := strings.TrimPrefix(id, "Go::")
+	if rest == id {
+		return "", false
+	}
+	if idx := strings.LastIndexByte(rest, ':'); idx >= 0 {
+		rest = rest[:idx]
+	}
+	return rest, true
+}
+
+// ortReportWriter writes the report as an ORT analyzer result (see
+// ortResult). Entries are buffered and the result is written on Close.
+type ortReportWriter struct {
+	w         io.Writer
+	project   string
+	generated time.Time
+	entries   []Entry
+}
+
+func (r *ortReportWriter) WriteEntry(e Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *ortReportWriter) Close() error {
+	generated := r.generated.UTC().Format(time.RFC3339)
+	result := ortResult{
+		Repository: ortRepository{Config: map[string]interface{}{}},
+		Analyzer: ortAnalyzer{
+			StartTime: generated,
+			EndTime:   generated,
+			// nothing that varies between machines, for reproducible output
+			Environment: ortEnvironment{
+				ORTVersion:   "gocomply",
+				Variables:    map[string]string{},
+				ToolVersions: map[string]string{},
+			},
+		},
+	}
+
+	project := ortProject{
+		ID:                 ortID("GoMod", r.project, ""),
+		DefinitionFilePath: "go.mod",
+		DeclaredLicenses:   []string{},
+		Scopes:             []ortScope{{Name: "main", Dependencies: []ortDependency{}}},
+	}
+	packages := []ortPackage{}
+	seen := make(map[string]bool)
+	for _, e := range r.entries {
+		id := ortID("Go", e.Module, e.Version)
+		if seen[id] {
+			continue // in several products
+		}
+		seen[id] = true
+
+		pkg := ortPackage{
+			ID:               id,
+			PURL:             e.purl(),
+			DeclaredLicenses: []string{},
+			HomepageURL:      "https://pkg.go.dev/" + e.Module,
+			VCS:              ortVCS{Revision: e.Revision},
+			VCSProcessed:     ortVCS{Revision: e.Revision},
+		}
+		if e.SPDX != "" {
+			pkg.DeclaredLicenses = []string{e.SPDX}
+			pkg.DeclaredLicensesProcessed.SPDXExpression = e.SPDX
+		}
+		packages = append(packages, pkg)
+		project.Scopes[0].Dependencies = append(project.Scopes[0].Dependencies, ortDependency{ID: id})
+	}
+	result.Analyzer.Result = ortAnalyzerResult{Projects: []ortProject{project}, Packages: packages}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	if _, err := io.WriteString(r.w, b.String()); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// readModulePath returns the module path declared by a go.mod file, or an
+// empty string if it can't be read.
+func readModulePath(goMod string) string {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if (len(fields) >= 2) && (fields[0] == "module") {
+			if path, err := strconv.Unquote(fields[1]); err == nil {
+				return path
+			}
+			return fields[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package licenses
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestORTReportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	report, err := newReportWriter(&buf, reportOptions{
+		Format:    "ort",
+		Project:   "example.org/app",
+		Generated: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report.WriteEntry(Entry{Module: "example.org/a", Version: "v1.0.0", SPDX: "MIT", License: "License A"})
+	report.WriteEntry(Entry{Module: "example.org/b", Version: "v0.1.0", License: "???"})
+	report.WriteEntry(Entry{Module: "example.org/a", Version: "v1.0.0", SPDX: "MIT", License: "License A"})
+	if err := report.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if detectReportFormat(buf.Bytes()) != "ort" {
+		t.Errorf("expected the ort format to be detected in:\n%s", buf.String())
+	}
+	for _, expected := range []string{
+		"  start_time: \"2021-01-02T03:04:05Z\"\n",
+		"      - id: 'GoMod::example.org/app:'\n",
+		"      - id: Go::example.org/a:v1.0.0\n        purl: pkg:golang/example.org/a@v1.0.0\n        declared_licenses:\n          - MIT\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, buf.String())
+		}
+	}
+
+	var result ortResult
+	if err := yaml.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	packages := result.Analyzer.Result.Packages
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages but got %d", len(packages))
+	}
+	if (len(packages[1].DeclaredLicenses) != 0) || (packages[1].DeclaredLicensesProcessed.SPDXExpression != "") {
+		t.Errorf("expected no declared license for an unidentified license but got %+v", packages[1])
+	}
+	var dependencies []string
+	for _, d := range result.Analyzer.Result.Projects[0].Scopes[0].Dependencies {
+		dependencies = append(dependencies, d.ID)
+	}
+	expected := []string{"Go::example.org/a:v1.0.0", "Go::example.org/b:v0.1.0"}
+	if !reflect.DeepEqual(dependencies, expected) {
+		t.Errorf("expected %q but got %q", expected, dependencies)
+	}
+
+	path := filepath.Join(t.TempDir(), "ort-result.yml")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	modules, err := loadReportModules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !modules["example.org/a"] || !modules["example.org/b"] || (len(modules) != 2) {
+		t.Errorf("expected both modules but got %v", modules)
+	}
+}
+
+func TestReadModulePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	os.WriteFile(path, []byte("// comment\nmodule \"example.org/app\"\n\ngo 1.16\n"), 0644)
+	if m := readModulePath(path); m != "example.org/app" {
+		t.Errorf("expected %q but got %q", "example.org/app", m)
+	}
+	if m := readModulePath(filepath.Join(t.TempDir(), "go.mod")); m != "" {
+		t.Errorf("expected no module path but got %q", m)
+	}
+}
@@ -0,0 +1,146 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// osvAPI is the base URL of the OSV.dev API
+var osvAPI = "https://api.osv.dev"
+
+// osvBatch is the most queries in each request to the OSV batch API
+const osvBatch = 1000
+
+// osvQuery is one query of the OSV batch API, by package URL
+type osvQuery struct {
+	Package struct {
+		PURL string `json:"purl"`
+	} `json:"package"`
+
+	// PageToken requests the next page of the results of a query, from
+	// osvResult.NextPage.
+	PageToken string `json:"page_token,omitempty"`
+}
+
+// osvResult is the result of one query of the OSV batch API.
+type osvResult struct {
+	IDs      []string
+	NextPage string // the PageToken for more results, if any
+}
+
+// osvVulnerabilities queries the OSV.dev batch API for the known
+// vulnerabilities of each module version, returning their ids (e.g.
+// "GO-2022-0969"), by module path, for the modules that have any. A module
+// replaced by another is queried as its replacement, and one replaced by a
+// local directory, or without a version, isn't queried.
+//
+// The modules that couldn't be queried, such as when OSV.dev is down, are
+// returned in unchecked, with the last error, rather than failing the scan.
+func osvVulnerabilities(ctx context.Context, modules []Module) (vulns map[string][]string, unchecked map[string]bool, err error) {
+	var queries []osvQuery
+	var paths []string
+	for _, m := range modules {
+		src := m.source()
+		if (m.ReplaceDir != "") || (src.Version == "") {
+			continue
+		}
+		var q osvQuery
+		q.Package.PURL = purl(src.Path, src.Version)
+		queries = append(queries, q)
+		paths = append(paths, m.Path)
+	}
+
+	vulns = make(map[string][]string)
+	unchecked = make(map[string]bool)
+	for start := 0; start < len(queries); start += osvBatch {
+		end := start + osvBatch
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		// the queries of the batch with more pages of results are repeated,
+		// with their page tokens, until none have any more
+		var pending []int
+		for i := start; i < end; i++ {
+			pending = append(pending, i)
+		}
+		for len(pending) > 0 {
+			batch := make([]osvQuery, len(pending))
+			for j, i := range pending {
+				batch[j] = queries[i]
+			}
+			results, batchErr := osvQueryBatch(ctx, batch)
+			if batchErr != nil {
+				for _, i := range pending {
+					delete(vulns, paths[i])
+					unchecked[paths[i]] = true
+				}
+				err = batchErr
+				break
+			}
+
+			var next []int
+			for j, r := range results {
+				i := pending[j]
+				if len(r.IDs) > 0 {
+					vulns[paths[i]] = append(vulns[paths[i]], r.IDs...)
+				}
+				if r.NextPage != "" {
+					queries[i].PageToken = r.NextPage
+					next = append(next, i)
+				}
+			}
+			pending = next
+		}
+	}
+	return vulns, unchecked, err
+}
+
+// osvQueryBatch makes one request to the OSV batch API, returning the result
+// of each query, in order.
+func osvQueryBatch(ctx context.Context, queries []osvQuery) ([]osvResult, error) {
+	body, err := json.Marshal(struct {
+		Queries []osvQuery `json:"queries"`
+	}{queries})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", osvAPI+"/v1/querybatch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	data, err := httpDo(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("OSV query failed: %w", err)
+	}
+
+	var response struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+			NextPageToken string `json:"next_page_token"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return nil, fmt.Errorf("OSV json decode error: %v", err)
+	}
+	if len(response.Results) != len(queries) {
+		return nil, fmt.Errorf("OSV response has %d results for %d queries", len(response.Results), len(queries))
+	}
+
+	results := make([]osvResult, len(queries))
+	for i, r := range response.Results {
+		for _, v := range r.Vulns {
+			results[i].IDs = append(results[i].IDs, v.ID)
+		}
+		results[i].NextPage = r.NextPageToken
+	}
+	return results, nil
+}
@@ -0,0 +1,86 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOSVVulnerabilities(t *testing.T) {
+	var purls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Queries []osvQuery
+		}
+		if (r.URL.Path != "/v1/querybatch") || (json.NewDecoder(r.Body).Decode(&request) != nil) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		// the second page of results is given by a page token
+		var results []string
+		for _, q := range request.Queries {
+			purls = append(purls, q.Package.PURL)
+			if q.PageToken == "page2" {
+				results = append(results, `{"vulns": [{"id": "GO-2023-0002"}]}`)
+			} else if strings.HasPrefix(q.Package.PURL, "pkg:golang/example.org/fork@") {
+				results = append(results, `{"vulns": [{"id": "GO-2022-0001", "modified": "2022-01-01T00:00:00Z"}], "next_page_token": "page2"}`)
+			} else {
+				results = append(results, `{}`)
+			}
+		}
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}))
+	defer server.Close()
+
+	oldAPI, oldLimiter := osvAPI, rateLimiter
+	osvAPI, rateLimiter = server.URL, NoRateLimit
+	defer func() { osvAPI, rateLimiter = oldAPI, oldLimiter }()
+
+	modules := []Module{
+		{Path: "example.org/a", Version: "v1.0.0"},
+		{Path: "example.org/b", Version: "v1.0.0", Replace: "example.org/fork", ReplaceVersion: "v1.1.0"},
+		{Path: "example.org/c", Version: "v1.0.0", Replace: "../c", ReplaceDir: "/src/c"},
+		{Path: "example.org/d"},
+		{Path: stdlibModule, Version: "go1.21.3"},
+	}
+	vulns, unchecked, err := osvVulnerabilities(context.Background(), modules)
+	if (err != nil) || (len(unchecked) != 0) {
+		t.Fatalf("unexpected error: %v (%v)", err, unchecked)
+	}
+
+	expected := map[string][]string{"example.org/b": {"GO-2022-0001", "GO-2023-0002"}}
+	if !reflect.DeepEqual(vulns, expected) {
+		t.Errorf("expected %v but got %v", expected, vulns)
+	}
+	expectedPURLs := []string{"pkg:golang/example.org/a@v1.0.0", "pkg:golang/example.org/fork@v1.1.0", "pkg:golang/stdlib@1.21.3", "pkg:golang/example.org/fork@v1.1.0"}
+	if !reflect.DeepEqual(purls, expectedPURLs) {
+		t.Errorf("expected queries for %v but got %v", expectedPURLs, purls)
+	}
+}
+
+func TestOSVVulnerabilitiesUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	oldAPI, oldLimiter := osvAPI, rateLimiter
+	osvAPI, rateLimiter = server.URL, NoRateLimit
+	defer func() { osvAPI, rateLimiter = oldAPI, oldLimiter }()
+
+	modules := []Module{{Path: "example.org/a", Version: "v1.0.0"}, {Path: "example.org/b"}}
+	vulns, unchecked, err := osvVulnerabilities(context.Background(), modules)
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	expected := map[string]bool{"example.org/a": true}
+	if (len(vulns) != 0) || !reflect.DeepEqual(unchecked, expected) {
+		t.Errorf("expected %v unchecked but got %v (and %v)", expected, unchecked, vulns)
+	}
+}
@@ -0,0 +1,178 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// When an override applies
+const (
+	overrideAlways    = "always"  // instead of network resolution (the default)
+	overrideOnFailure = "failure" // only if network resolution fails
+)
+
+// spdxTextURL is where the canonical text of an SPDX license is fetched from
+const spdxTextURL = "https://raw.githubusercontent.com/spdx/license-list-data/main/text/%s.txt"
+
+// override assigns a module's license manually: from a local File, from a
+// URL, or as an SPDX identifier with its canonical Text (fetched from the
+// SPDX license list if not given). SPDX may also be given with a File or URL
+// to record its identifier.
+type override struct {
+	Module string `yaml:"module"`
+	File   string `yaml:"file,omitempty"`
+	URL    string `yaml:"url,omitempty"`
+	SPDX   string `yaml:"spdx,omitempty"`
+	Text   string `yaml:"text,omitempty"`
+	When   string `yaml:"when,omitempty"`
+}
+
+// overrides maps a module path to its override
+type overrides map[string]override
+
+// loadOverrides reads an overrides file: a YAML document with an
+// "overrides" list, as in the policy file. A relative File is relative to
+// the directory of the overrides file.
+func loadOverrides(path string) (overrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides: %v", err)
+	}
+
+	var doc struct {
+		Overrides []override `yaml:"overrides"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing overrides %q: %v", path, err)
+	}
+
+	result := make(overrides)
+	if err := result.add(doc.Overrides, filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("error parsing overrides %q: %v", path, err)
+	}
+	return result, nil
+}
+
+// add validates and adds overrides, resolving each relative File against
+// dir. A later override for the same module replaces an earlier one.
+func (ovs overrides) add(list []override, dir string) error {
+	for _, ov := range list {
+		if ov.Module == "" {
+			return fmt.Errorf("override without a module")
+		}
+
+		sources := 0
+		for _, s := range []string{ov.File, ov.URL, ov.Text} {
+			if s != "" {
+				sources++
+			}
+		}
+		if (sources > 1) || ((sources == 0) && (ov.SPDX == "")) {
+			return fmt.Errorf("override for %q needs exactly one of a file, a url, or an spdx id (optionally with its text)", ov.Module)
+		}
+
+		switch ov.When {
+		case "":
+			ov.When = overrideAlways
+		case overrideAlways, overrideOnFailure:
+		default:
+			return fmt.Errorf("override for %q: when must be %q or %q, not %q", ov.Module, overrideAlways, overrideOnFailure, ov.When)
+		}
+
+		if (ov.File != "") && !filepath.IsAbs(ov.File) {
+			ov.File = filepath.Join(dir, ov.File)
+		}
+		ovs[ov.Module] = ov
+	}
+	return nil
+}
+
+// saveOverride adds an override to the "overrides" list of an overrides or
+// policy file, creating either if needed, and keeping the rest of the file
+// (including comments). A File is saved relative to the file's directory.
+func saveOverride(path string, ov override) error {
+	if ov.File != "" {
+		ov.File = filepath.ToSlash(relativeTo(filepath.Dir(path), ov.File))
+	}
+
+	data, err := os.ReadFile(path)
+	if (err != nil) && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading overrides: %v", err)
+	}
+
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing overrides %q: %v", path, err)
+		}
+	} else {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("error parsing overrides %q: expected a mapping", path)
+	}
+
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "overrides" {
+			list = root.Content[i+1]
+		}
+	}
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "overrides"}, list)
+	} else if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("error parsing overrides %q: overrides must be a list", path)
+	}
+
+	var item yaml.Node
+	if err := item.Encode(ov); err != nil {
+		return err
+	}
+	list.Content = append(list.Content, &item)
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// license returns the license assigned by an override.
+func (ov override) license(ctx context.Context) (licenseFile, error) {
+	var src string
+	var text string
+	var err error
+
+	switch {
+	case ov.File != "":
+		src = ov.File
+		text, err = readLicenseSource(ctx, ov.File)
+	case ov.URL != "":
+		src = ov.URL
+		text, err = readLicenseSource(ctx, ov.URL)
+	case ov.Text != "":
+		text = strings.TrimSpace(ov.Text)
+	default:
+		src = fmt.Sprintf(spdxTextURL, ov.SPDX)
+		text, err = readLicenseSource(ctx, src)
+	}
+	if err != nil {
+		return licenseFile{}, fmt.Errorf("error reading override for module %q: %v", ov.Module, err)
+	}
+
+	return licenseFile{
+		Text:      text,
+		SourceURL: src,
+		Retrieved: retrievalTime(),
+		SPDX:      ov.SPDX,
+	}, nil
+}
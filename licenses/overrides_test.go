@@ -0,0 +1,82 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "overrides.yaml")
+	data := `overrides:
+  - module: example.org/file
+    file: LICENSE.foo
+  - module: example.org/text
+    spdx: MIT
+    text: |
+      License text
+    when: failure
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE.foo"), []byte("License foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ovs, err := loadOverrides(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	license, err := ovs["example.org/file"].license(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (license.Text != "License foo") || (license.SourceURL != filepath.Join(dir, "LICENSE.foo")) {
+		t.Errorf("unexpected license %+v", license)
+	}
+	if ovs["example.org/file"].When != overrideAlways {
+		t.Errorf("expected an override to apply always by default")
+	}
+
+	license, err = ovs["example.org/text"].license(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (license.Text != "License text") || (license.SPDX != "MIT") {
+		t.Errorf("unexpected license %+v", license)
+	}
+}
+
+func TestOverridesValidate(t *testing.T) {
+	invalid := [][]override{
+		{{File: "LICENSE"}},
+		{{Module: "example.org/a"}},
+		{{Module: "example.org/a", File: "LICENSE", URL: "https://example.org/LICENSE"}},
+		{{Module: "example.org/a", SPDX: "MIT", When: "sometimes"}},
+	}
+	for _, list := range invalid {
+		if err := make(overrides).add(list, ""); err == nil {
+			t.Errorf("expected an error for %+v", list)
+		}
+	}
+}
+
+func TestScanModuleOverride(t *testing.T) {
+	o := &options{overrides: overrides{
+		"example.org/always": {Module: "example.org/always", SPDX: "MIT", Text: "License", When: overrideAlways},
+	}}
+
+	entry, err := scanModule(context.Background(), Module{Path: "example.org/always", Version: "v1.0.0"}, o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Entry{Module: "example.org/always", Version: "v1.0.0", PURL: "pkg:golang/example.org/always@v1.0.0", License: "License", SPDX: "MIT"}
+	if fmt.Sprintf("%+v", entry) != fmt.Sprintf("%+v", expected) {
+		t.Errorf("expected %+v but got %+v", expected, entry)
+	}
+}
@@ -0,0 +1,275 @@
+package licenses
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPolicyFile is read, if it exists, when no --policy is given, and
+// otherwise defaultTOMLPolicyFile is read if that exists
+const (
+	defaultPolicyFile     = ".gocomply.yaml"
+	defaultTOMLPolicyFile = "gocomply.toml"
+)
+
+// policy is a license policy: which SPDX licenses are allowed or denied,
+// with exceptions for specific modules. It is read from a YAML file like the
+// following, or from the same settings in TOML:
+//
+//	allow: [MIT, BSD-3-Clause, Apache-2.0]
+//	deny: [AGPL-3.0-only]
+//	unknown: deny
+//	exceptions:
+//	  - module: example.org/foo
+//	    spdx: GPL-2.0-only
+//	    reason: used only by an internal tool
+//	project:
+//	  license: MIT
+//	  distribution: binary
+//	proxy:
+//	  url: https://athens.example.org
+//	  rewrite:
+//	    - prefix: corp.example.org/
+//	      replace: ""
+//	overrides:
+//	  - module: git.example.org/foo
+//	    file: third_party/foo/LICENSE
+//	gitlab:
+//	  hosts: [gitlab.example.org]
+//	exclude: [corp.example.org/*]
+//	forges:
+//	  git.example.org: gitea
+//	report:
+//	  format: json
+//	  checksums: true
+//	auth:
+//	  ssh: false
+type policy struct {
+	// Allow lists the allowed SPDX license identifiers. If it is empty, any
+	// license that isn't denied is allowed.
+	Allow []string `yaml:"allow"`
+
+	// Deny lists denied SPDX license identifiers.
+	Deny []string `yaml:"deny"`
+
+	// Unknown is "deny" (the default) or "allow": whether a module is allowed
+	// if its license couldn't be found or identified.
+	Unknown string `yaml:"unknown"`
+
+	Exceptions []policyException `yaml:"exceptions"`
+
+	// Project is the project's own license, for the compat command.
+	Project projectLicense `yaml:"project"`
+
+	// Proxy configures fetching licenses from a module proxy.
+	Proxy proxyConfig `yaml:"proxy"`
+
+	// Overrides assign the licenses of specific modules manually.
+	Overrides []override `yaml:"overrides"`
+
+	// Requests configures the delays and timeout of requests.
+	Requests requestConfig `yaml:"requests"`
+
+	// GitLab lists self-hosted GitLab instances.
+	GitLab gitlabConfig `yaml:"gitlab"`
+
+	// Exclude and Only skip modules, as --exclude and --only do.
+	Exclude []string `yaml:"exclude"`
+	Only    []string `yaml:"only"`
+
+	// Forges gives the layout (gitea, gitlab, cgit or gogs) of the forges on
+	// other hosts, by host.
+	Forges map[string]string `yaml:"forges"`
+
+	// Report sets the defaults of the report options.
+	Report reportConfig `yaml:"report"`
+
+	// Auth sets which credentials and fallbacks may be used.
+	Auth authConfig `yaml:"auth"`
+}
+
+// policyException allows a module regardless of the policy: under any
+// license, or only under the given SPDX expression.
+type policyException struct {
+	Module string `yaml:"module"`
+	SPDX   string `yaml:"spdx"`
+	Reason string `yaml:"reason"`
+}
+
+// findPolicy returns the policy file read when no --policy is given:
+// defaultPolicyFile, or defaultTOMLPolicyFile if only that exists.
+func findPolicy() string {
+	if _, err := os.Stat(defaultPolicyFile); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(defaultTOMLPolicyFile); err == nil {
+			return defaultTOMLPolicyFile
+		}
+	}
+	return defaultPolicyFile
+}
+
+// isTOML returns true if a policy file is TOML rather than YAML.
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// loadPolicy reads a policy file. If path is empty, the file returned by
+// findPolicy is read if it exists, and otherwise there is no policy (a nil
+// result).
+func loadPolicy(path string) (*policy, error) {
+	optional := false
+	if path == "" {
+		path, optional = findPolicy(), true
+	}
+
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading policy: %v", err)
+	}
+
+	if isTOML(path) {
+		data, err = tomlToYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing policy %q: %v", path, err)
+		}
+	}
+
+	var p policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("error parsing policy %q: %v", path, err)
+	}
+
+	switch p.Unknown {
+	case "":
+		p.Unknown = "deny"
+	case "allow", "deny":
+	default:
+		return nil, fmt.Errorf("error parsing policy %q: unknown must be \"allow\" or \"deny\", not %q", path, p.Unknown)
+	}
+	if err := make(overrides).add(p.Overrides, ""); err != nil {
+		return nil, fmt.Errorf("error parsing policy %q: %v", path, err)
+	}
+	for _, patterns := range [][]string{p.Exclude, p.Only} {
+		if _, err := parsePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("error parsing policy %q: %v", path, err)
+		}
+	}
+	if err := validateForges(p.Forges); err != nil {
+		return nil, fmt.Errorf("error parsing policy %q: %v", path, err)
+	}
+	for _, e := range p.Exceptions {
+		if e.Module == "" {
+			return nil, fmt.Errorf("error parsing policy %q: exception without a module", path)
+		}
+	}
+
+	return &p, nil
+}
+
+// tomlToYAML converts a TOML policy to YAML, so that it is decoded (and
+// validated) the same way, with the same names, as a YAML policy.
+func tomlToYAML(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// exempt returns true if an exception allows a module with an SPDX
+// expression (empty if unknown).
+func (p *policy) exempt(module string, spdx string) bool {
+	for _, e := range p.Exceptions {
+		if (e.Module == module) && ((e.SPDX == "") || (e.SPDX == spdx)) {
+			return true
+		}
+	}
+	return false
+}
+
+// violation returns a description of how an entry violates the policy, or
+// an empty string if it is allowed.
+func (p *policy) violation(e Entry) string {
+	if p.exempt(e.Module, e.SPDX) {
+		return ""
+	}
+	if e.SPDX == "" {
+		if p.Unknown == "allow" {
+			return ""
+		}
+		return "license unknown"
+	}
+
+	// An expression is allowed if any OR alternative is allowed, and an
+	// alternative is allowed if all of its licenses are.
+	var reasons []string
+	for _, alternative := range splitSPDXOr(e.SPDX) {
+		reason := ""
+		for _, id := range spdxExpressionIDs(alternative) {
+			if reason = p.licenseViolation(id); reason != "" {
+				break
+			}
+		}
+		if reason == "" {
+			return ""
+		}
+		reasons = append(reasons, reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// missing returns a description of how a module without a license violates
+// the policy, or an empty string if it is allowed.
+func (p *policy) missing(module string) string {
+	if (p.Unknown == "allow") || p.exempt(module, "") {
+		return ""
+	}
+	return "no license found"
+}
+
+// licenseViolation returns why a single SPDX identifier is not allowed, or
+// an empty string if it is.
+func (p *policy) licenseViolation(id string) string {
+	for _, denied := range p.Deny {
+		if strings.EqualFold(id, denied) {
+			return fmt.Sprintf("license %s is denied", id)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return ""
+	}
+	for _, allowed := range p.Allow {
+		if strings.EqualFold(id, allowed) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("license %s is not allowed", id)
+}
+
+// splitSPDXOr splits an SPDX expression into its OR alternatives. Only OR
+// operators outside of parentheses split it, so "(A OR B) AND C" is a single
+// alternative.
+func splitSPDXOr(expr string) []string {
+	var alternatives []string
+	depth, start := 0, 0
+	words := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	for i, word := range words {
+		switch {
+		case word == "(":
+			depth++
+		case word == ")":
+			depth--
+		case (depth == 0) && strings.EqualFold(word, "OR"):
+			alternatives = append(alternatives, strings.Join(words[start:i], " "))
+			start = i + 1
+		}
+	}
+	return append(alternatives, strings.Join(words[start:], " "))
+}
@@ -0,0 +1,96 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := `allow: [MIT, Apache-2.0]
+deny: [AGPL-3.0-only]
+exceptions:
+  - module: example.org/gpl
+    spdx: GPL-2.0-only
+    reason: internal tool
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := loadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &policy{
+		Allow:   []string{"MIT", "Apache-2.0"},
+		Deny:    []string{"AGPL-3.0-only"},
+		Unknown: "deny",
+		Exceptions: []policyException{
+			{Module: "example.org/gpl", SPDX: "GPL-2.0-only", Reason: "internal tool"},
+		},
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("expected %+v but got %+v", expected, p)
+	}
+
+	if err := os.WriteFile(path, []byte("unknown: maybe\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(path); err == nil {
+		t.Errorf("expected an error for an invalid unknown")
+	}
+}
+
+func TestPolicyViolation(t *testing.T) {
+	p := &policy{
+		Allow:   []string{"MIT", "Apache-2.0", "BSD-3-Clause"},
+		Deny:    []string{"AGPL-3.0-only"},
+		Unknown: "deny",
+		Exceptions: []policyException{
+			{Module: "example.org/gpl", SPDX: "GPL-2.0-only"},
+			{Module: "example.org/anything"},
+		},
+	}
+
+	tests := []struct {
+		module   string
+		spdx     string
+		expected string
+	}{
+		{"example.org/a", "MIT", ""},
+		{"example.org/a", "Apache-2.0 AND MIT", ""},
+		{"example.org/a", "GPL-2.0-only OR MIT", ""},
+		{"example.org/a", "Apache-2.0 AND GPL-2.0-only", "license GPL-2.0-only is not allowed"},
+		{"example.org/a", "AGPL-3.0-only", "license AGPL-3.0-only is denied"},
+		{"example.org/a", "", "license unknown"},
+		{"example.org/gpl", "GPL-2.0-only", ""},
+		{"example.org/gpl", "GPL-3.0-only", "license GPL-3.0-only is not allowed"},
+		{"example.org/anything", "", ""},
+	}
+
+	for _, tt := range tests {
+		got := p.violation(Entry{Module: tt.module, SPDX: tt.spdx})
+		if got != tt.expected {
+			t.Errorf("%s %q: expected %q but got %q", tt.module, tt.spdx, tt.expected, got)
+		}
+	}
+
+	if got := p.missing("example.org/a"); got == "" {
+		t.Errorf("expected a missing license to violate the policy")
+	}
+	if got := p.missing("example.org/anything"); got != "" {
+		t.Errorf("expected an exception to allow a missing license, but got %q", got)
+	}
+}
+
+func TestSplitSPDXOr(t *testing.T) {
+	expected := []string{"MIT", "( Apache-2.0 OR BSD-3-Clause ) AND ISC"}
+	got := splitSPDXOr("MIT OR (Apache-2.0 OR BSD-3-Clause) AND ISC")
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+}
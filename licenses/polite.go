@@ -0,0 +1,202 @@
+package licenses
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter decides when a request to a host may be made. Wait is called
+// before every remote request and blocks until that request is allowed, or
+// returns ctx.Err() if ctx is done first.
+//
+// The default is a PoliteRateLimiter. Embedders running against their own
+// forges can set Options.RateLimiter to NoRateLimit to disable delays
+// entirely, or to their own implementation to apply a quota system.
+//
+// Wait may be called concurrently.
+type RateLimiter interface {
+	Wait(ctx context.Context, host string) error
+}
+
+// RateLimitObserver is optionally implemented by a RateLimiter that adapts
+// to the rate limit headers in each response from a host.
+type RateLimitObserver interface {
+	Observe(host string, resp *http.Response)
+}
+
+// rateLimiter is the RateLimiter used by httpGet, replaced during Scan by
+// Options.RateLimiter, if set.
+var rateLimiter RateLimiter = NewPoliteRateLimiter(DefaultPoliteDelay, map[string]time.Duration{
+	// rate limit is 5000/hour once authenticated - as low as 60/hour when
+	// anonymous! Requests are paced from its rate limit headers instead.
+	"api.github.com": 0,
+})
+
+// rateLimitHost returns the host that a URL is rate limited by.
+func rateLimitHost(rsc string) string {
+	host := rsc
+	if u, err := url.Parse(rsc); err == nil {
+		host = u.Hostname()
+	}
+	return strings.ToLower(host)
+}
+
+// waitForHost waits for permission from rateLimiter to request a URL.
+func waitForHost(ctx context.Context, rsc string) error {
+	return rateLimiter.Wait(ctx, rateLimitHost(rsc))
+}
+
+// observeHost passes a response to rateLimiter, if it is a
+// RateLimitObserver.
+func observeHost(rsc string, resp *http.Response) {
+	if o, ok := rateLimiter.(RateLimitObserver); ok {
+		o.Observe(rateLimitHost(rsc), resp)
+	}
+}
+
+// noRateLimit is a RateLimiter that never waits.
+type noRateLimit struct{}
+
+func (noRateLimit) Wait(ctx context.Context, host string) error {
+	return ctx.Err()
+}
+
+// NoRateLimit is a RateLimiter that never waits.
+var NoRateLimit RateLimiter = noRateLimit{}
+
+// DefaultPoliteDelay is the minimum time between requests to the same host.
+const DefaultPoliteDelay = 1 * time.Second
+
+// A PoliteRateLimiter paces requests once fewer than 1/rateLimitReserve of
+// a host's rate limit remain, but never waits longer than maxRateLimitWait
+// for a request. Past that, requests are made anyway and fail as rate
+// limited, so that the summary can say when to try again.
+const (
+	rateLimitReserve = 10
+	maxRateLimitWait = 2 * time.Minute
+)
+
+// PoliteRateLimiter spaces out requests to each host, to be a good citizen.
+//
+// It is also a RateLimitObserver. The delay for a host increases when the
+// rate limit headers of its responses say that few requests remain, and
+// doubles each time the host rate limits a request (as with GitHub's
+// secondary rate limits).
+type PoliteRateLimiter struct {
+	delay  time.Duration
+	delays map[string]time.Duration // overrides delay for specific hosts
+
+	mu      sync.Mutex
+	next    map[string]time.Time     // earliest time of the next request to each host
+	pace    map[string]time.Duration // adaptive delay for each host, if more than its delay
+	backoff map[string]time.Duration // delay after each host last rate limited a request
+}
+
+// NewPoliteRateLimiter returns a PoliteRateLimiter that waits at least delay
+// between requests to the same host, or the duration in delays for that host
+// (given in lower case) if present.
+func NewPoliteRateLimiter(delay time.Duration, delays map[string]time.Duration) *PoliteRateLimiter {
+	return &PoliteRateLimiter{
+		delay:   delay,
+		delays:  delays,
+		next:    make(map[string]time.Time),
+		pace:    make(map[string]time.Duration),
+		backoff: make(map[string]time.Duration),
+	}
+}
+
+// Wait blocks until a request to host is allowed, and reserves the slot for
+// that request. If ctx is done first, the slot is still reserved.
+func (p *PoliteRateLimiter) Wait(ctx context.Context, host string) error {
+	delay, ok := p.delays[host]
+	if !ok {
+		delay = p.delay
+	}
+
+	p.mu.Lock()
+	if pace := p.pace[host]; pace > delay {
+		delay = pace
+	}
+	now := time.Now()
+	start := p.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	p.next[host] = start.Add(delay)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Observe adapts the delay for a host to the rate limit headers of a
+// response from it. See PoliteRateLimiter.
+func (p *PoliteRateLimiter) Observe(host string, resp *http.Response) {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if limited, reset := parseRateLimit(resp, now); limited {
+		backoff := 2 * p.backoff[host]
+		if backoff < DefaultPoliteDelay {
+			backoff = DefaultPoliteDelay
+		}
+		if backoff > maxRateLimitWait {
+			backoff = maxRateLimitWait
+		}
+		p.backoff[host] = backoff
+		p.pace[host] = backoff
+
+		if reset.Sub(now) <= maxRateLimitWait && reset.After(p.next[host]) {
+			p.next[host] = reset
+		}
+		return
+	}
+	delete(p.backoff, host)
+
+	remaining, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	limit, err2 := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	reset, err3 := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if (err1 != nil) || (err2 != nil) || (err3 != nil) {
+		delete(p.pace, host)
+		return
+	}
+
+	// spread the remaining requests until the reset
+	pace := time.Duration(0)
+	if remaining*rateLimitReserve < limit {
+		pace = time.Until(time.Unix(reset, 0)) / time.Duration(remaining+1)
+		if pace > maxRateLimitWait {
+			pace = 0
+		}
+	}
+	if pace > 0 {
+		p.pace[host] = pace
+	} else {
+		delete(p.pace, host)
+	}
+}
+
+// requestConfig configures the delays between requests to each host, the
+// time limit for each request and the contact in the User-Agent, in the
+// policy file. Unset fields keep their defaults, and command-line options
+// take precedence.
+type requestConfig struct {
+	Delay       *time.Duration           `yaml:"delay"`
+	GitHubDelay *time.Duration           `yaml:"github_delay"`
+	Timeout     *time.Duration           `yaml:"timeout"`
+	Contact     *string                  `yaml:"contact"`
+	Hosts       map[string]time.Duration `yaml:"hosts"` // delay for specific hosts
+}
@@ -0,0 +1,161 @@
+package licenses
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPoliteRateLimiterPerHost(t *testing.T) {
+	p := NewPoliteRateLimiter(time.Hour, map[string]time.Duration{
+		"a.example.org": 50 * time.Millisecond,
+	})
+
+	start := time.Now()
+	p.Wait(context.Background(), "a.example.org")
+	p.Wait(context.Background(), "b.example.org") // a different host doesn't wait
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("expected the first request to each host not to wait, but waited %s", elapsed)
+	}
+
+	p.Wait(context.Background(), "a.example.org")
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected a second request to the same host to wait, but waited %s", elapsed)
+	}
+}
+
+func TestPoliteRateLimiterCancel(t *testing.T) {
+	p := NewPoliteRateLimiter(time.Hour, nil)
+	p.Wait(context.Background(), "a.example.org")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Wait(ctx, "a.example.org"); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to stop with its context, but waited %s", elapsed)
+	}
+}
+
+type recordingRateLimiter struct {
+	hosts []string
+}
+
+func (r *recordingRateLimiter) Wait(ctx context.Context, host string) error {
+	r.hosts = append(r.hosts, host)
+	return nil
+}
+
+func TestWaitForHost(t *testing.T) {
+	r := &recordingRateLimiter{}
+	old := rateLimiter
+	rateLimiter = r
+	defer func() { rateLimiter = old }()
+
+	waitForHost(context.Background(), "https://Example.ORG:8443/a/b")
+	if len(r.hosts) != 1 || r.hosts[0] != "example.org" {
+		t.Errorf("expected a wait for example.org but got %q", r.hosts)
+	}
+}
+
+func rateLimitResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestPoliteRateLimiterObserve(t *testing.T) {
+	p := NewPoliteRateLimiter(0, nil)
+	reset := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+
+	// plenty remaining: no delay
+	p.Observe("api.github.com", rateLimitResponse(200, map[string]string{
+		"X-RateLimit-Limit":     "5000",
+		"X-RateLimit-Remaining": "4999",
+		"X-RateLimit-Reset":     reset,
+	}))
+	if pace := p.pace["api.github.com"]; pace != 0 {
+		t.Errorf("expected no delay with plenty of requests remaining but got %s", pace)
+	}
+
+	// few remaining: the rest are spread until the reset
+	p.Observe("api.github.com", rateLimitResponse(200, map[string]string{
+		"X-RateLimit-Limit":     "5000",
+		"X-RateLimit-Remaining": "59",
+		"X-RateLimit-Reset":     reset,
+	}))
+	if pace := p.pace["api.github.com"]; (pace < 900*time.Millisecond) || (pace > time.Second) {
+		t.Errorf("expected a delay of about a second but got %s", pace)
+	}
+
+	// a secondary rate limit holds requests until Retry-After and backs off
+	p.Observe("api.github.com", rateLimitResponse(403, map[string]string{
+		"Retry-After": "30",
+	}))
+	if next := time.Until(p.next["api.github.com"]); (next < 29*time.Second) || (next > 30*time.Second) {
+		t.Errorf("expected the next request in 30s but got %s", next)
+	}
+	first := p.backoff["api.github.com"]
+	p.Observe("api.github.com", rateLimitResponse(403, map[string]string{
+		"Retry-After": "30",
+	}))
+	if second := p.backoff["api.github.com"]; second != 2*first {
+		t.Errorf("expected the backoff to double from %s but got %s", first, second)
+	}
+
+	// a limit that resets too far in the future doesn't hold requests
+	p = NewPoliteRateLimiter(0, nil)
+	p.Observe("api.github.com", rateLimitResponse(403, map[string]string{
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+	}))
+	if next := p.next["api.github.com"]; !next.IsZero() {
+		t.Errorf("expected requests not to be held for an hour, but got %s", next)
+	}
+}
+
+func TestConfigureRequests(t *testing.T) {
+	oldLimiter, oldTimeout, oldContact := rateLimiter, httpTimeout, httpContact
+	defer func() { rateLimiter, httpTimeout, httpContact = oldLimiter, oldTimeout, oldContact }()
+
+	delay, timeout, contact := 2*time.Second, 30*time.Second, "ops@example.org"
+	o := &options{
+		Delay:   DefaultPoliteDelay,
+		Timeout: 5 * time.Second,
+		set:     map[string]bool{"timeout": true},
+		policy: &policy{Requests: requestConfig{
+			Delay:   &delay,
+			Timeout: &timeout,
+			Contact: &contact,
+			Hosts:   map[string]time.Duration{"Git.Internal": 0},
+		}},
+	}
+	if err := o.configureRequests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := rateLimiter.(*PoliteRateLimiter)
+	if p.delay != delay {
+		t.Errorf("expected the policy's delay %s but got %s", delay, p.delay)
+	}
+	if d, ok := p.delays["git.internal"]; !ok || (d != 0) {
+		t.Errorf("expected no delay for git.internal but got (%s, %t)", d, ok)
+	}
+	if httpTimeout != 5*time.Second {
+		t.Errorf("expected the command-line timeout to take precedence but got %s", httpTimeout)
+	}
+	if httpContact != contact {
+		t.Errorf("expected %q but got %q", contact, httpContact)
+	}
+
+	o.Timeout = 0
+	if err := o.configureRequests(); err == nil {
+		t.Errorf("expected an error for a zero timeout")
+	}
+}
@@ -0,0 +1,188 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadReportModules reads an existing report, in any report format, and
+// returns the set of modules it has an entry for.
+func loadReportModules(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading existing report: %v", err)
+	}
+
+	var modules []string
+	switch detectReportFormat(data) {
+	case "json":
+		var report jsonReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("error parsing existing report %q: %v", path, err)
+		}
+		for _, e := range report.Entries {
+			modules = append(modules, e.Module)
+		}
+	case "text":
+		modules = parseTextReportModules(data)
+	case "ort":
+		var result ortResult
+		if err := yaml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("error parsing existing report %q: %v", path, err)
+		}
+		for _, p := range result.Analyzer.Result.Packages {
+			if m, ok := ortModule(p.ID); ok {
+				modules = append(modules, m)
+			}
+		}
+	case "gosource":
+		return nil, fmt.Errorf("existing report %q: the gosource format can't be read", path)
+	default:
+		modules, err = parseListReportModules(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing existing report %q: %v", path, err)
+		}
+	}
+
+	set := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		set[m] = true
+	}
+	return set, nil
+}
+
+// detectReportFormat returns the format of an existing report: "json",
+// "text", "list", "gosource" or "ort".
+func detectReportFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		return "json"
+	case bytes.HasPrefix(data, []byte(goSourceHeader)):
+		return "gosource"
+	case bytes.HasPrefix(data, []byte(ortHeader)):
+		return "ort"
+	case bytes.Contains(data, []byte(divider)):
+		return "text"
+	default:
+		return "list"
+	}
+}
+
+// reportTimes are the timestamps written in an existing report.
+type reportTimes struct {
+	generated time.Time         // zero if the report has none
+	retrieved map[string]string // by module, with --provenance
+}
+
+// parseReportTimes returns the timestamps in an existing report, in any
+// report format: when a structured report was generated, and when the
+// license of each entry was retrieved. Anything that can't be read is left
+// out.
+func parseReportTimes(data []byte) reportTimes {
+	times := reportTimes{retrieved: make(map[string]string)}
+	switch detectReportFormat(data) {
+	case "json":
+		var report jsonReport
+		if json.Unmarshal(data, &report) == nil {
+			times.generated, _ = time.Parse(time.RFC3339, report.Generated)
+			for _, e := range report.Entries {
+				if e.Retrieved != "" {
+					times.retrieved[e.Module] = e.Retrieved
+				}
+			}
+		}
+	case "ort":
+		var result ortResult
+		if yaml.Unmarshal(data, &result) == nil {
+			times.generated, _ = time.Parse(time.RFC3339, result.Analyzer.StartTime)
+		}
+	case "text":
+		walkTextReport(data, func(module string, line string) {
+			if strings.HasPrefix(line, "retrieved: ") {
+				times.retrieved[module] = strings.TrimPrefix(line, "retrieved: ")
+			}
+		})
+	}
+	return times
+}
+
+// parseTextReportModules returns the module of each entry in a text report.
+// Each entry begins with its module, at the start of the report or after the
+// divider ending the previous entry, ignoring any product section headings
+// and the trailer, and stopping at the first appendix.
+func parseTextReportModules(data []byte) []string {
+	return walkTextReport(data, nil)
+}
+
+// walkTextReport returns the module of each entry in a text report, as for
+// parseTextReportModules, and passes every other line of each entry to
+// entryLine, if given.
+func walkTextReport(data []byte, entryLine func(module string, line string)) []string {
+	var modules []string
+	rule := strings.Repeat("=", len(divider))
+	expectModule := true
+	inHeading := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == divider:
+			expectModule = true
+		case line == rule:
+			inHeading = !inHeading
+		case inHeading && strings.HasPrefix(line, "Appendix:"):
+			return modules
+		case inHeading || (line == ""):
+			// part of a heading or an entry
+		case !expectModule:
+			if entryLine != nil {
+				entryLine(modules[len(modules)-1], line)
+			}
+		case strings.HasPrefix(line, trailerPrefix):
+			// end of report
+		case strings.HasPrefix(line, stdlibName):
+			modules = append(modules, stdlibModule)
+			expectModule = false
+		default:
+			modules = append(modules, line)
+			expectModule = false
+		}
+	}
+	return modules
+}
+
+// parseListReportModules returns the module of each line of a list report.
+func parseListReportModules(data []byte) ([]string, error) {
+	var modules []string
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected \"module version SPDX-id source-url\"", i+1)
+		}
+		modules = append(modules, fields[0])
+	}
+	return modules, nil
+}
+
+// newModules returns the modules not in an existing report.
+func newModules(modules []Module, existing map[string]bool) []Module {
+	var result []Module
+	for _, m := range modules {
+		if !existing[m.Path] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package licenses
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadReportModules(t *testing.T) {
+	entries := []Entry{
+		{Module: "example.org/a", License: "License A\n\nexample.org/not-a-module", SPDX: "MIT"},
+		{Module: "example.org/b", License: "License B"},
+		{Module: stdlibModule, Version: "go1.21.3", Name: stdlibLabel("go1.21.3"), License: "License Go"},
+	}
+
+	for _, opts := range []reportOptions{
+		{Format: "text", Trailer: true},
+		{Format: "json"},
+		{Format: "list"},
+	} {
+		var buf bytes.Buffer
+		r, err := newReportWriter(&buf, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tr, ok := r.(*textReportWriter); ok {
+			if err := tr.WriteSection("product"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, e := range entries {
+			if err := r.WriteEntry(e); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		path := filepath.Join(t.TempDir(), "report")
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := loadReportModules(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", opts.Format, err)
+		}
+		expected := map[string]bool{"example.org/a": true, "example.org/b": true, stdlibModule: true}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %v but got %v", opts.Format, expected, got)
+		}
+	}
+}
+
+func TestNewModules(t *testing.T) {
+	modules := []Module{{Path: "example.org/a"}, {Path: "example.org/b"}, {Path: stdlibModule}}
+	got := newModules(modules, map[string]bool{"example.org/a": true, stdlibModule: true})
+
+	expected := []Module{{Path: "example.org/b"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+}
@@ -0,0 +1,114 @@
+package licenses
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+)
+
+// matchPrefixPatterns returns true if any of a comma-separated list of glob
+// patterns, as in GOPRIVATE, matches a module path or one of its prefixes, as
+// go does: a pattern with n path elements is matched against the first n
+// elements of the module path, so "*.example.org" matches
+// "git.example.org/foo/bar".
+func matchPrefixPatterns(globs string, module string) bool {
+	for _, glob := range strings.Split(globs, ",") {
+		glob = strings.TrimSuffix(strings.TrimSpace(glob), "/")
+		if glob == "" {
+			continue
+		}
+
+		n := strings.Count(glob, "/")
+		prefix := module
+		for i := 0; i < len(module); i++ {
+			if module[i] == '/' {
+				if n == 0 {
+					prefix = module[:i]
+					break
+				}
+				n--
+			}
+		}
+		if n > 0 {
+			continue // the module path has fewer elements than the pattern
+		}
+		if matched, _ := path.Match(glob, prefix); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// privatePatterns are the go environment's patterns of private modules,
+// which are never looked up publicly, and of modules that are never fetched
+// from a module proxy. go env defaults both GONOPROXY and GONOSUMDB to
+// GOPRIVATE.
+type privatePatterns struct {
+	Private string // GOPRIVATE, and GONOSUMDB if set
+	NoProxy string // GONOPROXY
+}
+
+// loadPrivatePatterns reads the patterns from go env.
+func loadPrivatePatterns(ctx context.Context) privatePatterns {
+	private := goEnv(ctx, "GOPRIVATE")
+	if nosumdb := goEnv(ctx, "GONOSUMDB"); nosumdb != "" {
+		private = strings.Trim(private+","+nosumdb, ",")
+	}
+	return privatePatterns{
+		Private: private,
+		NoProxy: goEnv(ctx, "GONOPROXY"),
+	}
+}
+
+// isPrivate returns true if a module matches GOPRIVATE or GONOSUMDB.
+func (p privatePatterns) isPrivate(module string) bool {
+	return matchPrefixPatterns(p.Private, module)
+}
+
+// noProxy returns true if a module matches GONOPROXY.
+func (p privatePatterns) noProxy(module string) bool {
+	return matchPrefixPatterns(p.NoProxy, module)
+}
+
+// checkedHosts are the hosts of private modules that checkPrivateCredentials
+// has already checked.
+var checkedHosts = struct {
+	sync.Mutex
+	hosts map[string]bool
+}{hosts: make(map[string]bool)}
+
+// hostCredentials returns true if there are credentials for a host, asking
+// git's credential helpers if necessary (see authorizeGitCredential).
+func hostCredentials(ctx context.Context, host string) bool {
+	if (host == "github.com") && githubAuth.IsSet() {
+		return true
+	}
+	if _, ok := gitlabTokens[strings.ToLower(host)]; ok {
+		return true
+	}
+	if auth := netrcAuth(host); (auth != nil) && auth.IsSet() {
+		return true
+	}
+	if storedCredential(host) != nil {
+		return true
+	}
+	return useGitCredentials && (gitCredential(ctx, host, true) != nil)
+}
+
+// checkPrivateCredentials warns, once per host, if there are no credentials
+// for the host of a private module, as its lookup and license are then
+// likely to fail.
+func checkPrivateCredentials(ctx context.Context, module string) {
+	host := strings.SplitN(module, "/", 2)[0]
+
+	checkedHosts.Lock()
+	checked := checkedHosts.hosts[host]
+	checkedHosts.hosts[host] = true
+	checkedHosts.Unlock()
+
+	if !checked && !hostCredentials(ctx, host) {
+		logf(levelWarning, phaseLookup, module, nil,
+			"warning: no credentials for %s, needed for private module %q (see Authentication in the README)", host, module)
+	}
+}
@@ -0,0 +1,45 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMatchPrefixPatterns(t *testing.T) {
+	tests := []struct {
+		globs    string
+		module   string
+		expected bool
+	}{
+		{"*.example.org", "git.example.org/foo/bar", true},
+		{"*.example.org", "example.org/foo", false},
+		{"github.com/corp", "github.com/corp/repo/v2", true},
+		{"github.com/corp/", "github.com/corp", true},
+		{"github.com/corp", "github.com/corporate/repo", false},
+		{"github.com/*/internal", "github.com/corp/internal/x", true},
+		{"github.com/*/internal", "github.com/corp", false},
+		{"example.net, git.example.org", "git.example.org/foo", true},
+		{"", "git.example.org/foo", false},
+	}
+	for _, tt := range tests {
+		if got := matchPrefixPatterns(tt.globs, tt.module); got != tt.expected {
+			t.Errorf("%q, %q: expected %t but got %t", tt.globs, tt.module, tt.expected, got)
+		}
+	}
+}
+
+func TestCheckPrivateCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	oldOutput, oldNetrcs, oldGit := logOutput, netrcs, useGitCredentials
+	logOutput, netrcs, useGitCredentials = &buf, nil, false
+	defer func() { logOutput, netrcs, useGitCredentials = oldOutput, oldNetrcs, oldGit }()
+
+	checkPrivateCredentials(context.Background(), "private.example.org/a")
+	checkPrivateCredentials(context.Background(), "private.example.org/b")
+
+	if n := strings.Count(buf.String(), "no credentials for private.example.org"); n != 1 {
+		t.Errorf("expected one warning but got %q", buf.String())
+	}
+}
@@ -0,0 +1,141 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// product is a named set of package patterns in the main module, such as a
+// binary built from ./cmd/foo. In a multi-product repository, report entries
+// are grouped into a section per product.
+type product struct {
+	Name     string
+	Patterns []string
+
+	// modules used by the product's packages and their dependencies
+	modules map[string]bool
+}
+
+// productFlags implements flag.Value for repeated
+// "--product name=pattern[,pattern...]" arguments.
+type productFlags []*product
+
+func (p *productFlags) String() string {
+	var parts []string
+	for _, prod := range *p {
+		parts = append(parts, prod.Name+"="+strings.Join(prod.Patterns, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *productFlags) Set(value string) error {
+	idx := strings.IndexByte(value, '=')
+	if idx < 1 || idx == len(value)-1 {
+		return fmt.Errorf("expected name=pattern[,pattern...]")
+	}
+	name := value[:idx]
+
+	for _, prod := range *p {
+		if prod.Name == name {
+			return fmt.Errorf("duplicate product %q", name)
+		}
+	}
+
+	*p = append(*p, &product{
+		Name:     name,
+		Patterns: strings.Split(value[idx+1:], ","),
+	})
+	return nil
+}
+
+// resolve finds every module needed to build the product's packages.
+func (p *product) resolve(ctx context.Context) error {
+	args := append([]string{"list", "-deps", "-f", "{{with .Module}}{{.Path}}{{end}}"}, p.Patterns...)
+	stdout, err := exec.CommandContext(ctx, "go", args...).Output()
+	if err != nil {
+		return fmt.Errorf("go list error for product %q: %+v: %s", p.Name, err, exitErrorStderr(err))
+	}
+
+	p.modules = map[string]bool{stdlibModule: true}
+	for _, line := range bytes.Split(stdout, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			p.modules[string(line)] = true
+		}
+	}
+	return nil
+}
+
+func (p *product) uses(module string) bool {
+	return p.modules[module]
+}
+
+// sectionWriter is implemented by a reportWriter that can group entries under
+// headings.
+type sectionWriter interface {
+	WriteSection(title string) error
+}
+
+// writeProductReport writes entries grouped by product. For reports that
+// support sections, each product gets its own section (repeating any entry
+// shared between products), followed by a section for entries that belong to
+// no product. Otherwise, each entry is written once, listing its products.
+func writeProductReport(report reportWriter, products []*product, entries []Entry) error {
+	sw, ok := report.(sectionWriter)
+	if !ok {
+		for _, e := range entries {
+			e.Products = []string{}
+			for _, p := range products {
+				if p.uses(e.Module) {
+					e.Products = append(e.Products, p.Name)
+				}
+			}
+			if err := report.WriteEntry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	usedByAny := func(e Entry) bool {
+		for _, p := range products {
+			if p.uses(e.Module) {
+				return true
+			}
+		}
+		return false
+	}
+
+	section := func(title string, match func(e Entry) bool) error {
+		if err := sw.WriteSection(title); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !match(e) {
+				continue
+			}
+			if err := report.WriteEntry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, p := range products {
+		if err := section("Product: "+p.Name, func(e Entry) bool { return p.uses(e.Module) }); err != nil {
+			return err
+		}
+	}
+
+	// only write the final section if it has any entries
+	for _, e := range entries {
+		if !usedByAny(e) {
+			return section("Not used by any product", func(e Entry) bool { return !usedByAny(e) })
+		}
+	}
+
+	return nil
+}
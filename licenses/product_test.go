@@ -0,0 +1,78 @@
+package licenses
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProductFlags(t *testing.T) {
+	var p productFlags
+	if err := p.Set("server=./cmd/server,./internal/..."); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Set("server=./cmd/other"); err == nil {
+		t.Errorf("expected an error for a duplicate product")
+	}
+	for _, invalid := range []string{"server", "=./cmd/server", "server="} {
+		if err := p.Set(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+
+	if len(p) != 1 || p[0].Name != "server" || strings.Join(p[0].Patterns, " ") != "./cmd/server ./internal/..." {
+		t.Errorf("unexpected products %s", p.String())
+	}
+}
+
+func TestWriteProductReport(t *testing.T) {
+	products := []*product{
+		{Name: "a", modules: map[string]bool{"example.org/shared": true, "example.org/a": true}},
+		{Name: "b", modules: map[string]bool{"example.org/shared": true}},
+	}
+	entries := []Entry{
+		{Module: "example.org/a", License: "A"},
+		{Module: "example.org/shared", License: "S"},
+		{Module: "example.org/unused", License: "U"},
+	}
+
+	var buf bytes.Buffer
+	r := newTextReportWriter(&buf, reportOptions{})
+	if err := writeProductReport(r, products, entries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var modules []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "Product: ") || strings.HasPrefix(line, "Not used") || strings.HasPrefix(line, "example.org/") {
+			modules = append(modules, line)
+		}
+	}
+
+	expected := "Product: a|example.org/a|example.org/shared|Product: b|example.org/shared|Not used by any product|example.org/unused"
+	if got := strings.Join(modules, "|"); got != expected {
+		t.Errorf("expected sections %q but got %q", expected, got)
+	}
+}
+
+func TestWriteProductReportJSON(t *testing.T) {
+	products := []*product{
+		{Name: "a", modules: map[string]bool{"example.org/a": true}},
+	}
+
+	var buf bytes.Buffer
+	r := &jsonReportWriter{w: &buf}
+	if err := writeProductReport(r, products, []Entry{{Module: "example.org/a"}, {Module: "example.org/b"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(r.report.Entries) != 2 {
+		t.Fatalf("expected each entry once but got %+v", r.report.Entries)
+	}
+	if p := r.report.Entries[0].Products; len(p) != 1 || p[0] != "a" {
+		t.Errorf("expected products [a] but got %v", p)
+	}
+	if p := r.report.Entries[1].Products; len(p) != 0 {
+		t.Errorf("expected no products but got %v", p)
+	}
+}
@@ -0,0 +1,52 @@
+package licenses
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// progress counts the modules scanned so far, to show "[42/317] module"
+// with an estimate of the time left.
+type progress struct {
+	total int
+	start time.Time
+}
+
+func newProgress(total int) *progress {
+	return &progress{total: total, start: time.Now()}
+}
+
+// message describes starting the nth module (from 0), with an estimate of
+// the time left from the average time taken by the modules before it.
+func (p *progress) message(n int, module string, now time.Time) string {
+	msg := fmt.Sprintf("[%d/%d] %s", n+1, p.total, module)
+	if n == 0 {
+		return msg
+	}
+	left := now.Sub(p.start) / time.Duration(n) * time.Duration(p.total-n)
+	return fmt.Sprintf("%s (about %s left)", msg, left.Round(time.Second))
+}
+
+// log logs starting the nth module. See logEmit for how progress is shown
+// on a terminal.
+func (p *progress) log(n int, module string) {
+	logEmit(logEvent{
+		Level:    levelInfo,
+		Phase:    phaseModule,
+		Module:   module,
+		Message:  p.message(n, module, time.Now()),
+		progress: true,
+	})
+}
+
+// isTerminal returns true if w is a terminal, rather than a file or pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return (err == nil) && (fi.Mode()&os.ModeCharDevice != 0)
+}
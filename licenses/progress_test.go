@@ -0,0 +1,36 @@
+package licenses
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProgressMessage(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &progress{total: 317, start: start}
+
+	if got := p.message(0, "example.org/a", start); got != "[1/317] example.org/a" {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	// 41 modules in 82s is 2s each, for the 276 left
+	expected := "[42/317] example.org/b (about 9m12s left)"
+	if got := p.message(41, "example.org/b", start.Add(82*time.Second)); got != expected {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+}
+
+func TestProgressNotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	oldOutput := logOutput
+	logOutput = &buf
+	defer func() { logOutput = oldOutput }()
+
+	p := newProgress(2)
+	p.log(0, "example.org/a")
+	logf(levelWarning, "", "", nil, "warning")
+	if buf.String() != "[1/2] example.org/a\nwarning\n" {
+		t.Errorf("expected plain lines but got %q", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package licenses
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// proxyConfig configures fetching licenses from the module zips served by a
+// module proxy (such as an Athens deployment for private modules), instead of
+// from each module's repository.
+type proxyConfig struct {
+	// URL of the proxy, as in GOPROXY, e.g. "https://athens.example.org"
+	URL string `yaml:"url"`
+
+	// Rewrite rules applied, in order, to a module path before requesting it
+	// from the proxy. Only the first matching rule is applied.
+	Rewrite []proxyRewrite `yaml:"rewrite"`
+}
+
+// proxyRewrite replaces the Prefix of a module path with Replace, e.g. to
+// strip "corp.example.org/" (Replace is empty) or to add "mirror/" (Prefix is
+// empty) for proxies that serve modules under different paths.
+type proxyRewrite struct {
+	Prefix  string `yaml:"prefix"`
+	Replace string `yaml:"replace"`
+}
+
+// rewrite returns the path a module is served at by the proxy.
+func (c proxyConfig) rewrite(module string) string {
+	for _, r := range c.Rewrite {
+		if strings.HasPrefix(module, r.Prefix) {
+			return r.Replace + strings.TrimPrefix(module, r.Prefix)
+		}
+	}
+	return module
+}
+
+// zipURL returns the URL of a module version's zip on the proxy.
+func (c proxyConfig) zipURL(m Module) string {
+	return fmt.Sprintf("%s/%s/@v/%s.zip",
+		strings.TrimSuffix(c.URL, "/"),
+		escapeModulePath(c.rewrite(m.Path)),
+		escapeModulePath(m.Version))
+}
+
+// escapeModulePath escapes a module path or version for a proxy request,
+// replacing each upper case letter with "!" and the lower case letter, as in
+// the GOPROXY protocol.
+func escapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if ('A' <= r) && (r <= 'Z') {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// tryGetProxyLicense fetches a module version's zip from the proxy and
+// returns the license files at the root of the module.
+func tryGetProxyLicense(ctx context.Context, c proxyConfig, m Module) (licenseFile, error) {
+	rsc := c.zipURL(m)
+
+	var auth *BasicAuth
+	if u, err := url.Parse(rsc); err == nil {
+		auth = netrcAuth(u.Hostname())
+	}
+
+	data, err := httpGet(ctx, rsc, auth)
+	if err != nil {
+		return licenseFile{}, err
+	}
+
+	parts, err := zipLicenseParts([]byte(data), rsc, licenseCandidates(m.Path))
+	if err != nil {
+		return licenseFile{}, fmt.Errorf("error reading module zip %q: %v", rsc, err)
+	}
+	if len(parts) == 0 {
+		return licenseFile{}, fmt.Errorf("no license found in module zip %q", rsc)
+	}
+
+	license := combineLicenseParts(parts)
+	license.Ref = m.Version
+	license.Retrieved = retrievalTime()
+	return license, nil
+}
+
+// zipLicenseParts returns the license files at the root of a module zip, in
+// order of precedence among candidates (see licenseCandidates). Every file in a module zip is in a "path@version/"
+// directory.
+func zipLicenseParts(data []byte, sourceURL string, candidates []string) ([]licensePart, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	type rankedFile struct {
+		rank int
+		name string
+		file *zip.File
+	}
+	var files []rankedFile
+
+	for _, f := range zr.File {
+		idx := strings.Index(f.Name, "@")
+		if idx < 0 {
+			continue
+		}
+		rest := f.Name[idx:]
+		slash := strings.IndexByte(rest, '/')
+		if slash < 0 {
+			continue
+		}
+		name := rest[slash+1:]
+		if (name == "") || (path.Dir(name) != ".") {
+			continue
+		}
+
+		if rank, ok := licenseFileRank(name, candidates); ok {
+			files = append(files, rankedFile{rank, name, f})
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].rank < files[j].rank
+	})
+
+	var parts []licensePart
+	for _, f := range files {
+		rc, err := f.file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		text, ok := licenseText(sourceURL, string(data))
+		if !ok {
+			continue
+		}
+
+		parts = append(parts, licensePart{
+			File:      f.name,
+			SourceURL: sourceURL,
+			Text:      text,
+		})
+	}
+	return parts, nil
+}
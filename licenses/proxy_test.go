@@ -0,0 +1,84 @@
+package licenses
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyZipURL(t *testing.T) {
+	c := proxyConfig{
+		URL: "https://athens.example.org/",
+		Rewrite: []proxyRewrite{
+			{Prefix: "corp.example.org/", Replace: ""},
+			{Prefix: "", Replace: "mirror/"},
+		},
+	}
+
+	tests := map[string]string{
+		"corp.example.org/Team/foo": "https://athens.example.org/!team/foo/@v/v1.0.0.zip",
+		"github.com/foo/bar":        "https://athens.example.org/mirror/github.com/foo/bar/@v/v1.0.0.zip",
+	}
+	for module, expected := range tests {
+		if got := c.zipURL(Module{Path: module, Version: "v1.0.0"}); got != expected {
+			t.Errorf("%s: expected %q but got %q", module, expected, got)
+		}
+	}
+}
+
+func TestTryGetProxyLicense(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"foo@v1.0.0/LICENSE":     testMITLicense,
+		"foo@v1.0.0/NOTICE":      "Foo\nCopyright 2021 Example Ltd.",
+		"foo@v1.0.0/sub/LICENSE": "not at the root",
+		"foo@v1.0.0/foo.go":      "package foo",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/foo/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	old := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = old }()
+
+	c := proxyConfig{
+		URL:     server.URL,
+		Rewrite: []proxyRewrite{{Prefix: "corp.example.org/", Replace: ""}},
+	}
+	license, err := tryGetProxyLicense(context.Background(), c, Module{Path: "corp.example.org/foo", Version: "v1.0.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(license.Parts) != 2 || license.Parts[0].File != "NOTICE" || license.Parts[1].File != "LICENSE" {
+		t.Errorf("expected a NOTICE and a LICENSE but got %+v", license.Parts)
+	}
+	if license.Ref != "v1.0.0" {
+		t.Errorf("expected ref v1.0.0 but got %q", license.Ref)
+	}
+
+	if _, err := tryGetProxyLicense(context.Background(), c, Module{Path: "corp.example.org/bar", Version: "v1.0.0"}); err == nil {
+		t.Errorf("expected an error for a missing module")
+	}
+}
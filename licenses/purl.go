@@ -0,0 +1,45 @@
+package licenses
+
+import (
+	"net/url"
+	"strings"
+)
+
+// purlStdlib is the package URL name of the Go standard library, as used by
+// OSV and Syft.
+const purlStdlib = "stdlib"
+
+// purl returns the package URL (purl) of a module version, e.g.
+// "pkg:golang/github.com/gorilla/mux@v1.8.0", so that a report joins with the
+// inventories of vulnerability scanners and SBOM tools. The standard library
+// is "pkg:golang/stdlib@1.21.3". A module without a version, as in a report
+// of a project without a go.mod, has no "@version".
+//
+// See https://github.com/package-url/purl-spec.
+func purl(module string, version string) string {
+	if module == stdlibModule {
+		module = purlStdlib
+		version = strings.TrimPrefix(version, "go")
+	}
+
+	segments := strings.Split(module, "/")
+	for i, s := range segments {
+		segments[i] = purlEscape(s)
+	}
+	p := "pkg:golang/" + strings.Join(segments, "/")
+	if version != "" {
+		p += "@" + purlEscape(version)
+	}
+	return p
+}
+
+// purlEscape percent-encodes a purl component, including "+", as in a
+// "+incompatible" version, which url.PathEscape leaves as is.
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+}
+
+// purl returns the entry's package URL. See purl.
+func (e Entry) purl() string {
+	return purl(e.Module, e.Version)
+}
@@ -0,0 +1,24 @@
+package licenses
+
+import (
+	"testing"
+)
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		module   string
+		version  string
+		expected string
+	}{
+		{"github.com/gorilla/mux", "v1.8.0", "pkg:golang/github.com/gorilla/mux@v1.8.0"},
+		{"github.com/example/old", "v2.0.0+incompatible", "pkg:golang/github.com/example/old@v2.0.0%2Bincompatible"},
+		{"example.org/a", "", "pkg:golang/example.org/a"},
+		{"example.org/with space", "v1.0.0", "pkg:golang/example.org/with%20space@v1.0.0"},
+		{stdlibModule, "go1.21.3", "pkg:golang/stdlib@1.21.3"},
+	}
+	for _, test := range tests {
+		if p := purl(test.module, test.version); p != test.expected {
+			t.Errorf("%s@%s: expected %q but got %q", test.module, test.version, test.expected, p)
+		}
+	}
+}
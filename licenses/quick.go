@@ -0,0 +1,95 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// quickBudget is the most time the quick command spends on each module
+const quickBudget = 500 * time.Millisecond
+
+// quickResult is the outcome of checking one module in the quick command
+type quickResult struct {
+	entry Entry
+	ok    bool // false if the module needs a full scan
+}
+
+// quickModule identifies a module's license from an override (that doesn't
+// need the network) or a local copy of the module within quickBudget.
+func quickModule(ctx context.Context, m Module, o *options) quickResult {
+	ctx, cancel := context.WithTimeout(ctx, quickBudget)
+	defer cancel()
+
+	done := make(chan quickResult, 1)
+	go func() {
+		if ov, ok := o.overrides[m.Path]; ok && (ov.When == overrideAlways) && (ov.URL == "") && ((ov.File != "") || (ov.Text != "")) {
+			if license, err := ov.license(ctx); err == nil {
+				done <- quickResult{newEntry(ctx, m, license, o), true}
+				return
+			}
+		}
+		if license, ok := localLicense(ctx, m); ok {
+			done <- quickResult{newEntry(ctx, m, license, o), true}
+			return
+		}
+		done <- quickResult{}
+	}()
+
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+		return quickResult{}
+	}
+}
+
+// runQuick implements the quick command, fast enough for a pre-commit hook.
+// Licenses are only identified from overrides, vendor and the module cache,
+// never the network. Each module with an unknown license, a policy
+// violation, or that needs a full scan (because it has no local copy) is
+// written to stdout, and the command fails if there are any.
+func runQuick(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	modules, err := modulesToScan(ctx, o, args)
+	if err != nil {
+		return err
+	}
+
+	opts := *o
+	opts.Provenance = false
+	opts.Copyrights = false
+
+	problems, missing := 0, 0
+	problem := func(module string, reason string) error {
+		problems++
+		_, err := fmt.Fprintf(stdout, "%s: %s\n", module, reason)
+		return err
+	}
+
+	for _, m := range modules {
+		r := quickModule(ctx, m, &opts)
+
+		reason := ""
+		switch {
+		case !r.ok:
+			reason = "needs a full scan (no local copy of its license)"
+			missing++
+		case o.policy != nil:
+			reason = o.policy.violation(r.entry)
+		case r.entry.SPDX == "":
+			reason = "license unknown"
+			missing++
+		}
+		if reason != "" {
+			if err := problem(m.Path, reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	if problems > 0 {
+		return &checkFailedError{Problems: problems, Missing: missing}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunQuick(t *testing.T) {
+	modcache := t.TempDir()
+	dir := filepath.Join(modcache, "example.org", "!upper@v1.0.0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT License\n\n"+testMITLicenseBody), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	goEnvCache.Lock()
+	previous, had := goEnvCache.values["GOMODCACHE"]
+	goEnvCache.values["GOMODCACHE"] = modcache
+	goEnvCache.Unlock()
+	defer func() {
+		goEnvCache.Lock()
+		if had {
+			goEnvCache.values["GOMODCACHE"] = previous
+		} else {
+			delete(goEnvCache.values, "GOMODCACHE")
+		}
+		goEnvCache.Unlock()
+	}()
+
+	license, ok := localLicense(context.Background(), Module{Path: "example.org/Upper", Version: "v1.0.0"})
+	if !ok || (license.SourceURL != filepath.Join(dir, "LICENSE")) {
+		t.Fatalf("expected the license in the module cache but got (%+v, %t)", license, ok)
+	}
+
+	var stdout bytes.Buffer
+	err := runQuick(context.Background(), &options{}, []string{"example.org/Upper@v1.0.0", "example.org/missing@v1.0.0"}, &stdout)
+
+	var failed *checkFailedError
+	if !errors.As(err, &failed) || (failed.Problems != 1) {
+		t.Fatalf("expected one problem but got %v", err)
+	}
+	expected := "example.org/missing: needs a full scan (no local copy of its license)\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q but got %q", expected, stdout.String())
+	}
+}
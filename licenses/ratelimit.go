@@ -0,0 +1,55 @@
+package licenses
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRateLimitWait is assumed when a server rate limits a request without
+// saying when to try again.
+const defaultRateLimitWait = time.Hour
+
+// parseRateLimit returns true if a response means the client is being rate
+// limited and, if so, the time when the server expects requests to succeed
+// again.
+//
+// This understands a 429 status, and the 403 status that GitHub uses with
+// X-RateLimit-Remaining: 0. The reset time is taken from a Retry-After header
+// (in seconds or as a HTTP date) or from X-RateLimit-Reset (in Unix seconds).
+func parseRateLimit(resp *http.Response, now time.Time) (bool, time.Time) {
+	limited := (resp.StatusCode == http.StatusTooManyRequests) ||
+		((resp.StatusCode == http.StatusForbidden) &&
+			((resp.Header.Get("X-RateLimit-Remaining") == "0") || (resp.Header.Get("Retry-After") != "")))
+	if !limited {
+		return false, time.Time{}
+	}
+
+	if after := resp.Header.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.ParseInt(after, 10, 64); err == nil {
+			return true, now.Add(time.Duration(seconds) * time.Second)
+		}
+		if t, err := http.ParseTime(after); err == nil {
+			return true, t
+		}
+	}
+
+	if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+		if seconds, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			return true, time.Unix(seconds, 0)
+		}
+	}
+
+	return true, now.Add(defaultRateLimitWait)
+}
+
+// rateLimitReset returns true if err was caused by rate limiting and, if so,
+// when the server expects requests to succeed again.
+func rateLimitReset(err error) (bool, time.Time) {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.RateLimited {
+		return true, statusErr.Reset
+	}
+	return false, time.Time{}
+}
@@ -0,0 +1,38 @@
+package licenses
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status   int
+		headers  map[string]string
+		limited  bool
+		expected time.Time
+	}{
+		{404, nil, false, time.Time{}},
+		{403, nil, false, time.Time{}},
+		{429, map[string]string{"Retry-After": "120"}, true, now.Add(2 * time.Minute)},
+		{429, map[string]string{"Retry-After": "Tue, 01 Jun 2021 13:00:00 GMT"}, true, now.Add(time.Hour)},
+		{429, nil, true, now.Add(defaultRateLimitWait)},
+		{403, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1622552400"}, true, now.Add(time.Hour)},
+	}
+
+	for i, test := range tests {
+		resp := &http.Response{StatusCode: test.status, Header: http.Header{}}
+		for k, v := range test.headers {
+			resp.Header.Set(k, v)
+		}
+
+		limited, reset := parseRateLimit(resp, now)
+		if limited != test.limited || !reset.Equal(test.expected) {
+			t.Errorf("test %d: expected (%t, %s) but got (%t, %s)",
+				i, test.limited, test.expected, limited, reset)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package licenses
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// inferredFromREADME marks a license inferred from a README
+const inferredFromREADME = "README"
+
+// readmeFiles to check, in order, for a license statement
+var readmeFiles = []string{
+	"README.md",
+	"README",
+	"README.markdown",
+	"README.rst",
+	"README.txt",
+}
+
+// markdownHeading matches a Markdown ATX heading, e.g. "## License"
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// isUnderline returns true if a line is the underline of a Markdown setext
+// or reStructuredText heading, e.g. "-------".
+func isUnderline(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if (len(line) < 3) || !strings.ContainsRune(`=-~^"'*+#`, rune(line[0])) {
+		return false
+	}
+	return strings.Trim(line, line[:1]) == ""
+}
+
+// licenseHeading matches the title of a license section
+var licenseHeading = regexp.MustCompile(`(?i)^(copyright|licen[cs](e|es|ing))\b`)
+
+// readmeLicenseExcerpt returns the part of a README that states its license:
+// a section with a heading like "License", or else the paragraphs containing
+// any license text (or URL) known to licensecheck.
+func readmeLicenseExcerpt(text string) (string, bool) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	// heading returns the level (1 is highest) and title if lines[i] is a
+	// heading. A heading underlined with "=" is level 1, otherwise 2.
+	heading := func(i int) (int, string, bool) {
+		if m := markdownHeading.FindStringSubmatch(lines[i]); m != nil {
+			return len(m[1]), m[2], true
+		}
+		if (i+1 < len(lines)) && (strings.TrimSpace(lines[i]) != "") && isUnderline(lines[i+1]) {
+			level := 2
+			if lines[i+1][0] == '=' {
+				level = 1
+			}
+			return level, strings.TrimSpace(lines[i]), true
+		}
+		return 0, "", false
+	}
+
+	for i := range lines {
+		level, title, ok := heading(i)
+		if !ok || !licenseHeading.MatchString(title) {
+			continue
+		}
+
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if l, _, ok := heading(j); ok && (l <= level) {
+				end = j
+				break
+			}
+		}
+
+		excerpt := strings.TrimSpace(strings.Join(lines[i:end], "\n"))
+		if excerpt != strings.TrimSpace(lines[i]) {
+			return excerpt, true
+		}
+	}
+
+	cov := licensecheck.Scan([]byte(text))
+	if len(cov.Match) == 0 {
+		return "", false
+	}
+
+	// expand the matched text to whole paragraphs
+	start := cov.Match[0].Start
+	end := cov.Match[len(cov.Match)-1].End
+	end = len(strings.TrimRight(text[:end], " \t\r\n"))
+	if idx := strings.LastIndex(text[:start], "\n\n"); idx >= 0 {
+		start = idx
+	} else {
+		start = 0
+	}
+	if idx := strings.Index(text[end:], "\n\n"); idx >= 0 {
+		end += idx
+	} else {
+		end = len(text)
+	}
+	return strings.TrimSpace(text[start:end]), true
+}
+
+// tryGetREADMELicense is a last resort for repositories that only state
+// their license in their README. The excerpt stating the license is marked
+// as inferred and low confidence.
+func tryGetREADMELicense(ctx context.Context, gi GoImport, gs GoSource) (licenseFile, bool) {
+	for _, file := range readmeFiles {
+		data, FileURL, ok := fetchRepoFile(ctx, gi, gs, file, "")
+		if !ok {
+			continue
+		}
+
+		excerpt, ok := readmeLicenseExcerpt(data)
+		if !ok {
+			return licenseFile{}, false
+		}
+
+		return licenseFile{
+			Text:          excerpt,
+			SourceURL:     FileURL.URL,
+			Ref:           FileURL.Ref,
+			Retrieved:     retrievalTime(),
+			Inferred:      inferredFromREADME,
+			LowConfidence: true,
+		}, true
+	}
+
+	return licenseFile{}, false
+}
@@ -0,0 +1,53 @@
+package licenses
+
+import (
+	"strings"
+	"testing"
+)
+
+var testMITLicenseBody = strings.TrimPrefix(testMITLicense, "MIT License\n\n")
+
+func TestREADMELicenseExcerpt(t *testing.T) {
+	tests := []struct {
+		name     string
+		readme   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "markdown section",
+			readme:   "# foo\n\nDoes things.\n\n## License\n\nMIT, see below.\n\n### Details\n\nMore.\n\n## Contributing\n\nPlease do.\n",
+			expected: "## License\n\nMIT, see below.\n\n### Details\n\nMore.",
+			ok:       true,
+		},
+		{
+			name:     "underlined section",
+			readme:   "foo\n===\n\nLicence\n-------\n\nBSD-style.\n\nThanks\n------\n",
+			expected: "Licence\n-------\n\nBSD-style.",
+			ok:       true,
+		},
+		{
+			name:     "license text without a section",
+			readme:   "# foo\n\nDoes things.\n\n" + testMITLicenseBody + "\n\nThanks!\n",
+			expected: testMITLicenseBody,
+			ok:       true,
+		},
+		{
+			name:   "empty section",
+			readme: "# foo\n\n## License\n\n## Contributing\n",
+			ok:     false,
+		},
+		{
+			name:   "no license",
+			readme: "# foo\n\nDoes things.\n",
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		excerpt, ok := readmeLicenseExcerpt(tt.readme)
+		if (excerpt != tt.expected) || (ok != tt.ok) {
+			t.Errorf("%s: expected (%q, %t) but got (%q, %t)", tt.name, tt.expected, tt.ok, excerpt, ok)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sharedCacheEnv names a shared cache if --shared-cache isn't given
+const sharedCacheEnv = "GOCOMPLY_SHARED_CACHE"
+
+// newSharedCache returns a cacheStore for a shared cache location:
+//
+//   - https://host/path (or http://) - a simple HTTP cache server, where
+//     entries are read with GET and written with PUT, authenticated from
+//     netrc by host.
+//   - s3://bucket/prefix - an Amazon S3 (or compatible) bucket, using the
+//     standard AWS_* environment variables.
+//   - gs://bucket/prefix - a Google Cloud Storage bucket, using an OAuth
+//     access token from $GOOGLE_OAUTH_ACCESS_TOKEN.
+func newSharedCache(location string) (cacheStore, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shared cache %q: %v", location, err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "http", "https":
+		return &remoteCache{
+			url: func(key string) string {
+				return strings.TrimSuffix(location, "/") + "/" + key + ".json"
+			},
+			sign: func(req *http.Request, body []byte) {
+				if auth := netrcAuth(u.Hostname()); auth != nil {
+					req.SetBasicAuth(auth.Username, auth.Token)
+				}
+			},
+		}, nil
+
+	case "s3":
+		s := s3Signer{
+			accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			token:     os.Getenv("AWS_SESSION_TOKEN"),
+			region:    os.Getenv("AWS_REGION"),
+		}
+		if s.region == "" {
+			s.region = os.Getenv("AWS_DEFAULT_REGION")
+		}
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		if (s.accessKey == "") || (s.secretKey == "") {
+			return nil, fmt.Errorf("shared cache %q requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", location)
+		}
+
+		// S3 compatible services are addressed by path
+		base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", u.Host, s.region)
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			base = strings.TrimSuffix(endpoint, "/") + "/" + u.Host
+		}
+		return &remoteCache{
+			url:  func(key string) string { return objectURL(base, prefix, key) },
+			sign: s.sign,
+		}, nil
+
+	case "gs":
+		token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("shared cache %q requires GOOGLE_OAUTH_ACCESS_TOKEN", location)
+		}
+		base := "https://storage.googleapis.com/" + u.Host
+		return &remoteCache{
+			url: func(key string) string { return objectURL(base, prefix, key) },
+			sign: func(req *http.Request, body []byte) {
+				req.Header.Set("Authorization", "Bearer "+token)
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported shared cache %q (expected a http(s)://, s3:// or gs:// URL)", location)
+	}
+}
+
+// objectURL returns the URL of a cache entry in a bucket.
+func objectURL(base string, prefix string, key string) string {
+	if prefix != "" {
+		return base + "/" + prefix + "/" + key + ".json"
+	}
+	return base + "/" + key + ".json"
+}
+
+// remoteCache is a cacheStore keeping each entry at a URL.
+//
+// Requests are made directly, rather than with httpDo, so that they are
+// neither cached themselves nor rate limited.
+type remoteCache struct {
+	url  func(key string) string
+	sign func(req *http.Request, body []byte) // authenticates a request
+}
+
+func (c *remoteCache) do(ctx context.Context, method string, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	setUserAgent(req)
+	c.sign(req, body)
+
+	return fetcher().Do(req)
+}
+
+func (c *remoteCache) Get(ctx context.Context, key string) (cacheEntry, bool, error) {
+	resp, err := c.do(ctx, "GET", key, nil)
+	if err != nil {
+		return cacheEntry{}, false, fmt.Errorf("error reading shared cache: %v", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	// S3 responds 403 rather than 404 without permission to list the bucket
+	if (resp.StatusCode == http.StatusNotFound) || (resp.StatusCode == http.StatusForbidden) {
+		return cacheEntry{}, false, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return cacheEntry{}, false, fmt.Errorf("error reading shared cache: http status code %d", resp.StatusCode)
+	}
+
+	var entry cacheEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		// a corrupt entry is just a miss
+		return cacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (c *remoteCache) Put(ctx context.Context, key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, "PUT", key, data)
+	if err != nil {
+		return fmt.Errorf("error writing shared cache: %v", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode > 299) {
+		return fmt.Errorf("error writing shared cache: http status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// layeredCache is a cacheStore that reads from each store in turn, copying a
+// hit into the stores before it, and writes to every store. This keeps a
+// local cache in front of a shared one.
+type layeredCache []cacheStore
+
+func (c layeredCache) Get(ctx context.Context, key string) (cacheEntry, bool, error) {
+	for i, store := range c {
+		entry, ok, err := store.Get(ctx, key)
+		if err != nil {
+			logf(levelWarning, "", "", err, "warning: %v", err)
+			continue
+		}
+		if ok {
+			for _, previous := range c[:i] {
+				if err := previous.Put(ctx, key, entry); err != nil {
+					logf(levelWarning, "", "", err, "warning: %v", err)
+				}
+			}
+			return entry, true, nil
+		}
+	}
+	return cacheEntry{}, false, nil
+}
+
+func (c layeredCache) Put(ctx context.Context, key string, entry cacheEntry) error {
+	var first error
+	for _, store := range c {
+		if err := store.Put(ctx, key, entry); (err != nil) && (first == nil) {
+			first = err
+		}
+	}
+	return first
+}
+
+// s3Signer signs requests with AWS Signature Version 4.
+type s3Signer struct {
+	accessKey string
+	secretKey string
+	token     string // optional session token
+	region    string
+	now       func() time.Time // for testing; time.Now if nil
+}
+
+func (s s3Signer) sign(req *http.Request, body []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.token != "" {
+		req.Header.Set("X-Amz-Security-Token", s.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
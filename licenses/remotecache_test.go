@@ -0,0 +1,112 @@
+package licenses
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCacheServer is a simple HTTP cache server storing bodies by path
+func testCacheServer(t *testing.T) (*httptest.Server, map[string]string) {
+	var mu sync.Mutex
+	objects := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "PUT":
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(data)
+		case "GET":
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, data)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, objects
+}
+
+func TestSharedCache(t *testing.T) {
+	server, objects := testCacheServer(t)
+
+	shared, err := newSharedCache(server.URL + "/cache/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	local := dirCache{dir: t.TempDir()}
+	c := layeredCache{local, shared}
+
+	key := cacheKey("https://example.org/LICENSE")
+	if _, ok, err := c.Get(context.Background(), key); ok || (err != nil) {
+		t.Fatalf("expected a miss but got (%t, %v)", ok, err)
+	}
+
+	// another runner shared an entry...
+	entry := cacheEntry{URL: "https://example.org/LICENSE", ETag: `"v1"`, Body: "License A"}
+	if err := shared.Put(context.Background(), key, entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := objects["/cache/"+key+".json"]; !ok {
+		t.Fatalf("expected the entry at /cache/%s.json but got %v", key, objects)
+	}
+
+	// ...which is found, and copied into the local cache
+	if got, ok, err := c.Get(context.Background(), key); !ok || (err != nil) || (got.Body != "License A") {
+		t.Fatalf("expected a hit but got (%+v, %t, %v)", got, ok, err)
+	}
+	if got, ok, _ := local.Get(context.Background(), key); !ok || (got.ETag != `"v1"`) {
+		t.Errorf("expected the entry to be copied into the local cache but got (%+v, %t)", got, ok)
+	}
+}
+
+func TestNewSharedCacheErrors(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "GOOGLE_OAUTH_ACCESS_TOKEN"} {
+		old, wasSet := os.LookupEnv(name)
+		defer func(name string) {
+			if wasSet {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		}(name)
+		os.Unsetenv(name)
+	}
+
+	for _, location := range []string{"s3://bucket/prefix", "gs://bucket", "ftp://example.org/cache"} {
+		if _, err := newSharedCache(location); err == nil {
+			t.Errorf("%s: expected an error", location)
+		}
+	}
+}
+
+func TestS3Signer(t *testing.T) {
+	s := s3Signer{
+		accessKey: "AKIDEXAMPLE",
+		secretKey: "secret",
+		region:    "eu-west-2",
+		now:       func() time.Time { return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC) },
+	}
+
+	req, _ := http.NewRequest("GET", "https://bucket.s3.eu-west-2.amazonaws.com/prefix/key.json", nil)
+	s.sign(req, nil)
+
+	auth := req.Header.Get("Authorization")
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20210601/eu-west-2/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(auth, expected) || (len(auth) != len(expected)+64) {
+		t.Errorf("expected an Authorization header starting %q but got %q", expected, auth)
+	}
+	if req.Header.Get("X-Amz-Date") != "20210601T120000Z" {
+		t.Errorf("unexpected X-Amz-Date %q", req.Header.Get("X-Amz-Date"))
+	}
+}
@@ -0,0 +1,518 @@
+package licenses
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+)
+
+// Entry is a single module's record in a license report.
+type Entry struct {
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+	License string `json:"license,omitempty"`
+
+	// Name labels an entry that isn't really a module, in place of Module,
+	// such as "Go standard library (go1.21.3)".
+	Name string `json:"name,omitempty"`
+
+	// PURL is the package URL of the module version (see purl). Text reports
+	// don't include it.
+	PURL string `json:"purl,omitempty"`
+
+	// SPDX is the SPDX license expression of License, if known, and
+	// SPDXConfidence is the percentage of License matching that expression.
+	SPDX           string  `json:"spdx,omitempty"`
+	SPDXConfidence float64 `json:"spdx_confidence,omitempty"`
+
+	// Inferred describes where the license was inferred from, if it isn't
+	// from a license file, such as "source headers".
+	Inferred string `json:"inferred_from,omitempty"`
+
+	// LowConfidence is true if License is an excerpt, as from a README, that
+	// may not be the whole license.
+	LowConfidence bool `json:"low_confidence,omitempty"`
+
+	// SourceURL is where the license was, or would be, obtained from.
+	SourceURL string `json:"source_url,omitempty"`
+
+	// ReplacedBy is the module or local directory that replaces the module,
+	// as in go.mod (e.g. "example.org/fork v1.1.0" or "../fork"), if any. The
+	// license is the replacement's.
+	ReplacedBy string `json:"replaced_by,omitempty"`
+
+	// Indirect is true if the module is only required by other modules.
+	Indirect bool `json:"indirect,omitempty"`
+
+	// BuildOnly is true if the module is only needed by the main module's
+	// tools, at build time, so is usually not shipped.
+	BuildOnly bool `json:"build_only,omitempty"`
+
+	// MovedTo is where the module's repository is now, if it has moved from
+	// where the module's import path points, such as a renamed GitHub
+	// repository.
+	MovedTo string `json:"moved_to,omitempty"`
+
+	// SHA256 is the hex-encoded SHA-256 of License, if checksums are enabled.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Ref, Revision and Retrieved record the provenance of License, if
+	// enabled, alongside SourceURL and SHA256. See licenseFile.
+	Ref       string `json:"ref,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Retrieved string `json:"retrieved,omitempty"` // RFC 3339
+
+	// Directory is the directory of the repository that License is from,
+	// for a module in a subdirectory of its repository, if provenance is
+	// enabled: the module's own, or "." for the repository root.
+	Directory string `json:"directory,omitempty"`
+
+	// Obligations summarises the obligations of each identified license, if
+	// enabled.
+	Obligations []licenseObligations `json:"obligations,omitempty"`
+
+	// Copyrights lists each copyright notice found in License and, if
+	// enabled, in the module's source file headers.
+	Copyrights []string `json:"copyrights,omitempty"`
+
+	// Licenses has each license individually, if License combines several
+	// license files or a license file contains several licenses.
+	Licenses []licensePart `json:"licenses,omitempty"`
+
+	// Products lists the products using this module, if products are defined.
+	Products []string `json:"products,omitempty"`
+
+	// Vulnerabilities lists the ids of the known vulnerabilities of the
+	// module version, such as "GO-2022-0969", with --osv.
+	Vulnerabilities []string `json:"vulnerabilities,omitempty"`
+
+	// VulnerabilitiesUnknown is true if, with --osv, OSV.dev couldn't be
+	// queried for the module version, so that Vulnerabilities is unknown.
+	VulnerabilitiesUnknown bool `json:"vulnerabilities_unknown,omitempty"`
+
+	// raw is the license before it was converted to plain text, if it was,
+	// for the evidence bundle.
+	raw string
+}
+
+// setProvenance records where the entry's license came from.
+func (e *Entry) setProvenance(license licenseFile) {
+	e.SourceURL = license.SourceURL
+	e.Ref = license.Ref
+	e.Revision = license.Revision
+	e.Retrieved = license.Retrieved.Format(time.RFC3339)
+	e.Directory = license.Dir
+	e.SHA256 = textSHA256(e.License)
+}
+
+// title returns the entry's label in a report: its Name, if any, or else
+// its module.
+func (e Entry) title() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.Module
+}
+
+// spdxText describes the SPDX expression of an entry, e.g. "MIT (100.0%
+// match)", "MIT (inferred from source headers)" or "NOASSERTION".
+func (e Entry) spdxText() string {
+	if e.SPDX == "" {
+		if e.Inferred != "" {
+			return fmt.Sprintf("%s (inferred from %s)", spdxNoAssertion, e.Inferred)
+		}
+		return spdxNoAssertion
+	}
+
+	var notes []string
+	if e.SPDXConfidence > 0 {
+		notes = append(notes, fmt.Sprintf("%.1f%% match", e.SPDXConfidence))
+	}
+	if e.Inferred != "" {
+		notes = append(notes, "inferred from "+e.Inferred)
+	}
+	if e.LowConfidence {
+		notes = append(notes, "low confidence")
+	}
+	if len(notes) == 0 {
+		return e.SPDX
+	}
+	return fmt.Sprintf("%s (%s)", e.SPDX, strings.Join(notes, ", "))
+}
+
+// provenanceText returns the provenance of an entry as "key: value" lines.
+func (e Entry) provenanceText() string {
+	var b strings.Builder
+	line := func(key string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+
+	line("source", e.SourceURL)
+	line("ref", e.Ref)
+	line("directory", e.Directory)
+	line("revision", e.Revision)
+	line("retrieved", e.Retrieved)
+	fmt.Fprintf(&b, "sha256:%s\n", textSHA256(e.License))
+	return b.String()
+}
+
+// textSHA256 returns the hex-encoded SHA-256 of a string.
+func textSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// reportWriter writes license report entries to the report destination (and
+// nowhere else). Close must be called to complete the report.
+type reportWriter interface {
+	WriteEntry(e Entry) error
+	Close() error
+}
+
+type reportOptions struct {
+	Format    string // "text" (default), "json", "list", "gosource" or "ort"
+	Checksums bool   // include the SHA-256 of each entry and the whole report
+	Trailer   bool   // text only: end with an entry count and report SHA-256
+
+	// Provenance includes where each license came from (see licenseFile)
+	Provenance bool
+
+	// Generated is the timestamp embedded in structured formats (see
+	// artifactTime).
+	Generated time.Time
+
+	// Package is the package of the gosource format
+	Package string
+
+	// Project is the main module, for the ort format
+	Project string
+
+	// PackageBased marks a report of the modules guessed from the packages
+	// imported by a project without a go.mod (see listPackageModules)
+	PackageBased bool
+}
+
+// validate returns an error if the options are invalid.
+func (opts reportOptions) validate() error {
+	switch opts.Format {
+	case "", "text":
+		return nil
+	case "json", "list", "gosource", "ort":
+		if opts.Trailer {
+			return fmt.Errorf("a trailer is not supported for the %s format", opts.Format)
+		}
+		if (opts.Checksums || opts.Provenance) && (opts.Format != "json") {
+			return fmt.Errorf("checksums and provenance are not supported for the %s format", opts.Format)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q", opts.Format)
+	}
+}
+
+// incompleteReportWriter is a reportWriter that can mark a report as
+// incomplete, such as after an interrupt, so that consumers don't mistake it
+// for a complete one.
+type incompleteReportWriter interface {
+	reportWriter
+	MarkIncomplete()
+}
+
+// excludedWriter is a reportWriter that can list the modules excluded from
+// the report in an appendix, so that exclusions can be reviewed.
+type excludedWriter interface {
+	reportWriter
+	WriteExcluded(excluded []excludedModule) error
+}
+
+// nativeWriter is a reportWriter that can list the native libraries linked
+// by cgo in an appendix, so that their licenses can be reviewed separately.
+type nativeWriter interface {
+	reportWriter
+	WriteNative(libs []nativeLibrary) error
+}
+
+func newReportWriter(w io.Writer, opts reportOptions) (reportWriter, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Format == "list" {
+		return &listReportWriter{w: w}, nil
+	}
+	if opts.Format == "gosource" {
+		return &goSourceReportWriter{w: w, pkg: opts.Package}, nil
+	}
+	if opts.Format == "ort" {
+		return &ortReportWriter{w: w, project: opts.Project, generated: opts.Generated}, nil
+	}
+	if opts.Format == "json" {
+		return &jsonReportWriter{
+			w:         w,
+			checksums: opts.Checksums,
+			report:    jsonReport{Generated: opts.Generated.Format(time.RFC3339), PackageBased: opts.PackageBased},
+		}, nil
+	}
+	return newTextReportWriter(w, opts), nil
+}
+
+// textReportWriter writes the plain text report. It keeps a running SHA-256
+// of every byte written so that an optional trailer line can be appended,
+// letting consumers detect a truncated report.
+type textReportWriter struct {
+	w          io.Writer
+	hash       hash.Hash
+	entries    int
+	checksums  bool
+	provenance bool
+	trailer    bool
+}
+
+func newTextReportWriter(w io.Writer, opts reportOptions) *textReportWriter {
+	h := sha256.New()
+	return &textReportWriter{
+		w:          io.MultiWriter(w, h),
+		hash:       h,
+		checksums:  opts.Checksums,
+		provenance: opts.Provenance,
+		trailer:    opts.Trailer || opts.Checksums,
+	}
+}
+
+func (r *textReportWriter) WriteEntry(e Entry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n%s\n\n", e.title(), e.License)
+	fmt.Fprintf(&b, "spdx: %s\n", e.spdxText())
+	if e.ReplacedBy != "" {
+		fmt.Fprintf(&b, "replaced by: %s\n", e.ReplacedBy)
+	}
+	if e.BuildOnly {
+		b.WriteString("build-time only: needed by tools, not shipped\n")
+	}
+	if e.MovedTo != "" {
+		fmt.Fprintf(&b, "repository moved to: %s\n", e.MovedTo)
+	}
+	for _, o := range e.Obligations {
+		fmt.Fprintf(&b, "obligations (%s): %s\n", o.SPDX, o)
+	}
+	if r.provenance {
+		b.WriteString(e.provenanceText())
+	} else if r.checksums {
+		fmt.Fprintf(&b, "sha256:%s\n", textSHA256(e.License))
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", divider)
+
+	_, err := io.WriteString(r.w, b.String())
+	if err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	r.entries++
+	return nil
+}
+
+// WriteSection writes a heading to group the entries that follow.
+func (r *textReportWriter) WriteSection(title string) error {
+	rule := strings.Repeat("=", len(divider))
+	_, err := fmt.Fprintf(r.w, "%s\n%s\n%s\n\n", rule, title, rule)
+	if err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// WriteNative writes an appendix listing the native libraries linked by cgo,
+// each with where it is linked, for their licenses to be reviewed separately.
+func (r *textReportWriter) WriteNative(libs []nativeLibrary) error {
+	if err := r.WriteSection("Appendix: native libraries (review their licenses separately)"); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, lib := range libs {
+		fmt.Fprintf(&b, "%s (%s)\n", lib.Name, strings.Join(lib.FoundIn, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", divider)
+
+	if _, err := io.WriteString(r.w, b.String()); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// WriteExcluded writes an appendix listing the excluded modules, each with
+// the pattern that excluded it and its reason, if any.
+func (r *textReportWriter) WriteExcluded(excluded []excludedModule) error {
+	if err := r.WriteSection("Appendix: excluded modules"); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, e := range excluded {
+		fmt.Fprintf(&b, "%s (%s)", e.Module, e.Pattern)
+		if e.Reason != "" {
+			fmt.Fprintf(&b, ": %s", e.Reason)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", divider)
+
+	if _, err := io.WriteString(r.w, b.String()); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// MarkIncomplete omits the trailer, so that an incomplete report fails
+// verification just like a truncated one.
+func (r *textReportWriter) MarkIncomplete() {
+	r.trailer = false
+}
+
+func (r *textReportWriter) Close() error {
+	if r.trailer {
+		return r.WriteTrailer()
+	}
+	return nil
+}
+
+// WriteTrailer appends a final line recording the number of entries and the
+// SHA-256 of every byte of the report that precedes the trailer line itself.
+//
+// A consumer can verify a report by hashing everything up to (but excluding)
+// the last line and comparing it with the trailer. A report without a
+// trailer, or with a mismatched one, was truncated or modified.
+func (r *textReportWriter) WriteTrailer() error {
+	sum := r.hash.Sum(nil)
+	_, err := fmt.Fprintf(r.w, "%s%d entries sha256:%x\n", trailerPrefix, r.entries, sum)
+	if err != nil {
+		return fmt.Errorf("error writing report trailer: %v", err)
+	}
+	return nil
+}
+
+const trailerPrefix = "gocomply-end-of-report: "
+
+// jsonReport is the document written by the json report format.
+type jsonReport struct {
+	Generated string  `json:"generated"` // RFC 3339
+	Entries   []Entry `json:"entries"`
+
+	// Incomplete is true if the scan stopped before every module was
+	// scanned, such as after an interrupt.
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// PackageBased is true if the project has no go.mod, so that each module
+	// is guessed from the packages imported, without a version.
+	PackageBased bool `json:"package_based,omitempty"`
+
+	// Excluded lists the modules skipped by --exclude or the ignore file.
+	Excluded []excludedModule `json:"excluded,omitempty"`
+
+	// NativeLibraries lists the C libraries linked by cgo, if enabled, whose
+	// licenses need reviewing separately.
+	NativeLibraries []nativeLibrary `json:"native_libraries,omitempty"`
+
+	// SHA256 is the hex-encoded SHA-256 of every other field of the report.
+	// See reportDigest.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// jsonReportWriter buffers entries and writes a single JSON document on
+// Close. A truncated JSON report fails to parse, so it needs no trailer.
+type jsonReportWriter struct {
+	w         io.Writer
+	checksums bool
+	report    jsonReport
+}
+
+func (r *jsonReportWriter) WriteEntry(e Entry) error {
+	if r.checksums {
+		e.SHA256 = textSHA256(e.License)
+	}
+	r.report.Entries = append(r.report.Entries, e)
+	return nil
+}
+
+func (r *jsonReportWriter) WriteExcluded(excluded []excludedModule) error {
+	r.report.Excluded = excluded
+	return nil
+}
+
+func (r *jsonReportWriter) WriteNative(libs []nativeLibrary) error {
+	r.report.NativeLibraries = libs
+	return nil
+}
+
+func (r *jsonReportWriter) MarkIncomplete() {
+	r.report.Incomplete = true
+}
+
+func (r *jsonReportWriter) Close() error {
+	if r.report.Entries == nil {
+		r.report.Entries = []Entry{}
+	}
+	if r.checksums {
+		digest, err := reportDigest(r.report)
+		if err != nil {
+			return fmt.Errorf("error writing report: %v", err)
+		}
+		r.report.SHA256 = digest
+	}
+
+	enc := json.NewEncoder(r.w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.report); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+// reportDigest returns a checksum over a whole structured report: the
+// SHA-256 of its compact JSON encoding, without its own SHA256. The encoding
+// has the fields in a fixed order, so this is independent of how the report
+// is formatted, and detects a change to any field of the report or of any
+// entry, as well as any added, removed or reordered entry.
+func reportDigest(report jsonReport) (string, error) {
+	report.SHA256 = ""
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// listReportWriter writes one "module version SPDX-id source-url" line per
+// entry. Unknown fields are written as "-", except for an unknown license,
+// which is written as the SPDX "NOASSERTION".
+type listReportWriter struct {
+	w io.Writer
+}
+
+func (r *listReportWriter) WriteEntry(e Entry) error {
+	field := func(s string, empty string) string {
+		if s == "" {
+			return empty
+		}
+		return s
+	}
+
+	_, err := fmt.Fprintf(r.w, "%s %s %s %s\n",
+		e.Module,
+		field(e.Version, "-"),
+		field(e.SPDX, spdxNoAssertion),
+		field(e.SourceURL, "-"))
+	if err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+func (r *listReportWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package licenses
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportWriterTrailer(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTextReportWriter(&buf, reportOptions{Trailer: true})
+
+	entries := []Entry{
+		{Module: "example.org/a", License: "License A"},
+		{Module: "example.org/b", License: "License B"},
+	}
+	for _, e := range entries {
+		if err := r.WriteEntry(e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	body := buf.String()
+
+	if err := r.WriteTrailer(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	trailer := strings.TrimPrefix(buf.String(), body)
+	expected := fmt.Sprintf("%s2 entries sha256:%x\n", trailerPrefix, sha256.Sum256([]byte(body)))
+	if trailer != expected {
+		t.Errorf("expected trailer %q but got %q", expected, trailer)
+	}
+}
+
+func TestJSONReportChecksums(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newReportWriter(&buf, reportOptions{Format: "json", Checksums: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.WriteEntry(Entry{Module: "example.org/a", License: "License A"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid json report: %v", err)
+	}
+
+	licenseSum := fmt.Sprintf("%x", sha256.Sum256([]byte("License A")))
+	unsigned := report
+	unsigned.SHA256 = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reportSum := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	if len(report.Entries) != 1 || report.Entries[0].SHA256 != licenseSum {
+		t.Errorf("expected one entry with sha256 %s but got %+v", licenseSum, report.Entries)
+	}
+	if report.SHA256 != reportSum {
+		t.Errorf("expected report sha256 %s but got %s", reportSum, report.SHA256)
+	}
+}
+
+func TestReportOptionsValidate(t *testing.T) {
+	if err := (reportOptions{Format: "json", Trailer: true}).validate(); err == nil {
+		t.Errorf("expected an error for a json trailer")
+	}
+	if err := (reportOptions{Format: "xml"}).validate(); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestListReport(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newReportWriter(&buf, reportOptions{Format: "list"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := []Entry{
+		{Module: "example.org/a", Version: "v1.2.3", SPDX: "MIT", SourceURL: "https://example.org/a.git"},
+		{Module: "example.org/b"},
+	}
+	for _, e := range entries {
+		if err := r.WriteEntry(e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "example.org/a v1.2.3 MIT https://example.org/a.git\n" +
+		"example.org/b - NOASSERTION -\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestTextReportProvenance(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTextReportWriter(&buf, reportOptions{Provenance: true})
+
+	e := Entry{Module: "example.org/a", License: "License A"}
+	e.setProvenance(licenseFile{
+		Text:      "License A",
+		SourceURL: "https://example.org/a/raw/main/LICENSE",
+		Ref:       "main",
+		Retrieved: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	})
+	if err := r.WriteEntry(e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "example.org/a\n\nLicense A\n\n" +
+		"spdx: NOASSERTION\n" +
+		"source: https://example.org/a/raw/main/LICENSE\n" +
+		"ref: main\n" +
+		"retrieved: 2021-06-01T12:00:00Z\n" +
+		"sha256:" + textSHA256("License A") + "\n\n" +
+		divider + "\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,274 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// reportChange is a module whose entry differs between two reports, for the
+// diff command.
+type reportChange struct {
+	Module string `json:"module"`
+
+	// OldVersion and NewVersion are the module's versions, if the reports
+	// give them (JSON reports do, but text reports don't).
+	OldVersion string `json:"old_version,omitempty"`
+	NewVersion string `json:"new_version,omitempty"`
+
+	OldSPDX string `json:"old_spdx,omitempty"`
+	NewSPDX string `json:"new_spdx,omitempty"`
+}
+
+// reportDiff is the difference between two reports: the modules added and
+// removed, and the modules whose license changed, either its SPDX expression
+// or, with the same expression, its text.
+type reportDiff struct {
+	Added       []reportChange `json:"added"`
+	Removed     []reportChange `json:"removed"`
+	Changed     []reportChange `json:"license_changed"`
+	TextChanged []reportChange `json:"license_text_changed"`
+}
+
+// empty returns true if the reports have the same modules and licenses.
+func (d reportDiff) empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.TextChanged) == 0
+}
+
+// runDiff implements the diff command: two reports, in the text or JSON
+// format, given as files (or - for stdin), are compared, and the modules
+// added, removed and whose licenses changed are written to stdout as
+// Markdown, for release notes or a pull request comment, or, with
+// --format=json, JSON.
+func runDiff(o *options, args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("diff requires two report files: old and new")
+	}
+	if (o.Format != "text") && (o.Format != "json") {
+		return fmt.Errorf("unknown diff format %q", o.Format)
+	}
+
+	var reports [2][]Entry
+	for i, path := range args {
+		data, err := readReportFile(path)
+		if err != nil {
+			return err
+		}
+		reports[i], err = parseReportEntries(data)
+		if err != nil {
+			return fmt.Errorf("error parsing report %q: %v", path, err)
+		}
+	}
+
+	d := diffReports(reports[0], reports[1])
+	if o.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(d)
+	}
+	return d.writeMarkdown(stdout)
+}
+
+// readReportFile reads a report from a file, or stdin for "-".
+func readReportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// parseReportEntries parses the entries of a text or JSON report. Entries
+// of a text report only have their Module (or Name), License and SPDX.
+func parseReportEntries(data []byte) ([]Entry, error) {
+	switch detectReportFormat(data) {
+	case "json":
+		var report jsonReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, err
+		}
+		return report.Entries, nil
+	case "text":
+		return parseTextReportEntries(data), nil
+	default:
+		return nil, fmt.Errorf("only text and JSON reports can be compared")
+	}
+}
+
+// parseTextReportEntries parses the entries of a text report, ignoring any
+// section headings and stopping at the first appendix, as for
+// parseTextReportModules. Each entry is its title, a blank line, its license
+// and a blank line, then lines of metadata starting with "spdx: ".
+func parseTextReportEntries(data []byte) []Entry {
+	var entries []Entry
+	rule := strings.Repeat("=", len(divider))
+	var lines []string
+	inHeading := false
+
+	end := func() {
+		if e, ok := parseTextReportEntry(lines); ok {
+			entries = append(entries, e)
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == divider:
+			end()
+		case line == rule:
+			inHeading = !inHeading
+		case inHeading && strings.HasPrefix(line, "Appendix:"):
+			return entries
+		case inHeading:
+			// a section heading
+		case (len(lines) == 0) && ((line == "") || strings.HasPrefix(line, trailerPrefix)):
+			// between entries, or the end of the report
+		default:
+			lines = append(lines, line)
+		}
+	}
+	end()
+	return entries
+}
+
+// parseTextReportEntry parses the lines of one entry of a text report.
+func parseTextReportEntry(lines []string) (Entry, bool) {
+	for len(lines) > 0 && (lines[len(lines)-1] == "") {
+		lines = lines[:len(lines)-1]
+	}
+
+	// the metadata is the last paragraph, starting with "spdx: "
+	spdx := -1
+	for i := len(lines) - 1; (i > 0) && (lines[i] != ""); i-- {
+		spdx = i
+	}
+	if (spdx < 0) || !strings.HasPrefix(lines[spdx], "spdx: ") {
+		return Entry{}, false
+	}
+
+	e := Entry{Module: lines[0]}
+	if strings.HasPrefix(e.Module, stdlibName) {
+		e.Module, e.Name = stdlibModule, lines[0]
+	}
+	if spdx >= 3 {
+		e.License = strings.Join(lines[2:spdx-1], "\n")
+	}
+
+	// "MIT (100.0% match)"
+	e.SPDX = strings.TrimPrefix(lines[spdx], "spdx: ")
+	if idx := strings.Index(e.SPDX, " ("); idx >= 0 {
+		e.SPDX = e.SPDX[:idx]
+	}
+	if e.SPDX == spdxNoAssertion {
+		e.SPDX = ""
+	}
+	return e, true
+}
+
+// diffReports compares the entries of an old report, before, and a new
+// report, after. An entry repeated in several product sections is only
+// compared once.
+func diffReports(before []Entry, after []Entry) reportDiff {
+	index := func(entries []Entry) (map[string]Entry, []string) {
+		byModule := make(map[string]Entry)
+		var modules []string
+		for _, e := range entries {
+			if _, ok := byModule[e.Module]; !ok {
+				byModule[e.Module] = e
+				modules = append(modules, e.Module)
+			}
+		}
+		sort.Strings(modules)
+		return byModule, modules
+	}
+	oldEntries, oldModules := index(before)
+	newEntries, newModules := index(after)
+
+	d := reportDiff{
+		Added:       []reportChange{},
+		Removed:     []reportChange{},
+		Changed:     []reportChange{},
+		TextChanged: []reportChange{},
+	}
+	for _, module := range oldModules {
+		if _, ok := newEntries[module]; !ok {
+			e := oldEntries[module]
+			d.Removed = append(d.Removed, reportChange{Module: module, OldVersion: e.Version, OldSPDX: e.SPDX})
+		}
+	}
+	for _, module := range newModules {
+		n := newEntries[module]
+		o, ok := oldEntries[module]
+		if !ok {
+			d.Added = append(d.Added, reportChange{Module: module, NewVersion: n.Version, NewSPDX: n.SPDX})
+			continue
+		}
+
+		c := reportChange{module, o.Version, n.Version, o.SPDX, n.SPDX}
+		switch {
+		case o.SPDX != n.SPDX:
+			d.Changed = append(d.Changed, c)
+		case strings.TrimSpace(o.License) != strings.TrimSpace(n.License):
+			d.TextChanged = append(d.TextChanged, c)
+		}
+	}
+	return d
+}
+
+// writeMarkdown writes the difference as Markdown lists.
+func (d reportDiff) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	if d.empty() {
+		b.WriteString("No third-party license changes.\n")
+	}
+
+	spdx := func(expression string) string {
+		if expression == "" {
+			return spdxNoAssertion
+		}
+		return expression
+	}
+	versions := func(c reportChange) string {
+		switch {
+		case (c.OldVersion != "") && (c.NewVersion != "") && (c.OldVersion != c.NewVersion):
+			return fmt.Sprintf(" %s → %s", c.OldVersion, c.NewVersion)
+		case c.NewVersion != "":
+			return " " + c.NewVersion
+		case c.OldVersion != "":
+			return " " + c.OldVersion
+		}
+		return ""
+	}
+	list := func(title string, changes []reportChange, describe func(c reportChange) string) {
+		if len(changes) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "**%s (%d)**\n\n", title, len(changes))
+		for _, c := range changes {
+			fmt.Fprintf(&b, "- `%s`%s: %s\n", c.Module, versions(c), describe(c))
+		}
+	}
+
+	list("Added", d.Added, func(c reportChange) string { return spdx(c.NewSPDX) })
+	list("Removed", d.Removed, func(c reportChange) string { return spdx(c.OldSPDX) })
+	list("License changed", d.Changed, func(c reportChange) string {
+		return fmt.Sprintf("%s → %s", spdx(c.OldSPDX), spdx(c.NewSPDX))
+	})
+	list("License text changed", d.TextChanged, func(c reportChange) string {
+		return spdx(c.NewSPDX) + ", with different text"
+	})
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,109 @@
+package licenses
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTextReportEntries(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTextReportWriter(&buf, reportOptions{Trailer: true, Provenance: true})
+	if err := w.WriteSection("Product: server"); err != nil {
+		t.Fatal(err)
+	}
+	entries := []Entry{
+		{Module: "example.org/a", License: testMITLicense, SPDX: "MIT", SPDXConfidence: 100, SourceURL: "https://example.org/a/LICENSE"},
+		{Module: "example.org/b", License: "Custom terms.\n\nspdx: not metadata"},
+		{Module: stdlibModule, Name: stdlibLabel("go1.21.3"), License: "BSD", SPDX: "BSD-3-Clause"},
+	}
+	for _, e := range entries {
+		if err := w.WriteEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteExcluded([]excludedModule{{"example.org/mine", "example.org/*", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := parseTextReportEntries(buf.Bytes())
+	if len(parsed) != 3 {
+		t.Fatalf("expected 3 entries but got %+v", parsed)
+	}
+	for i, e := range parsed {
+		if (e.Module != entries[i].Module) || (e.License != entries[i].License) || (e.SPDX != entries[i].SPDX) {
+			t.Errorf("expected %+v but got %+v", entries[i], e)
+		}
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	before := []Entry{
+		{Module: "example.org/kept", Version: "v1.0.0", SPDX: "MIT", License: "MIT"},
+		{Module: "example.org/relicensed", Version: "v1.0.0", SPDX: "MIT", License: "MIT"},
+		{Module: "example.org/reworded", Version: "v1.0.0", SPDX: "MIT", License: "MIT"},
+		{Module: "example.org/removed", Version: "v0.1.0", SPDX: "BSD-3-Clause"},
+	}
+	after := []Entry{
+		{Module: "example.org/added", Version: "v2.0.0"},
+		{Module: "example.org/kept", Version: "v1.1.0", SPDX: "MIT", License: "MIT\n"},
+		{Module: "example.org/relicensed", Version: "v2.0.0", SPDX: "GPL-3.0-only", License: "GPL"},
+		{Module: "example.org/reworded", Version: "v1.0.0", SPDX: "MIT", License: "MIT, reworded"},
+		{Module: "example.org/kept", Version: "v1.1.0", SPDX: "MIT", License: "MIT\n"},
+	}
+
+	d := diffReports(before, after)
+	expected := reportDiff{
+		Added:       []reportChange{{Module: "example.org/added", NewVersion: "v2.0.0"}},
+		Removed:     []reportChange{{Module: "example.org/removed", OldVersion: "v0.1.0", OldSPDX: "BSD-3-Clause"}},
+		Changed:     []reportChange{{"example.org/relicensed", "v1.0.0", "v2.0.0", "MIT", "GPL-3.0-only"}},
+		TextChanged: []reportChange{{"example.org/reworded", "v1.0.0", "v1.0.0", "MIT", "MIT"}},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, d)
+	}
+
+	var b strings.Builder
+	if err := d.writeMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	markdown := "**Added (1)**\n\n- `example.org/added` v2.0.0: NOASSERTION\n\n" +
+		"**Removed (1)**\n\n- `example.org/removed` v0.1.0: BSD-3-Clause\n\n" +
+		"**License changed (1)**\n\n- `example.org/relicensed` v1.0.0 → v2.0.0: MIT → GPL-3.0-only\n\n" +
+		"**License text changed (1)**\n\n- `example.org/reworded` v1.0.0: MIT, with different text\n"
+	if b.String() != markdown {
+		t.Errorf("expected %q but got %q", markdown, b.String())
+	}
+
+	b.Reset()
+	if err := diffReports(before, before).writeMarkdown(&b); (err != nil) || (b.String() != "No third-party license changes.\n") {
+		t.Errorf("expected no changes but got %q, %v", b.String(), err)
+	}
+}
+
+func TestParseReportEntriesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newReportWriter(&buf, reportOptions{Format: "json", Generated: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Module: "example.org/a", Version: "v1.0.0", SPDX: "MIT"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := parseReportEntries(buf.Bytes())
+	if (err != nil) || (len(entries) != 1) || (entries[0].Version != "v1.0.0") {
+		t.Errorf("expected one entry but got %+v, %v", entries, err)
+	}
+	if _, err := parseReportEntries([]byte("example.org/a v1.0.0 MIT https://example.org\n")); err == nil {
+		t.Errorf("expected an error for a list report")
+	}
+}
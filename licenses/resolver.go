@@ -0,0 +1,43 @@
+package licenses
+
+import "context"
+
+// Resolver resolves the modules of a provider that gocomply doesn't know,
+// such as an internal forge. Each method returns ok false to leave a module
+// or repository to the next Resolver, and then to gocomply itself.
+//
+// Resolvers are consulted in order, before the table of well-known modules
+// and any go-import lookup.
+type Resolver interface {
+	// Lookup resolves a module path to its repository.
+	Lookup(ctx context.Context, module string) (gi GoImport, ok bool, err error)
+
+	// FileURLs returns the URLs that a file, such as "LICENSE", may be at in
+	// a repository, in order, with the ref (e.g. a branch) of each. A
+	// response that is an HTML page rather than the file is skipped.
+	FileURLs(ctx context.Context, gi GoImport, file string) (urls []FileURL, ok bool)
+}
+
+// resolvers are consulted by lookupModule and resolveFileURL.
+var resolvers []Resolver
+
+// resolverLookup resolves a module with the first Resolver that handles it.
+func resolverLookup(ctx context.Context, module string) (GoImport, bool, error) {
+	for _, r := range resolvers {
+		if gi, ok, err := r.Lookup(ctx, module); ok || (err != nil) {
+			return gi, true, err
+		}
+	}
+	return GoImport{}, false, nil
+}
+
+// resolverFileURLs returns the URLs of a file from the first Resolver that
+// handles a repository.
+func resolverFileURLs(ctx context.Context, gi GoImport, file string) ([]FileURL, bool) {
+	for _, r := range resolvers {
+		if urls, ok := r.FileURLs(ctx, gi, file); ok {
+			return urls, true
+		}
+	}
+	return nil, false
+}
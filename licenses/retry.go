@@ -0,0 +1,59 @@
+package licenses
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultRetries is how many times a request is retried after a transient
+// failure.
+const defaultRetries = 3
+
+// httpRetries is how many times httpDo retries a request.
+var httpRetries = defaultRetries
+
+// retryBaseDelay is the delay before the first retry. It doubles for each
+// later retry.
+var retryBaseDelay = time.Second
+
+// retryable returns true if a request failed for a reason that may be
+// transient: a network error, a 5xx status, or rate limiting that is
+// expected to end soon (see maxRateLimitWait). Other statuses, such as 404,
+// are permanent.
+func retryable(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.RateLimited {
+			return time.Until(statusErr.Reset) <= maxRateLimitWait
+		}
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusRequestTimeout:
+			return true
+		}
+		return statusErr.StatusCode >= 500
+	}
+
+	if _, ok := tlsErrorText(err); ok {
+		return false // it won't fix itself
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.Temporary() || dnsErr.Timeout()
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryDelay returns the delay before a retry, after a number of previous
+// retries: exponential, with jitter so that concurrent clients don't retry
+// in lockstep.
+func retryDelay(retries int) time.Duration {
+	delay := retryBaseDelay << uint(retries)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
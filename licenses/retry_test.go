@@ -0,0 +1,71 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPGetRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		case attempts < 3:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, "License A")
+		}
+	}))
+	defer server.Close()
+
+	oldLimiter, oldDelay := rateLimiter, retryBaseDelay
+	rateLimiter, retryBaseDelay = NoRateLimit, time.Millisecond
+	defer func() { rateLimiter, retryBaseDelay = oldLimiter, oldDelay }()
+
+	text, err := httpGet(context.Background(), server.URL+"/LICENSE", nil)
+	if (err != nil) || (text != "License A") || (attempts != 3) {
+		t.Errorf("expected success on the third attempt but got (%q, %v) after %d", text, err, attempts)
+	}
+
+	// permanent failures aren't retried
+	attempts = 0
+	if _, err := httpGet(context.Background(), server.URL+"/missing", nil); (err == nil) || (attempts != 1) {
+		t.Errorf("expected one failed attempt but got %v after %d", err, attempts)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&httpStatusError{StatusCode: 404}, false},
+		{&httpStatusError{StatusCode: 403}, false},
+		{&httpStatusError{StatusCode: 502}, true},
+		{&httpStatusError{StatusCode: 429}, true},
+		{&httpStatusError{StatusCode: 403, RateLimited: true, Reset: time.Now().Add(time.Hour)}, false},
+		{&httpStatusError{StatusCode: 403, RateLimited: true, Reset: time.Now().Add(time.Second)}, true},
+		{fmt.Errorf("json decode error"), false},
+	}
+
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.expected {
+			t.Errorf("%v: expected %t but got %t", tt.err, tt.expected, got)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for retries := 0; retries < 4; retries++ {
+		max := retryBaseDelay << uint(retries)
+		if delay := retryDelay(retries); (delay < max/2) || (delay > max) {
+			t.Errorf("%d retries: expected a delay between %s and %s but got %s", retries, max/2, max, delay)
+		}
+	}
+}
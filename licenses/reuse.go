@@ -0,0 +1,164 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"strings"
+)
+
+// REUSE (https://reuse.software) keeps license texts in a LICENSES directory,
+// named by SPDX identifier, and may describe which files they apply to in a
+// Debian machine-readable copyright file at .reuse/dep5.
+const (
+	reuseLicensesDir = "LICENSES"
+	reuseDir         = ".reuse"
+	reuseDep5        = "dep5"
+)
+
+// dep5 is the licensing information parsed from a .reuse/dep5 file
+type dep5 struct {
+	Copyrights []string // copyright notices, in order, without duplicates
+	Licenses   []string // SPDX license and exception identifiers, in order, without duplicates
+}
+
+// parseDep5 parses the "Copyright" and "License" fields of every stanza of a
+// Debian machine-readable copyright file. Continuation lines (starting with
+// whitespace) of a Copyright field are additional notices.
+func parseDep5(text string) dep5 {
+	var result dep5
+	seenCopyright := make(map[string]bool)
+	seenLicense := make(map[string]bool)
+
+	addCopyright := func(c string) {
+		c = strings.TrimSpace(c)
+		if (c != "") && !seenCopyright[c] {
+			seenCopyright[c] = true
+			result.Copyrights = append(result.Copyrights, c)
+		}
+	}
+
+	field := ""
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			// continuation of a field
+			if field == "copyright" {
+				addCopyright(line)
+			}
+			continue
+		}
+
+		idx := strings.IndexByte(line, ':')
+		if idx < 0 {
+			field = ""
+			continue
+		}
+
+		field = strings.ToLower(strings.TrimSpace(line[:idx]))
+		value := strings.TrimSpace(line[idx+1:])
+
+		switch field {
+		case "copyright":
+			addCopyright(value)
+		case "license":
+			for _, id := range spdxExpressionIDs(value) {
+				if !seenLicense[id] {
+					seenLicense[id] = true
+					result.Licenses = append(result.Licenses, id)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// spdxExpressionIDs returns the license and exception identifiers in an SPDX
+// license expression such as "(MIT OR Apache-2.0) AND GPL-2.0 WITH
+// Classpath-exception-2.0".
+func spdxExpressionIDs(expr string) []string {
+	expr = strings.NewReplacer("(", " ", ")", " ").Replace(expr)
+
+	var ids []string
+	for _, word := range strings.Fields(expr) {
+		switch strings.ToUpper(word) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(word, "+"))
+	}
+	return ids
+}
+
+// tryGetREUSELicense is a fallback for repositories that can't be listed and
+// have no top-level license file. If the repository has a .reuse/dep5 file,
+// it is included, along with LICENSES/<id>.txt for every license it names.
+func tryGetREUSELicense(ctx context.Context, gi GoImport, gs GoSource) (licenseFile, bool) {
+	dep5URLs, decoder, err := resolveFileURL(ctx, gi, gs, reuseDir+"/"+reuseDep5)
+	if err != nil {
+		return licenseFile{}, false
+	}
+
+	for _, dep5URL := range dep5URLs {
+		data, err := httpGet(ctx, dep5URL.URL, nil)
+		if err != nil {
+			continue
+		}
+		data, err = decoder(data)
+		if err != nil {
+			continue
+		}
+		data, ok := licenseText(dep5URL.URL, data)
+		if !ok {
+			continue
+		}
+
+		parts := []licensePart{{
+			File:      reuseDir + "/" + reuseDep5,
+			SourceURL: dep5URL.URL,
+			Text:      data,
+		}}
+
+		for _, id := range parseDep5(data).Licenses {
+			file := reuseLicensesDir + "/" + id + ".txt"
+			licenseURLs, decoder, err := resolveFileURL(ctx, gi, gs, file)
+			if err != nil {
+				continue
+			}
+
+			for _, licenseURL := range licenseURLs {
+				if licenseURL.Ref != dep5URL.Ref {
+					continue
+				}
+
+				text, err := httpGet(ctx, licenseURL.URL, nil)
+				if err != nil {
+					break
+				}
+				text, err = decoder(text)
+				if err != nil {
+					break
+				}
+				text, ok := licenseText(licenseURL.URL, text)
+				if !ok {
+					break
+				}
+
+				parts = append(parts, licensePart{
+					File:      file,
+					SourceURL: licenseURL.URL,
+					Text:      text,
+				})
+			}
+		}
+
+		license := combineLicenseParts(parts)
+		license.Ref = dep5URL.Ref
+		license.Retrieved = retrievalTime()
+		return license, true
+	}
+
+	return licenseFile{}, false
+}
@@ -0,0 +1,39 @@
+package licenses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDep5(t *testing.T) {
+	input := `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: example
+Source: https://example.org/example
+
+Files: *
+Copyright: 2019 Jane Doe <jane@example.org>
+  2020 Example Ltd.
+License: MIT OR Apache-2.0
+
+Files: vendor/*
+Copyright: 2019 Jane Doe <jane@example.org>
+License: GPL-2.0-or-later WITH Classpath-exception-2.0
+`
+
+	expected := dep5{
+		Copyrights: []string{"2019 Jane Doe <jane@example.org>", "2020 Example Ltd."},
+		Licenses:   []string{"MIT", "Apache-2.0", "GPL-2.0-or-later", "Classpath-exception-2.0"},
+	}
+
+	if got := parseDep5(input); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+}
+
+func TestSPDXExpressionIDs(t *testing.T) {
+	expected := []string{"MIT", "Apache-2.0", "GPL-2.0", "Classpath-exception-2.0"}
+	got := spdxExpressionIDs("(MIT OR Apache-2.0) AND GPL-2.0+ WITH Classpath-exception-2.0")
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+}
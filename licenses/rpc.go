@@ -0,0 +1,220 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for a notification
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcScanParams are the parameters of the "scan" method. Modules are given
+// in the same form as command-line arguments ("path" or "path@version"). If
+// there are none, every module required by the module in the current
+// directory is scanned.
+type rpcScanParams struct {
+	Modules  []string `json:"modules"`
+	List     bool     `json:"list"`
+	Identify bool     `json:"identify"`
+}
+
+// rpcScanResult is the result of the "scan" method.
+type rpcScanResult struct {
+	Entries []Entry     `json:"entries"`
+	Summary *runSummary `json:"summary"`
+}
+
+// Parameters of the notifications sent while scanning.
+type (
+	// rpcProgress is sent as "progress" before each module is scanned
+	rpcProgress struct {
+		Module string `json:"module"`
+		Index  int    `json:"index"` // 1-based
+		Total  int    `json:"total"`
+	}
+
+	// rpcEntry is sent as "entry" for each module scanned successfully
+	rpcEntry struct {
+		Entry Entry `json:"entry"`
+	}
+
+	// rpcFailure is sent as "failure" for each module that failed
+	rpcFailure struct {
+		Module string `json:"module"`
+		Phase  string `json:"phase"`
+		Error  string `json:"error"`
+	}
+)
+
+// rpcWriter writes JSON-RPC messages, one per line.
+type rpcWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (w *rpcWriter) write(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(v); err != nil {
+		return fmt.Errorf("error writing rpc message: %v", err)
+	}
+	return nil
+}
+
+func (w *rpcWriter) notify(method string, params interface{}) error {
+	return w.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// runRPC implements the rpc command: JSON-RPC 2.0 over stdio, so that other
+// tools can drive scans programmatically. Each request is read from in, and
+// each response and notification is written to out, as one JSON object per
+// line. Requests are handled one at a time, in order.
+//
+// Methods:
+//
+//	scan (rpcScanParams) -> rpcScanResult, with "progress", "entry" and
+//	    "failure" notifications while it runs
+//	exit -> null, then the command exits
+func runRPC(ctx context.Context, o *options, in io.Reader, out io.Writer, scan scanFunc) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	w := &rpcWriter{enc: enc}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := w.write(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{rpcParseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rerr := rpcDispatch(ctx, o, req, w, scan)
+		if req.ID == nil {
+			// a notification has no response
+		} else if err := w.write(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}); err != nil {
+			return err
+		}
+
+		if (req.Method == "exit") && (rerr == nil) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func rpcDispatch(ctx context.Context, o *options, req rpcRequest, w *rpcWriter, scan scanFunc) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{rpcInvalidRequest, "expected jsonrpc \"2.0\""}
+	}
+
+	switch req.Method {
+	case "scan":
+		var params rpcScanParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &rpcError{rpcInvalidParams, err.Error()}
+			}
+		}
+		result, err := rpcScan(ctx, o, params, w, scan)
+		if err != nil {
+			return nil, &rpcError{rpcServerError, err.Error()}
+		}
+		return result, nil
+
+	case "exit":
+		return json.RawMessage("null"), nil
+
+	default:
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+func rpcScan(ctx context.Context, o *options, params rpcScanParams, w *rpcWriter, scan scanFunc) (*rpcScanResult, error) {
+	modules, err := modulesToScan(ctx, o, params.Modules)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := *o
+	opts.List = params.List
+	opts.Identify = params.Identify
+
+	summary := newRunSummary()
+	result := &rpcScanResult{Entries: []Entry{}, Summary: summary}
+
+	for i, m := range modules {
+		if err := w.notify("progress", rpcProgress{m.Path, i + 1, len(modules)}); err != nil {
+			return nil, err
+		}
+		summary.Modules++
+
+		entry, err := scan(ctx, m, &opts)
+		if err != nil {
+			phase := scanErrorPhase(err)
+			if phase == phaseLookup {
+				summary.lookupFailed(m.Path, err)
+			} else {
+				summary.licenseFailed(m.Path, err)
+			}
+			if err := w.notify("failure", rpcFailure{m.Path, phase, err.Error()}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !opts.List || opts.Identify {
+			summary.found(entry)
+		}
+		result.Entries = append(result.Entries, entry)
+		if err := w.notify("entry", rpcEntry{entry}); err != nil {
+			return nil, err
+		}
+	}
+
+	summary.finish()
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRPC(t *testing.T) {
+	scan := func(ctx context.Context, m Module, o *options) (Entry, error) {
+		if m.Path == "example.org/b" {
+			return Entry{}, &scanError{phaseLookup, fmt.Errorf("unable to lookup module")}
+		}
+		return Entry{Module: m.Path, Version: m.Version, License: "License " + m.Path}, nil
+	}
+
+	input := strings.Join([]string{
+		`not json`,
+		`{"jsonrpc": "2.0", "id": 1, "method": "bogus"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "scan", "params": {"modules": ["example.org/a@v1.0.0", "example.org/b"]}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "exit"}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "scan"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runRPC(context.Background(), &options{}, strings.NewReader(input), &out, scan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+
+	var messages []message
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m message
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid message %q: %v", line, err)
+		}
+		messages = append(messages, m)
+	}
+
+	// parse error, unknown method, 3 progress, 2 entries, 1 failure, scan result, exit
+	var methods []string
+	for _, m := range messages {
+		switch {
+		case m.Method != "":
+			methods = append(methods, m.Method)
+		case m.Error != nil:
+			methods = append(methods, fmt.Sprintf("error %d", m.Error.Code))
+		default:
+			methods = append(methods, "result "+string(m.ID))
+		}
+	}
+	expected := []string{
+		"error -32700",
+		"error -32601",
+		"progress", "entry",
+		"progress", "failure",
+		"progress", "entry",
+		"result 2",
+		"result 3",
+	}
+	if strings.Join(methods, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("expected messages %q but got %q", expected, methods)
+	}
+
+	var result rpcScanResult
+	if err := json.Unmarshal(messages[8].Result, &result); err != nil {
+		t.Fatalf("invalid scan result: %v", err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].Version != "v1.0.0" {
+		t.Errorf("unexpected entries %+v", result.Entries)
+	}
+	if result.Summary.LookupFailures != 1 || result.Summary.Found != 2 {
+		t.Errorf("unexpected summary %+v", result.Summary)
+	}
+}
@@ -0,0 +1,1173 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// options are the command-line options shared by every command
+type options struct {
+	Output         string
+	Format         string
+	Package        string
+	SummaryPath    string
+	Trailer        bool
+	Checksums      bool
+	Provenance     bool
+	List           bool
+	Identify       bool
+	Copyrights     bool
+	PlainText      bool
+	Obligations    bool
+	Products       productFlags
+	Exclude        patternFlags
+	Only           patternFlags
+	IgnoreFile     string
+	Baseline       string
+	NewOnly        string
+	Against        string
+	Policy         string
+	Project        projectLicense
+	Proxy          string
+	Overrides      string
+	NoKnown        bool
+	Strict         bool
+	Cache          string
+	SharedCache    string
+	CacheMaxAge    time.Duration
+	Fresh          time.Duration
+	Retries        int
+	Journal        string
+	Lock           string
+	Resume         bool
+	DryRun         bool
+	Fix            bool
+	Listen         string
+	Evidence       string
+	Bazel          string
+	Cgo            bool
+	Tools          string
+	Refetch        bool
+	OSV            bool
+	CgoBinary      string
+	AuditLog       string
+	Sign           string
+	SignKey        string
+	Delay          time.Duration
+	GitHubDelay    time.Duration
+	Timeout        time.Duration
+	Contact        string
+	CACert         string
+	ClientCert     string
+	ClientKey      string
+	Deadline       time.Duration
+	GitCredentials bool
+	SSH            bool
+	Quiet          bool
+	Verbose        bool
+	VeryVerbose    bool
+
+	// loaded by options.load
+	baseline  *baseline
+	policy    *policy          // nil if there is no policy
+	existing  map[string]bool  // modules in the NewOnly report
+	overrides overrides        // from the policy and Overrides files
+	journal   string           // Journal, or the default journal, if any
+	lock      *lockFile        // nil without a Lock
+	set       map[string]bool  // flags given on the command-line
+	private   privatePatterns  // from go env
+	ignore    patternFlags     // from the ignore file
+	excluded  []excludedModule // skipped by modulesToScan, for the appendix
+	fixer     *fixer           // with --fix on a terminal
+	evidence  *evidenceBundle  // with --evidence, while scanning
+	committed *reportTimes     // with --against, while regenerating
+
+	packageBased bool // modules are guessed from packages (GOPATH mode)
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Output, "output", "", "write the report to this file instead of stdout (recommended)")
+	fs.StringVar(&o.Output, "o", "", "shorthand for -output")
+	fs.BoolVar(&o.Trailer, "trailer", false, "append a line with an entry count and SHA-256 of the report")
+	fs.StringVar(&o.Format, "format", "text", "report format: text, json, gosource (a generated Go file) or ort (an OSS Review Toolkit analyzer result)")
+	fs.StringVar(&o.Package, "package", "", "for --format=gosource, the Go package (default $GOPACKAGE, or the output file's directory)")
+	fs.StringVar(&logFormat, "log-format", "text", "format of progress and warnings on stderr: text or json")
+	fs.StringVar(&ciMode, "ci", "", "integrate with a CI system: github, for workflow annotations, a job summary and step outputs")
+	fs.BoolVar(&o.Quiet, "q", false, "only log errors, not progress and warnings")
+	fs.BoolVar(&o.Quiet, "quiet", false, "same as -q")
+	fs.BoolVar(&o.Verbose, "v", false, "also log each module's repository and each request's URL and HTTP status")
+	fs.BoolVar(&o.VeryVerbose, "vv", false, "as -v, and also log how long each request took")
+	fs.StringVar(&o.SummaryPath, "summary", "", "also write the end-of-run summary as JSON to this file")
+	fs.StringVar(&o.Bazel, "bazel", "", "read the modules from this Bazel file instead of go list: deps.bzl or WORKSPACE (go_repository rules), MODULE.bazel (the go_deps extension), or saved \"bazel query --output=build\" output")
+	fs.BoolVar(&o.OSV, "osv", false, "also query OSV.dev for the known vulnerabilities of each module version, and list their ids in structured reports")
+	fs.BoolVar(&o.Refetch, "refetch", false, "for the verify command, also fetch each license in a JSON report again (using the --cache, falling back to the module cache) and fail if any no longer matches its checksum")
+	fs.StringVar(&o.Tools, "tools", toolsExclude, "what to do with modules only needed by tools (go.mod tool directives or a tools.go): exclude, annotate (as build-time only) or include")
+	fs.BoolVar(&o.Cgo, "cgo", false, "also list the C libraries linked by cgo (from #cgo directives), whose licenses need reviewing separately, in an appendix")
+	fs.StringVar(&o.CgoBinary, "cgo-binary", "", "with --cgo, also list the shared libraries that this built binary is dynamically linked against")
+	fs.StringVar(&o.AuditLog, "audit-log", "", "record every request made (URL, method, status, bytes, duration and whether the cache was used) in this file, as lines of JSON")
+	fs.StringVar(&o.Evidence, "evidence", "", "also archive every license file, as fetched, in this zip file, with a manifest of URLs, refs, timestamps, HTTP headers and checksums")
+	fs.StringVar(&o.Sign, "sign", "", "with --output, also write a detached signature of the report and evidence bundle: gpg (.asc) or cosign (.bundle, keyless unless --sign-key)")
+	fs.StringVar(&o.SignKey, "sign-key", "", "with --sign, the gpg key (as for --local-user) or cosign key reference to sign with")
+	fs.BoolVar(&o.Checksums, "checksums", false, "include a SHA-256 of each license text and of the whole report")
+	fs.BoolVar(&o.Provenance, "provenance", false, "record where each license came from: URL, ref, revision, retrieval time and SHA-256")
+	fs.BoolVar(&o.List, "list", false, "list \"module version SPDX-id source-url\" per line without fetching license texts")
+	fs.BoolVar(&o.Identify, "identify", false, "with --list, fetch each license (without printing it) to identify its SPDX id")
+	fs.BoolVar(&o.Copyrights, "source-copyrights", false, "also collect copyright notices from the headers of each module's downloaded Go source files")
+	fs.BoolVar(&o.PlainText, "plain-text", false, "convert Markdown and reStructuredText license files, like LICENSE.md, to plain text in the report")
+	fs.BoolVar(&o.Obligations, "obligations", false, "summarise the standard obligations of each identified license")
+	fs.Var(&o.Products, "product", "group the report by product, given as name=pattern[,pattern...] (repeatable)")
+	fs.Var(&o.Exclude, "exclude", "skip modules matching this glob (as in GOPRIVATE) or, with a re: prefix, regular expression (repeatable)")
+	fs.Var(&o.Only, "only", "scan only modules matching this glob or re: regular expression (repeatable)")
+	fs.StringVar(&o.IgnoreFile, "ignore-file", "", "file of module patterns to skip, each with an optional # reason for the report's appendix (default "+defaultIgnoreFile+", if it exists)")
+	fs.StringVar(&o.Baseline, "baseline", "", "approved inventory (JSON): only report modules and licenses not in it")
+	fs.StringVar(&o.Policy, "policy", "", "license policy (YAML or TOML) for the check command (default "+defaultPolicyFile+" or "+defaultTOMLPolicyFile+", if it exists)")
+	fs.StringVar(&o.Policy, "config", "", "shorthand for -policy: the same file (YAML, or TOML if named *.toml) also sets the defaults of other options")
+	fs.StringVar(&o.Project.License, "project-license", "", "the project's own SPDX license, for the compat command (default: detected from its license file)")
+	fs.StringVar(&o.Project.Distribution, "distribution", "", "how the project is distributed, for the compat command: binary (default), source or saas")
+	fs.StringVar(&o.Proxy, "proxy", "", "fetch licenses from the module zips on this module proxy, falling back to each repository")
+	fs.StringVar(&o.Overrides, "overrides", "", "overrides file (YAML) assigning the licenses of specific modules manually")
+	fs.BoolVar(&o.NoKnown, "no-known-modules", false, "don't use the built-in table of well-known modules' repositories; look every module up")
+	fs.StringVar(&o.NewOnly, "new-only", "", "existing report: only scan and report modules that are not already in it")
+	fs.StringVar(&o.Against, "against", "", "committed report: for the check command, regenerate it and fail with a diff if it is out of date")
+	fs.BoolVar(&o.Strict, "strict", false, "exit with a non-zero status if any license is missing or unidentified, or violates the policy")
+	fs.StringVar(&o.Cache, "cache", os.Getenv(cacheEnv), "cache downloads in this directory (or, given alone, in the user cache directory), revalidating them instead of downloading them again (default $"+cacheEnv+")")
+	fs.DurationVar(&o.Fresh, "fresh", 0, "with --cache and --output, skip the scan if the report was written less than this long ago with the same go.sum and options, as for go:generate")
+	fs.StringVar(&o.SharedCache, "shared-cache", os.Getenv(sharedCacheEnv), "also share the cache at this http(s)://, s3:// or gs:// URL (default $"+sharedCacheEnv+")")
+	fs.DurationVar(&o.CacheMaxAge, "older-than", defaultCacheMaxAge, "for cache clean, remove entries stored longer ago than this (0 removes every entry)")
+	fs.IntVar(&o.Retries, "retries", defaultRetries, "retry each request this many times after a transient failure (a network error, 5xx status or brief rate limit)")
+	fs.DurationVar(&o.Delay, "delay", DefaultPoliteDelay, "minimum delay between requests to the same host")
+	fs.DurationVar(&o.GitHubDelay, "github-delay", 0, "minimum delay between GitHub API requests (which are also paced by its rate limit)")
+	fs.DurationVar(&o.Timeout, "timeout", DefaultHTTPTimeout, "time limit for each HTTP request")
+	fs.StringVar(&o.Contact, "contact", os.Getenv(contactEnv), "a URL or email address to send in the User-Agent, so that the admins of the hosts requested can contact you (default $"+contactEnv+")")
+	fs.StringVar(&o.CACert, "ca-cert", os.Getenv(caCertEnv), "also trust the certificate authorities in this PEM file, such as a proxy's private CA (default $"+caCertEnv+")")
+	fs.StringVar(&o.ClientCert, "client-cert", os.Getenv(clientCertEnv), "present the client certificate in this PEM file, for mutual TLS (default $"+clientCertEnv+")")
+	fs.StringVar(&o.ClientKey, "client-key", os.Getenv(clientKeyEnv), "the private key of --client-cert, in a PEM file (default $"+clientKeyEnv+")")
+	fs.DurationVar(&o.Deadline, "deadline", 0, "time limit for the whole command, after which it stops early (0 for none)")
+	fs.BoolVar(&o.GitCredentials, "git-credentials", true, "ask git's credential helpers for credentials for a host that requires them (-git-credentials=false to disable)")
+	fs.BoolVar(&o.SSH, "ssh", true, "for a private module, fall back to a shallow git clone over SSH if its license can't be fetched over https (-ssh=false to disable)")
+	fs.StringVar(&o.Journal, "journal", "", "record each module's result in this file as it completes (default: in the user cache directory)")
+	fs.StringVar(&o.Lock, "lock", "", "record each module's license and its SHA-256 in this file (e.g. gocomply.lock), and warn when one changes")
+	fs.BoolVar(&o.Resume, "resume", false, "continue from the journal of a previous run that stopped, without scanning its completed modules again")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "resolve each module and print the requests that would be made for its license, without downloading anything")
+	fs.StringVar(&o.Listen, "listen", defaultListen, "for the serve command, the address to listen on")
+	fs.BoolVar(&o.Fix, "fix", false, "on a terminal, ask for the license URL or file path of each module whose license can't be found, and offer to save it as an override")
+}
+
+// verbosity returns the logVerbosity of the -q, -v and -vv options.
+func (o *options) verbosity() (int, error) {
+	switch {
+	case o.Quiet && (o.Verbose || o.VeryVerbose):
+		return 0, fmt.Errorf("-q cannot be combined with -v or -vv")
+	case o.Quiet:
+		return -1, nil
+	case o.VeryVerbose:
+		return 2, nil
+	case o.Verbose:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// parsed records which flags were given on the command-line, so that they
+// take precedence over the policy file.
+func (o *options) parsed(fs *flag.FlagSet) {
+	o.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		o.set[f.Name] = true
+	})
+}
+
+// load reads any files named by the options.
+func (o *options) load(ctx context.Context) error {
+	o.journal = o.Journal
+	if o.journal == "" {
+		o.journal = defaultJournalPath()
+	}
+
+	if o.Retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	httpRetries = o.Retries
+	o.private = loadPrivatePatterns(ctx)
+
+	var caches layeredCache
+	if o.Cache != "" {
+		caches = append(caches, dirCache{dir: o.Cache})
+	}
+	if o.SharedCache != "" {
+		shared, err := newSharedCache(o.SharedCache)
+		if err != nil {
+			return err
+		}
+		caches = append(caches, shared)
+	}
+	if len(caches) == 1 {
+		httpCache = caches[0]
+	} else if len(caches) > 1 {
+		httpCache = caches
+	}
+
+	if o.Baseline != "" {
+		b, err := loadBaseline(o.Baseline)
+		if err != nil {
+			return err
+		}
+		o.baseline = b
+	}
+	p, err := loadPolicy(o.Policy)
+	if err != nil {
+		return err
+	}
+	o.policy = p
+	o.applyConfig()
+	useGitCredentials = o.GitCredentials
+
+	if err := o.configureRequests(); err != nil {
+		return err
+	}
+	if err := configureTLS(o.CACert, o.ClientCert, o.ClientKey); err != nil {
+		return err
+	}
+
+	var gitlab gitlabConfig
+	if p != nil {
+		gitlab = p.GitLab
+	}
+	configureGitLab(gitlab, os.Getenv)
+	if p != nil {
+		configureForges(p.Forges)
+	}
+
+	o.overrides = make(overrides)
+	if p != nil {
+		dir, err := filepath.Abs(filepath.Dir(o.policyPath()))
+		if err != nil {
+			return err
+		}
+		o.overrides.add(p.Overrides, dir) // already validated
+	}
+	if o.Overrides != "" {
+		ovs, err := loadOverrides(o.Overrides)
+		if err != nil {
+			return err
+		}
+		for module, ov := range ovs {
+			o.overrides[module] = ov
+		}
+	}
+
+	if o.Fix {
+		save := o.Overrides
+		if save == "" {
+			save = o.policyPath()
+		}
+		if isTOML(save) {
+			return fmt.Errorf("--fix can't save overrides to %q: give a YAML file with --overrides", save)
+		}
+		o.fixer = newFixer(save)
+	}
+
+	ignore, err := loadIgnoreFile(o.IgnoreFile)
+	if err != nil {
+		return err
+	}
+	o.ignore = ignore
+
+	if o.Lock != "" {
+		lock, err := loadLock(o.Lock)
+		if err != nil {
+			return err
+		}
+		o.lock = lock
+	}
+
+	if o.NewOnly != "" {
+		existing, err := loadReportModules(o.NewOnly)
+		if err != nil {
+			return err
+		}
+		o.existing = existing
+	}
+	return nil
+}
+
+// configureRequests sets the request delays, timeout and contact from the
+// command-line and the policy file.
+func (o *options) configureRequests() error {
+	var c requestConfig
+	if o.policy != nil {
+		c = o.policy.Requests
+	}
+
+	delay, githubDelay, timeout := o.Delay, o.GitHubDelay, o.Timeout
+	if (c.Delay != nil) && !o.set["delay"] {
+		delay = *c.Delay
+	}
+	if (c.GitHubDelay != nil) && !o.set["github-delay"] {
+		githubDelay = *c.GitHubDelay
+	}
+	if (c.Timeout != nil) && !o.set["timeout"] {
+		timeout = *c.Timeout
+	}
+	httpContact = o.Contact
+	if (c.Contact != nil) && !o.set["contact"] {
+		httpContact = *c.Contact
+	}
+	if (delay < 0) || (githubDelay < 0) || (timeout <= 0) {
+		return fmt.Errorf("delays must not be negative, and the timeout must be positive")
+	}
+
+	delays := map[string]time.Duration{"api.github.com": githubDelay}
+	for host, d := range c.Hosts {
+		if d < 0 {
+			return fmt.Errorf("the delay for %s must not be negative", host)
+		}
+		delays[strings.ToLower(host)] = d
+	}
+	if o.set["github-delay"] {
+		delays["api.github.com"] = githubDelay
+	}
+
+	rateLimiter = NewPoliteRateLimiter(delay, delays)
+	httpTimeout = timeout
+	return nil
+}
+
+// projectLicense returns the project's license and distribution model from
+// the command-line, or else the policy.
+func (o *options) projectLicense() projectLicense {
+	p := o.Project
+	if o.policy != nil {
+		if p.License == "" {
+			p.License = o.policy.Project.License
+		}
+		if p.Distribution == "" {
+			p.Distribution = o.policy.Project.Distribution
+		}
+	}
+	return p
+}
+
+// policyPath returns the path of the policy file.
+func (o *options) policyPath() string {
+	if o.Policy == "" {
+		return findPolicy()
+	}
+	return o.Policy
+}
+
+// proxy returns the module proxy configuration from the command-line and
+// the policy file. Its URL is empty if there is no proxy.
+func (o *options) proxy() proxyConfig {
+	var c proxyConfig
+	if o.policy != nil {
+		c = o.policy.Proxy
+	}
+	if o.Proxy != "" {
+		c.URL = o.Proxy
+	}
+	return c
+}
+
+// reportOptions returns validated options for a reportWriter.
+func (o *options) reportOptions() (reportOptions, error) {
+	generated, err := artifactTime()
+	if err != nil {
+		return reportOptions{}, err
+	}
+
+	format := o.Format
+	if o.List {
+		if format != "text" {
+			return reportOptions{}, fmt.Errorf("--list cannot be combined with --format")
+		}
+		format = "list"
+	}
+
+	opts := reportOptions{
+		Format:     format,
+		Checksums:  o.Checksums,
+		Provenance: o.Provenance,
+		Trailer:    o.Trailer,
+		Generated:  generated,
+	}
+	if format == "gosource" {
+		opts.Package, err = goPackageName(o.Package, o.Output)
+		if err != nil {
+			return reportOptions{}, err
+		}
+	}
+	if format == "ort" {
+		opts.Project = readModulePath(findGoMod())
+	}
+	return opts, opts.validate()
+}
+
+// modulesToScan returns the modules given as arguments or, if there are
+// none, the modules of the Bazel file given by --bazel, or else every module
+// required by the module in the current directory. In
+// either case, the standard library is added to the end, and modules
+// skipped by --exclude, --only and the ignore file are removed (and those
+// excluded recorded in o.excluded).
+func modulesToScan(ctx context.Context, o *options, args []string) ([]Module, error) {
+	var modules []Module
+	var tools map[string]bool
+
+	switch o.Tools {
+	case "", toolsExclude, toolsAnnotate, toolsInclude:
+	default:
+		return nil, fmt.Errorf("unknown --tools %q (expected exclude, annotate or include)", o.Tools)
+	}
+
+	if len(args) > 0 {
+		for _, arg := range args {
+			// future-proof - might take arguments in future
+			if strings.HasPrefix(arg, "-") {
+				return nil, fmt.Errorf("unrecognised argument %q", arg)
+			}
+			modules = append(modules, parseModuleArg(arg))
+		}
+	} else if o.Bazel != "" {
+		var err error
+		modules, err = bazelModules(o.Bazel)
+		if err != nil {
+			return nil, err
+		}
+	} else if !hasGoMod() {
+		logf(levelWarning, phaseSetup, "", nil, "warning: no go.mod, so listing the packages imported (GOPATH mode) and guessing their modules from their import paths")
+		var err error
+		modules, err = listPackageModules(ctx)
+		if err != nil {
+			return nil, err
+		}
+		o.packageBased = true
+	} else {
+		var err error
+		modules, err = listModules(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if o.Tools != toolsInclude {
+			tools, err = toolModules(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the standard library, at the version of the toolchain in use
+	modules = append(modules, Module{Path: stdlibModule, Version: stdlibVersion(ctx)})
+
+	modules, o.excluded = moduleFilter{o.Exclude, o.Only, o.ignore}.filter(modules)
+	modules, toolsExcluded := applyToolsMode(o.Tools, modules, tools)
+	o.excluded = append(o.excluded, toolsExcluded...)
+	return modules, nil
+}
+
+// scanError is returned by scanModule, recording which phase failed
+type scanError struct {
+	Phase string // phaseLookup or phaseLicense
+	Err   error
+}
+
+func (e *scanError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *scanError) Unwrap() error {
+	return e.Err
+}
+
+// scanErrorPhase returns the phase in which a scan failed.
+func scanErrorPhase(err error) string {
+	var serr *scanError
+	if errors.As(err, &serr) {
+		return serr.Phase
+	}
+	return phaseLicense
+}
+
+// scanModule looks up a module and, unless only listing modules, fetches its
+// license. Errors are of type *scanError.
+func scanModule(ctx context.Context, m Module, o *options) (Entry, error) {
+	ov, ok := o.overrides[m.Path]
+	if ok && (ov.When == overrideAlways) {
+		return overrideEntry(ctx, m, ov, o)
+	}
+
+	entry, err := scanModuleRemote(ctx, m, o)
+	if (err != nil) && ok {
+		logf(levelWarning, scanErrorPhase(err), m.Path, err, "%v (using override)", err)
+		return overrideEntry(ctx, m, ov, o)
+	}
+	return entry, err
+}
+
+// overrideEntry returns the entry for a module's override.
+func overrideEntry(ctx context.Context, m Module, ov override, o *options) (Entry, error) {
+	license, err := ov.license(ctx)
+	if err != nil {
+		return Entry{}, &scanError{phaseLicense, err}
+	}
+	return newEntry(ctx, m, license, o), nil
+}
+
+// scanModuleRemote looks up a module and fetches its license from the
+// network. For a module that go.mod replaces, that is its replacement's
+// license, or for a local directory, the license in that directory.
+func scanModuleRemote(ctx context.Context, m Module, o *options) (Entry, error) {
+	if m.ReplaceDir != "" {
+		return scanReplacementDir(ctx, m, o)
+	}
+	src := m.source()
+	module := src.Path
+
+	// "golang.org is a known non-module"
+	// if strings.HasPrefix(module, "golang.org") {
+	//    continue
+	// }
+
+	if (module == stdlibModule) && (m.Version != "") && (!o.List || o.Identify) {
+		license, err := getStdlibLicense(ctx, m.Version)
+		if err == nil {
+			return newEntry(ctx, m, license, o), nil
+		}
+		logf(levelWarning, phaseLicense, module, err, "warning: %v (using the latest license)", err)
+	}
+
+	if proxy := o.proxy(); (proxy.URL != "") && (src.Version != "") && (module != stdlibModule) && (!o.List || o.Identify) && !o.private.noProxy(module) {
+		license, err := tryGetProxyLicense(ctx, proxy, src)
+		if err == nil {
+			return newEntry(ctx, m, license, o), nil
+		}
+		logf(levelWarning, phaseLicense, module, err, "warning: module proxy: %v", err)
+	}
+
+	gi, gs, err := lookupModule(ctx, module, o)
+	if err != nil {
+		return Entry{}, &scanError{phaseLookup, fmt.Errorf("unable to lookup module %q: %w", module, err)}
+	}
+	logf(levelDebug, phaseLookup, module, nil, "%s: repository %s (%s)", module, gi.RepoRoot, gi.Vcs)
+
+	if o.List && !o.Identify {
+		return Entry{
+			Module:     m.Path,
+			Version:    m.Version,
+			PURL:       purl(m.Path, m.Version),
+			ReplacedBy: m.replacement(),
+			Indirect:   m.Indirect,
+			BuildOnly:  m.Tool,
+			SourceURL:  gi.RepoRoot,
+		}, nil
+	}
+
+	license, err := sharedLicense(ctx, module, gi, gs)
+	if (err != nil) && o.trySSH(module, gi) {
+		if sshLicense, sshErr := tryGetSSHLicense(ctx, gi); sshErr == nil {
+			license, err = sshLicense, nil
+		} else {
+			err = fmt.Errorf("%w (and over SSH: %v)", err, sshErr)
+		}
+	}
+	if err != nil {
+		return Entry{}, &scanError{phaseLicense, fmt.Errorf("unable to find a license for module %q: %w", module, err)}
+	}
+
+	return newEntry(ctx, m, license, o), nil
+}
+
+// scanReplacementDir returns the entry for a module that go.mod replaces
+// with a local directory, from the license in that directory.
+func scanReplacementDir(ctx context.Context, m Module, o *options) (Entry, error) {
+	if o.List && !o.Identify {
+		return Entry{Module: m.Path, Version: m.Version, PURL: purl(m.Path, m.Version), ReplacedBy: m.replacement(), Indirect: m.Indirect, BuildOnly: m.Tool}, nil
+	}
+	parts := dirLicenseParts(m.ReplaceDir, licenseCandidates(m.Path))
+	if len(parts) == 0 {
+		return Entry{}, &scanError{phaseLicense, fmt.Errorf("unable to find a license for module %q in %s, which replaces it", m.Path, m.Replace)}
+	}
+	license := combineLicenseParts(parts)
+	license.Retrieved = retrievalTime()
+	return newEntry(ctx, m, license, o), nil
+}
+
+// trySSH returns true if a license that couldn't be fetched over https should
+// be fetched over SSH instead: if SSH is enabled and the module is private,
+// or its repository is only given as an SSH remote.
+func (o *options) trySSH(module string, gi GoImport) bool {
+	if !o.SSH || (gi.Vcs != "git") {
+		return false
+	}
+	return o.private.isPrivate(module) || isSSHRemote(gi.RepoRoot)
+}
+
+// lookupModule resolves a module to its repository, using any resolvers,
+// then the table of known modules unless disabled or the module is private,
+// or an earlier lookup under the same go-import prefix.
+func lookupModule(ctx context.Context, module string, o *options) (GoImport, GoSource, error) {
+	if gi, ok, err := resolverLookup(ctx, module); ok {
+		return gi, GoSource{}, err
+	}
+
+	private := o.private.isPrivate(module)
+	if private {
+		checkPrivateCredentials(ctx, module)
+	}
+
+	if !o.NoKnown && !private {
+		if gi, gs, ok := lookupKnownModule(module); ok {
+			return gi, gs, nil
+		}
+	}
+	if gi, gs, ok := sharedLookup(module); ok {
+		return gi, gs, nil
+	}
+
+	gi, gs, err := lookup(ctx, module, private)
+	if err == nil {
+		rememberLookup(module, gi, gs)
+	}
+	return gi, gs, err
+}
+
+// newEntry returns the report entry for a module's license.
+func newEntry(ctx context.Context, m Module, license licenseFile, o *options) Entry {
+	if o.PlainText {
+		plain := plainLicense(license)
+		if plain.Text != license.Text {
+			plain.raw = license.Text
+		}
+		license = plain
+	}
+	entry := Entry{Module: m.Path, Version: m.Version, License: license.Text, raw: license.raw}
+	entry.PURL = entry.purl()
+	if m.Path == stdlibModule {
+		entry.Name = stdlibLabel(m.Version)
+	}
+	entry.ReplacedBy = m.replacement()
+	entry.Indirect = m.Indirect
+	entry.BuildOnly = m.Tool
+	entry.classify()
+	entry.Inferred = license.Inferred
+	entry.LowConfidence = license.LowConfidence
+	entry.MovedTo = license.MovedTo
+	if license.SPDX != "" {
+		entry.SPDX = license.SPDX
+		entry.SPDXConfidence = 0
+	}
+	if len(license.Parts) > 1 {
+		entry.Licenses = license.Parts
+	}
+	if o.Obligations {
+		entry.Obligations = obligationsOf(entry.SPDX)
+	}
+	entry.Copyrights = extractCopyrights(license.Text)
+	if o.Copyrights && (m.Path != stdlibModule) {
+		// the standard library's notice is in its license
+		entry.Copyrights = mergeCopyrights(entry.Copyrights, moduleCopyrights(ctx, m))
+	}
+	if o.List {
+		// only the identification is listed, not the text
+		entry.License = ""
+		entry.Licenses = nil
+		entry.SourceURL = license.SourceURL
+	}
+	if o.Provenance {
+		entry.setProvenance(license)
+	}
+	return entry
+}
+
+// moduleCopyrights returns the copyright notices in the source headers of a
+// module in the local module cache. Failures are only warnings, as the
+// notices in the license are already recorded.
+func moduleCopyrights(ctx context.Context, m Module) []string {
+	dir, err := moduleDir(ctx, m)
+	if err == nil && dir == "" {
+		err = fmt.Errorf("module %q is not in the module cache", m.Path)
+	}
+	if err != nil {
+		logf(levelWarning, phaseLicense, m.Path, err, "warning: unable to read source copyrights: %v", err)
+		return nil
+	}
+
+	notices, err := sourceCopyrights(dir)
+	if err != nil {
+		logf(levelWarning, phaseLicense, m.Path, err, "warning: unable to read source copyrights: %v", err)
+	}
+	return notices
+}
+
+// createOutput returns the report destination: the output file, if set, or
+// stdout. The caller must call Close on the result.
+func createOutput(o *options, stdout io.Writer) (io.WriteCloser, error) {
+	if o.Output == "" {
+		return nopWriteCloser{stdout}, nil
+	}
+
+	return createReplaceFile(o.Output)
+}
+
+// replaceFile is written in place of a file when closed, so that a reader
+// never sees a partial file. If the contents are unchanged, the file is left
+// alone, keeping its modification time, so that regenerating an unchanged
+// report (such as with go:generate) doesn't trigger rebuilds.
+type replaceFile struct {
+	*os.File
+	path string
+}
+
+func createReplaceFile(path string) (*replaceFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file: %v", err)
+	}
+	return &replaceFile{File: f, path: path}, nil
+}
+
+// Abort discards what was written, leaving the file as it was.
+func (f *replaceFile) Abort() error {
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+func (f *replaceFile) Close() error {
+	tmp := f.File.Name()
+	if err := f.File.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+		if sameFileContents(tmp, f.path) {
+			return os.Remove(tmp)
+		}
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// sameFileContents returns true if two files can be read and are identical.
+func sameFileContents(a string, b string) bool {
+	da, err := os.ReadFile(a)
+	if err != nil {
+		return false
+	}
+	db, err := os.ReadFile(b)
+	return (err == nil) && bytes.Equal(da, db)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// scanModules scans each module in turn, recording statistics to summary
+// and passing each successful entry to emit. Licenses on GitHub are first
+// prefetched in batches (see prefetchGitHubLicenses).
+//
+// Each successful entry is also recorded in the journal, if any, which is
+// removed once every module has succeeded. With --resume, modules recorded
+// by a previous run aren't scanned again.
+//
+// If the scan is stopping (see handleInterrupts and --deadline), the modules
+// not yet scanned are recorded to summary and the error from stopped is
+// returned.
+func scanModules(ctx context.Context, o *options, modules []Module, summary *runSummary, emit func(e Entry) error) (err error) {
+	var j *journal
+	failed := false
+	if o.journal != "" {
+		j, err = openJournal(o.journal, o.Resume, o.journalOptions())
+		if err != nil {
+			return err
+		}
+		if len(j.done) > 0 {
+			logf(levelInfo, phaseSetup, "", nil, "resuming with %d modules from %s", len(j.done), o.journal)
+		}
+		defer func() {
+			// keep the journal to resume from, unless the run completed
+			if (err != nil) || failed {
+				j.close()
+			} else {
+				err = j.finish()
+			}
+		}()
+	}
+
+	// without license texts, there is nothing to lock
+	lock := o.lock
+	if o.List && !o.Identify {
+		lock = nil
+	}
+	if lock != nil {
+		defer func() {
+			if lerr := lock.write(); (lerr != nil) && (err == nil) {
+				err = lerr
+			}
+		}()
+	}
+
+	if githubAuth.IsSet() && (!o.List || o.Identify) {
+		var remaining []Module
+		for _, m := range modules {
+			if _, ok := j.resumed(m); !ok {
+				remaining = append(remaining, m)
+			}
+		}
+		prefetchGitHubLicenses(ctx, githubRepos(remaining, o))
+	}
+
+	stop := func(modules []Module) error {
+		for _, m := range modules {
+			summary.NotScanned = append(summary.NotScanned, m.Path)
+		}
+		return stopped(ctx)
+	}
+
+	p := newProgress(len(modules))
+	for i, m := range modules {
+		if stopping(ctx) {
+			return stop(modules[i:])
+		}
+
+		p.log(i, m.Path)
+		summary.Modules++
+
+		var entry Entry
+		var err error
+		if evidenceLog != nil {
+			evidenceLog.reset()
+		}
+		if e, ok := j.resumed(m); ok {
+			entry = e
+		} else {
+			entry, err = scanModule(ctx, m, o)
+			if (err != nil) && (ctx.Err() != nil) {
+				// cut short, rather than failed
+				summary.Modules--
+				return stop(modules[i:])
+			}
+			if (err != nil) && (o.fixer != nil) {
+				if e, ok := o.fixer.fix(ctx, m, err, o); ok {
+					entry, err = e, nil
+				}
+			}
+			if (err == nil) && (j != nil) {
+				if err := j.record(m, entry); err != nil {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			failed = true
+			phase := scanErrorPhase(err)
+			if phase == phaseLookup {
+				summary.lookupFailed(m.Path, err)
+			} else {
+				summary.licenseFailed(m.Path, err)
+			}
+			logf(levelWarning, phase, m.Path, err, "%v", err)
+			continue
+		}
+
+		if o.evidence != nil {
+			if err := o.evidence.add(m, entry); err != nil {
+				return err
+			}
+		}
+		if lock != nil {
+			if change := lock.update(m, entry); change != "" {
+				summary.licenseChanged(m.Path)
+				logf(levelWarning, phaseLicense, m.Path, nil, "WARNING: %s: %s (see %s)", m.Path, change, o.Lock)
+			}
+		}
+
+		if err := emit(entry); err != nil {
+			return err
+		}
+		if !o.List || o.Identify {
+			summary.found(entry)
+			if entry.SPDX == "" {
+				logf(levelWarning, phaseLicense, m.Path, nil, "warning: license for module %q does not match any known license", m.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// runScan implements the scan command: every module is scanned and its
+// license written to the report.
+func runScan(ctx context.Context, o *options, args []string, stdout io.Writer) (err error) {
+	summary := newRunSummary()
+
+	reportOpts, err := o.reportOptions()
+	if err != nil {
+		return err
+	}
+	if (o.committed != nil) && !o.committed.generated.IsZero() {
+		reportOpts.Generated = o.committed.generated
+	}
+	if err := validateSign(o.Sign); err != nil {
+		return err
+	}
+	if (o.Sign != "") && (o.Output == "") {
+		return fmt.Errorf("--sign requires --output")
+	}
+
+	// with --fresh, a report that is up to date isn't scanned again, unless
+	// there's also an evidence bundle to write
+	inputs := ""
+	if _, ok := o.stampPath(); ok && !o.DryRun && (o.Evidence == "") {
+		inputs = generateInputs(o, args, reportOpts)
+		if reportFresh(o, inputs, time.Now()) {
+			logf(levelInfo, phaseSetup, "", nil, "%s is up to date (see --fresh)", o.Output)
+			return nil
+		}
+	}
+
+	if o.AuditLog != "" {
+		requestAudit, err = openAuditLog(o.AuditLog)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := requestAudit.Close(); (cerr != nil) && (err == nil) {
+				err = cerr
+			}
+			requestAudit = nil
+		}()
+	}
+
+	modules, err := modulesToScan(ctx, o, args)
+	if err != nil {
+		return err
+	}
+	reportOpts.PackageBased = o.packageBased
+	if o.existing != nil {
+		n := len(modules)
+		modules = newModules(modules, o.existing)
+		logf(levelInfo, phaseSetup, "", nil, "%d of %d modules are already in %s", n-len(modules), n, o.NewOnly)
+	}
+	if o.DryRun {
+		return runDryRun(ctx, o, modules, stdout)
+	}
+
+	for _, p := range o.Products {
+		if err := p.resolve(ctx); err != nil {
+			return err
+		}
+	}
+
+	out, err := createOutput(o, stdout)
+	if err != nil {
+		return err
+	}
+	complete := false // without failures
+	written := false  // the report, even if incomplete
+	defer func() {
+		if f, ok := out.(*replaceFile); ok && !written {
+			// an error stopped the report, so keep the previous one
+			f.Abort()
+		} else if cerr := out.Close(); cerr != nil {
+			written = false
+			if err == nil {
+				err = fmt.Errorf("error closing output file: %v", cerr)
+			}
+		}
+		if complete && (inputs != "") && (err == nil) {
+			err = writeStamp(o, inputs, time.Now())
+		}
+		// the evidence bundle, deferred later, is closed by now
+		if (o.Sign != "") && written {
+			if serr := signArtifacts(ctx, o); (serr != nil) && (err == nil) {
+				err = serr
+			}
+		}
+	}()
+
+	report, err := newReportWriter(out, reportOpts)
+	if err != nil {
+		return err
+	}
+	if sw, ok := report.(sectionWriter); ok && reportOpts.PackageBased {
+		if err := sw.WriteSection(packageBasedTitle); err != nil {
+			return err
+		}
+	}
+
+	if o.Evidence != "" {
+		o.evidence, err = createEvidenceBundle(o.Evidence, reportOpts.Generated)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := o.evidence.Close(); cerr != nil {
+				written = false
+				if err == nil {
+					err = cerr
+				}
+			}
+			o.evidence = nil
+		}()
+	}
+
+	// With products, entries are collected and written in sections at the
+	// end. Otherwise, they are written as they are found.
+	var entries []Entry
+	emit := report.WriteEntry
+	if len(o.Products) > 0 {
+		emit = func(e Entry) error {
+			entries = append(entries, e)
+			return nil
+		}
+	}
+
+	// strict mode counts the problems with the unapproved entries
+	problems, missing := 0, 0
+	if o.Strict {
+		next := emit
+		emit = func(e Entry) error {
+			if o.policy != nil {
+				if reason := o.policy.violation(e); reason != "" {
+					problems++
+					logf(levelWarning, phaseLicense, e.Module, nil, "%s: %s", e.Module, reason)
+					return next(e)
+				}
+			}
+			if e.SPDX == "" {
+				problems++
+				missing++
+				logf(levelWarning, phaseLicense, e.Module, nil, "%s: license unknown", e.Module)
+			}
+			return next(e)
+		}
+	}
+
+	if o.baseline != nil {
+		next := emit
+		emit = func(e Entry) error {
+			if o.baseline.approved(e) {
+				return nil
+			}
+			return next(e)
+		}
+	}
+
+	if o.committed != nil {
+		next := emit
+		emit = func(e Entry) error {
+			if retrieved, ok := o.committed.retrieved[e.Module]; ok && (e.Retrieved != "") {
+				e.Retrieved = retrieved
+			}
+			return next(e)
+		}
+	}
+
+	if o.OSV {
+		vulns, unchecked, err := osvVulnerabilities(ctx, modules)
+		if err != nil {
+			logf(levelWarning, phaseSetup, "", err, "warning: unable to check %d module(s) for known vulnerabilities: %v", len(unchecked), err)
+		}
+		if len(vulns) > 0 {
+			logf(levelWarning, phaseSetup, "", nil, "warning: %d module(s) have known vulnerabilities, according to OSV.dev", len(vulns))
+		}
+		next := emit
+		emit = func(e Entry) error {
+			e.Vulnerabilities = vulns[e.Module]
+			e.VulnerabilitiesUnknown = unchecked[e.Module]
+			return next(e)
+		}
+	}
+
+	// if stopped early, the completed entries are still written
+	scanErr := scanModules(ctx, o, modules, summary, emit)
+	if isStopped(scanErr) {
+		if r, ok := report.(incompleteReportWriter); ok {
+			r.MarkIncomplete()
+		}
+	} else if scanErr != nil {
+		return scanErr
+	}
+
+	if len(o.Products) > 0 {
+		if err := writeProductReport(report, o.Products, entries); err != nil {
+			return err
+		}
+	}
+
+	if w, ok := report.(excludedWriter); ok && (len(o.excluded) > 0) {
+		if err := w.WriteExcluded(o.excluded); err != nil {
+			return err
+		}
+	}
+
+	if o.Cgo {
+		libs, err := nativeLibraries(ctx, o.CgoBinary)
+		if err != nil {
+			return err
+		}
+		if len(libs) > 0 {
+			names := make([]string, len(libs))
+			for i, lib := range libs {
+				names[i] = lib.Name
+			}
+			logf(levelWarning, phaseSetup, "", nil, "warning: native libraries linked by cgo need their licenses reviewed separately: %s", strings.Join(names, ", "))
+		}
+		if w, ok := report.(nativeWriter); ok && (len(libs) > 0) {
+			if err := w.WriteNative(libs); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := report.Close(); err != nil {
+		return err
+	}
+	written = true
+
+	summary.finish()
+	summary.log()
+	if err := writeCISummary(summary, o.Output, problems, os.Getenv); err != nil {
+		return err
+	}
+	if o.SummaryPath != "" {
+		if err := summary.writeFile(o.SummaryPath); err != nil {
+			return err
+		}
+	}
+	if scanErr != nil {
+		return scanErr
+	}
+	complete = len(summary.Failed) == 0
+
+	if o.Strict {
+		problems += len(summary.Failed)
+		missing += len(summary.Failed)
+		if problems > 0 {
+			return &checkFailedError{Problems: problems, Missing: missing}
+		}
+	}
+	return nil
+}
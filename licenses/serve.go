@@ -0,0 +1,251 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultListen is the address that the serve command listens on
+const defaultListen = "localhost:8080"
+
+// serveMaxBody bounds the size of a request body, such as a go.mod
+const serveMaxBody = 4 * 1024 * 1024
+
+// serveRequest is the body of a "POST /v1/licenses" request. Modules are
+// given in the same form as command-line arguments ("path" or
+// "path@version"), and GoMod is the contents of a go.mod file, each of whose
+// requirements is also looked up.
+type serveRequest struct {
+	Modules  []string `json:"modules,omitempty"`
+	GoMod    string   `json:"go_mod,omitempty"`
+	List     bool     `json:"list,omitempty"`
+	Identify bool     `json:"identify,omitempty"`
+}
+
+// serveResponse is the response to a "POST /v1/licenses" request.
+type serveResponse struct {
+	Entries []Entry      `json:"entries"`
+	Failed  []rpcFailure `json:"failed"`
+	Summary *runSummary  `json:"summary"`
+}
+
+// server answers license lookups over HTTP for the serve command.
+type server struct {
+	o    *options
+	scan scanFunc
+
+	// mu allows one lookup at a time, so that a busy server is as polite to
+	// each host as a single run (see rateLimiter).
+	mu sync.Mutex
+
+	// known has the entry of each module at a version already looked up, by
+	// module@version and options, as a version's license doesn't change.
+	known map[string]Entry
+}
+
+func newServer(o *options, scan scanFunc) *server {
+	return &server{o: o, scan: scan, known: make(map[string]Entry)}
+}
+
+// handler returns the server's routes.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/licenses", s.handleLicenses)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	return mux
+}
+
+// serveError writes an error response as a JSON object.
+func serveError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{message})
+}
+
+func (s *server) handleLicenses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		serveError(w, http.StatusMethodNotAllowed, "expected POST")
+		return
+	}
+
+	var req serveRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, serveMaxBody)).Decode(&req); err != nil {
+		serveError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	var modules []Module
+	for _, arg := range req.Modules {
+		modules = append(modules, parseModuleArg(arg))
+	}
+	if req.GoMod != "" {
+		required, err := parseGoModRequires(req.GoMod)
+		if err != nil {
+			serveError(w, http.StatusBadRequest, fmt.Sprintf("invalid go.mod: %v", err))
+			return
+		}
+		modules = append(modules, required...)
+	}
+	if len(modules) == 0 {
+		serveError(w, http.StatusBadRequest, "expected modules or a go_mod")
+		return
+	}
+	logf(levelInfo, phaseSetup, "", nil, "%s %s: %d modules", r.RemoteAddr, r.URL.Path, len(modules))
+
+	resp, err := s.lookup(r.Context(), modules, req)
+	if err != nil {
+		serveError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(resp)
+}
+
+// lookup scans each module, or reuses the entry of a module at a version
+// already looked up.
+func (s *server) lookup(ctx context.Context, modules []Module, req serveRequest) (*serveResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opts := *s.o
+	opts.List = req.List
+	opts.Identify = req.Identify
+
+	summary := newRunSummary()
+	resp := &serveResponse{Entries: []Entry{}, Failed: []rpcFailure{}, Summary: summary}
+	for _, m := range modules {
+		if stopping(ctx) {
+			return nil, stopped(ctx)
+		}
+		summary.Modules++
+
+		key := fmt.Sprintf("%s@%s list=%t identify=%t", m.Path, m.Version, opts.List, opts.Identify)
+		entry, ok := s.known[key]
+		if !ok {
+			var err error
+			entry, err = s.scan(ctx, m, &opts)
+			if err != nil {
+				phase := scanErrorPhase(err)
+				if phase == phaseLookup {
+					summary.lookupFailed(m.Path, err)
+				} else {
+					summary.licenseFailed(m.Path, err)
+				}
+				logf(levelWarning, phase, m.Path, err, "%v", err)
+				resp.Failed = append(resp.Failed, rpcFailure{m.Path, phase, err.Error()})
+				continue
+			}
+			if m.Version != "" {
+				s.known[key] = entry
+			}
+		}
+
+		if !opts.List || opts.Identify {
+			summary.found(entry)
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+
+	summary.finish()
+	return resp, nil
+}
+
+// parseGoModRequires returns the modules required by a go.mod file, from its
+// "require" directives.
+func parseGoModRequires(data string) ([]Module, error) {
+	var modules []Module
+	inBlock := false
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock && (fields[0] == ")"):
+			inBlock = false
+			continue
+		case inBlock:
+			// a requirement
+		case (fields[0] == "require") && (len(fields) == 2) && (fields[1] == "("):
+			inBlock = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		default:
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"path version\"", n)
+		}
+		path := fields[0]
+		if strings.HasPrefix(path, `"`) {
+			unquoted, err := strconv.Unquote(path)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			path = unquoted
+		}
+		modules = append(modules, Module{Path: path, Version: fields[1]})
+	}
+	return modules, scanner.Err()
+}
+
+// runServe implements the serve command: license lookups are answered over
+// HTTP, so that many repositories and CI jobs can share one warm cache (see
+// --cache and --shared-cache) rather than each making their own requests.
+// The server stops, after finishing any lookup in progress, when ctx is done.
+//
+// Routes:
+//
+//	POST /v1/licenses (serveRequest) -> serveResponse
+//	GET /healthz -> "ok"
+func runServe(ctx context.Context, o *options, args []string, scan scanFunc) error {
+	if len(args) > 0 {
+		return fmt.Errorf("serve takes no arguments (got %q)", args[0])
+	}
+	if httpCache == nil {
+		logf(levelWarning, phaseSetup, "", nil, "warning: serving without a --cache or --shared-cache, so downloads aren't kept between restarts")
+	}
+
+	srv := &http.Server{Addr: o.Listen, Handler: newServer(o, scan).handler()}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	logf(levelInfo, phaseSetup, "", nil, "serving license lookups on http://%s/v1/licenses", o.Listen)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		<-done
+		return stopped(ctx)
+	}
+	return err
+}
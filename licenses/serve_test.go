@@ -0,0 +1,94 @@
+package licenses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoModRequires(t *testing.T) {
+	data := `module example.org/app
+
+go 1.16
+
+require example.org/a v1.0.0 // indirect
+
+require (
+	example.org/b v0.1.0
+	"example.org/c" v2.0.0+incompatible
+)
+
+replace example.org/b => ../b
+`
+	modules, err := parseGoModRequires(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Module{
+		{Path: "example.org/a", Version: "v1.0.0"},
+		{Path: "example.org/b", Version: "v0.1.0"},
+		{Path: "example.org/c", Version: "v2.0.0+incompatible"},
+	}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("expected %v but got %v", expected, modules)
+	}
+
+	if _, err := parseGoModRequires("require (\n\texample.org/a\n)\n"); err == nil {
+		t.Errorf("expected a requirement without a version to fail")
+	}
+}
+
+func TestServe(t *testing.T) {
+	scans := 0
+	scan := func(ctx context.Context, m Module, o *options) (Entry, error) {
+		scans++
+		if m.Path == "example.org/b" {
+			return Entry{}, &scanError{phaseLookup, fmt.Errorf("unable to lookup module")}
+		}
+		return Entry{Module: m.Path, Version: m.Version, SPDX: "MIT", License: "License " + m.Path}, nil
+	}
+	srv := httptest.NewServer(newServer(&options{}, scan).handler())
+	defer srv.Close()
+
+	post := func(body string) (int, serveResponse) {
+		resp, err := http.Post(srv.URL+"/v1/licenses", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result serveResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	body := `{"modules": ["example.org/a@v1.0.0", "example.org/b"], "go_mod": "require example.org/c v1.2.0\n"}`
+	status, result := post(body)
+	if (status != http.StatusOK) || (len(result.Entries) != 2) || (len(result.Failed) != 1) {
+		t.Fatalf("expected 2 entries and 1 failure but got %d: %+v", status, result)
+	}
+	if (result.Entries[1].Module != "example.org/c") || (result.Failed[0].Module != "example.org/b") || (result.Summary.Found != 2) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	// modules at a version are only looked up once, and failures again
+	if _, result = post(body); (scans != 4) || (len(result.Entries) != 2) {
+		t.Errorf("expected 4 scans in all but got %d: %+v", scans, result)
+	}
+
+	if status, _ := post(`{}`); status != http.StatusBadRequest {
+		t.Errorf("expected an empty request to fail but got %d", status)
+	}
+	resp, err := http.Get(srv.URL + "/v1/licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to be refused but got %d", resp.StatusCode)
+	}
+}
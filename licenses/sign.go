@@ -0,0 +1,87 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// signCommand runs a signing tool, returning its combined output. It is
+// replaced in tests.
+var signCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+func validateSign(mode string) error {
+	switch mode {
+	case "", "gpg", "cosign":
+		return nil
+	default:
+		return fmt.Errorf("unknown signing method %q (expected gpg or cosign)", mode)
+	}
+}
+
+// signatureSuffix returns the suffix of the detached signature of a file
+// signed with mode.
+func signatureSuffix(mode string) string {
+	if mode == "cosign" {
+		return ".bundle"
+	}
+	return ".asc"
+}
+
+// signFile writes a detached signature of path, beside it, and returns the
+// signature's path.
+//
+// With gpg, the signature is ASCII-armored and made with the default secret
+// key, or with key (any gpg --local-user). With cosign, it is a Sigstore
+// bundle of the signature and certificate, made keyless (with an OIDC
+// identity, such as a CI job's) or with key (any cosign --key reference).
+func signFile(ctx context.Context, mode string, key string, path string) (string, error) {
+	sig := path + signatureSuffix(mode)
+
+	var name string
+	var args []string
+	switch mode {
+	case "gpg":
+		name = "gpg"
+		args = []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", sig}
+		if key != "" {
+			args = append(args, "--local-user", key)
+		}
+	case "cosign":
+		name = "cosign"
+		args = []string{"sign-blob", "--yes", "--bundle", sig}
+		if key != "" {
+			args = append(args, "--key", key)
+		}
+	default:
+		return "", validateSign(mode)
+	}
+	args = append(args, path)
+
+	if out, err := signCommand(ctx, name, args...); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("error signing %s with %s: %v: %s", path, name, err, msg)
+		}
+		return "", fmt.Errorf("error signing %s with %s: %v", path, name, err)
+	}
+	return sig, nil
+}
+
+// signArtifacts signs each file written by a run: the report and the
+// evidence bundle, if any.
+func signArtifacts(ctx context.Context, o *options) error {
+	for _, path := range []string{o.Output, o.Evidence} {
+		if path == "" {
+			continue
+		}
+		sig, err := signFile(ctx, o.Sign, o.SignKey, path)
+		if err != nil {
+			return err
+		}
+		logf(levelInfo, phaseSummary, "", nil, "signed %s: %s", path, sig)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package licenses
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSignFile(t *testing.T) {
+	var got []string
+	oldCommand := signCommand
+	signCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		got = append([]string{name}, args...)
+		return nil, nil
+	}
+	defer func() { signCommand = oldCommand }()
+
+	tests := []struct {
+		mode     string
+		key      string
+		sig      string
+		expected []string
+	}{
+		{"gpg", "", "r.txt.asc", []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", "r.txt.asc", "r.txt"}},
+		{"gpg", "ABCD", "r.txt.asc", []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", "r.txt.asc", "--local-user", "ABCD", "r.txt"}},
+		{"cosign", "", "r.txt.bundle", []string{"cosign", "sign-blob", "--yes", "--bundle", "r.txt.bundle", "r.txt"}},
+		{"cosign", "cosign.key", "r.txt.bundle", []string{"cosign", "sign-blob", "--yes", "--bundle", "r.txt.bundle", "--key", "cosign.key", "r.txt"}},
+	}
+	for _, test := range tests {
+		sig, err := signFile(context.Background(), test.mode, test.key, "r.txt")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.mode, err)
+			continue
+		}
+		if sig != test.sig {
+			t.Errorf("%s: expected %q but got %q", test.mode, test.sig, sig)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %q but got %q", test.mode, test.expected, got)
+		}
+	}
+
+	signCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("gpg: no default secret key\n"), errors.New("exit status 2")
+	}
+	if _, err := signFile(context.Background(), "gpg", "", "r.txt"); (err == nil) || !strings.Contains(err.Error(), "no default secret key") {
+		t.Errorf("expected the tool's output in the error but got %v", err)
+	}
+	if _, err := signFile(context.Background(), "pgp", "", "r.txt"); err == nil {
+		t.Errorf("expected an error for an unknown method")
+	}
+}
+
+func TestRunScanSign(t *testing.T) {
+	o := &options{Format: "text", Sign: "gpg"}
+	if err := runScan(context.Background(), o, nil, nil); (err == nil) || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("expected --sign to require --output but got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package licenses
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// inferredFromSource marks a license inferred from source file headers
+const inferredFromSource = "source headers"
+
+// spdxIdentifierLine matches an SPDX short-form identifier in a source file,
+// e.g. "// SPDX-License-Identifier: MIT".
+var spdxIdentifierLine = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+?)\s*(\*/)?\s*$`)
+
+// sourceLicenseFiles returns the top-level Go source files most likely to
+// state a repository's license in their header: doc.go, a file named after
+// the repository, and main.go.
+func sourceLicenseFiles(gi GoImport) []string {
+	name := path.Base(strings.TrimSuffix(gi.RepoRoot, ".git"))
+	name = strings.TrimPrefix(name, "go-")
+
+	files := []string{"doc.go"}
+	if (name != "doc") && (name != "main") {
+		files = append(files, name+".go")
+	}
+	return append(files, "main.go")
+}
+
+// uncomment returns the text of a Go comment block without comment markers.
+func uncomment(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "//"):
+			line = strings.TrimPrefix(line, "//")
+		case strings.HasPrefix(line, "/*"):
+			line = strings.TrimPrefix(line, "/*")
+		case strings.HasPrefix(line, "*/"):
+			line = strings.TrimPrefix(line, "*/")
+		case strings.HasPrefix(line, "*"):
+			line = strings.TrimPrefix(line, "*")
+		}
+		line = strings.TrimSuffix(line, "*/")
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// headerLicense returns the license stated in a Go source file header: the
+// expression of an SPDX-License-Identifier line, or else the text of the
+// header if it contains a known license.
+func headerLicense(header string) (text string, spdx string, ok bool) {
+	text = uncomment(header)
+	if text == "" {
+		return "", "", false
+	}
+
+	for _, line := range strings.Split(header, "\n") {
+		if m := spdxIdentifierLine.FindStringSubmatch(line); m != nil {
+			return text, m[1], true
+		}
+	}
+
+	if classifyLicense(text).SPDX != "" {
+		return text, "", true
+	}
+	return "", "", false
+}
+
+// tryGetInferredLicense is a last resort for repositories without a license
+// file, inferring the license from source headers or, failing that, the
+// README.
+func tryGetInferredLicense(ctx context.Context, gi GoImport, gs GoSource) (licenseFile, bool) {
+	if license, ok := tryGetSourceHeaderLicense(ctx, gi, gs); ok {
+		return license, true
+	}
+	return tryGetREADMELicense(ctx, gi, gs)
+}
+
+// tryGetSourceHeaderLicense is a last resort for repositories without a
+// license file. The headers of a few top-level Go source files are checked
+// for an SPDX-License-Identifier or a license block, and the license is
+// marked as inferred.
+func tryGetSourceHeaderLicense(ctx context.Context, gi GoImport, gs GoSource) (licenseFile, bool) {
+	var ref string
+
+	for _, file := range sourceLicenseFiles(gi) {
+		data, FileURL, ok := fetchRepoFile(ctx, gi, gs, file, ref)
+		if !ok {
+			continue
+		}
+		ref = FileURL.Ref
+
+		header, err := readSourceHeader(strings.NewReader(data))
+		if err != nil {
+			continue
+		}
+		text, spdx, ok := headerLicense(header)
+		if !ok {
+			continue
+		}
+
+		return licenseFile{
+			Text:      text,
+			SourceURL: FileURL.URL,
+			Ref:       FileURL.Ref,
+			Retrieved: retrievalTime(),
+			SPDX:      spdx,
+			Inferred:  inferredFromSource,
+		}, true
+	}
+
+	return licenseFile{}, false
+}
+
+// fetchRepoFile fetches a file from a repository at the first ref it exists
+// at or, if ref is not empty, at that ref only.
+func fetchRepoFile(ctx context.Context, gi GoImport, gs GoSource, file string, ref string) (string, FileURL, bool) {
+	fileURLs, decoder, err := resolveFileURL(ctx, gi, gs, file)
+	if err != nil {
+		return "", FileURL{}, false
+	}
+
+	for _, u := range fileURLs {
+		if (ref != "") && (u.Ref != ref) {
+			continue
+		}
+
+		data, err := httpGet(ctx, u.URL, nil)
+		if err != nil {
+			continue
+		}
+		data, err = decoder(data)
+		if err != nil {
+			continue
+		}
+		data, err = normalizeText(data)
+		if err != nil {
+			continue
+		}
+		return data, u, true
+	}
+
+	return "", FileURL{}, false
+}
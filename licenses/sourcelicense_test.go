@@ -0,0 +1,77 @@
+package licenses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderLicense(t *testing.T) {
+	tests := []struct {
+		header string
+		text   string
+		spdx   string
+		ok     bool
+	}{
+		{
+			header: "// Copyright 2020 Example Ltd.\n// SPDX-License-Identifier: MIT OR Apache-2.0\n\n",
+			text:   "Copyright 2020 Example Ltd.\nSPDX-License-Identifier: MIT OR Apache-2.0",
+			spdx:   "MIT OR Apache-2.0",
+			ok:     true,
+		},
+		{
+			header: "/* SPDX-License-Identifier: BSD-3-Clause */\n",
+			text:   "SPDX-License-Identifier: BSD-3-Clause",
+			spdx:   "BSD-3-Clause",
+			ok:     true,
+		},
+		{
+			header: "// Package foo does things.\n",
+			ok:     false,
+		},
+		{
+			header: "",
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		text, spdx, ok := headerLicense(tt.header)
+		if (text != tt.text) || (spdx != tt.spdx) || (ok != tt.ok) {
+			t.Errorf("%q: expected (%q, %q, %t) but got (%q, %q, %t)",
+				tt.header, tt.text, tt.spdx, tt.ok, text, spdx, ok)
+		}
+	}
+}
+
+func TestHeaderLicenseBlock(t *testing.T) {
+	header := "/*\n" + testMITLicense + "\n*/\n\n// Package foo does things.\n"
+
+	_, spdx, ok := headerLicense(header)
+	if !ok || spdx != "" {
+		t.Errorf("expected a license block without an SPDX identifier, but got (%q, %t)", spdx, ok)
+	}
+}
+
+func TestSourceLicenseFiles(t *testing.T) {
+	got := sourceLicenseFiles(GoImport{RepoRoot: "https://github.com/example/go-widget.git"})
+	expected := []string{"doc.go", "widget.go", "main.go"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+}
+
+func TestEntrySPDXText(t *testing.T) {
+	tests := []struct {
+		entry    Entry
+		expected string
+	}{
+		{Entry{}, "NOASSERTION"},
+		{Entry{SPDX: "MIT", SPDXConfidence: 100}, "MIT (100.0% match)"},
+		{Entry{SPDX: "MIT", Inferred: inferredFromSource}, "MIT (inferred from source headers)"},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.spdxText(); got != tt.expected {
+			t.Errorf("expected %q but got %q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package licenses
+
+import (
+	"math"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// spdxNoAssertion is the SPDX value for a license that couldn't be identified
+const spdxNoAssertion = "NOASSERTION"
+
+// classification is the SPDX identification of a license text.
+type classification struct {
+	// SPDX is an SPDX license expression, such as "MIT" or, for a file
+	// containing several licenses, "Apache-2.0 AND MIT". It is empty if the
+	// text does not match any known license.
+	SPDX string
+
+	// Confidence is the percentage of the text that matched a known license.
+	Confidence float64
+}
+
+// classifyLicense identifies the licenses in a license text.
+func classifyLicense(text string) classification {
+	cov := licensecheck.Scan([]byte(text))
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range cov.Match {
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		ids = append(ids, m.ID)
+	}
+
+	if len(ids) == 0 {
+		return classification{}
+	}
+
+	return classification{
+		SPDX:       strings.Join(ids, " AND "),
+		Confidence: math.Round(cov.Percent*10) / 10,
+	}
+}
+
+// classify sets the SPDX fields of an entry from its license text.
+func (e *Entry) classify() {
+	c := classifyLicense(e.License)
+	e.SPDX = c.SPDX
+	e.SPDXConfidence = c.Confidence
+}
@@ -0,0 +1,42 @@
+package licenses
+
+import (
+	"testing"
+)
+
+const testMITLicense = `MIT License
+
+Copyright (c) 2018 Jeff Dickey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.`
+
+func TestClassifyLicense(t *testing.T) {
+	c := classifyLicense(testMITLicense)
+	if c.SPDX != "MIT" {
+		t.Errorf("expected MIT but got %q", c.SPDX)
+	}
+	if c.Confidence < 90 {
+		t.Errorf("expected a high confidence but got %.1f", c.Confidence)
+	}
+
+	c = classifyLicense("All rights reserved. Do not copy.")
+	if c.SPDX != "" {
+		t.Errorf("expected no match but got %q", c.SPDX)
+	}
+}
@@ -0,0 +1,166 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// isSSHRemote returns true if a repository URL is an SSH remote, either as a
+// URL ("ssh://git@host/path") or in scp-like syntax ("git@host:path").
+func isSSHRemote(repoRoot string) bool {
+	if strings.HasPrefix(repoRoot, "-") {
+		return false // it would be an option to git
+	}
+	if strings.HasPrefix(repoRoot, "ssh://") || strings.HasPrefix(repoRoot, "git+ssh://") {
+		return true
+	}
+	colon := strings.IndexByte(repoRoot, ':')
+	return (colon > 0) && !strings.Contains(repoRoot, "://") && !strings.ContainsAny(repoRoot[:colon], "/")
+}
+
+// sshRemote returns the SSH remote of a repository: its URL if it is already
+// an SSH remote, or "ssh://git@host/path" for a https URL, as forges serve
+// the same repositories over both.
+func sshRemote(repoRoot string) (string, bool) {
+	if isSSHRemote(repoRoot) {
+		return repoRoot, true
+	}
+	const https = "https://"
+	if !strings.HasPrefix(repoRoot, https) {
+		return "", false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(repoRoot, https), "/")
+	if idx := strings.IndexByte(rest, '@'); (idx >= 0) && (idx < strings.IndexByte(rest+"/", '/')) {
+		rest = rest[idx+1:] // drop any userinfo
+	}
+	if !strings.Contains(rest, "/") {
+		return "", false
+	}
+	return "ssh://git@" + rest, true
+}
+
+// runGit runs a git command in a directory and returns its stdout. Git and
+// ssh are never allowed to prompt, so that only the user's SSH agent and
+// keys are used, unless GIT_SSH_COMMAND is already set.
+var runGit = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=10")
+	}
+
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s error: %v: %s", args[0], err, strings.TrimSpace(string(exitErrorStderr(err))))
+	}
+	return stdout, nil
+}
+
+// sshLicenses caches the result of tryGetSSHLicense for each remote, including
+// failures, as each attempt is a clone.
+var sshLicenses = struct {
+	sync.Mutex
+	results map[string]sshResult
+}{results: make(map[string]sshResult)}
+
+type sshResult struct {
+	license licenseFile
+	err     error
+}
+
+// tryGetSSHLicense fetches the license files at the root of a repository's
+// default branch with a shallow, blobless clone over SSH, using the user's
+// SSH agent and keys. This reaches private repositories that can't be
+// accessed over https.
+func tryGetSSHLicense(ctx context.Context, gi GoImport) (licenseFile, error) {
+	remote, ok := sshRemote(gi.RepoRoot)
+	if (gi.Vcs != "git") || !ok {
+		return licenseFile{}, fmt.Errorf("no SSH remote for %q", gi.RepoRoot)
+	}
+
+	sshLicenses.Lock()
+	defer sshLicenses.Unlock()
+	if r, ok := sshLicenses.results[remote]; ok {
+		return r.license, r.err
+	}
+
+	license, err := cloneLicense(ctx, remote)
+	sshLicenses.results[remote] = sshResult{license, err}
+	return license, err
+}
+
+// cloneLicense implements tryGetSSHLicense for a remote.
+func cloneLicense(ctx context.Context, remote string) (licenseFile, error) {
+	dir, err := os.MkdirTemp("", "gocomply-ssh-")
+	if err != nil {
+		return licenseFile{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	// the remote comes from a go-import meta tag, served by anyone
+	if strings.HasPrefix(remote, "-") {
+		return licenseFile{}, fmt.Errorf("invalid remote %q", remote)
+	}
+	if err := waitForHost(ctx, remote); err != nil {
+		return licenseFile{}, err
+	}
+	if _, err := runGit(ctx, dir, "clone", "--quiet", "--depth=1", "--filter=blob:none", "--no-checkout", "--", remote, "."); err != nil {
+		return licenseFile{}, err
+	}
+
+	ref := "HEAD"
+	if stdout, err := runGit(ctx, dir, "symbolic-ref", "--short", "HEAD"); err == nil {
+		ref = strings.TrimSpace(string(stdout))
+	}
+
+	// "<mode> <type> <object>\t<name>" for each file at the root
+	stdout, err := runGit(ctx, dir, "ls-tree", "HEAD")
+	if err != nil {
+		return licenseFile{}, err
+	}
+	blobs := make(map[string]string)
+	var names []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(line[:tab])
+		if (len(fields) != 3) || (fields[1] != "blob") {
+			continue
+		}
+		names = append(names, line[tab+1:])
+		blobs[line[tab+1:]] = fields[2]
+	}
+
+	var parts []licensePart
+	for _, name := range rankLicenseFiles(names, repoLicenseFiles) {
+		data, err := runGit(ctx, dir, "cat-file", "blob", blobs[name])
+		if err != nil {
+			return licenseFile{}, err
+		}
+		text, ok := licenseText(name, string(data))
+		if !ok {
+			continue
+		}
+		parts = append(parts, licensePart{
+			File:      name,
+			SourceURL: remote,
+			Revision:  blobs[name],
+			Text:      text,
+		})
+	}
+	if len(parts) == 0 {
+		return licenseFile{}, fmt.Errorf("no license files in %s", remote)
+	}
+
+	license := combineLicenseParts(parts)
+	license.Ref = ref
+	license.Retrieved = retrievalTime()
+	return license, nil
+}
@@ -0,0 +1,68 @@
+package licenses
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSSHRemote(t *testing.T) {
+	tests := map[string]string{
+		"https://git.example.org/group/repo.git": "ssh://git@git.example.org/group/repo.git",
+		"https://user@git.example.org/repo/":     "ssh://git@git.example.org/repo",
+		"ssh://git@git.example.org/repo":         "ssh://git@git.example.org/repo",
+		"git@git.example.org:group/repo.git":     "git@git.example.org:group/repo.git",
+		"https://git.example.org":                "",
+		"http://git.example.org/repo":            "",
+		"--upload-pack=touch x:y":                "",
+	}
+	for repoRoot, expected := range tests {
+		remote, ok := sshRemote(repoRoot)
+		if (remote != expected) || (ok != (expected != "")) {
+			t.Errorf("%s: expected %q but got %q, %t", repoRoot, expected, remote, ok)
+		}
+	}
+}
+
+func TestCloneLicense(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.org"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("License A\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "--quiet")
+	git("checkout", "--quiet", "-b", "trunk")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial")
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	license, err := cloneLicense(context.Background(), "file://"+filepath.ToSlash(dir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (license.Text != "License A") || (license.Ref != "trunk") || (license.Revision == "") {
+		t.Errorf("unexpected license %+v", license)
+	}
+
+	if _, err := cloneLicense(context.Background(), "--upload-pack=touch x:y"); err == nil {
+		t.Errorf("expected an error for a remote that is an option")
+	}
+}
@@ -0,0 +1,143 @@
+package licenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// stdlibModule is the module reported for the Go standard library
+const stdlibModule = "github.com/golang/go"
+
+// stdlibName labels the standard library's entry in a report, instead of
+// stdlibModule, which isn't a module anyone requires
+const stdlibName = "Go standard library"
+
+// stdlibLicenseFiles are the files making up the standard library's
+// license, at the root of the Go repository. Releases before AUTHORS was
+// removed also have that (see goProjectNoticeFiles).
+var stdlibLicenseFiles = []string{"LICENSE", "PATENTS"}
+
+// goProjectNoticeFiles complete the license of the Go project's own
+// repositories, the standard library and golang.org/x: PATENTS is the patent
+// grant that accompanies the BSD license, and AUTHORS, where a repository
+// still has it, lists "The Go Authors" named by its copyright notice.
+var goProjectNoticeFiles = []string{"PATENTS", "AUTHORS"}
+
+// isGoProject returns true for a module of the Go project itself.
+func isGoProject(module string) bool {
+	return (module == stdlibModule) || strings.HasPrefix(module, "golang.org/x/")
+}
+
+// licenseCandidates returns the files to look for in a listing of a
+// module's files, in order of precedence: repoLicenseFiles and, for the Go
+// project, its notice files after them.
+func licenseCandidates(module string) []string {
+	if !isGoProject(module) {
+		return repoLicenseFiles
+	}
+	files := append([]string{}, repoLicenseFiles...)
+	return append(files, goProjectNoticeFiles...)
+}
+
+// withGoProjectNotices appends the notice files of a Go project module that
+// its license doesn't already include, such as from the GitHub API, which
+// only gives license files.
+func withGoProjectNotices(ctx context.Context, module string, gi GoImport, gs GoSource, license licenseFile) licenseFile {
+	if !isGoProject(module) || (license.Inferred != "") {
+		return license
+	}
+
+	have := make(map[string]bool)
+	for _, part := range license.Parts {
+		have[part.File] = true
+	}
+	var files []string
+	for _, file := range goProjectNoticeFiles {
+		if !have[file] {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return license
+	}
+
+	fetched, err := fetchLicenseFiles(ctx, module, gi, gs, files)
+	if (err != nil) || (len(fetched.parts) == 0) {
+		return license
+	}
+
+	parts := license.Parts
+	if len(parts) == 0 {
+		parts = []licensePart{{File: "LICENSE", SourceURL: license.SourceURL, Revision: license.Revision, Text: license.Text}}
+	}
+	combined := combineLicenseParts(append(parts, fetched.parts...))
+	license.Text, license.Parts = combined.Text, combined.Parts
+	return license
+}
+
+// goRelease matches the version of a released Go toolchain, which is also
+// the tag of that release in the Go repository, e.g. "go1.21.3" or
+// "go1.22rc1"
+var goRelease = regexp.MustCompile(`^go1(\.[0-9]+)*((beta|rc)[0-9]+)?$`)
+
+// stdlibVersion returns the release of the Go toolchain in use, as reported
+// by "go env GOVERSION", so that the standard library's license is the one
+// it was released under. It is empty for a development toolchain.
+func stdlibVersion(ctx context.Context) string {
+	version := goEnv(ctx, "GOVERSION")
+	if version == "" {
+		version = runtime.Version()
+	}
+	return parseGoVersion(version)
+}
+
+// parseGoVersion returns the release tag of a Go version such as
+// "go1.21.3 X:boringcrypto", or an empty string for one that isn't a
+// release, such as "devel go1.22-1f0bc3c Tue Sep 5 12:00:00 2023 +0000".
+func parseGoVersion(version string) string {
+	fields := strings.Fields(version)
+	if (len(fields) == 0) || !goRelease.MatchString(fields[0]) {
+		return ""
+	}
+	return fields[0]
+}
+
+// stdlibLabel returns the label of the standard library's entry in a report,
+// e.g. "Go standard library (go1.21.3)".
+func stdlibLabel(version string) string {
+	if version == "" {
+		return stdlibName
+	}
+	return fmt.Sprintf("%s (%s)", stdlibName, version)
+}
+
+// getStdlibLicense fetches the standard library's license, at the tag of a
+// Go release, rather than at the head of the Go repository.
+func getStdlibLicense(ctx context.Context, version string) (licenseFile, error) {
+	var parts []licensePart
+	for _, file := range append(stdlibLicenseFiles, "AUTHORS") {
+		url := fmt.Sprintf("https://raw.githubusercontent.com/golang/go/%s/%s", version, file)
+		data, err := httpGet(ctx, url, nil)
+		var statusErr *httpStatusError
+		if (file == "AUTHORS") && errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound) {
+			continue // removed in Go 1.19
+		} else if err != nil {
+			return licenseFile{}, fmt.Errorf("unable to fetch the Go %s at %s: %w", file, version, err)
+		}
+		text, ok := licenseText(url, data)
+		if !ok {
+			return licenseFile{}, fmt.Errorf("unable to fetch the Go %s at %s: not a license file", file, version)
+		}
+		parts = append(parts, licensePart{File: file, SourceURL: url, Text: text})
+	}
+
+	license := combineLicenseParts(parts)
+	license.Ref = version
+	license.Retrieved = retrievalTime()
+	return license, nil
+}
@@ -0,0 +1,85 @@
+package licenses
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	tests := map[string]string{
+		"go1.21.3":                    "go1.21.3",
+		"go1.16":                      "go1.16",
+		"go1.22rc1":                   "go1.22rc1",
+		"go1.21.3 X:boringcrypto":     "go1.21.3",
+		"devel go1.22-1f0bc3c Tue +0": "",
+		"":                            "",
+	}
+	for input, expected := range tests {
+		if version := parseGoVersion(input); version != expected {
+			t.Errorf("%q: expected %q but got %q", input, expected, version)
+		}
+	}
+}
+
+func TestGetStdlibLicense(t *testing.T) {
+	oldFetcher, oldLimiter := httpFetcher, rateLimiter
+	rateLimiter = NoRateLimit
+	httpFetcher = replayFetcher{
+		"https://raw.githubusercontent.com/golang/go/go1.21.3/LICENSE": "Copyright (c) 2009 The Go Authors.\n",
+		"https://raw.githubusercontent.com/golang/go/go1.21.3/PATENTS": "Additional IP Rights Grant (Patents)\n",
+	}
+	defer func() { httpFetcher, rateLimiter = oldFetcher, oldLimiter }()
+
+	license, err := getStdlibLicense(context.Background(), "go1.21.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (len(license.Parts) != 2) || (license.Parts[0].File != "LICENSE") || (license.Parts[1].File != "PATENTS") {
+		t.Errorf("expected LICENSE and PATENTS but got %+v", license.Parts)
+	}
+	if license.Ref != "go1.21.3" {
+		t.Errorf("expected %q but got %q", "go1.21.3", license.Ref)
+	}
+
+	if _, err := getStdlibLicense(context.Background(), "go1.99.0"); err == nil {
+		t.Errorf("expected an error for an unknown release")
+	}
+
+	entry := newEntry(context.Background(), Module{Path: stdlibModule, Version: "go1.21.3"}, license, &options{})
+	if entry.title() != "Go standard library (go1.21.3)" {
+		t.Errorf("expected %q but got %q", "Go standard library (go1.21.3)", entry.title())
+	}
+}
+
+func TestGoProjectNotices(t *testing.T) {
+	oldResolvers, oldFetcher, oldLimiter := resolvers, httpFetcher, rateLimiter
+	resolvers, rateLimiter = []Resolver{forgeResolver{}}, NoRateLimit
+	httpFetcher = replayFetcher{
+		"https://forge.test/golang.org/x/text/raw/LICENSE": testMITLicense,
+		"https://forge.test/golang.org/x/text/raw/PATENTS": "Additional IP Rights Grant (Patents)",
+		"https://forge.test/example.org/a/raw/LICENSE":     testMITLicense,
+		"https://forge.test/example.org/a/raw/PATENTS":     "Additional IP Rights Grant (Patents)",
+	}
+	defer func() { resolvers, httpFetcher, rateLimiter = oldResolvers, oldFetcher, oldLimiter }()
+
+	tests := map[string][]string{
+		"golang.org/x/text": {"LICENSE", "PATENTS"},
+		"example.org/a":     {"LICENSE"},
+	}
+	for module, expected := range tests {
+		gi := GoImport{ImportPrefix: module, Vcs: "git", RepoRoot: "https://forge.test/" + module}
+		license, err := getLicense(context.Background(), module, gi, GoSource{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", module, err)
+			continue
+		}
+		var files []string
+		for _, part := range license.Parts {
+			files = append(files, part.File)
+		}
+		if !reflect.DeepEqual(files, expected) {
+			t.Errorf("%s: expected %v but got %v", module, expected, files)
+		}
+	}
+}
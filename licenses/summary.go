@@ -0,0 +1,152 @@
+package licenses
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runSummary collects statistics about a run, reported when it finishes so
+// that users can quickly gauge how complete a report is.
+type runSummary struct {
+	start time.Time
+
+	Modules         int      `json:"modules"`          // modules scanned
+	Found           int      `json:"licenses_found"`   // licenses written to the report
+	LookupFailures  int      `json:"lookup_failures"`  // modules not resolved to a repository
+	LicenseFailures int      `json:"license_failures"` // modules with no license found
+	Unknown         []string `json:"unknown_licenses"` // modules whose license was not identified
+	Failed          []string `json:"failed"`           // modules missing from the report
+	RateLimited     []string `json:"rate_limited"`     // failed modules that were rate limited
+	NotScanned      []string `json:"not_scanned"`      // modules skipped after an interrupt
+	LicenseChanged  []string `json:"license_changed"`  // modules whose license differs from the lock file
+	Elapsed         float64  `json:"elapsed_seconds"`
+
+	// SPDX counts the modules with each identified SPDX expression
+	SPDX map[string]int `json:"spdx"`
+
+	// RetryAfter is the earliest time that a complete re-run is expected to
+	// succeed, if any module was skipped due to rate limiting (RFC 3339).
+	RetryAfter string `json:"retry_after,omitempty"`
+	retryAfter time.Time
+}
+
+func newRunSummary() *runSummary {
+	return &runSummary{
+		start:          time.Now(),
+		Failed:         []string{},
+		Unknown:        []string{},
+		RateLimited:    []string{},
+		NotScanned:     []string{},
+		LicenseChanged: []string{},
+		SPDX:           map[string]int{},
+	}
+}
+
+// found records a license written to the report
+func (s *runSummary) found(e Entry) {
+	s.Found++
+	if e.SPDX == "" {
+		s.Unknown = append(s.Unknown, e.Module)
+	} else {
+		s.SPDX[e.SPDX]++
+	}
+}
+
+// licenseChanged records a license that differs from the lock file
+func (s *runSummary) licenseChanged(module string) {
+	s.LicenseChanged = append(s.LicenseChanged, module)
+}
+
+func (s *runSummary) lookupFailed(module string, err error) {
+	s.LookupFailures++
+	s.failed(module, err)
+}
+
+func (s *runSummary) licenseFailed(module string, err error) {
+	s.LicenseFailures++
+	s.failed(module, err)
+}
+
+func (s *runSummary) failed(module string, err error) {
+	s.Failed = append(s.Failed, module)
+
+	if limited, reset := rateLimitReset(err); limited {
+		s.RateLimited = append(s.RateLimited, module)
+		if reset.After(s.retryAfter) {
+			s.retryAfter = reset
+			s.RetryAfter = reset.UTC().Format(time.RFC3339)
+		}
+	}
+}
+
+// finish records the elapsed time.
+func (s *runSummary) finish() {
+	s.Elapsed = time.Since(s.start).Round(time.Millisecond).Seconds()
+}
+
+func (s *runSummary) String() string {
+	var b strings.Builder
+	failures := s.LookupFailures + s.LicenseFailures
+	elapsed := time.Duration(s.Elapsed * float64(time.Second)).Round(time.Second)
+
+	fmt.Fprintf(&b, "summary: %d modules scanned, %d licenses found, %d failed ", s.Modules, s.Found, failures)
+	fmt.Fprintf(&b, "(%d lookup, %d license) in %s", s.LookupFailures, s.LicenseFailures, elapsed)
+	if len(s.SPDX) > 0 {
+		ids := make([]string, 0, len(s.SPDX))
+		for id := range s.SPDX {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		counts := make([]string, len(ids))
+		for i, id := range ids {
+			counts[i] = fmt.Sprintf("%s: %d", id, s.SPDX[id])
+		}
+		fmt.Fprintf(&b, "\nlicenses: %s", strings.Join(counts, ", "))
+	}
+	if len(s.Unknown) > 0 {
+		fmt.Fprintf(&b, "\nunidentified licenses: %s", strings.Join(s.Unknown, ", "))
+	}
+	if failures > 0 {
+		fmt.Fprintf(&b, "\nmissing from report: %s", strings.Join(s.Failed, ", "))
+	}
+	if len(s.LicenseChanged) > 0 {
+		fmt.Fprintf(&b, "\nLICENSE CHANGED: %s", strings.Join(s.LicenseChanged, ", "))
+	}
+	if len(s.NotScanned) > 0 {
+		fmt.Fprintf(&b, "\nnot scanned (interrupted): %s", strings.Join(s.NotScanned, ", "))
+	}
+	if len(s.RateLimited) > 0 {
+		fmt.Fprintf(&b, "\nrate limited: %s", strings.Join(s.RateLimited, ", "))
+		fmt.Fprintf(&b, "\na complete re-run is expected to succeed after %s", s.RetryAfter)
+	}
+	return b.String()
+}
+
+// log writes the summary to the log
+func (s *runSummary) log() {
+	logEmit(logEvent{
+		Level:   levelInfo,
+		Phase:   phaseSummary,
+		Message: s.String(),
+		Summary: s,
+	})
+}
+
+// writeFile writes the summary as JSON to a file
+func (s *runSummary) writeFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing summary: %v", err)
+	}
+	return nil
+}
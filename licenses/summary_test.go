@@ -0,0 +1,41 @@
+package licenses
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunSummaryString(t *testing.T) {
+	s := newRunSummary()
+	s.Modules = 4
+	s.found(Entry{Module: "example.org/c", SPDX: "MIT"})
+	s.found(Entry{Module: "example.org/d"})
+	s.lookupFailed("example.org/a", nil)
+	s.licenseFailed("example.org/b", nil)
+	s.Elapsed = 61.4
+
+	expected := "summary: 4 modules scanned, 2 licenses found, 2 failed (1 lookup, 1 license) in 1m1s\n" +
+		"licenses: MIT: 1\n" +
+		"unidentified licenses: example.org/d\n" +
+		"missing from report: example.org/a, example.org/b"
+	if s.String() != expected {
+		t.Errorf("expected %q but got %q", expected, s.String())
+	}
+}
+
+func TestRunSummaryRetryAfter(t *testing.T) {
+	s := newRunSummary()
+	reset := time.Date(2021, 6, 1, 13, 0, 0, 0, time.UTC)
+
+	s.licenseFailed("example.org/a", fmt.Errorf("wrapped: %w", &httpStatusError{StatusCode: 429, RateLimited: true, Reset: reset}))
+	s.licenseFailed("example.org/b", &httpStatusError{StatusCode: 429, RateLimited: true, Reset: reset.Add(-time.Minute)})
+	s.licenseFailed("example.org/c", &httpStatusError{StatusCode: 404})
+
+	if len(s.RateLimited) != 2 {
+		t.Errorf("expected two rate limited modules but got %v", s.RateLimited)
+	}
+	if s.RetryAfter != "2021-06-01T13:00:00Z" {
+		t.Errorf("expected the latest reset time but got %q", s.RetryAfter)
+	}
+}
@@ -0,0 +1,39 @@
+package licenses
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// artifactTime returns the timestamp to embed in generated artifacts.
+//
+// If the SOURCE_DATE_EPOCH environment variable is set, it is used instead of
+// the current time so that reproducible builds can regenerate identical
+// artifacts. See https://reproducible-builds.org/specs/source-date-epoch/
+func artifactTime() (time.Time, error) {
+	epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || epoch == "" {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: must be a decimal number of seconds", epoch)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// retrievalTime returns the current time, clamped to be no later than
+// SOURCE_DATE_EPOCH (if set and valid) as required for reproducible builds.
+func retrievalTime() time.Time {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	epoch, err := artifactTime()
+	if (err == nil) && epoch.Before(now) {
+		return epoch
+	}
+	return now
+}
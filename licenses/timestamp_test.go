@@ -0,0 +1,32 @@
+package licenses
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestArtifactTime(t *testing.T) {
+	old, wasSet := os.LookupEnv("SOURCE_DATE_EPOCH")
+	defer func() {
+		if wasSet {
+			os.Setenv("SOURCE_DATE_EPOCH", old)
+		} else {
+			os.Unsetenv("SOURCE_DATE_EPOCH")
+		}
+	}()
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	ts, err := artifactTime()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC); !ts.Equal(expected) {
+		t.Errorf("expected %s but got %s", expected, ts)
+	}
+
+	os.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := artifactTime(); err == nil {
+		t.Errorf("expected an error for an invalid SOURCE_DATE_EPOCH")
+	}
+}
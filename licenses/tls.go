@@ -0,0 +1,101 @@
+package licenses
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// caCertEnv, clientCertEnv and clientKeyEnv name the files for --ca-cert,
+// --client-cert and --client-key if they aren't given
+const (
+	caCertEnv     = "GOCOMPLY_CA_CERT"
+	clientCertEnv = "GOCOMPLY_CLIENT_CERT"
+	clientKeyEnv  = "GOCOMPLY_CLIENT_KEY"
+)
+
+// configureTLS makes the shared httpTransport trust the certificate
+// authorities in a PEM file as well as the system's, such as the private CA
+// of a proxy that intercepts TLS, and present a client certificate for mutual
+// TLS. Any of the files may be empty.
+func configureTLS(caFile string, certFile string, keyFile string) error {
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("--client-cert and --client-key must be given together")
+	}
+	if (caFile == "") && (certFile == "") {
+		return nil
+	}
+
+	config := &tls.Config{}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("error reading CA certificates: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if (err != nil) || (pool == nil) {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("error loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := defaultTransport.Clone()
+	transport.TLSClientConfig = config
+	httpTransport = transport
+	return nil
+}
+
+// tlsErrorText describes a TLS error, with how to fix it where that's
+// likely to be a private CA or a proxy, or returns false for any other error.
+func tlsErrorText(err error) (string, bool) {
+	var unknown x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var header tls.RecordHeaderError
+	switch {
+	case errors.As(err, &unknown):
+		return "certificate signed by an unknown authority (if a proxy or a private CA is in the way, trust it with --ca-cert)", true
+	case errors.As(err, &hostname):
+		return hostname.Error() + " (if a proxy intercepts TLS, check HTTPS_PROXY and NO_PROXY)", true
+	case errors.As(err, &invalid):
+		return invalid.Error(), true
+	case errors.As(err, &header):
+		return "the server didn't respond with TLS (is it an http:// proxy given as https://?)", true
+	default:
+		return "", false
+	}
+}
+
+// tlsWarned records the hosts for which a TLS error has been logged, so that
+// the same error isn't repeated for every request to the same host
+var tlsWarned sync.Map
+
+// warnTLSError logs a warning for a TLS error making a request, once per
+// host (see tlsErrorText).
+func warnTLSError(rsc string, err error) {
+	text, ok := tlsErrorText(err)
+	if !ok {
+		return
+	}
+	host := rsc
+	if u, err := url.Parse(rsc); err == nil {
+		host = u.Host
+	}
+	if _, warned := tlsWarned.LoadOrStore(host, true); !warned {
+		logf(levelWarning, "", "", err, "warning: TLS error for %s: %s", host, text)
+	}
+}
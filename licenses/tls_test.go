@@ -0,0 +1,105 @@
+package licenses
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block to a file in dir and returns its path.
+func writePEM(t *testing.T, dir string, name string, blockType string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigureTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "no client certificate", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "License A")
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+	defer func() { httpTransport = defaultTransport }()
+
+	// untrusted
+	_, err := httpClient().Get(server.URL)
+	if text, ok := tlsErrorText(err); !ok {
+		t.Errorf("expected a TLS error but got %v", err)
+	} else if retryable(err) {
+		t.Errorf("expected %q not to be retried", text)
+	}
+
+	// a client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	certFile := writePEM(t, dir, "client.pem", "CERTIFICATE", der)
+	keyFile := writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+
+	if err := configureTLS(caFile, "", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := httpClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected %d without a client certificate but got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if err := configureTLS(caFile, certFile, keyFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err = httpClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if err := configureTLS("", certFile, ""); err == nil {
+		t.Errorf("expected an error for a client certificate without a key")
+	}
+	if err := configureTLS(certFile+".missing", "", ""); err == nil {
+		t.Errorf("expected an error for a missing CA file")
+	}
+}
@@ -0,0 +1,108 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The values of --tools: what to do with the modules that are only needed
+// by the main module's tools, which are usually run at build time but not
+// shipped.
+const (
+	toolsExclude  = "exclude"  // skip them, listing them in the appendix of excluded modules
+	toolsAnnotate = "annotate" // report them, marked as build-time only
+	toolsInclude  = "include"  // report them like any other module
+)
+
+// toolModules returns the modules that are only needed by the main module's
+// tools: by the packages named in go.mod's tool directives (Go 1.24 and
+// later), or imported by files with the "tools" build constraint (the
+// tools.go convention), but not by any package built without it.
+func toolModules(ctx context.Context) (map[string]bool, error) {
+	patterns := []string{"./..."}
+	if hasToolDirective(findGoMod()) {
+		patterns = append(patterns, "tool")
+	}
+	// -e, as tools.go conventionally imports commands, which aren't
+	// importable packages
+	tools, err := depModules(ctx, append([]string{"-e", "-tags", "tools"}, patterns...)...)
+	if err != nil {
+		return nil, err
+	}
+	built, err := depModules(ctx, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	for module := range built {
+		delete(tools, module)
+	}
+	return tools, nil
+}
+
+// depModules returns the modules, other than the main module, that provide
+// the packages matched by "go list" arguments and their dependencies.
+func depModules(ctx context.Context, args ...string) (map[string]bool, error) {
+	args = append([]string{"list", "-deps", "-f", "{{with .Module}}{{if not .Main}}{{.Path}}{{end}}{{end}}"}, args...)
+	stdout, err := exec.CommandContext(ctx, "go", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+
+	modules := make(map[string]bool)
+	for _, line := range bytes.Split(stdout, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			modules[string(line)] = true
+		}
+	}
+	return modules, nil
+}
+
+// hasToolDirective returns true if a go.mod has any tool directives.
+func hasToolDirective(goMod string) bool {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if (len(fields) >= 2) && (fields[0] == "tool") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyToolsMode marks, or with toolsExclude removes, the modules that are
+// only needed by tools, returning the modules to scan and any excluded.
+func applyToolsMode(mode string, modules []Module, tools map[string]bool) ([]Module, []excludedModule) {
+	if (mode == toolsInclude) || (len(tools) == 0) {
+		return modules, nil
+	}
+
+	var result []Module
+	var excluded []excludedModule
+	for _, m := range modules {
+		if !tools[m.Path] {
+			result = append(result, m)
+			continue
+		}
+		if mode == toolsAnnotate {
+			m.Tool = true
+			result = append(result, m)
+			continue
+		}
+		logf(levelInfo, phaseModule, m.Path, nil, "skipping %s (only needed by tools)", m.Path)
+		excluded = append(excluded, excludedModule{m.Path, "--tools=" + toolsExclude, "only needed by tools, at build time"})
+	}
+	return result, excluded
+}
@@ -0,0 +1,61 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyToolsMode(t *testing.T) {
+	modules := []Module{
+		{Path: "example.org/lib", Version: "v1.0.0"},
+		{Path: "example.org/linter", Version: "v2.0.0"},
+	}
+	tools := map[string]bool{"example.org/linter": true}
+
+	result, excluded := applyToolsMode(toolsExclude, modules, tools)
+	if !reflect.DeepEqual(result, modules[:1]) {
+		t.Errorf("expected %+v but got %+v", modules[:1], result)
+	}
+	if (len(excluded) != 1) || (excluded[0].Module != "example.org/linter") {
+		t.Errorf("expected the tool module to be excluded but got %+v", excluded)
+	}
+
+	result, excluded = applyToolsMode(toolsAnnotate, modules, tools)
+	if (len(result) != 2) || result[0].Tool || !result[1].Tool || (len(excluded) != 0) {
+		t.Errorf("expected the tool module to be marked but got %+v, %+v", result, excluded)
+	}
+	if modules[1].Tool {
+		t.Errorf("expected the original modules unchanged")
+	}
+
+	result, excluded = applyToolsMode(toolsInclude, modules, tools)
+	if !reflect.DeepEqual(result, modules) || (len(excluded) != 0) {
+		t.Errorf("expected %+v but got %+v, %+v", modules, result, excluded)
+	}
+}
+
+func TestHasToolDirective(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		goMod    string
+		expected bool
+	}{
+		{"module example.org/a\n\ngo 1.24\n\ntool golang.org/x/tools/cmd/stringer\n", true},
+		{"module example.org/a\n\ntool (\n\tgolang.org/x/tools/cmd/stringer\n)\n", true},
+		{"module example.org/tool\n\nrequire example.org/tool v1.0.0\n", false},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, "go.mod")
+		if err := os.WriteFile(path, []byte(test.goMod), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := hasToolDirective(path); got != test.expected {
+			t.Errorf("%d: expected %v but got %v", i, test.expected, got)
+		}
+	}
+	if hasToolDirective(filepath.Join(dir, "missing")) {
+		t.Errorf("expected false for a missing go.mod")
+	}
+}
@@ -0,0 +1,105 @@
+package licenses
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpTransport is shared by every request, so that connections to hosts
+// that are requested many times, such as raw.githubusercontent.com, are kept
+// alive and reused (with HTTP/2 where the server supports it). It is
+// defaultTransport unless configureTLS replaces it.
+var httpTransport http.RoundTripper = defaultTransport
+
+// defaultTransport uses any proxy given by the environment (HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY) and the system's certificate authorities.
+var defaultTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   4,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
+// contactEnv gives --contact if it isn't given
+const contactEnv = "GOCOMPLY_CONTACT"
+
+// httpContact is a URL or email address that the admins of the hosts
+// requested can use to contact whoever runs gocomply, if given
+var httpContact string
+
+// userAgent returns the User-Agent of every request, identifying gocomply as
+// an automated client, as several code hosts' policies require, e.g.
+// "gocomply/v1.2.0 (+https://pkg.go.dev/tawesoft.co.uk/gopkg/gocomply;
+// ops@example.org)".
+func userAgent() string {
+	v := strings.Trim(moduleVersion(), "()")
+	about := "+https://pkg.go.dev/tawesoft.co.uk/gopkg/gocomply"
+	if httpContact != "" {
+		about += "; " + httpContact
+	}
+	return fmt.Sprintf("gocomply/%s (%s)", v, about)
+}
+
+// setUserAgent sets the User-Agent of a request, unless it has one.
+func setUserAgent(req *http.Request) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent())
+	}
+}
+
+// httpClient returns a client using the shared httpTransport, with the
+// current httpTimeout.
+func httpClient() *http.Client {
+	return &http.Client{
+		Transport: httpTransport,
+		Timeout:   httpTimeout,
+	}
+}
+
+// Fetcher makes the HTTP requests of a scan. An *http.Client is a Fetcher,
+// so a consumer can give one with a custom transport (for a proxy or mTLS,
+// for example) or a fake that records and replays responses for testing.
+//
+// A Fetcher is responsible for its own timeouts. Requests are still paced by
+// the rate limiter, retried and cached as usual.
+type Fetcher interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// httpFetcher, if not nil, makes every request instead of httpClient.
+var httpFetcher Fetcher
+
+// fetcher returns the Fetcher to make a request with, recording it in the
+// audit log, if any.
+func fetcher() Fetcher {
+	var f Fetcher = httpClient()
+	if httpFetcher != nil {
+		f = httpFetcher
+	}
+	if requestAudit != nil {
+		return auditFetcher{f, requestAudit}
+	}
+	return f
+}
+
+// maxDrain is the most of an unwanted response body that is read so that its
+// connection can be reused. A longer body's connection is closed instead.
+const maxDrain = 64 * 1024
+
+// drainAndClose reads a little of a response body and closes it, so that the
+// connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrain))
+	body.Close()
+}
@@ -0,0 +1,61 @@
+package licenses
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPConnectionReuse(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "License A")
+	}))
+	var connections int32
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	oldLimiter := rateLimiter
+	rateLimiter = NoRateLimit
+	defer func() { rateLimiter = oldLimiter }()
+
+	for _, path := range []string{"/LICENSE", "/missing", "/COPYING"} {
+		httpGet(context.Background(), server.URL+path, nil)
+	}
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Errorf("expected one connection to be reused but got %d connections", n)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+		fmt.Fprint(w, "License A")
+	}))
+	defer server.Close()
+
+	oldLimiter, oldContact := rateLimiter, httpContact
+	rateLimiter, httpContact = NoRateLimit, "ops@example.org"
+	defer func() { rateLimiter, httpContact = oldLimiter, oldContact }()
+
+	if _, err := httpGet(context.Background(), server.URL+"/LICENSE", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "gocomply/") || !strings.HasSuffix(got, "; ops@example.org)") {
+		t.Errorf("expected a gocomply User-Agent with the contact but got %q", got)
+	}
+}
@@ -0,0 +1,308 @@
+package licenses
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// scanFunc scans a single module, as scanModule does.
+type scanFunc func(ctx context.Context, m Module, o *options) (Entry, error)
+
+// tuiRow is the state of one module in the interactive review.
+type tuiRow struct {
+	module   Module
+	entry    Entry
+	err      error
+	override bool // entry was assigned manually
+}
+
+func (r *tuiRow) status() string {
+	switch {
+	case r.override:
+		return "override"
+	case r.err != nil:
+		return "FAILED"
+	default:
+		return "ok"
+	}
+}
+
+// openURL opens a URL in the user's browser.
+var openURL = func(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+const tuiHelp = `commands:
+  l, list              list modules and their status
+  s, show N            show the license (or error) for module N
+  r, retry [N]         retry module N, or every failed module
+  o, open N            open the source URL for module N in a browser
+  v, override N SRC    use the license at SRC (a URL or a file path) for module N
+  w, export [FILE]     write the report to FILE (default: --output)
+  h, help              show this help
+  q, quit              exit
+`
+
+// runTUI implements the tui command: an interactive review of a scan where
+// the user can retry failures, open source URLs, assign manual overrides and
+// export the final report, without editing configuration and rerunning.
+//
+// Commands are read from in, one per line, and everything else is written to
+// out (normally stderr, as stdout is reserved for reports).
+func runTUI(ctx context.Context, o *options, args []string, in io.Reader, out io.Writer, scan scanFunc) error {
+	modules, err := modulesToScan(ctx, o, args)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range o.Products {
+		if err := p.resolve(ctx); err != nil {
+			return err
+		}
+	}
+
+	rows := make([]*tuiRow, len(modules))
+	for i, m := range modules {
+		rows[i] = &tuiRow{module: m}
+	}
+
+	resolve := func(i int) {
+		r := rows[i]
+		fmt.Fprintf(out, "[%d/%d] %s\n", i+1, len(rows), r.module.Path)
+		r.entry, r.err = scan(ctx, r.module, o)
+		r.override = false
+	}
+
+	for i := range rows {
+		resolve(i)
+	}
+
+	fmt.Fprintf(out, "\n")
+	tuiList(out, rows)
+	fmt.Fprintf(out, "\n%s", tuiHelp)
+
+	// parse a 1-based row number argument
+	row := func(fields []string) (*tuiRow, int, error) {
+		if len(fields) < 2 {
+			return nil, 0, fmt.Errorf("missing module number")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if (err != nil) || (n < 1) || (n > len(rows)) {
+			return nil, 0, fmt.Errorf("invalid module number %q", fields[1])
+		}
+		return rows[n-1], n - 1, nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "gocomply> ")
+		if !scanner.Scan() {
+			fmt.Fprintf(out, "\n")
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		err := func() error {
+			switch fields[0] {
+			case "l", "list":
+				tuiList(out, rows)
+
+			case "s", "show":
+				r, _, err := row(fields)
+				if err != nil {
+					return err
+				}
+				if r.err != nil && !r.override {
+					fmt.Fprintf(out, "%v\n", r.err)
+				} else {
+					fmt.Fprintf(out, "%s\n", r.entry.License)
+				}
+
+			case "r", "retry":
+				if len(fields) > 1 {
+					_, i, err := row(fields)
+					if err != nil {
+						return err
+					}
+					resolve(i)
+				} else {
+					for i, r := range rows {
+						if r.err != nil && !r.override {
+							resolve(i)
+						}
+					}
+				}
+				tuiList(out, rows)
+
+			case "o", "open":
+				r, _, err := row(fields)
+				if err != nil {
+					return err
+				}
+				u := r.entry.SourceURL
+				if u == "" {
+					u = "https://" + r.module.Path
+				}
+				fmt.Fprintf(out, "opening %s\n", u)
+				return openURL(u)
+
+			case "v", "override":
+				r, _, err := row(fields)
+				if err != nil {
+					return err
+				}
+				if len(fields) < 3 {
+					return fmt.Errorf("missing license URL or file path")
+				}
+				text, err := readLicenseSource(ctx, fields[2])
+				if err != nil {
+					return err
+				}
+				r.entry = Entry{
+					Module:    r.module.Path,
+					Version:   r.module.Version,
+					PURL:      purl(r.module.Path, r.module.Version),
+					License:   text,
+					SourceURL: fields[2],
+				}
+				r.entry.classify()
+				r.override = true
+				tuiList(out, rows)
+
+			case "w", "export":
+				path := o.Output
+				if len(fields) > 1 {
+					path = fields[1]
+				}
+				if path == "" {
+					return fmt.Errorf("missing file to export to")
+				}
+				n, err := tuiExport(o, rows, path)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "wrote %d entries to %s\n", n, path)
+
+			case "h", "help", "?":
+				fmt.Fprintf(out, "%s", tuiHelp)
+
+			case "q", "quit", "exit":
+				return io.EOF
+
+			default:
+				return fmt.Errorf("unknown command %q (try \"help\")", fields[0])
+			}
+			return nil
+		}()
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+func tuiList(out io.Writer, rows []*tuiRow) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "#\tstatus\tmodule\tsource\n")
+	for i, r := range rows {
+		source := r.entry.SourceURL
+		if r.err != nil && !r.override {
+			source = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, r.status(), r.module.Path, source)
+	}
+	tw.Flush()
+}
+
+// readLicenseSource reads a license text from a http(s) URL or a file path.
+func readLicenseSource(ctx context.Context, src string) (string, error) {
+	var data string
+	if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+		text, err := httpGet(ctx, src, nil)
+		if err != nil {
+			return "", err
+		}
+		data = text
+	} else {
+		raw, err := os.ReadFile(src)
+		if err != nil {
+			return "", err
+		}
+		data = string(raw)
+	}
+
+	text, err := normalizeText(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", src, err)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// tuiExport writes every resolved or overridden module to a report file,
+// returning the number of entries written.
+func tuiExport(o *options, rows []*tuiRow, path string) (n int, err error) {
+	reportOpts, err := o.reportOptions()
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("error creating output file: %v", err)
+	}
+	defer func() {
+		if cerr := f.Close(); (cerr != nil) && (err == nil) {
+			err = fmt.Errorf("error closing output file: %v", cerr)
+		}
+	}()
+
+	report, err := newReportWriter(f, reportOpts)
+	if err != nil {
+		return 0, err
+	}
+
+	var entries []Entry
+	for _, r := range rows {
+		if r.err == nil || r.override {
+			entries = append(entries, r.entry)
+		}
+	}
+
+	if len(o.Products) > 0 {
+		err = writeProductReport(report, o.Products, entries)
+	} else {
+		for _, e := range entries {
+			if err = report.WriteEntry(e); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries), report.Close()
+}
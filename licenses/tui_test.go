@@ -0,0 +1,67 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTUI(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.txt")
+	override := filepath.Join(dir, "LICENSE")
+	if err := os.WriteFile(override, []byte("License C\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// example.org/b fails the first time only, example.org/c always fails
+	attempts := map[string]int{}
+	scan := func(ctx context.Context, m Module, o *options) (Entry, error) {
+		attempts[m.Path]++
+		if (m.Path == "example.org/b" && attempts[m.Path] == 1) || m.Path == "example.org/c" {
+			return Entry{}, &scanError{phaseLicense, fmt.Errorf("no license found")}
+		}
+		return Entry{Module: m.Path, License: "License " + m.Path}, nil
+	}
+
+	input := strings.Join([]string{
+		"retry",
+		"override 3 " + override,
+		"bogus",
+		"export " + report,
+		"quit",
+	}, "\n")
+
+	var out bytes.Buffer
+	o := &options{Format: "text"}
+	args := []string{"example.org/a", "example.org/b", "example.org/c"}
+	if err := runTUI(context.Background(), o, args, strings.NewReader(input), &out, scan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attempts["example.org/a"] != 1 || attempts["example.org/b"] != 2 || attempts["example.org/c"] != 2 {
+		t.Errorf("unexpected retries %v", attempts)
+	}
+	if !strings.Contains(out.String(), `unknown command "bogus"`) {
+		t.Errorf("expected an unknown command error in output %q", out.String())
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"License example.org/a", "License example.org/b", "License C"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %q in exported report %q", expected, data)
+		}
+	}
+
+	// the standard library is always scanned, so 4 entries
+	if !strings.Contains(out.String(), "wrote 4 entries") {
+		t.Errorf("expected 4 entries to be exported in output %q", out.String())
+	}
+}
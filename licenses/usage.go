@@ -0,0 +1,117 @@
+package licenses
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const usageHeader = `usage: gocomply [command] [flags] [module[@version]...]
+
+Writes the license of each module required by the module in the current
+directory (or of each module given) to stdout. Progress, warnings and
+errors are written to stderr.
+
+commands:
+  scan     write a report of every module's license (the default)
+  check    compare licenses against a --baseline or --policy
+  list     list each module's license without fetching its text (--list)
+  verify   check the checksums of existing reports, given as files
+  diff     list the modules added or removed and licenses changed between two reports
+  compat   check licenses are compatible with the project's own license
+  why      explain why a module is in the report, and where its license is from
+  quick    check from overrides and the module cache, without the network
+  cache    manage the --cache: status, clean or warm
+  tui      review each module's license interactively
+  rpc      serve scans as JSON-RPC over stdin and stdout
+  serve    serve license lookups over HTTP, sharing one cache
+
+flags:
+`
+
+// commands are the names of gocomply's commands. Without one, the command
+// is scan.
+var commands = []string{"scan", "check", "list", "compat", "why", "quick", "cache", "tui", "rpc", "serve", "verify", "diff"}
+
+// isCommand returns true if arg names one of commands.
+func isCommand(arg string) bool {
+	for _, c := range commands {
+		if arg == c {
+			return true
+		}
+	}
+	return false
+}
+
+// usage writes the command's usage, with every flag of fs, to w.
+func usage(fs *flag.FlagSet, w io.Writer) {
+	fmt.Fprint(w, usageHeader)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// version returns the version of the gocomply module that the running
+// binary was built from, as recorded by "go install", and the Go version.
+func version() string {
+	return fmt.Sprintf("gocomply %s (%s)", moduleVersion(), runtime.Version())
+}
+
+// moduleVersion returns the version of the gocomply module that the running
+// binary was built from, or "(devel)".
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && (info.Main.Version != "") {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// optionalValues gives the value of each flag that may be given without one,
+// such as a bare --cache for the default cache directory.
+var optionalValues = map[string]func() string{
+	"cache": defaultCacheDir,
+}
+
+// expandOptionalValues gives a value to each flag in optionalValues that is
+// given without one: at the end of args, or followed by another flag.
+// Otherwise, the next argument is its value, as usual.
+func expandOptionalValues(args []string) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, ok := optionalValues[name]
+		if ok && (arg != name) && ((i+1 == len(args)) || strings.HasPrefix(args[i+1], "-")) {
+			arg = arg + "=" + value()
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// parseFlags parses flags and positional arguments in any order, unlike
+// fs.Parse, which stops at the first positional argument. Everything after
+// a "--" is positional.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		consumed := len(args) - len(fs.Args())
+		if (consumed > 0) && (args[consumed-1] == "--") {
+			return append(positional, fs.Args()...), nil
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,83 @@
+package licenses
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	type row struct {
+		args     []string
+		expected []string
+		format   string
+	}
+	tests := []row{
+		{[]string{"example.org/a", "-format", "json", "example.org/b"}, []string{"example.org/a", "example.org/b"}, "json"},
+		{[]string{"--format=json", "example.org/a"}, []string{"example.org/a"}, "json"},
+		{[]string{"example.org/a", "--", "-format", "json"}, []string{"example.org/a", "-format", "json"}, "text"},
+		{nil, nil, "text"},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("gocomply", flag.ContinueOnError)
+		var o options
+		o.register(fs)
+		got, err := parseFlags(fs, test.args)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) || (o.Format != test.format) {
+			t.Errorf("%q: expected %q and format %s but got %q and format %s", test.args, test.expected, test.format, got, o.Format)
+		}
+	}
+	logFormat = "text"
+}
+
+func TestParseFlagsHelp(t *testing.T) {
+	fs := flag.NewFlagSet("gocomply", flag.ContinueOnError)
+	var o options
+	o.register(fs)
+	var out bytes.Buffer
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() { usage(fs, &out) }
+
+	if _, err := parseFlags(fs, []string{"example.org/a", "--help"}); err != flag.ErrHelp {
+		t.Fatalf("expected %v but got %v", flag.ErrHelp, err)
+	}
+	for _, expected := range []string{"usage: gocomply", "  check ", "-format"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected usage to contain %q but got %q", expected, out.String())
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	if v := version(); !strings.HasPrefix(v, "gocomply ") {
+		t.Errorf("unexpected version %q", v)
+	}
+}
+
+func TestExpandOptionalValues(t *testing.T) {
+	cache := defaultCacheDir()
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"-o", "licenses.txt", "--cache"}, []string{"-o", "licenses.txt", "--cache=" + cache}},
+		{[]string{"-cache", "--quiet"}, []string{"-cache=" + cache, "--quiet"}},
+		{[]string{"--cache", "dir", "scan"}, []string{"--cache", "dir", "scan"}},
+		{[]string{"--cache=dir"}, []string{"--cache=dir"}},
+		{[]string{"--", "--cache"}, []string{"--", "--cache"}},
+		{[]string{"cache"}, []string{"cache"}},
+	}
+
+	for _, tt := range tests {
+		if actual := expandOptionalValues(tt.args); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%q: expected %q but got %q", tt.args, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// runVerify implements the verify command: each report given is checked
+// against its own checksums, so that a truncated, incomplete or modified
+// report is detected. A text report needs a trailer (see --trailer) and a
+// JSON report needs its checksums (see --checksums). Each report's result is
+// written to stdout, and the command fails if any report doesn't verify.
+//
+// With --refetch, the license of each entry of a JSON report is also fetched
+// again and checked against the report (see refetchReport).
+func runVerify(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("verify requires a report file (or - for stdin)")
+	}
+
+	failures := 0
+	for _, path := range args {
+		data, err := readReportFile(path)
+		if err != nil {
+			return err
+		}
+
+		entries, err := verifyReport(data)
+		if err != nil {
+			failures++
+			fmt.Fprintf(stdout, "%s: FAILED: %v\n", path, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: OK (%d entries)\n", path, entries)
+
+		if o.Refetch {
+			drifted, err := refetchReport(ctx, o, data, stdout)
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			if drifted > 0 {
+				failures++
+				fmt.Fprintf(stdout, "%s: FAILED: %d license(s) no longer match\n", path, drifted)
+				continue
+			}
+			fmt.Fprintf(stdout, "%s: OK (every license still matches)\n", path)
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d report(s) failed verification", failures)
+	}
+	return nil
+}
+
+// verifyReport checks a text or JSON report against its checksums, and
+// returns its number of entries.
+func verifyReport(data []byte) (int, error) {
+	if trimmed := bytes.TrimSpace(data); (len(trimmed) > 0) && (trimmed[0] == '{') {
+		return verifyJSONReport(data)
+	}
+	return verifyTextReport(data)
+}
+
+// refetchReport fetches the license of each entry of a JSON report again,
+// from the --cache if enabled, or else the module's repository, falling back
+// to a local copy of the module (in vendor or the module cache) if that
+// fails. Each entry whose license no longer matches its checksum, or can't
+// be fetched, is written to stdout, and their number returned.
+//
+// The license is fetched with the options given, which should be those that
+// generated the report, such as --plain-text.
+func refetchReport(ctx context.Context, o *options, data []byte, stdout io.Writer) (int, error) {
+	if detectReportFormat(data) != "json" {
+		return 0, fmt.Errorf("--refetch needs a JSON report, which records each module's version")
+	}
+	var report jsonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return 0, fmt.Errorf("malformed report: %v", err)
+	}
+
+	opts := *o
+	opts.List = false
+
+	drifted := 0
+	for _, e := range report.Entries {
+		if ctx.Err() != nil {
+			return drifted, ctx.Err()
+		}
+		if e.License == "" {
+			continue // an inventory, without license texts
+		}
+		expected := e.SHA256
+		if expected == "" {
+			expected = textSHA256(e.License)
+		}
+
+		m := entryModule(e)
+		logf(levelInfo, phaseLicense, m.Path, nil, "re-fetching the license of %s@%s", m.Path, m.Version)
+		license := ""
+		if entry, err := scanModule(ctx, m, &opts); err == nil {
+			license = entry.License
+		} else if local, ok := localLicense(ctx, m); ok {
+			license = newEntry(ctx, m, local, &opts).License
+		} else {
+			drifted++
+			fmt.Fprintf(stdout, "%s %s: FAILED: %v\n", m.Path, m.Version, err)
+			continue
+		}
+
+		if textSHA256(license) != expected {
+			drifted++
+			fmt.Fprintf(stdout, "%s %s: DRIFT: the license no longer matches the report\n", m.Path, m.Version)
+		}
+	}
+	return drifted, nil
+}
+
+// entryModule returns the module of a report entry, with its replacement.
+func entryModule(e Entry) Module {
+	m := Module{Path: e.Module, Version: e.Version}
+	if e.ReplacedBy == "" {
+		return m
+	}
+	if fields := strings.Fields(e.ReplacedBy); len(fields) == 2 {
+		m.Replace, m.ReplaceVersion = fields[0], fields[1]
+		return m
+	}
+
+	// a local directory, relative to go.mod
+	m.Replace = e.ReplacedBy
+	m.ReplaceDir = e.ReplacedBy
+	if !filepath.IsAbs(m.ReplaceDir) {
+		m.ReplaceDir = filepath.Join(filepath.Dir(findGoMod()), filepath.FromSlash(m.ReplaceDir))
+	}
+	return m
+}
+
+// verifyTextReport checks a text report against its trailer (see
+// textReportWriter.WriteTrailer).
+func verifyTextReport(data []byte) (int, error) {
+	body := bytes.TrimSuffix(data, []byte("\n"))
+	idx := bytes.LastIndexByte(body, '\n') + 1
+	last := string(body[idx:])
+	if !strings.HasPrefix(last, trailerPrefix) {
+		return 0, fmt.Errorf("no trailer: the report is truncated or incomplete, or was generated without --trailer")
+	}
+
+	var entries int
+	var sum string
+	if _, err := fmt.Sscanf(strings.TrimPrefix(last, trailerPrefix), "%d entries sha256:%s", &entries, &sum); err != nil {
+		return 0, fmt.Errorf("malformed trailer %q", last)
+	}
+
+	actual := sha256.Sum256(data[:idx])
+	if hex.EncodeToString(actual[:]) != sum {
+		return 0, fmt.Errorf("checksum mismatch: the report was modified after it was generated")
+	}
+	return entries, nil
+}
+
+// verifyJSONReport checks a JSON report against each entry's checksum and
+// the checksum of the whole report (see reportDigest).
+func verifyJSONReport(data []byte) (int, error) {
+	var report jsonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return 0, fmt.Errorf("malformed report: %v", err)
+	}
+	if report.Incomplete {
+		return 0, fmt.Errorf("the report is incomplete")
+	}
+	if report.SHA256 == "" {
+		return 0, fmt.Errorf("no checksum: the report was generated without --checksums")
+	}
+
+	for _, e := range report.Entries {
+		if (e.SHA256 != "") && (e.SHA256 != textSHA256(e.License)) {
+			return 0, fmt.Errorf("checksum mismatch for module %q", e.Module)
+		}
+	}
+	digest, err := reportDigest(report)
+	if err != nil {
+		return 0, err
+	}
+	if digest != report.SHA256 {
+		return 0, fmt.Errorf("checksum mismatch: the report was modified after it was generated")
+	}
+	return len(report.Entries), nil
+}
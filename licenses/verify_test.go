@@ -0,0 +1,120 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyReport(t *testing.T) {
+	entries := []Entry{
+		{Module: "example.org/a", License: "License A"},
+		{Module: "example.org/b", Version: "v1.0.0", License: "License B"},
+	}
+	for _, format := range []string{"text", "json"} {
+		var buf bytes.Buffer
+		w, err := newReportWriter(&buf, reportOptions{Format: format, Checksums: true, Generated: time.Unix(0, 0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if err := w.WriteEntry(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := verifyReport(buf.Bytes()); (err != nil) || (n != 2) {
+			t.Errorf("%s: expected 2 entries but got %d, %v", format, n, err)
+		}
+
+		modified := strings.Replace(buf.String(), "License B", "License C", 1)
+		if _, err := verifyReport([]byte(modified)); err == nil {
+			t.Errorf("%s: expected a modified report to fail", format)
+		}
+
+		// text reports don't include versions
+		bumped := strings.Replace(buf.String(), "v1.0.0", "v1.0.1", 1)
+		if _, err := verifyReport([]byte(bumped)); (format == "json") && (err == nil) {
+			t.Errorf("%s: expected a report with a changed version to fail", format)
+		}
+
+		truncated := buf.Bytes()[:buf.Len()/2]
+		if _, err := verifyReport(truncated); err == nil {
+			t.Errorf("%s: expected a truncated report to fail", format)
+		}
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runVerify(context.Background(), &options{}, nil, &stdout); err == nil {
+		t.Errorf("expected an error without a report")
+	}
+}
+
+func TestRefetchReport(t *testing.T) {
+	credentialsOnce.Do(func() {}) // don't read the user's credentials
+
+	oldResolvers, oldFetcher, oldLimiter := resolvers, httpFetcher, rateLimiter
+	resolvers, rateLimiter = []Resolver{forgeResolver{}}, NoRateLimit
+	httpFetcher = replayFetcher{
+		"https://forge.test/example.org/a/raw/LICENSE": testMITLicense,
+		"https://forge.test/example.org/b/raw/LICENSE": testMITLicense,
+	}
+	defer func() { resolvers, httpFetcher, rateLimiter = oldResolvers, oldFetcher, oldLimiter }()
+
+	var buf bytes.Buffer
+	w, err := newReportWriter(&buf, reportOptions{Format: "json", Checksums: true, Generated: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Entry{
+		{Module: "example.org/a", Version: "v1.0.0", License: testMITLicense},
+		{Module: "example.org/b", Version: "v1.0.0", License: "License B, since relicensed"},
+		{Module: "example.org/c", Version: "v1.0.0", License: "License C, since deleted"},
+		{Module: "example.org/d", Version: "v1.0.0"},
+	} {
+		if err := w.WriteEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	drifted, err := refetchReport(context.Background(), &options{}, buf.Bytes(), &stdout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if drifted != 2 {
+		t.Errorf("expected 2 licenses to have drifted but got %d", drifted)
+	}
+	out := stdout.String()
+	if strings.Contains(out, "example.org/a") || !strings.Contains(out, "example.org/b v1.0.0: DRIFT") ||
+		!strings.Contains(out, "example.org/c v1.0.0: FAILED") || strings.Contains(out, "example.org/d") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if _, err := refetchReport(context.Background(), &options{}, []byte("example.org/a\n\nLicense\n\n"+divider+"\n"), &stdout); err == nil {
+		t.Errorf("expected an error for a text report")
+	}
+}
+
+func TestEntryModule(t *testing.T) {
+	m := entryModule(Entry{Module: "example.org/c", Version: "v1.0.0", ReplacedBy: "example.org/fork v1.1.0"})
+	if src := m.source(); src != (Module{Path: "example.org/fork", Version: "v1.1.0"}) {
+		t.Errorf("expected the replacement but got %+v", src)
+	}
+
+	m = entryModule(Entry{Module: "example.org/d", Version: "v1.0.0", ReplacedBy: "../d"})
+	if (m.replacement() != "../d") || !filepath.IsAbs(m.ReplaceDir) {
+		t.Errorf("expected a local replacement but got %+v", m)
+	}
+}
@@ -0,0 +1,290 @@
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// The ways a module can be used by the main module, in a whyReport.
+const (
+	usedBuilt = "built"      // by a package built without tests or tools
+	usedTest  = "tests only" // only by tests
+	usedTool  = "tools only" // only by tools, at build time
+	usedNone  = "not used"   // required, but no package of it is imported
+)
+
+// whyReport explains why a module is in the report, for the why command.
+type whyReport struct {
+	Module     string `json:"module"`
+	Version    string `json:"version,omitempty"`
+	Indirect   bool   `json:"indirect"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	Used       string `json:"used"`
+
+	// Chain is the shortest import chain from a package in the main module
+	// to a package in the module, as given by "go mod why", or empty if the
+	// main module doesn't need it.
+	Chain []string `json:"import_chain"`
+
+	// Targets are the products (with --product), or else the main packages
+	// of the main module, whose builds need the module.
+	Targets []string `json:"targets"`
+
+	// SPDX, Repository, SourceURL and Ref describe where the license came
+	// from, or LicenseError why it couldn't be found.
+	SPDX         string `json:"spdx,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+	SourceURL    string `json:"source_url,omitempty"`
+	Ref          string `json:"ref,omitempty"`
+	Override     bool   `json:"override,omitempty"`
+	LicenseError string `json:"license_error,omitempty"`
+}
+
+// runWhy implements the why command: it explains why the module given as
+// its one argument is in the report, writing a whyReport to stdout as text
+// or, with --format=json, JSON.
+func runWhy(ctx context.Context, o *options, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one module argument, e.g. gocomply why golang.org/x/text")
+	}
+	if (o.Format != "text") && (o.Format != "json") {
+		return fmt.Errorf("unknown why format %q", o.Format)
+	}
+
+	m, err := whyModule(ctx, parseModuleArg(args[0]).Path)
+	if err != nil {
+		return err
+	}
+	r := whyReport{
+		Module:     m.Path,
+		Version:    m.Version,
+		Indirect:   m.Indirect,
+		ReplacedBy: m.replacement(),
+	}
+
+	if r.Chain, err = importChain(ctx, m.Path); err != nil {
+		return err
+	}
+	if r.Used, err = moduleUse(ctx, m.Path); err != nil {
+		return err
+	}
+	if r.Targets, err = buildTargets(ctx, o, m.Path); err != nil {
+		return err
+	}
+
+	opts := *o
+	opts.List = false
+	opts.Provenance = true
+	_, r.Override = o.overrides[m.Path]
+	entry, err := scanModule(ctx, m, &opts)
+	if err != nil {
+		r.LicenseError = err.Error()
+	} else {
+		r.SPDX = entry.spdxText()
+		r.SourceURL = entry.SourceURL
+		r.Ref = entry.Ref
+	}
+	if gi, _, err := lookupModule(ctx, m.source().Path, o); (err == nil) && (m.ReplaceDir == "") {
+		r.Repository = gi.RepoRoot
+	}
+
+	if o.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(r)
+	}
+	return r.write(stdout)
+}
+
+// whyModule returns a module in the main module's build list.
+func whyModule(ctx context.Context, path string) (Module, error) {
+	stdout, err := exec.CommandContext(ctx, "go", "list", "-m", "-json", path).Output()
+	if err != nil {
+		return Module{}, fmt.Errorf("module %q is not required by the main module: %+v: %s", path, err, exitErrorStderr(err))
+	}
+	modules, err := parseModuleList(stdout)
+	if err != nil {
+		return Module{}, err
+	}
+	if len(modules) != 1 {
+		return Module{}, fmt.Errorf("module %q is the main module", path)
+	}
+	return modules[0], nil
+}
+
+// importChain returns the shortest import chain from the main module to a
+// package in a module, from "go mod why -m".
+func importChain(ctx context.Context, module string) ([]string, error) {
+	stdout, err := exec.CommandContext(ctx, "go", "mod", "why", "-m", module).Output()
+	if err != nil {
+		return nil, fmt.Errorf("go why error: %+v: %s", err, exitErrorStderr(err))
+	}
+	return parseImportChain(stdout), nil
+}
+
+// parseImportChain parses the stanza of "go mod why -m" for one module into
+// its import chain, or nil if the main module doesn't need it.
+func parseImportChain(stdout []byte) []string {
+	var chain []string
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case (line == "") || strings.HasPrefix(line, "# "):
+			// the stanza's heading
+		case strings.HasPrefix(line, "("):
+			// "(main module does not need module example.org/a)"
+			return nil
+		default:
+			chain = append(chain, line)
+		}
+	}
+	return chain
+}
+
+// moduleUse returns how a module is used by the main module's packages: by
+// a package that is built, or only by tests or tools.
+func moduleUse(ctx context.Context, module string) (string, error) {
+	built, err := depModules(ctx, "./...")
+	if err != nil {
+		return "", err
+	}
+	if built[module] {
+		return usedBuilt, nil
+	}
+
+	tested, err := depModules(ctx, "-test", "./...")
+	if err != nil {
+		return "", err
+	}
+	if tested[module] {
+		return usedTest, nil
+	}
+
+	tools, err := toolModules(ctx)
+	if err != nil {
+		return "", err
+	}
+	if tools[module] {
+		return usedTool, nil
+	}
+	return usedNone, nil
+}
+
+// buildTargets returns the products, if any, or else the main packages in
+// the main module, that need a module.
+func buildTargets(ctx context.Context, o *options, module string) ([]string, error) {
+	if len(o.Products) > 0 {
+		var targets []string
+		for _, p := range o.Products {
+			if err := p.resolve(ctx); err != nil {
+				return nil, err
+			}
+			if p.uses(module) {
+				targets = append(targets, p.Name)
+			}
+		}
+		return targets, nil
+	}
+
+	packages, err := exec.CommandContext(ctx, "go", "list", "-deps", "-f",
+		`{{.ImportPath}}{{"\t"}}{{with .Module}}{{.Path}}{{end}}`, "./...").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+	mains, err := exec.CommandContext(ctx, "go", "list", "-f",
+		`{{if eq .Name "main"}}{{.ImportPath}}{{"\t"}}{{join .Deps " "}}{{end}}`, "./...").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %+v: %s", err, exitErrorStderr(err))
+	}
+	return parseMainTargets(packages, mains, module), nil
+}
+
+// parseMainTargets returns the main packages that depend on a package in a
+// module, given "go list" output of each package's import path and module,
+// and of each main package's import path and dependencies, separated by
+// tabs.
+func parseMainTargets(packages []byte, mains []byte, module string) []string {
+	inModule := make(map[string]bool)
+	for _, line := range strings.Split(string(packages), "\n") {
+		fields := strings.Split(line, "\t")
+		if (len(fields) == 2) && (fields[1] == module) {
+			inModule[fields[0]] = true
+		}
+	}
+
+	targets := []string{}
+	for _, line := range strings.Split(string(mains), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			continue
+		}
+		for _, dep := range strings.Fields(fields[1]) {
+			if inModule[dep] {
+				targets = append(targets, fields[0])
+				break
+			}
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// write writes the report as text.
+func (r whyReport) write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", r.Module, r.Version)
+	if r.Indirect {
+		b.WriteString("requirement: indirect (required by other modules)\n")
+	} else {
+		b.WriteString("requirement: direct (in go.mod)\n")
+	}
+	if r.ReplacedBy != "" {
+		fmt.Fprintf(&b, "replaced by: %s\n", r.ReplacedBy)
+	}
+	fmt.Fprintf(&b, "used: %s\n", r.Used)
+
+	if len(r.Chain) > 0 {
+		b.WriteString("import chain:\n")
+		for _, pkg := range r.Chain {
+			fmt.Fprintf(&b, "    %s\n", pkg)
+		}
+	} else {
+		b.WriteString("import chain: none (the main module does not need it)\n")
+	}
+
+	if len(r.Targets) > 0 {
+		fmt.Fprintf(&b, "targets: %s\n", strings.Join(r.Targets, ", "))
+	} else {
+		b.WriteString("targets: none\n")
+	}
+
+	if r.LicenseError != "" {
+		fmt.Fprintf(&b, "license: %s\n", r.LicenseError)
+	} else {
+		fmt.Fprintf(&b, "license: %s\n", r.SPDX)
+		if r.Override {
+			b.WriteString("override: configured in --overrides\n")
+		}
+		if r.SourceURL != "" {
+			fmt.Fprintf(&b, "source: %s", r.SourceURL)
+			if r.Ref != "" {
+				fmt.Fprintf(&b, " (at %s)", r.Ref)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if r.Repository != "" {
+		fmt.Fprintf(&b, "repository: %s\n", r.Repository)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,68 @@
+package licenses
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImportChain(t *testing.T) {
+	stdout := "# golang.org/x/text\nexample.org/app\nexample.org/app/lang\ngolang.org/x/text/language\n"
+	expected := []string{"example.org/app", "example.org/app/lang", "golang.org/x/text/language"}
+	if chain := parseImportChain([]byte(stdout)); !reflect.DeepEqual(chain, expected) {
+		t.Errorf("expected %v but got %v", expected, chain)
+	}
+
+	stdout = "# example.org/unused\n(main module does not need module example.org/unused)\n"
+	if chain := parseImportChain([]byte(stdout)); chain != nil {
+		t.Errorf("expected no chain but got %v", chain)
+	}
+}
+
+func TestParseMainTargets(t *testing.T) {
+	packages := "example.org/app/cmd/server\texample.org/app\n" +
+		"golang.org/x/text/language\tgolang.org/x/text\n" +
+		"fmt\t\n"
+	mains := "example.org/app/cmd/server\tfmt golang.org/x/text/language\n" +
+		"example.org/app/cmd/tool\tfmt\n"
+
+	targets := parseMainTargets([]byte(packages), []byte(mains), "golang.org/x/text")
+	expected := []string{"example.org/app/cmd/server"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected %v but got %v", expected, targets)
+	}
+}
+
+func TestWhyReportWrite(t *testing.T) {
+	r := whyReport{
+		Module:     "golang.org/x/text",
+		Version:    "v0.3.0",
+		Indirect:   true,
+		Used:       usedBuilt,
+		Chain:      []string{"example.org/app", "golang.org/x/text/language"},
+		Targets:    []string{"example.org/app/cmd/server"},
+		SPDX:       "BSD-3-Clause (100.0% match)",
+		SourceURL:  "https://example.org/LICENSE",
+		Ref:        "v0.3.0",
+		Repository: "https://go.googlesource.com/text",
+	}
+	var b strings.Builder
+	if err := r.write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `golang.org/x/text v0.3.0
+requirement: indirect (required by other modules)
+used: built
+import chain:
+    example.org/app
+    golang.org/x/text/language
+targets: example.org/app/cmd/server
+license: BSD-3-Clause (100.0% match)
+source: https://example.org/LICENSE (at v0.3.0)
+repository: https://go.googlesource.com/text
+`
+	if b.String() != expected {
+		t.Errorf("expected %q but got %q", expected, b.String())
+	}
+}
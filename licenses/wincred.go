@@ -0,0 +1,40 @@
+package licenses
+
+import (
+	"net/http"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// credentialBlobString decodes the secret of a stored credential. Git
+// credential helpers on Windows have stored it as UTF-16 (little endian) or
+// as UTF-8. Tokens are ASCII, so a secret with a NUL in every other byte is
+// UTF-16.
+func credentialBlobString(blob []byte) string {
+	if (len(blob) >= 2) && (len(blob)%2 == 0) && (blob[1] == 0) {
+		s := make([]uint16, len(blob)/2)
+		for i := range s {
+			s[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+		}
+		return string(utf16.Decode(s))
+	}
+	if !utf8.Valid(blob) {
+		return ""
+	}
+	return string(blob)
+}
+
+// authorizeStoredCredential adds the credentials for the host of a https
+// request from the Windows Credential Manager (see storedCredential), and
+// returns true if it did.
+func authorizeStoredCredential(req *http.Request) bool {
+	if req.URL.Scheme != "https" {
+		return false
+	}
+	auth := storedCredential(req.URL.Host)
+	if auth == nil {
+		return false
+	}
+	req.SetBasicAuth(auth.Username, auth.Token)
+	return true
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package licenses
+
+// storedCredential returns nil, as there is no Windows Credential Manager.
+func storedCredential(host string) *BasicAuth {
+	return nil
+}
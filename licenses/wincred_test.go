@@ -0,0 +1,16 @@
+package licenses
+
+import "testing"
+
+func TestCredentialBlobString(t *testing.T) {
+	tests := map[string][]byte{
+		"":      {},
+		"token": []byte("token"),
+		"tok":   {'t', 0, 'o', 0, 'k', 0},
+	}
+	for expected, blob := range tests {
+		if got := credentialBlobString(blob); got != expected {
+			t.Errorf("%v: expected %q but got %q", blob, expected, got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package licenses
+
+import (
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW = advapi32.NewProc("CredReadW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+// credTypeGeneric is CRED_TYPE_GENERIC, the type of the credentials stored
+// by git's credential helpers.
+const credTypeGeneric = 1
+
+// winCredential is the CREDENTIALW structure.
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// storedCredential returns the credentials for a host in the Windows
+// Credential Manager, as stored by Git Credential Manager or
+// git-credential-wincred ("git:https://host"), or nil.
+func storedCredential(host string) *BasicAuth {
+	target, err := syscall.UTF16PtrFromString("git:https://" + host)
+	if err != nil {
+		return nil
+	}
+
+	var cred *winCredential
+	r, _, _ := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return nil
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	n := int(cred.CredentialBlobSize)
+	if (n == 0) || (cred.CredentialBlob == nil) {
+		return nil
+	}
+	blob := make([]byte, n)
+	copy(blob, (*[1 << 20]byte)(unsafe.Pointer(cred.CredentialBlob))[:n:n])
+
+	auth := &BasicAuth{
+		Username: utf16PtrString(cred.UserName),
+		Token:    credentialBlobString(blob),
+	}
+	if !auth.IsSet() {
+		return nil
+	}
+	return auth
+}
+
+// utf16PtrString returns the string at a NUL terminated UTF-16 pointer.
+func utf16PtrString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	var s []uint16
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Pointer(uintptr(ptr) + 2) {
+		s = append(s, *(*uint16)(ptr))
+	}
+	return string(utf16.Decode(s))
+}
@@ -0,0 +1,21 @@
+// Give open source Golang developers the credit they deserve, follow your
+// legal obligations, and save time with `gocomply`.
+//
+// This little program scans the Go module in the current
+// directory for all direct and indirect dependencies, and attempts to download
+// and write all of their license files to stdout. Progress or warnings are
+// written to stderr.
+//
+// See https://www.tawesoft.co.uk/gopkg/gocomply
+//
+package main
+
+import (
+	"os"
+
+	"tawesoft.co.uk/gopkg/gocomply/licenses"
+)
+
+func main() {
+	os.Exit(licenses.Main(os.Args[1:]))
+}